@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// alertmanagerWebhook is the payload Prometheus Alertmanager sends to webhook
+// receivers, see
+// https://prometheus.io/docs/alerting/latest/configuration/#webhook_config
+type alertmanagerWebhook struct {
+	Version string              `json:"version"`
+	Status  string              `json:"status"`
+	Alerts  []alertmanagerAlert `json:"alerts"`
+}
+
+type alertmanagerAlert struct {
+	Status      string            `json:"status"` // firing or resolved
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+	Fingerprint string            `json:"fingerprint"`
+}
+
+// key identifies an alert: by its fingerprint, or (for Alertmanager versions
+// which do not send fingerprints) by its labels.
+func (a *alertmanagerAlert) key() string {
+	if a.Fingerprint != "" {
+		return a.Fingerprint
+	}
+	names := make([]string, 0, len(a.Labels))
+	for name := range a.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var key strings.Builder
+	for _, name := range names {
+		key.WriteString(name + "=" + a.Labels[name] + "\x00")
+	}
+	return key.String()
+}
+
+// alertmanagerReceiver is an HTTP handler for Alertmanager webhooks, which
+// retains the currently firing alerts.
+type alertmanagerReceiver struct {
+	mu     sync.Mutex
+	alerts map[string]alertmanagerAlert // by key()
+}
+
+func newAlertmanagerReceiver() *alertmanagerReceiver {
+	return &alertmanagerReceiver{alerts: make(map[string]alertmanagerAlert)}
+}
+
+func (rcv *alertmanagerReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var hook alertmanagerWebhook
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&hook); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	for _, a := range hook.Alerts {
+		if a.Status == "resolved" {
+			delete(rcv.alerts, a.key())
+			continue
+		}
+		rcv.alerts[a.key()] = a
+	}
+}
+
+// severityRank orders alerts by their severity label, most severe first.
+func severityRank(severity string) int {
+	switch strings.ToLower(severity) {
+	case "critical", "page", "error":
+		return 0
+	case "warning":
+		return 1
+	case "info", "none":
+		return 3
+	default:
+		return 2
+	}
+}
+
+func severityColor(severity string) string {
+	switch severityRank(severity) {
+	case 0:
+		return "red"
+	case 1:
+		return "yellow"
+	case 3:
+		return "blue"
+	default:
+		return ""
+	}
+}
+
+// firing returns the currently firing alerts, most severe (then oldest)
+// first. Alerts whose end time passed without Alertmanager sending a
+// resolved notification (e.g. because send_resolved is disabled) are
+// dropped.
+func (rcv *alertmanagerReceiver) firing(now time.Time) []alertmanagerAlert {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	alerts := make([]alertmanagerAlert, 0, len(rcv.alerts))
+	for key, a := range rcv.alerts {
+		if !a.EndsAt.IsZero() && a.EndsAt.Before(now) {
+			delete(rcv.alerts, key)
+			continue
+		}
+		alerts = append(alerts, a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		ri, rj := severityRank(alerts[i].Labels["severity"]), severityRank(alerts[j].Labels["severity"])
+		if ri != rj {
+			return ri < rj
+		}
+		return alerts[i].StartsAt.Before(alerts[j].StartsAt)
+	})
+	return alerts
+}
+
+// alertsPanel shows the alerts received from Alertmanager (see
+// -http-listen).
+type alertsPanel struct{}
+
+func newAlertsPanel() (panel, error) {
+	return &alertsPanel{}, nil
+}
+
+func (p *alertsPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Alerts")
+	if *httpListen == "" {
+		d.drawMessage(dc, y, "-http-listen not set")
+		return nil
+	}
+	now := time.Now()
+	alerts := d.alertHook.firing(now)
+	if len(alerts) == 0 {
+		d.drawMessage(dc, y, "no firing alerts")
+		return nil
+	}
+	rows := make([][]cell, 0, len(alerts))
+	for _, a := range alerts {
+		severity := a.Labels["severity"]
+		summary := a.Annotations["summary"]
+		if summary == "" {
+			summary = a.Annotations["description"]
+		}
+		if instance := a.Labels["instance"]; summary == "" && instance != "" {
+			summary = instance
+		}
+		age := "-"
+		if !a.StartsAt.IsZero() {
+			age = now.Sub(a.StartsAt).Round(time.Second).String()
+		}
+		rows = append(rows, []cell{
+			{text: severity, color: severityColor(severity)},
+			{text: a.Labels["alertname"]},
+			{text: age, color: "darkgray"},
+			{text: summary, scroll: true},
+		})
+	}
+	d.drawTable(dc, y, []string{"severity", "alert", "age", "summary"}, rows)
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertmanagerReceiver(t *testing.T) {
+	rcv := newAlertmanagerReceiver()
+	post := func(body string) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		rcv.ServeHTTP(rec, httptest.NewRequest("POST", "/alertmanager", strings.NewReader(body)))
+		if got, want := rec.Code, http.StatusOK; got != want {
+			t.Fatalf("unexpected HTTP status: got %d, want %d (%s)", got, want, rec.Body.String())
+		}
+	}
+	post(`{
+  "version": "4",
+  "status": "firing",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "DiskFull", "severity": "warning", "instance": "nas:9100"},
+      "annotations": {"summary": "disk 91% full"},
+      "startsAt": "2022-10-12T08:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "fingerprint": "a1"
+    },
+    {
+      "status": "firing",
+      "labels": {"alertname": "NodeDown", "severity": "critical", "instance": "router:9100"},
+      "annotations": {},
+      "startsAt": "2022-10-12T09:00:00Z",
+      "fingerprint": "b2"
+    }
+  ]
+}`)
+	now := time.Date(2022, 10, 12, 10, 0, 0, 0, time.UTC)
+	alerts := rcv.firing(now)
+	var names []string
+	for _, a := range alerts {
+		names = append(names, a.Labels["alertname"])
+	}
+	if got, want := strings.Join(names, ","), "NodeDown,DiskFull"; got != want {
+		t.Errorf("firing alerts = %s, want %s (critical first)", got, want)
+	}
+
+	post(`{"version": "4", "status": "resolved", "alerts": [{"status": "resolved", "labels": {"alertname": "NodeDown"}, "fingerprint": "b2"}]}`)
+	if got := rcv.firing(now); len(got) != 1 || got[0].Labels["alertname"] != "DiskFull" {
+		t.Errorf("firing alerts after resolve = %+v, want only DiskFull", got)
+	}
+
+	rec := httptest.NewRecorder()
+	rcv.ServeHTTP(rec, httptest.NewRequest("GET", "/alertmanager", nil))
+	if got, want := rec.Code, http.StatusMethodNotAllowed; got != want {
+		t.Errorf("GET: unexpected HTTP status: got %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+)
+
+var alertBannerFlag = flag.Bool("alert-banner",
+	true,
+	"overlay a full-width banner on every page while a critical condition (/perm almost full, crash-looping service, SoC temperature above its critical threshold) persists. Press a (see -keyboard) or POST /alerts to acknowledge the current conditions")
+
+// alertCondition is a critical condition, identified by key so that an
+// acknowledgement applies until the condition clears.
+type alertCondition struct {
+	key     string
+	message string
+}
+
+// criticalConditions returns the conditions which currently warrant the
+// alert banner.
+func (d *statusDrawer) criticalConditions() []alertCondition {
+	var conds []alertCondition
+	if used, err := permUsage(); err == nil {
+		if metricColor("perm", used, "") == "red" {
+			conds = append(conds, alertCondition{
+				key:     "perm",
+				message: fmt.Sprintf("/perm is %.f%% full", used),
+			})
+		}
+	}
+	if services, updated, _ := d.services.get(); !updated.IsZero() {
+		for _, svc := range services {
+			if svc.crashLooping {
+				conds = append(conds, alertCondition{
+					key:     "crashloop " + svc.name,
+					message: svc.name + " is crash-looping",
+				})
+			}
+		}
+	}
+	if d.celsiusErr == nil && metricColor("temperature", d.celsius, "") == "red" {
+		conds = append(conds, alertCondition{
+			key:     "temperature",
+			message: fmt.Sprintf("SoC temperature %.1f °C", d.celsius),
+		})
+	}
+	return conds
+}
+
+// alertBanner tracks which critical conditions were acknowledged.
+type alertBanner struct {
+	mu      sync.Mutex
+	current []alertCondition
+	acked   map[string]bool
+
+	// faces of the banner, created when the number of lines changes
+	lines      int
+	face, hint font.Face
+}
+
+func newAlertBanner() *alertBanner {
+	return &alertBanner{acked: make(map[string]bool)}
+}
+
+// acknowledge hides the banner until a new critical condition occurs.
+func (b *alertBanner) acknowledge() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, cond := range b.current {
+		b.acked[cond.key] = true
+	}
+}
+
+// ServeHTTP lists the current conditions on GET and acknowledges them on
+// POST.
+func (b *alertBanner) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		b.mu.Lock()
+		var lines []string
+		for _, cond := range b.current {
+			line := cond.message
+			if b.acked[cond.key] {
+				line += " (acknowledged)"
+			}
+			lines = append(lines, line)
+		}
+		b.mu.Unlock()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if len(lines) == 0 {
+			fmt.Fprintln(w, "no critical conditions")
+			return
+		}
+		fmt.Fprintln(w, strings.Join(lines, "\n"))
+	case http.MethodPost:
+		b.acknowledge()
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// update records the current conditions and returns those which were not
+// acknowledged. Acknowledgements of conditions which cleared are forgotten,
+// so that the banner shows again if they re-occur.
+func (b *alertBanner) update(conds []alertCondition) []alertCondition {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current = conds
+	present := make(map[string]bool)
+	var show []alertCondition
+	for _, cond := range conds {
+		present[cond.key] = true
+		if !b.acked[cond.key] {
+			show = append(show, cond)
+		}
+	}
+	for key := range b.acked {
+		if !present[key] {
+			delete(b.acked, key)
+		}
+	}
+	sort.Slice(show, func(i, j int) bool { return show[i].key < show[j].key })
+	return show
+}
+
+// alertsToShow returns the unacknowledged critical conditions, if the alert
+// banner is enabled.
+func (d *statusDrawer) alertsToShow() []alertCondition {
+	if d.alerts == nil {
+		return nil
+	}
+	return d.alerts.update(d.criticalConditions())
+}
+
+// drawAlertBanner overlays the alert banner for show across the middle of
+// the buffer.
+func (d *statusDrawer) drawAlertBanner(show []alertCondition) {
+	b := d.alerts
+	r := image.Rect(0, d.h*3/8, d.w, d.h*5/8)
+	dc := d.overlayContext(r)
+	setColor(dc, "red")
+	dc.DrawRectangle(0, 0, float64(r.Dx()), float64(r.Dy()))
+	dc.Fill()
+	// Size the text so that all conditions (plus the hint) fit.
+	lines := len(show) + 1
+	if lines != b.lines {
+		size := float64(r.Dy()) / float64(lines) / 1.6
+		if max := float64(r.Dy()) / 4; size > max {
+			size = max
+		}
+		b.face = d.newFace(d.regular, size)
+		b.hint = d.newFace(d.regular, size/2)
+		b.lines = lines
+	}
+	dc.SetFontFace(b.face)
+	lineHeight := dc.FontHeight() * 1.4
+	y := (float64(r.Dy()) - float64(lines)*lineHeight) / 2
+	dc.SetRGB(1, 1, 1)
+	for _, cond := range show {
+		y += lineHeight
+		dc.DrawStringAnchored(fitString(dc, cond.message, 0.95*float64(r.Dx())), float64(r.Dx())/2, y, 0.5, 0)
+	}
+	dc.SetFontFace(b.hint)
+	dc.DrawStringAnchored("press a or POST /alerts to acknowledge", float64(r.Dx())/2, y+lineHeight, 0.5, 0)
+	d.drawOverlay(dc, r, draw.Src)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAlertBannerAcknowledge(t *testing.T) {
+	b := &alertBanner{acked: make(map[string]bool)}
+	hot := alertCondition{key: "temperature", message: "SoC temperature 85.0 °C"}
+	full := alertCondition{key: "perm", message: "/perm is 95% full"}
+
+	if got, want := b.update([]alertCondition{hot}), []alertCondition{hot}; !reflect.DeepEqual(got, want) {
+		t.Errorf("update() = %v, want %v", got, want)
+	}
+	b.acknowledge()
+	if got := b.update([]alertCondition{hot}); len(got) != 0 {
+		t.Errorf("update() after acknowledge = %v, want none", got)
+	}
+	// A new condition shows the banner again, but only for that condition.
+	if got, want := b.update([]alertCondition{hot, full}), []alertCondition{full}; !reflect.DeepEqual(got, want) {
+		t.Errorf("update() = %v, want %v", got, want)
+	}
+	// Once a condition cleared, its acknowledgement is forgotten.
+	b.update(nil)
+	if got, want := b.update([]alertCondition{hot}), []alertCondition{hot}; !reflect.DeepEqual(got, want) {
+		t.Errorf("update() after clearing = %v, want %v", got, want)
+	}
+}
+
+func TestAlertBannerHTTP(t *testing.T) {
+	b := newAlertBanner()
+	hot := alertCondition{key: "temperature", message: "SoC temperature 85.0 °C"}
+	b.update([]alertCondition{hot})
+
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest("GET", "/alerts", nil))
+	if got := rec.Body.String(); !strings.Contains(got, hot.message) {
+		t.Errorf("GET /alerts = %q, want it to contain %q", got, hot.message)
+	}
+
+	rec = httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest("POST", "/alerts", nil))
+	if rec.Code != 200 {
+		t.Fatalf("POST /alerts: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := b.update([]alertCondition{hot}); len(got) != 0 {
+		t.Errorf("update() after POST /alerts = %v, want none", got)
+	}
+
+	rec = httptest.NewRecorder()
+	b.ServeHTTP(rec, httptest.NewRequest("DELETE", "/alerts", nil))
+	if rec.Code != 405 {
+		t.Errorf("DELETE /alerts: status %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	autoBrightness = flag.Bool("auto-brightness",
+		false,
+		"adjust the backlight (see -backlight) and the contrast to the room lighting, as measured by an ambient light sensor (IIO, in /sys/bus/iio/devices). Without a backlight, the colors are dimmed instead")
+
+	autoBrightnessMin = flag.Float64("auto-brightness-min",
+		0.1,
+		"with -auto-brightness, the fraction of full brightness in a dark room")
+
+	backlightFlag = flag.String("backlight",
+		"",
+		"with -auto-brightness, the backlight device in /sys/class/backlight to control, e.g. rpi_backlight (Raspberry Pi touchscreen). If empty, the first one is used")
+)
+
+const (
+	// autoBrightnessLux is the illuminance (daylight close to a window) at
+	// which the display is at full brightness and contrast.
+	autoBrightnessLux = 10000
+
+	// autoBrightnessFade is how long the brightness takes to fade across its
+	// whole range, which smoothes over brief changes of the light (e.g. a
+	// shadow passing over the sensor).
+	autoBrightnessFade = 30 * time.Second
+
+	// autoContrast is how much the contrast changes in a dark (less) or in
+	// a bright (more) room.
+	autoContrast = 0.3
+
+	// autoBrightnessSteps is the number of steps in which the brightness
+	// changes, each of which requires computing a new colorCurve.
+	autoBrightnessSteps = 32
+)
+
+// lightAdjust adjusts a colorCurve to the room lighting, see
+// -auto-brightness. The zero value does not change any colors.
+type lightAdjust struct {
+	contrast float64 // added to the contrast factor, e.g. -0.3
+	dim      float64 // fraction by which colors are dimmed
+}
+
+// colorCurve returns the curve for gamma, brightness and tint, adjusted by a.
+func (a lightAdjust) colorCurve(gamma, brightness float64, tint [3]float64) *colorCurve {
+	// the flags were validated by newColorCurve in newStatusDrawer
+	curve, _ := newContrastColorCurve(gamma, brightness*(1-a.dim), 1+a.contrast, tint)
+	return curve
+}
+
+// lightLevel maps the illuminance in lux to a level from 0 (dark) to 1
+// (autoBrightnessLux or brighter). The scale is logarithmic, like the
+// perception of brightness.
+func lightLevel(lux float64) float64 {
+	level := math.Log10(math.Max(lux, 0)+1) / math.Log10(autoBrightnessLux+1)
+	return math.Max(0, math.Min(1, level))
+}
+
+// backlight is a backlight device in /sys/class/backlight.
+type backlight struct {
+	dir string
+	max int
+}
+
+func readSysfsInt(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+// findBacklight returns the backlight device name in root (i.e.
+// /sys/class/backlight), or the first one if name is empty.
+func findBacklight(root, name string) (*backlight, error) {
+	if name == "" {
+		matches, err := filepath.Glob(filepath.Join(root, "*", "max_brightness"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, errors.New("no backlight found")
+		}
+		name = filepath.Base(filepath.Dir(matches[0]))
+	}
+	dir := filepath.Join(root, name)
+	max, err := readSysfsInt(filepath.Join(dir, "max_brightness"))
+	if err != nil {
+		return nil, err
+	}
+	if max <= 0 {
+		return nil, fmt.Errorf("%s: max_brightness is %d", dir, max)
+	}
+	return &backlight{dir: dir, max: max}, nil
+}
+
+// set sets the backlight to the fraction of full brightness.
+func (b *backlight) set(fraction float64) error {
+	value := int(math.Round(fraction * float64(b.max)))
+	if value < 1 {
+		value = 1 // 0 turns off some backlights
+	}
+	return os.WriteFile(filepath.Join(b.dir, "brightness"), []byte(strconv.Itoa(value)), 0644)
+}
+
+// autoBrightnessControl adjusts the backlight and the contrast to the
+// ambient light, see -auto-brightness.
+type autoBrightnessControl struct {
+	light     *poller[float64]
+	backlight *backlight // nil if there is none, in which case colors are dimmed
+	min       float64    // as per -auto-brightness-min
+
+	level   float64 // faded towards the lightLevel of the sensor
+	updated time.Time
+	step    int // of the adjustment and backlight, -1 until the sensor works
+
+	gamma, brightness float64     // as per -gamma and -brightness
+	curve             *colorCurve // adjusted as per step
+}
+
+// newAutoBrightness returns the auto brightness control as per the flags,
+// or nil if -auto-brightness is not set.
+func newAutoBrightness() (*autoBrightnessControl, error) {
+	if !*autoBrightness {
+		return nil, nil
+	}
+	if *autoBrightnessMin < 0 || *autoBrightnessMin > 1 {
+		return nil, fmt.Errorf("-auto-brightness-min must be between 0 and 1, got %v", *autoBrightnessMin)
+	}
+	a := &autoBrightnessControl{
+		light:      newLightPoller(),
+		min:        *autoBrightnessMin,
+		step:       -1,
+		gamma:      *gammaFlag,
+		brightness: *brightnessFlag,
+	}
+	a.curve = lightAdjust{}.colorCurve(a.gamma, a.brightness, noTint)
+	bl, err := findBacklight("/sys/class/backlight", *backlightFlag)
+	if err != nil {
+		if *backlightFlag != "" {
+			return nil, fmt.Errorf("-backlight: %v", err)
+		}
+		slog.Info("no backlight, dimming colors instead", "err", err)
+	}
+	a.backlight = bl
+	return a, nil
+}
+
+// update fades the brightness towards the ambient light at now and returns
+// the current step (0 to autoBrightnessSteps), or -1 until the sensor
+// works.
+func (a *autoBrightnessControl) update(now time.Time) int {
+	lux, updated, err := a.light.get()
+	if updated.IsZero() || err != nil {
+		return a.step // keep the current brightness until the sensor works
+	}
+	target := lightLevel(lux)
+	if a.updated.IsZero() {
+		a.level = target
+	} else {
+		delta := float64(now.Sub(a.updated)) / float64(autoBrightnessFade)
+		if target > a.level {
+			a.level = math.Min(target, a.level+delta)
+		} else {
+			a.level = math.Max(target, a.level-delta)
+		}
+	}
+	a.updated = now
+	return int(math.Round(a.level * autoBrightnessSteps))
+}
+
+// adjustment returns the lightAdjust for step, with which the contrast
+// decreases in a dark room and increases in a bright one. Without a
+// backlight, it also dims the colors.
+func (a *autoBrightnessControl) adjustment(step int) lightAdjust {
+	if step < 0 {
+		return lightAdjust{}
+	}
+	level := float64(step) / autoBrightnessSteps
+	adj := lightAdjust{contrast: autoContrast * (2*level - 1)}
+	if a.backlight == nil {
+		adj.dim = (1 - a.min) * (1 - level)
+	}
+	return adj
+}
+
+// adjust sets the backlight as per the ambient light at now, if it changed
+// noticeably, and returns the corresponding lightAdjust.
+func (a *autoBrightnessControl) adjust(now time.Time) lightAdjust {
+	step := a.update(now)
+	adj := a.adjustment(step)
+	if step == a.step {
+		return adj
+	}
+	if a.backlight != nil {
+		level := float64(step) / autoBrightnessSteps
+		if err := a.backlight.set(a.min + (1-a.min)*level); err != nil {
+			slog.Warn("setting backlight failed", "err", err)
+		}
+	}
+	a.curve = adj.colorCurve(a.gamma, a.brightness, noTint)
+	a.step = step
+	return adj
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLightLevel(t *testing.T) {
+	for _, tt := range []struct {
+		lux  float64
+		want float64
+	}{
+		{-1, 0},
+		{0, 0},
+		{autoBrightnessLux, 1},
+		{100000, 1},
+	} {
+		if got := lightLevel(tt.lux); got != tt.want {
+			t.Errorf("lightLevel(%v) = %v, want %v", tt.lux, got, tt.want)
+		}
+	}
+	if dim, office := lightLevel(5), lightLevel(300); dim >= office {
+		t.Errorf("lightLevel(5) = %v, not below lightLevel(300) = %v", dim, office)
+	}
+}
+
+func TestBacklight(t *testing.T) {
+	root := t.TempDir()
+	if _, err := findBacklight(root, ""); err == nil {
+		t.Errorf("findBacklight() without backlights succeeded unexpectedly")
+	}
+	dir := filepath.Join(root, "rpi_backlight")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "max_brightness"), []byte("255\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bl, err := findBacklight(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := findBacklight(root, "10-0045"); err == nil {
+		t.Errorf("findBacklight(10-0045) succeeded unexpectedly")
+	}
+
+	a := &autoBrightnessControl{
+		light:      &poller[float64]{running: true},
+		backlight:  bl,
+		min:        0.1,
+		step:       -1,
+		gamma:      1,
+		brightness: 1,
+	}
+	now := time.Date(2022, 8, 20, 22, 0, 0, 0, time.UTC)
+	if adj := a.adjust(now); adj != (lightAdjust{}) {
+		t.Errorf("adjust() before the sensor works = %+v, want none", adj)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "brightness")); !os.IsNotExist(err) {
+		t.Errorf("backlight set before the sensor works")
+	}
+
+	brightness := func() string {
+		b, err := os.ReadFile(filepath.Join(dir, "brightness"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(b))
+	}
+	// dark room
+	a.light.val, a.light.updated = 0, now
+	if adj := a.adjust(now); adj.contrast >= 0 || adj.dim != 0 {
+		t.Errorf("adjust() in a dark room = %+v, want less contrast, not dimmed", adj)
+	}
+	if got, want := brightness(), "26"; got != want {
+		t.Errorf("backlight in a dark room = %s, want %s", got, want)
+	}
+	if a.curve == nil {
+		t.Errorf("color curve not adjusted")
+	}
+
+	// the lights are switched on: the brightness fades
+	a.light.val = autoBrightnessLux
+	a.adjust(now.Add(autoBrightnessFade / 2))
+	if got, want := brightness(), "140"; got != want {
+		t.Errorf("backlight while fading = %s, want %s", got, want)
+	}
+	if adj := a.adjust(now.Add(autoBrightnessFade)); adj.contrast <= 0 {
+		t.Errorf("adjust() in a bright room = %+v, want more contrast", adj)
+	}
+	if got, want := brightness(), "255"; got != want {
+		t.Errorf("backlight in a bright room = %s, want %s", got, want)
+	}
+
+	// without a backlight, colors are dimmed instead
+	a.backlight = nil
+	if got := a.adjustment(0); got.dim != 0.9 {
+		t.Errorf("adjustment(0) without a backlight = %+v, want dimmed by 0.9", got)
+	}
+}
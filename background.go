@@ -0,0 +1,158 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+	"strings"
+)
+
+var backgroundFlag = flag.String("background",
+	"flat",
+	"what to draw behind the panels: flat (the classic dark gray), gradient (a vertical gradient, optionally with colors like gradient:#404048:#1e1e24), optionally combined with a subtle pattern via +dots, +grid or +stripes (e.g. gradient+dots). Non-flat backgrounds are dithered on 16 bpp displays")
+
+var (
+	// defaultGradientTop and defaultGradientBottom are the colors of
+	// -background=gradient, which is lighter at the top and darker at the
+	// bottom than the flat background.
+	defaultGradientTop    = color.RGBA{R: 64, G: 64, B: 72, A: 255}
+	defaultGradientBottom = color.RGBA{R: 30, G: 30, B: 36, A: 255}
+)
+
+// background describes what is drawn behind the panels, see -background.
+type background struct {
+	top, bottom color.RGBA // equal for a flat background
+	pattern     string     // empty, dots, grid or stripes
+}
+
+// flat returns whether the background is a single color.
+func (b background) flat() bool {
+	return b.top == b.bottom && b.pattern == ""
+}
+
+// parseHexColor parses colors like #404048.
+func parseHexColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("malformed color %q: expected e.g. #404048", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("malformed color %q: %v", s, err)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
+
+// parseBackground parses spec (as per -background), in which flat refers to
+// the color base.
+func parseBackground(spec string, base color.RGBA) (background, error) {
+	b := background{top: base, bottom: base}
+	for _, part := range strings.Split(spec, "+") {
+		name, args, _ := strings.Cut(part, ":")
+		switch name {
+		case "flat":
+		case "gradient":
+			b.top, b.bottom = defaultGradientTop, defaultGradientBottom
+			if args == "" {
+				continue
+			}
+			from, to, ok := strings.Cut(args, ":")
+			if !ok {
+				return background{}, fmt.Errorf("-background: malformed gradient %q: expected e.g. gradient:#404048:#1e1e24", part)
+			}
+			var err error
+			if b.top, err = parseHexColor(from); err != nil {
+				return background{}, fmt.Errorf("-background: %v", err)
+			}
+			if b.bottom, err = parseHexColor(to); err != nil {
+				return background{}, fmt.Errorf("-background: %v", err)
+			}
+		case "dots", "grid", "stripes":
+			b.pattern = name
+		default:
+			return background{}, fmt.Errorf("-background: unknown background %q: expected flat, gradient, dots, grid or stripes", part)
+		}
+	}
+	return b, nil
+}
+
+// patternLight is how much lighter the pattern is than the background.
+const patternLight = 10
+
+// render returns the background for a display of the bounds r, with the
+// pattern scaled by scaleFactor.
+func (b background) render(r image.Rectangle, scaleFactor float64) *image.RGBA {
+	img := image.NewRGBA(r)
+	lerp := func(from, to uint8, frac float64) uint8 {
+		return uint8(float64(from) + frac*(float64(to)-float64(from)) + 0.5)
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		frac := 0.0
+		if r.Dy() > 1 {
+			frac = float64(y-r.Min.Y) / float64(r.Dy()-1)
+		}
+		row := image.Rect(r.Min.X, y, r.Max.X, y+1)
+		draw.Draw(img, row, &image.Uniform{color.RGBA{
+			R: lerp(b.top.R, b.bottom.R, frac),
+			G: lerp(b.top.G, b.bottom.G, frac),
+			B: lerp(b.top.B, b.bottom.B, frac),
+			A: 255,
+		}}, image.Point{}, draw.Src)
+	}
+	if b.pattern == "" {
+		return img
+	}
+	spacing := int(24 * scaleFactor)
+	if spacing < 4 {
+		spacing = 4
+	}
+	dot := int(2 * scaleFactor)
+	if dot < 1 {
+		dot = 1
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dx, dy := (x-r.Min.X)%spacing, (y-r.Min.Y)%spacing
+			var on bool
+			switch b.pattern {
+			case "dots":
+				on = dx < dot && dy < dot
+			case "grid":
+				on = dx < dot || dy < dot
+			case "stripes":
+				on = (x-r.Min.X+y-r.Min.Y)%spacing < dot
+			}
+			if !on {
+				continue
+			}
+			off := img.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				if v := img.Pix[off+c]; v < 255-patternLight {
+					img.Pix[off+c] = v + patternLight
+				}
+			}
+		}
+	}
+	return img
+}
+
+// bayer4 is the 4×4 ordered dithering matrix.
+var bayer4 = [4][4]uint8{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// ditherAdd adds the dither threshold t (0 to 15, as per bayer4) to v before
+// v is truncated to bits bits.
+func ditherAdd(v uint8, t uint8, bits uint) uint8 {
+	step := uint16(1) << (8 - bits)
+	sum := uint16(v) + uint16(t)*step/16
+	if sum > 255 {
+		return 255
+	}
+	return uint8(sum)
+}
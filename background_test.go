@@ -0,0 +1,91 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/gokrazy/fbstatus/internal/fbimage"
+)
+
+func TestParseBackground(t *testing.T) {
+	base := color.RGBA{R: 50, G: 50, B: 50, A: 255}
+	for _, tt := range []struct {
+		spec string
+		want background
+	}{
+		{"flat", background{top: base, bottom: base}},
+		{"gradient", background{top: defaultGradientTop, bottom: defaultGradientBottom}},
+		{
+			"gradient:#102030:#000000",
+			background{
+				top:    color.RGBA{R: 0x10, G: 0x20, B: 0x30, A: 255},
+				bottom: color.RGBA{A: 255},
+			},
+		},
+		{"flat+dots", background{top: base, bottom: base, pattern: "dots"}},
+		{"gradient+grid", background{top: defaultGradientTop, bottom: defaultGradientBottom, pattern: "grid"}},
+	} {
+		got, err := parseBackground(tt.spec, base)
+		if err != nil {
+			t.Errorf("parseBackground(%q): %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseBackground(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+	for _, spec := range []string{"", "marble", "gradient:#102030", "gradient:#1020:#000000", "gradient:red:blue"} {
+		if _, err := parseBackground(spec, base); err == nil {
+			t.Errorf("parseBackground(%q) unexpectedly succeeded", spec)
+		}
+	}
+}
+
+func TestRenderBackground(t *testing.T) {
+	b := background{
+		top:    color.RGBA{R: 100, G: 100, B: 100, A: 255},
+		bottom: color.RGBA{R: 0, G: 0, B: 0, A: 255},
+	}
+	r := image.Rect(0, 0, 10, 101)
+	img := b.render(r, 1)
+	for _, tt := range []struct {
+		y    int
+		want uint8
+	}{
+		{0, 100},
+		{50, 50},
+		{100, 0},
+	} {
+		if got := img.RGBAAt(5, tt.y); got.R != tt.want || got.A != 255 {
+			t.Errorf("row %d = %v, want %d", tt.y, got, tt.want)
+		}
+	}
+
+	b.pattern = "dots"
+	img = b.render(r, 1)
+	if got, want := img.RGBAAt(0, 0).R, uint8(100+patternLight); got != want {
+		t.Errorf("dot = %d, want %d", got, want)
+	}
+	if got, want := img.RGBAAt(5, 0).R, uint8(100); got != want {
+		t.Errorf("between dots = %d, want %d", got, want)
+	}
+}
+
+func TestDitherBGR565(t *testing.T) {
+	// 50 is between 48 and 56, which BGR565 can represent for red and blue:
+	// dithering must mix both in the ratio 3:1 instead of always using 48.
+	r := image.Rect(0, 0, 4, 4)
+	src := uniformRGBA(r, color.RGBA{R: 50, G: 50, B: 50, A: 0xff})
+	dst := &fbimage.BGR565{Pix: make([]byte, 2*r.Dx()*r.Dy()), Rect: r, Stride: 2 * r.Dx()}
+	copyRGBAtoBGR565(dst, src, r, nil, true)
+	var sum int
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			sum += int(dst.At(x, y).(color.NRGBA).R)
+		}
+	}
+	if got, want := float64(sum)/16, 50.0; got != want {
+		t.Errorf("average red = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// crashLoopBadge returns the text of the crash-loop badge, which lists the
+// services that are crash-looping or permanently stopped, or the empty
+// string if all services are fine.
+func crashLoopBadge(services []serviceState) string {
+	var looping, stopped []string
+	for _, svc := range services {
+		switch {
+		case svc.crashLooping:
+			looping = append(looping, svc.name)
+		case svc.state == "stopped":
+			stopped = append(stopped, svc.name)
+		}
+	}
+	var parts []string
+	if len(looping) > 0 {
+		parts = append(parts, "crash-looping: "+strings.Join(looping, ", "))
+	}
+	if len(stopped) > 0 {
+		parts = append(parts, "stopped: "+strings.Join(stopped, ", "))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// drawBadge draws text in white on a red rounded rectangle whose top edge is
+// at y. If alignRight is true, x specifies the right edge of the badge,
+// otherwise the left edge. It returns the bottom edge of the badge.
+func drawBadge(dc *gg.Context, text string, x, y float64, alignRight bool) float64 {
+	return drawBadgeLines(dc, []string{text}, x, y, alignRight)
+}
+
+// drawBadgeLines is like drawBadge, but for multiple lines of text.
+func drawBadgeLines(dc *gg.Context, lines []string, x, y float64, alignRight bool) float64 {
+	em, _ := dc.MeasureString("m")
+	var w float64
+	for idx, line := range lines {
+		lines[idx] = fitString(dc, line, float64(dc.Width())-8*em)
+		if lw, _ := dc.MeasureString(lines[idx]); lw > w {
+			w = lw
+		}
+	}
+	w += 2 * em
+	lineHeight := dc.FontHeight() * lineSpacing
+	h := dc.FontHeight()*2 + float64(len(lines)-1)*lineHeight
+	if alignRight {
+		x -= w
+	}
+	dc.Push()
+	defer dc.Pop()
+	setColor(dc, "red")
+	dc.DrawRoundedRectangle(x, y, w, h, em/2)
+	dc.Fill()
+	dc.SetRGB(1, 1, 1)
+	for idx, line := range lines {
+		dc.DrawStringAnchored(line, x+em, y+dc.FontHeight()+float64(idx)*lineHeight, 0, 0.35)
+	}
+	return y + h
+}
+
+// drawBadges draws the “previous boot crashed” and crash-loop badges (if
+// any) into dc, one below the other.
+func (d *statusDrawer) drawBadges(dc *gg.Context, alignRight bool) {
+	em, _ := dc.MeasureString("m")
+	x := 3 * em
+	if alignRight {
+		x = float64(dc.Width()) - 3*em
+	}
+	y := em
+	if lines := d.pstore.badgeLines(); len(lines) > 0 {
+		y = drawBadgeLines(dc, lines, x, y, alignRight) + em/2
+	}
+	services, updated, _ := d.services.get()
+	if updated.IsZero() {
+		return
+	}
+	if text := crashLoopBadge(services); text != "" {
+		drawBadge(dc, text, x, y, alignRight)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/gokrazy/fbstatus/internal/btmgmt"
+)
+
+// bluezStorage is where bluetoothd stores information about known devices,
+// from which we take the device names.
+const bluezStorage = "/var/lib/bluetooth"
+
+type bluetoothDevice struct {
+	addr    btmgmt.Address
+	name    string
+	battery int // percent, or -1 if unknown
+}
+
+type bluetoothController struct {
+	info    btmgmt.Info
+	devices []bluetoothDevice
+}
+
+// bluetoothDeviceName returns the name which bluetoothd recorded for the
+// device, or the empty string.
+func bluetoothDeviceName(storage string, controller, device btmgmt.Address) string {
+	f, err := os.Open(filepath.Join(storage, controller.String(), device.String(), "info"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if name := strings.TrimPrefix(scanner.Text(), "Name="); name != scanner.Text() {
+			return name
+		}
+	}
+	return ""
+}
+
+// bluetoothBattery returns the battery level of a device which the kernel
+// knows about (e.g. HID devices, which show up as
+// /sys/class/power_supply/hid-<address>-battery), or -1.
+func bluetoothBattery(powerSupply string, device btmgmt.Address) int {
+	matches, _ := filepath.Glob(filepath.Join(powerSupply, "*"+strings.ToLower(device.String())+"*", "capacity"))
+	for _, m := range matches {
+		b, err := os.ReadFile(m)
+		if err != nil {
+			continue
+		}
+		if capacity, err := strconv.Atoi(strings.TrimSpace(string(b))); err == nil {
+			return capacity
+		}
+	}
+	return -1
+}
+
+func readBluetooth() ([]bluetoothController, error) {
+	conn, err := btmgmt.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	indexes, err := conn.Controllers()
+	if err != nil {
+		return nil, err
+	}
+	controllers := make([]bluetoothController, 0, len(indexes))
+	for _, index := range indexes {
+		info, err := conn.Info(index)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := conn.Connections(index)
+		if err != nil {
+			return nil, err
+		}
+		c := bluetoothController{info: info}
+		for _, addr := range addrs {
+			c.devices = append(c.devices, bluetoothDevice{
+				addr:    addr,
+				name:    bluetoothDeviceName(bluezStorage, info.Address, addr),
+				battery: bluetoothBattery("/sys/class/power_supply", addr),
+			})
+		}
+		controllers = append(controllers, c)
+	}
+	return controllers, nil
+}
+
+// bluetoothPanel shows the Bluetooth controllers and their connected devices.
+type bluetoothPanel struct {
+	controllers *poller[[]bluetoothController]
+}
+
+func newBluetoothPanel() (panel, error) {
+	return &bluetoothPanel{
+		controllers: newPoller(5*time.Second, func(context.Context) ([]bluetoothController, error) {
+			return readBluetooth()
+		}),
+	}, nil
+}
+
+func (p *bluetoothPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Bluetooth")
+	controllers, updated, err := p.controllers.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	if len(controllers) == 0 {
+		d.drawMessage(dc, y, "no controllers")
+		return nil
+	}
+	lineHeight := dc.FontHeight() * lineSpacing
+	for _, c := range controllers {
+		state := "powered"
+		if !c.info.Powered {
+			state = "off"
+		}
+		d.drawMessage(dc, y, "hci"+strconv.Itoa(int(c.info.Index))+" "+c.info.Address.String()+" "+c.info.Name+": "+state)
+		y += lineHeight
+		if len(c.devices) == 0 {
+			continue
+		}
+		rows := make([][]cell, 0, len(c.devices))
+		for _, dev := range c.devices {
+			battery := cell{text: "-", color: "darkgray"}
+			if dev.battery >= 0 {
+				battery = cell{text: strconv.Itoa(dev.battery) + "%"}
+				if dev.battery < 20 {
+					battery.color = "red"
+				}
+			}
+			rows = append(rows, []cell{
+				{text: dev.name},
+				{text: dev.addr.String(), color: "darkgray"},
+				battery,
+			})
+		}
+		y = d.drawTable(dc, y, []string{"device", "address", "battery"}, rows) + lineHeight/2
+	}
+	return nil
+}
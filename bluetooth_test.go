@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gokrazy/fbstatus/internal/btmgmt"
+)
+
+func TestBluetoothNameAndBattery(t *testing.T) {
+	controller := btmgmt.Address{Addr: [6]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}}
+	device := btmgmt.Address{Addr: [6]byte{0x13, 0x71, 0xda, 0x7d, 0x1a, 0x00}}
+
+	storage := t.TempDir()
+	dir := filepath.Join(storage, "06:05:04:03:02:01", "00:1A:7D:DA:71:13")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "info"), []byte("[General]\nName=Keyboard K380\nClass=0x002540\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bluetoothDeviceName(storage, controller, device), "Keyboard K380"; got != want {
+		t.Errorf("bluetoothDeviceName() = %q, want %q", got, want)
+	}
+
+	powerSupply := t.TempDir()
+	if got := bluetoothBattery(powerSupply, device); got != -1 {
+		t.Errorf("bluetoothBattery() = %d, want -1", got)
+	}
+	dir = filepath.Join(powerSupply, "hid-00:1a:7d:da:71:13-battery")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "capacity"), []byte("85\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bluetoothBattery(powerSupply, device), 85; got != want {
+		t.Errorf("bluetoothBattery() = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+var (
+	waitForFlag = flag.String("wait-for",
+		"",
+		"comma-separated conditions to wait for before taking over the console, so that the kernel's and gokrazy's boot messages stay readable until the system is up: services (all services supervised by gokrazy are up, like with -splash) and clock (the system clock was set, e.g. via NTP). Empty takes over the console immediately")
+
+	waitForTimeout = flag.Duration("wait-for-timeout",
+		5*time.Minute,
+		"with -wait-for, the maximum duration to wait, e.g. when a service never comes up or there is no network connectivity")
+)
+
+// bootPollInterval is how often the conditions of -wait-for are checked.
+const bootPollInterval = time.Second
+
+// bootGate waits for the conditions of -wait-for.
+type bootGate struct {
+	services bool
+	clock    bool
+	deadline time.Time
+
+	settled time.Time // when all services were first seen up, or zero
+}
+
+func parseBootGate(spec string) (*bootGate, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	g := &bootGate{}
+	for _, cond := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(cond) {
+		case "services":
+			g.services = true
+		case "clock":
+			g.clock = true
+		default:
+			return nil, fmt.Errorf("unknown -wait-for condition %q, expected services or clock", cond)
+		}
+	}
+	return g, nil
+}
+
+// clockSet reports whether the system clock was set, using the same
+// heuristic as gokrazy.WaitForClock().
+func clockSet(now time.Time) bool {
+	return !now.Before(time.Unix(60*60*24*365, 0))
+}
+
+// ready reports whether all conditions are met (or the deadline passed),
+// given the service listing polled at now (nil if not polled yet). If not,
+// the returned reason describes what is still missing.
+func (g *bootGate) ready(services []serviceState, now time.Time) (bool, string) {
+	if now.After(g.deadline) {
+		return true, ""
+	}
+	if g.clock && !clockSet(now) {
+		return false, "clock not set"
+	}
+	if g.services {
+		up := len(services) > 0
+		for _, svc := range services {
+			up = up && serviceUp(svc)
+		}
+		switch {
+		case !up:
+			g.settled = time.Time{}
+			return false, "services starting"
+		case g.settled.IsZero():
+			g.settled = now
+			return false, "services settling"
+		case now.Sub(g.settled) < splashSettle:
+			return false, "services settling"
+		}
+	}
+	return true, ""
+}
+
+// wait blocks until the system is up as per the conditions of g.
+func (g *bootGate) wait(ctx context.Context) error {
+	g.deadline = time.Now().Add(*waitForTimeout)
+	var services *poller[[]serviceState]
+	if g.services {
+		services = newServicesPoller(bootPollInterval, false)
+	}
+	tick := time.NewTicker(bootPollInterval)
+	defer tick.Stop()
+	var last string
+	for {
+		var states []serviceState
+		if services != nil {
+			states, _, _ = services.get()
+		}
+		now := time.Now()
+		ok, reason := g.ready(states, now)
+		if ok {
+			if now.After(g.deadline) {
+				slog.Warn("-wait-for timeout exceeded, taking over the console", "timeout", *waitForTimeout, "waiting", last)
+			} else {
+				slog.Info("system is up, taking over the console")
+			}
+			return nil
+		}
+		if reason != last {
+			slog.Info("waiting before taking over the console", "reason", reason)
+			last = reason
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+}
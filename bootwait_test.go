@@ -0,0 +1,122 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBootGate(t *testing.T) {
+	for _, tt := range []struct {
+		spec               string
+		services, clock    bool
+		wantNil, wantError bool
+	}{
+		{spec: "", wantNil: true},
+		{spec: "services", services: true},
+		{spec: "clock", clock: true},
+		{spec: "clock, services", services: true, clock: true},
+		{spec: "network", wantError: true},
+	} {
+		g, err := parseBootGate(tt.spec)
+		if tt.wantError {
+			if err == nil {
+				t.Errorf("parseBootGate(%q) succeeded unexpectedly", tt.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseBootGate(%q): %v", tt.spec, err)
+			continue
+		}
+		if tt.wantNil {
+			if g != nil {
+				t.Errorf("parseBootGate(%q) = %+v, want nil", tt.spec, g)
+			}
+			continue
+		}
+		if g.services != tt.services || g.clock != tt.clock {
+			t.Errorf("parseBootGate(%q) = services %v, clock %v, want services %v, clock %v", tt.spec, g.services, g.clock, tt.services, tt.clock)
+		}
+	}
+}
+
+func TestBootGateReady(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	unset := time.Unix(42, 0)
+	running := serviceState{name: "fbstatus", state: "running"}
+	exited := serviceState{name: "backupd", state: "exited"}
+
+	for _, tt := range []struct {
+		desc     string
+		gate     bootGate
+		services [][]serviceState // polled every second
+		start    time.Time
+		want     bool
+	}{
+		{
+			desc:     "clock set",
+			gate:     bootGate{clock: true},
+			services: [][]serviceState{nil},
+			start:    start,
+			want:     true,
+		},
+		{
+			desc:     "clock not set",
+			gate:     bootGate{clock: true},
+			services: [][]serviceState{nil, nil},
+			start:    unset,
+			want:     false,
+		},
+		{
+			desc: "services settled",
+			gate: bootGate{services: true},
+			services: [][]serviceState{
+				{running, exited},
+				{running, running},
+				{running, running},
+				{running, running},
+				{running, running},
+			},
+			start: start,
+			want:  true,
+		},
+		{
+			desc: "services restarting",
+			gate: bootGate{services: true},
+			services: [][]serviceState{
+				{running, running},
+				{running, running},
+				{running, exited},
+				{running, running},
+			},
+			start: start,
+			want:  false,
+		},
+		{
+			desc:     "no services yet",
+			gate:     bootGate{services: true},
+			services: [][]serviceState{nil, nil, nil, nil, nil},
+			start:    start,
+			want:     false,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			g := tt.gate
+			g.deadline = tt.start.Add(time.Minute)
+			var got bool
+			for idx, services := range tt.services {
+				got, _ = g.ready(services, tt.start.Add(time.Duration(idx)*time.Second))
+			}
+			if got != tt.want {
+				t.Errorf("ready() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		g := &bootGate{services: true, clock: true, deadline: unset.Add(time.Minute)}
+		if got, _ := g.ready(nil, unset.Add(2*time.Minute)); !got {
+			t.Errorf("ready() after the deadline = false, want true")
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"strings"
+)
+
+var breakglassPort = flag.Int("breakglass-port",
+	22,
+	"TCP port on which breakglass (the gokrazy SSH server) listens")
+
+// breakglassLine returns a host information line (in $color$text markup)
+// indicating whether the box is currently being administered remotely, or
+// the empty string if breakglass is not running.
+func breakglassLine() string {
+	remotes, listening, err := tcpPeers(*breakglassPort)
+	if err != nil || !listening {
+		return ""
+	}
+	if len(remotes) == 0 {
+		return "$$breakglass: $darkgray$no active session"
+	}
+	return "$$breakglass: $yellow$active session from " + strings.Join(remotes, ", ")
+}
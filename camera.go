@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"image"
+	"time"
+
+	"github.com/gokrazy/fbstatus/internal/v4l2"
+)
+
+var (
+	cameraDevice = flag.String("camera-device",
+		"/dev/video0",
+		"Video4Linux2 device to capture snapshots from for the camera panel")
+
+	cameraInterval = flag.Duration("camera-interval",
+		10*time.Second,
+		"how often the camera panel captures a new snapshot")
+)
+
+// cameraWidth and cameraHeight are the requested capture size, which is
+// plenty for a panel and supported by practically all cameras.
+const (
+	cameraWidth  = 640
+	cameraHeight = 480
+)
+
+func newCameraPanel() (panel, error) {
+	return &imagePanel{
+		title: "Camera " + *cameraDevice,
+		img: newPoller(*cameraInterval, func(context.Context) (image.Image, error) {
+			return v4l2.Capture(*cameraDevice, cameraWidth, cameraHeight)
+		}),
+	}, nil
+}
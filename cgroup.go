@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseProcCgroup returns the cgroup v2 path of a process from the contents
+// of /proc/[pid]/cgroup, or the empty string if it is not in any.
+func parseProcCgroup(b []byte) string {
+	for _, line := range strings.Split(string(b), "\n") {
+		if path := strings.TrimPrefix(line, "0::"); path != line {
+			return path
+		}
+	}
+	return ""
+}
+
+// resourceUsage is the resource usage of a service.
+type resourceUsage struct {
+	cpu    time.Duration // cumulative
+	memory uint64        // bytes
+}
+
+// cgroupUsage returns the resource usage of the processes in the cgroup at
+// path (as per /proc/[pid]/cgroup). The memory usage includes the page cache
+// of the cgroup.
+func cgroupUsage(path string) (resourceUsage, error) {
+	dir := filepath.Join(cgroupRoot, path)
+	b, err := os.ReadFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return resourceUsage{}, err
+	}
+	cpu, err := parseCgroupCPUUsage(b)
+	if err != nil {
+		return resourceUsage{}, err
+	}
+	b, err = os.ReadFile(filepath.Join(dir, "memory.current"))
+	if err != nil {
+		return resourceUsage{}, err
+	}
+	memory, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return resourceUsage{}, err
+	}
+	return resourceUsage{cpu: cpu, memory: memory}, nil
+}
+
+// processTreeUsage returns the resource usage of process pid and all its
+// descendants in procs, for services which do not have a cgroup of their own.
+func processTreeUsage(procs map[int]procSample, pid int) resourceUsage {
+	children := make(map[int][]int)
+	for _, p := range procs {
+		children[p.ppid] = append(children[p.ppid], p.pid)
+	}
+	var u resourceUsage
+	queue := []int{pid}
+	seen := make(map[int]bool)
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		sample, ok := procs[p]
+		if !ok || seen[p] {
+			continue
+		}
+		seen[p] = true
+		u.cpu += time.Duration(sample.ticks) * time.Second / clockTicks
+		u.memory += sample.rss
+		queue = append(queue, children[p]...)
+	}
+	return u
+}
+
+// serviceUsageTracker derives the CPU usage of services from consecutive
+// samples of their cumulative CPU time.
+type serviceUsageTracker struct {
+	prev     map[string]time.Duration // CPU time, by service
+	prevTime time.Time
+}
+
+// update sets the CPU and memory usage of the running services in states,
+// preferring the cgroup of a service (if gokrazy placed it into one of its
+// own) over summing up its process tree.
+func (t *serviceUsageTracker) update(states []serviceState, now time.Time) {
+	cgroups := make(map[int]string) // by pid
+	shared := make(map[string]int)  // number of services, by cgroup
+	for _, svc := range states {
+		if svc.state != "running" {
+			continue
+		}
+		b, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", svc.pid))
+		if err != nil {
+			continue
+		}
+		if path := parseProcCgroup(b); path != "" && path != "/" {
+			cgroups[svc.pid] = path
+			shared[path]++
+		}
+	}
+
+	var procs map[int]procSample
+	elapsed := now.Sub(t.prevTime)
+	cur := make(map[string]time.Duration)
+	for idx := range states {
+		svc := &states[idx]
+		if svc.state != "running" {
+			continue
+		}
+		var u resourceUsage
+		var err error
+		if path := cgroups[svc.pid]; path != "" && shared[path] == 1 {
+			u, err = cgroupUsage(path)
+			svc.cgroup = err == nil
+		}
+		if !svc.cgroup {
+			if procs == nil {
+				if procs, err = sampleProcesses(); err != nil {
+					return
+				}
+			}
+			if _, ok := procs[svc.pid]; !ok {
+				continue // exited in the meantime
+			}
+			u = processTreeUsage(procs, svc.pid)
+		}
+		svc.memory = u.memory
+		svc.hasUsage = true
+		if prev, ok := t.prev[svc.name]; ok && elapsed > 0 && u.cpu >= prev {
+			svc.cpu = 100 * float64(u.cpu-prev) / float64(elapsed)
+		}
+		cur[svc.name] = u.cpu
+	}
+	t.prev, t.prevTime = cur, now
+}
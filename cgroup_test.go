@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseProcCgroup(t *testing.T) {
+	for _, tt := range []struct {
+		contents string
+		want     string
+	}{
+		{"0::/gokrazy/backupd\n", "/gokrazy/backupd"},
+		{"0::/\n", "/"},
+		// cgroup v1 hierarchies in hybrid mode are ignored
+		{"12:cpu,cpuacct:/foo\n0::/bar\n", "/bar"},
+		{"12:cpu,cpuacct:/foo\n", ""},
+	} {
+		if got := parseProcCgroup([]byte(tt.contents)); got != tt.want {
+			t.Errorf("parseProcCgroup(%q) = %q, want %q", tt.contents, got, tt.want)
+		}
+	}
+}
+
+func TestProcessTreeUsage(t *testing.T) {
+	procs := map[int]procSample{
+		1:   {pid: 1, ppid: 0, ticks: 1000, rss: 1 << 20},
+		100: {pid: 100, ppid: 1, ticks: 150, rss: 4 << 20},
+		101: {pid: 101, ppid: 100, ticks: 50, rss: 2 << 20},
+		102: {pid: 102, ppid: 101, ticks: 100, rss: 1 << 20},
+		200: {pid: 200, ppid: 1, ticks: 999, rss: 8 << 20},
+	}
+	got := processTreeUsage(procs, 100)
+	want := resourceUsage{cpu: 3 * time.Second, memory: 7 << 20}
+	if got != want {
+		t.Errorf("processTreeUsage(100) = %+v, want %+v", got, want)
+	}
+}
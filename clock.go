@@ -0,0 +1,62 @@
+package main
+
+import (
+	"time"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+// clockPanel shows the time in huge digits, plus date and uptime, for
+// deployments which are primarily used as a wall clock.
+type clockPanel struct {
+	// faces are created on first draw, when the panel size is known
+	height       int
+	digits, date font.Face
+}
+
+func newClockPanel() (panel, error) {
+	return &clockPanel{}, nil
+}
+
+func (p *clockPanel) faces(d *statusDrawer, dc *gg.Context) {
+	if p.height == dc.Height() {
+		return
+	}
+	p.height = dc.Height()
+	// Start with digits filling a third of the panel height, then shrink
+	// them until they fit the width.
+	size := float64(dc.Height()) / 3
+	for {
+		p.digits = d.newFace(d.regular, size)
+		dc.SetFontFace(p.digits)
+		if w, _ := dc.MeasureString("00:00:00"); w <= 0.9*float64(dc.Width()) {
+			break
+		}
+		size *= 0.9
+	}
+	p.date = d.newFace(d.regular, size/4)
+}
+
+func (p *clockPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	p.faces(d, dc)
+	now := time.Now()
+	cx := float64(dc.Width()) / 2
+	cy := float64(dc.Height()) / 2
+
+	dc.Push()
+	defer dc.Pop()
+	dc.SetFontFace(p.digits)
+	dc.DrawStringAnchored(now.Format("15:04:05"), cx, cy, 0.5, 0.35)
+	digitsH := dc.FontHeight()
+
+	dc.SetFontFace(p.date)
+	y := cy + digitsH/2 + dc.FontHeight()
+	dc.DrawStringAnchored(now.Format("Monday, 2 January 2006"), cx, y, 0.5, 0.5)
+	if up, err := uptime(); err == nil {
+		setColor(dc, "darkgray")
+		y += dc.FontHeight() * lineSpacing
+		dc.DrawStringAnchored("up for "+up, cx, y, 0.5, 0.5)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"net"
+	"strings"
+)
+
+// configLines returns the lines (in $color$text markup) of the configuration
+// overlay, which tells users how to reach the appliance and which pages are
+// available. current is the page currently displayed.
+func (d *statusDrawer) configLines(current *page) []string {
+	lines := []string{
+		"$$gokrazy web interface: $blue$http://" + d.hostname + "/",
+	}
+	if *httpListen != "" {
+		if _, port, err := net.SplitHostPort(*httpListen); err == nil {
+			lines = append(lines, "$$fbstatus web interface: $blue$http://"+d.hostname+":"+port+"/")
+		}
+	}
+	private, public := interfaceAddrs()
+	if addrs := append(private, public...); len(addrs) > 0 {
+		lines = append(lines, "$$addresses: "+strings.Join(addrs, ", "))
+	}
+	pages := make([]string, 0, len(d.pages))
+	for idx, pg := range d.pages {
+		color := "$$"
+		if pg == current {
+			color = "$green$"
+		}
+		pages = append(pages, fmt.Sprintf("%s%d %s", color, idx+1, pg.name))
+	}
+	lines = append(lines, "$$pages: "+strings.Join(pages, "$$, "))
+	lines = append(lines, "$$fbstatus version: "+fbstatusVersion())
+	return lines
+}
+
+// drawConfigOverlay draws the configuration overlay, which is shown after
+// touching and holding the touchscreen, over the center of the display.
+func (d *statusDrawer) drawConfigOverlay(current *page) {
+	d.drawTextOverlay("fbstatus on "+d.hostname, d.configLines(current), "tap to close")
+}
+
+// drawTextOverlay draws a box with title, lines (in $color$text markup) and
+// footer over the center of the display.
+func (d *statusDrawer) drawTextOverlay(title string, lines []string, footer string) {
+	em := 16 * d.scaleFactor // font size of the host information
+	r := image.Rect(d.w/10, d.h/10, d.w*9/10, d.h*9/10)
+	dc := d.overlayContext(r)
+	dc.SetRGBA(0, 0, 0, 0.9)
+	dc.DrawRoundedRectangle(0, 0, float64(r.Dx()), float64(r.Dy()), em)
+	dc.Fill()
+
+	dc.SetFontFace(d.faces.large)
+	dc.SetRGB(1, 1, 1)
+	y := 3 * em
+	dc.DrawString(fitString(dc, title, float64(r.Dx())-4*em), 2*em, y)
+
+	dc.SetFontFace(d.faces.text)
+	lineHeight := dc.FontHeight() * lineSpacing
+	y += lineHeight
+	for _, line := range lines {
+		y += lineHeight
+		drawMarkup(dc, fitMarkup(dc, line, float64(r.Dx())-4*em), 2*em, y)
+	}
+
+	setColor(dc, "darkgray")
+	dc.DrawStringAnchored(footer, float64(r.Dx())/2, float64(r.Dy())-2*em, 0.5, 0)
+	d.drawOverlay(dc, r, draw.Over)
+}
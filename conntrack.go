@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gokrazy/fbstatus/internal/nftcounter"
+)
+
+var nftCountersFlag = flag.String("nft-counters",
+	"",
+	"comma-separated list of nftables counter objects to display, each specified as [label=]family/table/name, e.g. dropped=inet/filter/dropped")
+
+// conntrackLine returns a host information line (in $color$text markup)
+// with the number of connection tracking entries versus the limit, as the
+// kernel silently drops new connections once the table is full. The empty
+// string is returned if connection tracking is not in use.
+func conntrackLine() string {
+	read := func(name string) (uint64, error) {
+		b, err := os.ReadFile("/proc/sys/net/netfilter/" + name)
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	}
+	count, err := read("nf_conntrack_count")
+	if err != nil {
+		return ""
+	}
+	limit, err := read("nf_conntrack_max")
+	if err != nil || limit == 0 {
+		return ""
+	}
+	used := 100 * float64(count) / float64(limit)
+	return fmt.Sprintf("$$conntrack: $%s$%d$$ of %d entries (%.f%%)", usageColor("conntrack", used), count, limit, used)
+}
+
+type nftCounter struct {
+	label  string
+	family uint8
+	table  string
+	name   string
+}
+
+func parseNFTCounters(spec string) ([]nftCounter, error) {
+	var counters []nftCounter
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		label, path, hasLabel := strings.Cut(s, "=")
+		if !hasLabel {
+			path = s
+		}
+		parts := strings.Split(path, "/")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("malformed nftables counter %q: expected [label=]family/table/name", s)
+		}
+		family, ok := nftcounter.Families[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("malformed nftables counter %q: unknown family %q", s, parts[0])
+		}
+		if !hasLabel {
+			label = parts[2]
+		}
+		counters = append(counters, nftCounter{
+			label:  label,
+			family: family,
+			table:  parts[1],
+			name:   parts[2],
+		})
+	}
+	return counters, nil
+}
+
+// nftCountersLine returns a host information line (in $color$text markup)
+// with the values of the specified counters.
+func nftCountersLine(counters []nftCounter) string {
+	descs := make([]string, 0, len(counters))
+	for _, c := range counters {
+		val, err := nftcounter.Get(c.family, c.table, c.name)
+		if err != nil {
+			descs = append(descs, "$$"+c.label+" $red$unavailable")
+			continue
+		}
+		descs = append(descs, fmt.Sprintf("$$%s %d packets (%s)", c.label, val.Packets, formatBytes(val.Bytes)))
+	}
+	return "$$nftables: " + strings.Join(descs, "$$, ")
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseNFTCounters(t *testing.T) {
+	got, err := parseNFTCounters("dropped=inet/filter/dropped,ip/nat/masqueraded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []nftCounter{
+		{label: "dropped", family: unix.NFPROTO_INET, table: "filter", name: "dropped"},
+		{label: "masqueraded", family: unix.NFPROTO_IPV4, table: "nat", name: "masqueraded"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNFTCounters() = %+v, want %+v", got, want)
+	}
+	for _, spec := range []string{"filter/dropped", "foo/filter/dropped"} {
+		if _, err := parseNFTCounters(spec); err == nil {
+			t.Errorf("parseNFTCounters(%q) unexpectedly succeeded", spec)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+var podmanStorage = flag.String("podman-storage",
+	"/var/lib/containers/storage",
+	"podman storage root (graphroot in storage.conf), used for displaying container names in the containers panel")
+
+// cgroupRoot is where gokrazy mounts the cgroup2 hierarchy.
+const cgroupRoot = "/sys/fs/cgroup"
+
+type containerSample struct {
+	id  string
+	cpu time.Duration // usage_usec from cpu.stat
+	mem uint64        // bytes, from memory.current
+}
+
+// parseCgroupCPUUsage returns the usage_usec field of a cgroup2 cpu.stat
+// file.
+func parseCgroupCPUUsage(b []byte) (time.Duration, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		key, val, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || key != "usage_usec" {
+			continue
+		}
+		usec, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(usec) * time.Microsecond, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("usage_usec not found in cpu.stat")
+}
+
+// containerID extracts the container ID from a podman cgroup directory name,
+// which is libpod-<id> with the cgroupfs cgroup manager (used on gokrazy)
+// and libpod-<id>.scope with the systemd cgroup manager.
+func containerID(dir string) (string, bool) {
+	base := filepath.Base(dir)
+	if !strings.HasPrefix(base, "libpod-") || strings.HasPrefix(base, "libpod-conmon-") {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(base, "libpod-"), ".scope"), true
+}
+
+func sampleContainers(root string) ([]containerSample, error) {
+	var dirs []string
+	for _, pattern := range []string{
+		"libpod_parent/libpod-*",
+		"machine.slice/libpod-*.scope",
+	} {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, matches...)
+	}
+	var samples []containerSample
+	for _, dir := range dirs {
+		id, ok := containerID(dir)
+		if !ok {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "cpu.stat"))
+		if err != nil {
+			continue // container exited in the meantime
+		}
+		cpu, err := parseCgroupCPUUsage(b)
+		if err != nil {
+			continue
+		}
+		b, err = os.ReadFile(filepath.Join(dir, "memory.current"))
+		if err != nil {
+			continue
+		}
+		mem, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, containerSample{
+			id:  id,
+			cpu: cpu,
+			mem: mem,
+		})
+	}
+	return samples, nil
+}
+
+// containerNames returns the names of all containers known to podman, keyed
+// by container ID.
+func containerNames(storage string) map[string]string {
+	names := make(map[string]string)
+	b, err := os.ReadFile(filepath.Join(storage, "overlay-containers", "containers.json"))
+	if err != nil {
+		return names
+	}
+	var containers []struct {
+		ID    string   `json:"id"`
+		Names []string `json:"names"`
+	}
+	if err := json.Unmarshal(b, &containers); err != nil {
+		return names
+	}
+	for _, c := range containers {
+		if len(c.Names) > 0 {
+			names[c.ID] = c.Names[0]
+		}
+	}
+	return names
+}
+
+type containerUsage struct {
+	name string
+	cpu  float64 // percent of one CPU
+	mem  uint64
+}
+
+// containersPanel shows the podman containers which are currently running,
+// with their resource usage as accounted in their cgroup (the processes
+// running inside containers are not supervised by gokrazy).
+type containersPanel struct {
+	containers *poller[[]containerUsage]
+}
+
+func newContainersPanel() (panel, error) {
+	var (
+		prev     = make(map[string]time.Duration)
+		prevTime time.Time
+	)
+	return &containersPanel{
+		containers: newPoller(3*time.Second, func(context.Context) ([]containerUsage, error) {
+			samples, err := sampleContainers(cgroupRoot)
+			if err != nil {
+				return nil, err
+			}
+			names := containerNames(*podmanStorage)
+			now := time.Now()
+			elapsed := now.Sub(prevTime)
+			cur := make(map[string]time.Duration, len(samples))
+			usage := make([]containerUsage, 0, len(samples))
+			for _, sample := range samples {
+				cur[sample.id] = sample.cpu
+				name, ok := names[sample.id]
+				if !ok {
+					name = sample.id
+					if len(name) > 12 {
+						name = name[:12]
+					}
+				}
+				u := containerUsage{
+					name: name,
+					mem:  sample.mem,
+				}
+				if old, ok := prev[sample.id]; ok && elapsed > 0 {
+					u.cpu = 100 * float64(sample.cpu-old) / float64(elapsed)
+				}
+				usage = append(usage, u)
+			}
+			prev, prevTime = cur, now
+			sort.Slice(usage, func(i, j int) bool { return usage[i].name < usage[j].name })
+			return usage, nil
+		}),
+	}, nil
+}
+
+func (p *containersPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Containers")
+	containers, updated, err := p.containers.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	if len(containers) == 0 {
+		d.drawMessage(dc, y, "no running containers")
+		return nil
+	}
+	rows := make([][]cell, 0, len(containers))
+	for _, c := range containers {
+		cpuColor := "darkgray"
+		if c.cpu >= 1 {
+			cpuColor = ""
+		}
+		rows = append(rows, []cell{
+			{text: c.name},
+			{text: fmt.Sprintf("%5.1f%%", c.cpu), color: cpuColor},
+			{text: formatBytes(c.mem)},
+		})
+	}
+	d.drawTable(dc, y, []string{"container", "cpu", "memory"}, rows)
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCgroupCPUUsage(t *testing.T) {
+	const cpuStat = `usage_usec 1234567
+user_usec 1000000
+system_usec 234567
+nr_periods 0
+nr_throttled 0
+throttled_usec 0
+`
+	got, err := parseCgroupCPUUsage([]byte(cpuStat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1234567 * time.Microsecond; got != want {
+		t.Errorf("parseCgroupCPUUsage() = %v, want %v", got, want)
+	}
+}
+
+func TestContainerID(t *testing.T) {
+	for _, tt := range []struct {
+		dir    string
+		wantID string
+		wantOK bool
+	}{
+		{"/sys/fs/cgroup/libpod_parent/libpod-0123abcd", "0123abcd", true},
+		{"/sys/fs/cgroup/machine.slice/libpod-0123abcd.scope", "0123abcd", true},
+		{"/sys/fs/cgroup/libpod_parent/libpod-conmon-0123abcd", "", false},
+	} {
+		id, ok := containerID(tt.dir)
+		if id != tt.wantID || ok != tt.wantOK {
+			t.Errorf("containerID(%q) = %q, %v, want %q, %v", tt.dir, id, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mqttControlTopic = flag.String("mqtt-control-topic",
+		"",
+		"if non-empty, MQTT topic prefix (e.g. fbstatus/living-room) under which fbstatus subscribes to <prefix>/page, <prefix>/blank, <prefix>/hud, <prefix>/notify, <prefix>/rebooting and <prefix>/maintenance to be controlled remotely, mirroring the HTTP endpoints of -http-listen. Requires -mqtt-broker")
+
+	blankAfter = flag.Duration("blank-after",
+		0,
+		"if non-zero, blank the display (like a screensaver) when there was no keyboard, touch or remote control input for this long")
+)
+
+// defaultWakeDuration is how long the display stays on after input woke it
+// up from being blanked remotely, unless -blank-after is set.
+const defaultWakeDuration = time.Minute
+
+// displayControl holds the display state which can be changed remotely, via
+// HTTP (-http-listen) or MQTT (-mqtt-control-topic), or via input devices.
+type displayControl struct {
+	mu      sync.Mutex
+	page    int       // index into statusDrawer.pages, or -1 to rotate
+	rotated time.Time // when rotating pages (re)started
+	blanked bool
+	config  bool // whether the configuration overlay is shown
+	hud     bool // whether the debug HUD is shown
+	legend  bool // whether the legend of the resource usage table is shown
+
+	lastInput time.Time
+	woken     bool // whether input woke up the display since it was blanked
+
+	schedule *displaySchedule // nil if -display-schedule is empty
+	// overridden is until when the display is on although schedule says
+	// off, because it was turned on remotely.
+	overridden time.Time
+
+	presence *presenceMonitor // nil if -presence is empty
+
+	// changed is signaled when the page or blanking changed, so that the
+	// display is redrawn without waiting for the next frame.
+	changed chan struct{}
+}
+
+func newDisplayControl() *displayControl {
+	now := time.Now()
+	return &displayControl{
+		page:      -1,
+		rotated:   now,
+		lastInput: now,
+		changed:   make(chan struct{}, 1),
+	}
+}
+
+func (c *displayControl) notify() {
+	select {
+	case c.changed <- struct{}{}:
+	default: // a redraw is already pending
+	}
+}
+
+// pinnedPage returns the index of the page selected remotely, if any.
+func (c *displayControl) pinnedPage() (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.page, c.page > -1
+}
+
+// rotationStarted returns when rotating pages (re)started, i.e. when the
+// first page was shown.
+func (c *displayControl) rotationStarted() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rotated
+}
+
+// restartRotation shows the first page, as if rotating pages just started.
+func (c *displayControl) restartRotation() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rotated = time.Now()
+}
+
+// isBlanked reports whether the display should be blank: because it was
+// blanked remotely or is off as per -display-schedule (and not woken up by
+// input within the wake duration), because none of the -presence devices
+// was seen (and there was no input) for -presence-timeout, or because there
+// was no input for -blank-after.
+func (c *displayControl) isBlanked() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isBlankedLocked()
+}
+
+func (c *displayControl) isBlankedLocked() bool {
+	now := time.Now()
+	idle := now.Sub(c.lastInput)
+	wake := defaultWakeDuration
+	if *blankAfter > 0 {
+		wake = *blankAfter
+	}
+	if c.blanked {
+		return !c.woken || idle >= wake
+	}
+	if c.scheduledOffLocked(now) {
+		return idle >= wake
+	}
+	if c.presence != nil && c.presence.absent(now, c.lastInput) {
+		return true
+	}
+	return *blankAfter > 0 && idle >= *blankAfter
+}
+
+// scheduledOffLocked reports whether the display is off at now as per
+// -display-schedule, unless it was turned on remotely.
+func (c *displayControl) scheduledOffLocked(now time.Time) bool {
+	return c.schedule != nil && !c.schedule.on(now) && !now.Before(c.overridden)
+}
+
+// setBlankedLocked blanks or unblanks the display remotely. Unblanking also
+// ends a -blank-after blanking and overrides -display-schedule until the
+// display is scheduled to be on next.
+func (c *displayControl) setBlankedLocked(blank bool) {
+	c.blanked = blank
+	c.woken = false
+	if blank {
+		c.overridden = time.Time{}
+	} else {
+		now := time.Now()
+		c.lastInput = now
+		if c.scheduledOffLocked(now) {
+			c.overridden = c.schedule.nextOn(now)
+			if c.overridden.IsZero() {
+				c.overridden = now.AddDate(0, 0, 7)
+			}
+		}
+	}
+	c.notify()
+}
+
+// wake records input activity. If the display is blank, it is woken up and
+// shows the first page, and wake returns true: the input should not have any
+// other effect.
+func (c *displayControl) wake() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	blanked := c.isBlankedLocked()
+	c.lastInput = time.Now()
+	if !blanked {
+		return false
+	}
+	if c.blanked {
+		c.woken = true
+	}
+	c.page = -1
+	c.rotated = c.lastInput
+	c.config = false
+	c.notify()
+	return true
+}
+
+func (c *displayControl) configShown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config
+}
+
+// showConfig shows or hides the configuration overlay, see config.go.
+func (c *displayControl) showConfig(show bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config = show
+	c.notify()
+}
+
+func (c *displayControl) hudShown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hud
+}
+
+// showHUD shows or hides the debug HUD, see hud.go.
+func (c *displayControl) showHUD(show bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hud = show
+	c.notify()
+}
+
+func (c *displayControl) legendShown() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.legend
+}
+
+// showLegend shows or hides the legend of the resource usage table, see
+// legend.go.
+func (c *displayControl) showLegend(show bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.legend = show
+	c.notify()
+}
+
+// setPage selects the page to display. name is either the name of a page as
+// specified in -pages (e.g. status or services+top), its 1-based number,
+// next or prev (the page following or preceding the currently displayed one)
+// or auto to resume rotating pages every -rotate interval.
+func (d *statusDrawer) setPage(name string) error {
+	name = strings.TrimSpace(name)
+	idx := -1
+	switch name {
+	case "auto", "":
+	case "next", "prev":
+		step := 1
+		if name == "prev" {
+			step = len(d.pages) - 1
+		}
+		cur := d.currentPage()
+		for i, pg := range d.pages {
+			if pg == cur {
+				idx = (i + step) % len(d.pages)
+			}
+		}
+	default:
+		for i, pg := range d.pages {
+			if pg.name == name {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			n, err := strconv.Atoi(name)
+			if err != nil || n < 1 || n > len(d.pages) {
+				return fmt.Errorf("unknown page %q", name)
+			}
+			idx = n - 1
+		}
+	}
+	d.control.mu.Lock()
+	defer d.control.mu.Unlock()
+	d.control.page = idx
+	d.control.notify()
+	return nil
+}
+
+// parseSwitch parses the payload of an on/off command, accepting the
+// payloads Home Assistant uses for switches.
+func parseSwitch(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid value %q, expected on or off", value)
+}
+
+// setBlank blanks (value on) or unblanks (value off) the display.
+func (d *statusDrawer) setBlank(value string) error {
+	blank, err := parseSwitch(value)
+	if err != nil {
+		return err
+	}
+	d.control.mu.Lock()
+	defer d.control.mu.Unlock()
+	d.control.setBlankedLocked(blank)
+	return nil
+}
+
+// toggleBlank blanks the display if it is on and vice versa.
+func (d *statusDrawer) toggleBlank() {
+	d.control.mu.Lock()
+	defer d.control.mu.Unlock()
+	d.control.setBlankedLocked(!d.control.isBlankedLocked())
+}
+
+// controlHandler returns an HTTP handler which calls fn with the form value
+// param of POST requests.
+func controlHandler(param string, fn func(string) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := fn(r.FormValue(param)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	})
+}
+
+// handleControlMessage applies an MQTT message published to one of the
+// control topics below prefix.
+func (d *statusDrawer) handleControlMessage(prefix, topic string, payload []byte) error {
+	switch strings.TrimPrefix(topic, prefix+"/") {
+	case "page":
+		return d.setPage(string(payload))
+	case "blank":
+		return d.setBlank(string(payload))
+	case "hud":
+		return d.setHUD(string(payload))
+	case "rebooting":
+		return d.announceReboot(string(payload))
+	case "maintenance":
+		return d.postponeMaintenance(string(payload))
+	case "notify":
+		notif, err := parseNotificationPayload(payload, time.Now())
+		if err != nil {
+			return err
+		}
+		d.notifier.add(notif)
+		return nil
+	}
+	return fmt.Errorf("unexpected topic %q", topic)
+}
+
+// subscribeControl connects to the broker and applies control messages until
+// the connection fails.
+func (d *statusDrawer) subscribeControl(prefix string) error {
+	ctx, canc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer canc()
+	c, err := dialMQTT(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if err := c.Subscribe(prefix+"/page", prefix+"/blank", prefix+"/hud", prefix+"/notify", prefix+"/rebooting", prefix+"/maintenance"); err != nil {
+		return err
+	}
+	for {
+		msg, err := c.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if err := d.handleControlMessage(prefix, msg.Topic, msg.Payload); err != nil {
+			slog.Warn("MQTT control message failed", "topic", msg.Topic, "err", err)
+		}
+	}
+}
+
+// mqttControl subscribes to the -mqtt-control-topic topics, reconnecting
+// whenever the connection fails.
+func (d *statusDrawer) mqttControl(prefix string) {
+	if *mqttBroker == "" {
+		slog.Error("-mqtt-control-topic requires -mqtt-broker")
+		return
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+	for {
+		if err := d.subscribeControl(prefix); err != nil {
+			slog.Warn("MQTT control subscription failed", "err", err)
+		}
+		time.Sleep(10 * time.Second)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDisplayControl(t *testing.T) {
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	*pagesFlag = "status,clock+version"
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		want string // page name, empty for rotation
+	}{
+		{"clock+version", "clock+version"},
+		{"1", "status"},
+		{"next", "clock+version"},
+		{"next", "status"},
+		{"prev", "clock+version"},
+		{"auto", ""},
+	} {
+		if err := d.setPage(tt.name); err != nil {
+			t.Fatalf("setPage(%q): %v", tt.name, err)
+		}
+		idx, ok := d.control.pinnedPage()
+		got := ""
+		if ok {
+			got = d.pages[idx].name
+		}
+		if got != tt.want {
+			t.Errorf("setPage(%q): pinned page = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	for _, name := range []string{"top", "0", "3"} {
+		if err := d.setPage(name); err == nil {
+			t.Errorf("setPage(%q) unexpectedly succeeded", name)
+		}
+	}
+
+	defer func(password string) { httpPassword = password }(httpPassword)
+	httpPassword = "secret"
+	handler := d.httpHandler()
+	post := func(path string, form url.Values) int {
+		req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("gokrazy", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if got, want := post("/blank", url.Values{"blank": {"on"}}), http.StatusOK; got != want {
+		t.Fatalf("POST /blank: unexpected HTTP status: got %d, want %d", got, want)
+	}
+	if err := d.draw1(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.At(10, 10), (color.RGBA{A: 0xff}); got != want {
+		t.Errorf("blanked display: pixel = %v, want %v", got, want)
+	}
+	if got, want := post("/blank", url.Values{"blank": {"maybe"}}), http.StatusBadRequest; got != want {
+		t.Errorf("POST /blank=maybe: unexpected HTTP status: got %d, want %d", got, want)
+	}
+
+	// The MQTT control topics mirror the HTTP endpoints.
+	const prefix = "fbstatus/living-room"
+	for _, msg := range []struct {
+		topic, payload string
+	}{
+		{prefix + "/blank", "OFF"},
+		{prefix + "/page", "clock+version"},
+		{prefix + "/notify", "dinner is ready"},
+		{prefix + "/notify", `{"text": "door open", "severity": "warning", "timeout": 60}`},
+	} {
+		if err := d.handleControlMessage(prefix, msg.topic, []byte(msg.payload)); err != nil {
+			t.Fatalf("handleControlMessage(%s, %s): %v", msg.topic, msg.payload, err)
+		}
+	}
+	if d.control.isBlanked() {
+		t.Errorf("display still blanked")
+	}
+	if got, want := d.currentPage().name, "clock+version"; got != want {
+		t.Errorf("current page = %q, want %q", got, want)
+	}
+	if err := d.draw1(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.At(10, 10), (color.RGBA{A: 0xff}); got == want {
+		t.Errorf("display not redrawn after unblanking")
+	}
+	if got := d.notifier.active(time.Now()); len(got) != 2 || got[1].severity != "warning" {
+		t.Errorf("notifications = %+v, want dinner is ready and door open (warning)", got)
+	}
+}
+
+func TestWake(t *testing.T) {
+	defer func(d time.Duration) { *blankAfter = d }(*blankAfter)
+	*blankAfter = 0
+	c := newDisplayControl()
+	if c.wake() {
+		t.Errorf("wake() on a display which is on returned true")
+	}
+
+	// blanked remotely: input wakes up the display for defaultWakeDuration
+	c.blanked = true
+	c.page = 1
+	if !c.wake() {
+		t.Errorf("wake() on a blanked display returned false")
+	}
+	if c.isBlanked() {
+		t.Errorf("display still blanked after input")
+	}
+	if _, ok := c.pinnedPage(); ok {
+		t.Errorf("waking up did not resume rotating pages from the first page")
+	}
+	c.lastInput = time.Now().Add(-defaultWakeDuration)
+	if !c.isBlanked() {
+		t.Errorf("display not blanked again after the wake duration")
+	}
+
+	// screensaver
+	*blankAfter = 5 * time.Minute
+	c = newDisplayControl()
+	if c.isBlanked() {
+		t.Errorf("display blanked before -blank-after")
+	}
+	c.lastInput = time.Now().Add(-*blankAfter)
+	if !c.isBlanked() {
+		t.Errorf("display not blanked after -blank-after without input")
+	}
+	if !c.wake() || c.isBlanked() {
+		t.Errorf("input did not wake up the display")
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+var dhcpLeasesFile = flag.String("dhcp-leases",
+	"/perm/dhcp4d/leases.json",
+	"DHCP server lease file to display in the dhcp-clients panel: either a router7 dhcp4d leases.json or a dnsmasq.leases file")
+
+type dhcpClient struct {
+	hostname string
+	addr     string
+	hwaddr   string
+	expiry   time.Time // zero for infinite leases
+}
+
+// parseRouter7Leases parses the lease file of the router7 DHCP server.
+func parseRouter7Leases(b []byte) ([]dhcpClient, error) {
+	var leases []struct {
+		Addr             string    `json:"addr"`
+		HardwareAddr     string    `json:"hardware_addr"`
+		Hostname         string    `json:"hostname"`
+		HostnameOverride string    `json:"hostname_override"`
+		Expiry           time.Time `json:"expiry"`
+	}
+	if err := json.Unmarshal(b, &leases); err != nil {
+		return nil, err
+	}
+	clients := make([]dhcpClient, 0, len(leases))
+	for _, l := range leases {
+		hostname := l.Hostname
+		if l.HostnameOverride != "" {
+			hostname = l.HostnameOverride
+		}
+		clients = append(clients, dhcpClient{
+			hostname: hostname,
+			addr:     l.Addr,
+			hwaddr:   l.HardwareAddr,
+			expiry:   l.Expiry,
+		})
+	}
+	return clients, nil
+}
+
+// parseDnsmasqLeases parses a dnsmasq lease file, whose lines consist of
+// expiry (UNIX time, 0 for infinite), MAC address, IP address, hostname (*
+// if unknown) and client ID.
+func parseDnsmasqLeases(b []byte) ([]dhcpClient, error) {
+	var clients []dhcpClient
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		expiry, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed dnsmasq lease %q: %v", scanner.Text(), err)
+		}
+		c := dhcpClient{
+			addr:     fields[2],
+			hwaddr:   fields[1],
+			hostname: fields[3],
+		}
+		if c.hostname == "*" {
+			c.hostname = ""
+		}
+		if expiry != 0 {
+			c.expiry = time.Unix(expiry, 0)
+		}
+		clients = append(clients, c)
+	}
+	return clients, scanner.Err()
+}
+
+func readDHCPLeases(fn string) ([]dhcpClient, error) {
+	b, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(b)), "[") {
+		return parseRouter7Leases(b)
+	}
+	return parseDnsmasqLeases(b)
+}
+
+// dhcpClientsPanel lists the clients which hold a lease from the DHCP server
+// running on this device (e.g. a router7 router).
+type dhcpClientsPanel struct {
+	clients *poller[[]dhcpClient]
+}
+
+func newDHCPClientsPanel() (panel, error) {
+	return &dhcpClientsPanel{
+		clients: newPoller(10*time.Second, func(context.Context) ([]dhcpClient, error) {
+			clients, err := readDHCPLeases(*dhcpLeasesFile)
+			if err != nil {
+				return nil, err
+			}
+			sort.Slice(clients, func(i, j int) bool {
+				return compareIPs(clients[i].addr, clients[j].addr) < 0
+			})
+			return clients, nil
+		}),
+	}, nil
+}
+
+// compareIPs orders IPv4 addresses numerically, falling back to string
+// comparison.
+func compareIPs(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	if len(pa) == 4 && len(pb) == 4 {
+		for i := range pa {
+			na, erra := strconv.Atoi(pa[i])
+			nb, errb := strconv.Atoi(pb[i])
+			if erra != nil || errb != nil {
+				break
+			}
+			if na != nb {
+				return na - nb
+			}
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+func (p *dhcpClientsPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "DHCP clients")
+	clients, updated, err := p.clients.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	now := time.Now()
+	rows := make([][]cell, 0, len(clients))
+	for _, c := range clients {
+		remaining := cell{text: "∞"}
+		if !c.expiry.IsZero() {
+			if left := c.expiry.Sub(now); left > 0 {
+				remaining = cell{text: left.Round(time.Minute).String()}
+			} else {
+				remaining = cell{text: "expired", color: "darkgray"}
+			}
+		}
+		hostname := cell{text: c.hostname}
+		if hostname.text == "" {
+			hostname = cell{text: "?", color: "darkgray"}
+		}
+		rows = append(rows, []cell{
+			hostname,
+			{text: c.addr},
+			{text: c.hwaddr, color: "darkgray"},
+			remaining,
+		})
+	}
+	d.drawPagedTable(dc, y, []string{"hostname", "address", "MAC", "remaining"}, rows)
+	return nil
+}
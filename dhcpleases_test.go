@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseRouter7Leases(t *testing.T) {
+	const leases = `[{"num":2,"addr":"10.0.0.12","hardware_addr":"b8:27:eb:00:00:01","hostname":"raspberrypi","hostname_override":"scan2drive","expiry":"2022-08-01T13:00:00Z","last_ack":"2022-08-01T12:40:00Z"}]`
+	got, err := parseRouter7Leases([]byte(leases))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []dhcpClient{{
+		hostname: "scan2drive",
+		addr:     "10.0.0.12",
+		hwaddr:   "b8:27:eb:00:00:01",
+		expiry:   time.Date(2022, 8, 1, 13, 0, 0, 0, time.UTC),
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRouter7Leases() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseDnsmasqLeases(t *testing.T) {
+	const leases = `1659358800 b8:27:eb:00:00:01 10.0.0.12 raspberrypi 01:b8:27:eb:00:00:01
+0 b8:27:eb:00:00:02 10.0.0.13 * *
+`
+	got, err := parseDnsmasqLeases([]byte(leases))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []dhcpClient{
+		{hostname: "raspberrypi", addr: "10.0.0.12", hwaddr: "b8:27:eb:00:00:01", expiry: time.Unix(1659358800, 0)},
+		{addr: "10.0.0.13", hwaddr: "b8:27:eb:00:00:02"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDnsmasqLeases() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCompareIPs(t *testing.T) {
+	addrs := []string{"10.0.0.100", "10.0.0.9", "10.0.0.12"}
+	sort.Slice(addrs, func(i, j int) bool { return compareIPs(addrs[i], addrs[j]) < 0 })
+	if want := []string{"10.0.0.9", "10.0.0.12", "10.0.0.100"}; !reflect.DeepEqual(addrs, want) {
+		t.Errorf("sorted = %v, want %v", addrs, want)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/sys/unix"
+)
+
+// diskstatsSectorSize is the unit of the sector fields in /proc/diskstats,
+// which is 512 bytes regardless of the sector size of the device.
+const diskstatsSectorSize = 512
+
+type diskSample struct {
+	major, minor uint32
+	name         string
+	reads        uint64 // completed
+	writes       uint64 // completed
+	readBytes    uint64
+	writeBytes   uint64
+}
+
+// parseDiskstats parses the contents of /proc/diskstats as per
+// Documentation/admin-guide/iostats.rst, skipping loop and RAM devices.
+func parseDiskstats(b []byte) ([]diskSample, error) {
+	var samples []diskSample
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			return nil, fmt.Errorf("malformed diskstats line: %q", line)
+		}
+		name := fields[2]
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			continue
+		}
+		var nums [8]uint64
+		for idx := range nums {
+			n, err := strconv.ParseUint(fields[3+idx], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("malformed diskstats line: %q: %v", line, err)
+			}
+			nums[idx] = n
+		}
+		major, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		minor, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, diskSample{
+			major:      uint32(major),
+			minor:      uint32(minor),
+			name:       name,
+			reads:      nums[0],
+			readBytes:  nums[2] * diskstatsSectorSize,
+			writes:     nums[4],
+			writeBytes: nums[6] * diskstatsSectorSize,
+		})
+	}
+	return samples, nil
+}
+
+// permDevice returns the major and minor number of the block device which
+// holds the /perm file system.
+func permDevice() (major, minor uint32, _ error) {
+	var st unix.Stat_t
+	if err := unix.Stat("/perm", &st); err != nil {
+		return 0, 0, err
+	}
+	dev := uint64(st.Dev)
+	return unix.Major(dev), unix.Minor(dev), nil
+}
+
+type diskUsage struct {
+	name        string
+	perm        bool    // whether the device holds /perm
+	read, write float64 // bytes per second
+	readIOPS    float64
+	writeIOPS   float64
+}
+
+// diskRates returns the throughput of the devices in cur since prev, which
+// was sampled elapsed seconds earlier. Devices which never saw any I/O (e.g.
+// empty card readers) are omitted. perm is the device number of /perm.
+func diskRates(prev, cur []diskSample, elapsed float64, perm [2]uint32) []diskUsage {
+	old := make(map[string]diskSample, len(prev))
+	for _, s := range prev {
+		old[s.name] = s
+	}
+	var usage []diskUsage
+	for _, s := range cur {
+		if s.reads == 0 && s.writes == 0 {
+			continue
+		}
+		u := diskUsage{
+			name: s.name,
+			perm: s.major == perm[0] && s.minor == perm[1],
+		}
+		if o, ok := old[s.name]; ok && elapsed > 0 && s.readBytes >= o.readBytes && s.writeBytes >= o.writeBytes {
+			u.read = float64(s.readBytes-o.readBytes) / elapsed
+			u.write = float64(s.writeBytes-o.writeBytes) / elapsed
+			u.readIOPS = float64(s.reads-o.reads) / elapsed
+			u.writeIOPS = float64(s.writes-o.writes) / elapsed
+		}
+		usage = append(usage, u)
+	}
+	return usage
+}
+
+// disksPanel shows the throughput and IOPS of each block device, with the
+// device holding /perm highlighted.
+type disksPanel struct {
+	disks *poller[[]diskUsage]
+}
+
+func newDisksPanel() (panel, error) {
+	var (
+		prev     []diskSample
+		prevTime time.Time
+	)
+	return &disksPanel{
+		disks: newPoller(frameInterval, func(context.Context) ([]diskUsage, error) {
+			b, err := os.ReadFile("/proc/diskstats")
+			if err != nil {
+				return nil, err
+			}
+			cur, err := parseDiskstats(b)
+			if err != nil {
+				return nil, err
+			}
+			var perm [2]uint32
+			if major, minor, err := permDevice(); err == nil {
+				perm = [2]uint32{major, minor}
+			}
+			now := time.Now()
+			usage := diskRates(prev, cur, now.Sub(prevTime).Seconds(), perm)
+			prev, prevTime = cur, now
+			return usage, nil
+		}),
+	}, nil
+}
+
+func (p *disksPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Disk I/O")
+	disks, updated, err := p.disks.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	if len(disks) == 0 {
+		d.drawMessage(dc, y, "no block devices")
+		return nil
+	}
+	rate := func(v float64, format func(float64) string) cell {
+		if v == 0 {
+			return cell{text: "0", color: "darkgray"}
+		}
+		return cell{text: format(v)}
+	}
+	throughput := func(v float64) string { return formatBytes(uint64(v)) + "/s" }
+	iops := func(v float64) string { return strconv.FormatFloat(v, 'f', 0, 64) }
+	header := []string{"device", "read", "write", "r/s", "w/s"}
+	rows := make([][]cell, 0, len(disks))
+	for _, u := range disks {
+		name := cell{text: u.name}
+		if u.perm {
+			name = cell{text: u.name + " (/perm)", color: "green"}
+		}
+		rows = append(rows, []cell{
+			name,
+			rate(u.read, throughput),
+			rate(u.write, throughput),
+			rate(u.readIOPS, iops),
+			rate(u.writeIOPS, iops),
+		})
+	}
+	d.drawTable(dc, y, header, rows)
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDiskstats(t *testing.T) {
+	const diskstats = `   7       0 loop0 100 0 200 10 0 0 0 0 0 10 10 0 0 0 0
+ 179       0 mmcblk0 5000 1200 400000 3000 800 900 64000 7000 0 6000 10000 0 0 0 0
+ 179       4 mmcblk0p4 4000 1000 300000 2500 800 900 64000 7000 0 5000 9500 0 0 0 0
+   1       0 ram0 0 0 0 0 0 0 0 0 0 0 0
+`
+	got, err := parseDiskstats([]byte(diskstats))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []diskSample{
+		{major: 179, minor: 0, name: "mmcblk0", reads: 5000, writes: 800, readBytes: 400000 * 512, writeBytes: 64000 * 512},
+		{major: 179, minor: 4, name: "mmcblk0p4", reads: 4000, writes: 800, readBytes: 300000 * 512, writeBytes: 64000 * 512},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDiskstats() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseDiskstats([]byte("179 0 mmcblk0 1 2\n")); err == nil {
+		t.Errorf("parseDiskstats(truncated) unexpectedly succeeded")
+	}
+}
+
+func TestDiskRates(t *testing.T) {
+	prev := []diskSample{
+		{major: 8, minor: 0, name: "sda", reads: 100, writes: 10, readBytes: 1 << 20, writeBytes: 0},
+		{major: 8, minor: 4, name: "sda4", reads: 50, writes: 10, readBytes: 1 << 19, writeBytes: 0},
+	}
+	cur := []diskSample{
+		{major: 8, minor: 0, name: "sda", reads: 120, writes: 30, readBytes: 3 << 20, writeBytes: 1 << 20},
+		{major: 8, minor: 4, name: "sda4", reads: 50, writes: 30, readBytes: 1 << 19, writeBytes: 1 << 20},
+		{major: 8, minor: 16, name: "sdb", reads: 0, writes: 0}, // empty card reader
+		{major: 8, minor: 32, name: "sdc", reads: 1, writes: 0}, // plugged in
+	}
+	got := diskRates(prev, cur, 2, [2]uint32{8, 4})
+	want := []diskUsage{
+		{name: "sda", read: 1 << 20, write: 1 << 19, readIOPS: 10, writeIOPS: 10},
+		{name: "sda4", perm: true, write: 1 << 19, writeIOPS: 10},
+		{name: "sdc"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diskRates() = %+v, want %+v", got, want)
+	}
+}
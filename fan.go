@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var fanWarnCelsius = flag.Float64("fan-warn-celsius",
+	60,
+	"SoC temperature (in °C) above which a fan reporting 0 RPM is flagged as stalled")
+
+// fanReading is the state of one fan as reported by the Linux hwmon
+// subsystem, see https://www.kernel.org/doc/Documentation/hwmon/sysfs-interface
+type fanReading struct {
+	name string // hwmon chip name, e.g. pwmfan or emc2301
+	rpm  int    // -1 if the fan has no tachometer
+	duty int    // percent, -1 if the fan is not PWM controlled
+}
+
+func readHwmonInt(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+// readFans returns all fans found in the hwmon directory root (usually
+// /sys/class/hwmon). The official Raspberry Pi fan (pwm-fan driver) only
+// reports its duty cycle, whereas fan controllers like the EMC2301 found on
+// CM4 carrier boards also report the speed.
+func readFans(root string) ([]fanReading, error) {
+	chips, err := filepath.Glob(filepath.Join(root, "hwmon*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(chips)
+	var fans []fanReading
+	for _, chip := range chips {
+		name := filepath.Base(chip)
+		if b, err := os.ReadFile(filepath.Join(chip, "name")); err == nil {
+			name = strings.TrimSpace(string(b))
+		}
+		// Fans are numbered from 1, and fanN_input and pwmN refer to the
+		// same fan (for the drivers we care about).
+		for i := 1; ; i++ {
+			f := fanReading{name: name, rpm: -1, duty: -1}
+			if rpm, err := readHwmonInt(filepath.Join(chip, fmt.Sprintf("fan%d_input", i))); err == nil {
+				f.rpm = rpm
+			}
+			if pwm, err := readHwmonInt(filepath.Join(chip, fmt.Sprintf("pwm%d", i))); err == nil {
+				f.duty = pwm * 100 / 255
+			}
+			if f.rpm == -1 && f.duty == -1 {
+				break
+			}
+			fans = append(fans, f)
+		}
+	}
+	return fans, nil
+}
+
+// fanLine returns a host information line (in $color$text markup)
+// describing the fans. A fan which reports 0 RPM while the SoC is hot is
+// flagged in red, as it is likely stalled or disconnected.
+func fanLine(fans []fanReading, celsius float64, celsiusErr error) string {
+	hot := celsiusErr == nil && celsius >= *fanWarnCelsius
+	descs := make([]string, 0, len(fans))
+	for _, f := range fans {
+		var parts []string
+		if f.rpm > -1 {
+			parts = append(parts, fmt.Sprintf("%d RPM", f.rpm))
+		}
+		if f.duty > -1 {
+			parts = append(parts, fmt.Sprintf("%d%% duty", f.duty))
+		}
+		desc := "$$" + strings.Join(parts, ", ")
+		if f.rpm == 0 && hot {
+			desc = "$red$" + strings.Join(parts, ", ") + " (stalled?)"
+		}
+		if len(fans) > 1 {
+			desc = "$$" + f.name + " " + desc
+		}
+		descs = append(descs, desc)
+	}
+	return "$$fan: " + strings.Join(descs, "$$; ")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadFans(t *testing.T) {
+	root := t.TempDir()
+	for path, contents := range map[string]string{
+		"hwmon0/name":        "cpu_thermal\n",
+		"hwmon0/temp1_input": "45000\n",
+		"hwmon1/name":        "pwmfan\n",
+		"hwmon1/pwm1":        "255\n",
+		"hwmon2/name":        "emc2301\n",
+		"hwmon2/fan1_input":  "2970\n",
+		"hwmon2/pwm1":        "102\n",
+	} {
+		fn := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := readFans(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []fanReading{
+		{name: "pwmfan", rpm: -1, duty: 100},
+		{name: "emc2301", rpm: 2970, duty: 40},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readFans() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFanLine(t *testing.T) {
+	fans := []fanReading{{name: "emc2301", rpm: 0, duty: 100}}
+	if got, want := fanLine(fans, 40, nil), "$$fan: $$0 RPM, 100% duty"; got != want {
+		t.Errorf("fanLine(cool) = %q, want %q", got, want)
+	}
+	if got, want := fanLine(fans, 75, nil), "$$fan: $red$0 RPM, 100% duty (stalled?)"; got != want {
+		t.Errorf("fanLine(hot) = %q, want %q", got, want)
+	}
+}
@@ -14,8 +14,7 @@ import (
 	"image/draw"
 	"io"
 	"io/ioutil"
-	"log"
-	"math"
+	"log/slog"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
@@ -24,6 +23,9 @@ import (
 	"runtime/pprof"
 	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/fogleman/gg"
@@ -32,11 +34,12 @@ import (
 	"github.com/gokrazy/fbstatus/internal/fbimage"
 	"github.com/gokrazy/gokrazy"
 	"github.com/gokrazy/stat/statexp"
-	"github.com/golang/freetype/truetype"
 	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
 	"golang.org/x/image/font/gofont/goitalic"
 	"golang.org/x/image/font/gofont/gomono"
 	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
 
 	_ "embed"
 	_ "image/png"
@@ -74,6 +77,11 @@ func scaleImage(bounds image.Rectangle, maxW, maxH int) image.Rectangle {
 	return image.Rect(0, 0, scaledW, scaledH)
 }
 
+const lineSpacing = 1.5
+
+// frameInterval is how often fbstatus redraws the display.
+const frameInterval = 1 * time.Second
+
 var colorNameToRGBA = map[string]color.NRGBA{
 	"darkgray": color.NRGBA{R: 0x55, G: 0x57, B: 0x53},
 	"red":      color.NRGBA{R: 0xEF, G: 0x29, B: 0x29},
@@ -91,31 +99,101 @@ type statusDrawer struct {
 	bounds      image.Rectangle
 	w, h        int
 	scaleFactor float64
+	areas       statusLayout
 	buffer      *image.RGBA
+	background  *image.RGBA
+	backdrop    *image.RGBA            // nil for a flat background, see -background
+	dither      bool                   // whether to dither when copying to BGR565
+	shown       *image.RGBA            // last frame on the display, nil if -transition=none
+	frame       *image.RGBA            // frame of a transition
+	curve       *colorCurve            // nil if the colors are not changed, see gamma.go
+	curved      *image.RGBA            // buffer with curve applied, for the slow path
+	night       *nightShift            // nil if -night-temperature is not set
+	auto        *autoBrightnessControl // nil if -auto-brightness is not set
 	files       map[string]*os.File
+	unavailable []bool // per module: whether its files could not be opened
+	statRetry   map[string]*backoff
+	source      statusSource
 	bgcolor     color.RGBA
 	hostname    string
+	hostTmpl    *template.Template
+	hostVars    map[string]string
 	modules     []statexp.ProcessAndFormatter
+	temperature *temperatureSensor
+	gus         *gusChecker
+	clock       *clockStatus
+	throttled   *throttledSensor
+	gpio        *gpioInputs
+	probes      probes
+	network     *networkConfig
+	ifaceErrors *ifaceErrorTracker
+	links       *poller[[]ifaceLink]
+	tls         tlsCertificates
+	services    *poller[[]serviceState]
+	nftCounters []nftCounter
+	wan         *wanMonitor
+	ipv6        *ipv6Monitor    // nil if -ipv6=false
+	mdns        *mdnsAdvertiser // nil if -mdns=false or -http-listen is empty
+	pstore      *pstoreCrash
+	watchdogs   *poller[map[string][]string] // owners, by device file
+	fileShares  *fileShares
+	alerts      *alertBanner // nil if -alert-banner=false
+	alertHook   *alertmanagerReceiver
+	notifier    *notifier
+	reboot      *rebootNotice
+	maintenance *maintenanceSchedule // nil if -maintenance is empty
+	control     *displayControl
+	irBindings  *irBindings
+	splash      *splashScreen         // nil if -splash=false or once it ended
+	update      *poller[updateStatus] // nil if -update-overlay=false or not on gokrazy
 	g           *gg.Context
 	gstat       *gg.Context
 	ggopher     *gg.Context
+	face        font.Face
+	monoface    font.Face
+	italicface  font.Face
+	regular     *opentype.Font
+	overlay     *gg.Context      // shared by the overlays, see overlayContext
+	faces       overlayFaces     // of the overlays
+	fallbacks   []*opentype.Font // as per -fallback-fonts
+	pages       []*page
+	started     time.Time
 
 	// state
+	mu                   sync.Mutex // guards the state and buffer, see webui.go
 	slowPathNotified     bool
 	last                 [][][]string
+	summary              *statSummary
+	statAverages         [][]string         // row of the resource usage table
+	statPeaks            [][]string         // row of the resource usage table
+	statLayout           statLayout         // fits all rows of the resource usage table
+	resources            map[string]float64 // most recent row of last, by metric
+	recording            *panelStatus       // of the panel being drawn, see statusjson.go
+	panelOrigin          image.Point        // of the panel being drawn, in buffer coordinates
+	marquees             []*marquee         // of the current frame, see marquee.go
 	lastRender, lastCopy time.Duration
+	stats                frameStats
+	health               *healthState
+	lastPage             *page
+	overlayShown         bool // whether an overlay was drawn in the previous frame
+	blanked              bool // whether the display was blanked
+	shownPage            *page
+	transition           *transition // in progress, see transition.go
+	hud                  *hudState   // once the debug HUD was shown
+	slowTransitions      bool
+	celsius              float64
+	celsiusErr           error
 }
 
-func newStatusDrawer(img draw.Image) (*statusDrawer, error) {
+// newStatusDrawer returns a statusDrawer for img, which is displayed on a
+// display widthMM millimeters wide (0 if unknown).
+func newStatusDrawer(img draw.Image, widthMM uint32) (*statusDrawer, error) {
 	bounds := img.Bounds()
 	w := bounds.Max.X
 	h := bounds.Max.Y
 
-	scaleFactor := math.Floor(float64(w) / 1024)
-	if scaleFactor < 1 {
-		scaleFactor = 1
-	}
-	log.Printf("font scale factor: %.f", scaleFactor)
+	scaleFactor := displayScale(w, widthMM)
+	slog.Debug("font scale factor", "factor", scaleFactor)
 
 	// draw the gokrazy gopher image
 	gokrazyLogo, _, err := image.Decode(bytes.NewReader(gokrazyLogoPNG))
@@ -124,177 +202,391 @@ func newStatusDrawer(img draw.Image) (*statusDrawer, error) {
 	}
 
 	bgcolor := color.RGBA{R: 50, G: 50, B: 50, A: 255}
+	bg, err := parseBackground(*backgroundFlag, bgcolor)
+	if err != nil {
+		return nil, err
+	}
+
+	curve, err := newColorCurve(*gammaFlag, *brightnessFlag, noTint)
+	if err != nil {
+		return nil, err
+	}
+	night, err := newNightShift()
+	if err != nil {
+		return nil, err
+	}
+	auto, err := newAutoBrightness()
+	if err != nil {
+		return nil, err
+	}
 
 	// We do all rendering into an *image.RGBA buffer, for which all drawing
 	// operations are optimized in Go. Only at the very end do we copy the
 	// buffer contents to the framebuffer (BGR565 or BGRA)
 	buffer := image.NewRGBA(bounds)
-	draw.Draw(buffer, bounds, &image.Uniform{bgcolor}, image.Point{}, draw.Src)
+	var backdrop *image.RGBA
+	if bg.flat() {
+		draw.Draw(buffer, bounds, &image.Uniform{bgcolor}, image.Point{}, draw.Src)
+	} else {
+		backdrop = bg.render(bounds, scaleFactor)
+		copy(buffer.Pix, backdrop.Pix)
+	}
 
-	// place the gopher in the top right half (centered)
+	// place the gopher in its area (centered below the title), which is the
+	// top right half on landscape displays
+	areas := newStatusLayout(w, h)
+	ga := areas.gopher
 	borderTop := int(50 * scaleFactor)
-	gopherRect := scaleImage(gokrazyLogo.Bounds(), w/2, h/2-borderTop)
-	gopherRect = gopherRect.Add(image.Point{w / 2, 0})
-	padX := ((w / 2) - gopherRect.Size().X) / 2
-	padY := borderTop + ((h/2)-gopherRect.Size().Y)/2
+	gopherRect := scaleImage(gokrazyLogo.Bounds(), ga.Dx(), ga.Dy()-borderTop)
+	gopherRect = gopherRect.Add(ga.Min)
+	padX := (ga.Dx() - gopherRect.Size().X) / 2
+	padY := borderTop + (ga.Dy()-gopherRect.Size().Y)/2
 	gopherRect = gopherRect.Add(image.Point{padX, padY})
 
 	t1 := time.Now()
 	xdraw.BiLinear.Scale(buffer, gopherRect, gokrazyLogo, gokrazyLogo.Bounds(), draw.Over, nil)
-	log.Printf("gopher scaled in %v", time.Since(t1))
+	slog.Debug("gopher scaled", "duration", time.Since(t1))
 
-	g := gg.NewContext(w/2, h/2)
-	gstat := gg.NewContext(w, h/2)
-	ggopher := gg.NewContext(w/2, h/2)
+	// retain a copy of the static background for switching between pages
+	background := image.NewRGBA(bounds)
+	copy(background.Pix, buffer.Pix)
+
+	g := gg.NewContext(areas.info.Dx(), areas.info.Dy())
+	gstat := gg.NewContext(areas.stats.Dx(), areas.stats.Dy())
+	ggopher := gg.NewContext(ga.Dx(), ga.Dy())
 
 	// draw textual information in a block of key: value details
-	font, err := truetype.Parse(goregular.TTF)
+	fallbacks := loadFallbackFonts(*fallbackFonts)
+	font, err := opentype.Parse(goregular.TTF)
 	if err != nil {
 		return nil, err
 	}
 
 	size := float64(16)
 	size *= scaleFactor
-	face := truetype.NewFace(font, &truetype.Options{Size: size})
+	face := newFallbackFace(font, fallbacks, size)
 	g.SetFontFace(face)
 
-	monofont, err := truetype.Parse(gomono.TTF)
+	monofont, err := opentype.Parse(gomono.TTF)
 	if err != nil {
 		return nil, err
 	}
-	monoface := truetype.NewFace(monofont, &truetype.Options{Size: size})
+	monoface := newFallbackFace(monofont, fallbacks, size)
 	gstat.SetFontFace(monoface)
 
-	italicfont, err := truetype.Parse(goitalic.TTF)
+	italicfont, err := opentype.Parse(goitalic.TTF)
 	if err != nil {
 		return nil, err
 	}
-	italicface := truetype.NewFace(italicfont, &truetype.Options{Size: 2 * size})
+	italicface := newFallbackFace(italicfont, fallbacks, 2*size)
 	ggopher.SetFontFace(italicface)
 
-	{
-		r, gg, b, a := bgcolor.RGBA()
-		ggopher.SetRGBA(
-			float64(r)/0xffff,
-			float64(gg)/0xffff,
-			float64(b)/0xffff,
-			float64(a)/0xffff)
-	}
-	ggopher.Clear()
-	ggopher.SetRGB(1, 1, 1)
-	padX = ((w / 2) - int(66*scaleFactor)) / 2
+	overlay := gg.NewContext(w, h)
+	faces := overlayFaces{
+		huge:   newFallbackFace(font, fallbacks, 3*size),
+		large:  newFallbackFace(font, fallbacks, 2*size),
+		medium: newFallbackFace(font, fallbacks, 1.5*size),
+		text:   newFallbackFace(font, fallbacks, 1.25*size),
+	}
+
+	clearBackground(ggopher, bgcolor, backdrop, ga.Min)
+	padX = (ga.Dx() - int(66*scaleFactor)) / 2
 	ggopher.DrawString("gokrazy!", float64(padX)-(30*scaleFactor), 42*scaleFactor)
 
 	hostname, err := os.Hostname()
 	if err != nil {
-		log.Print(err)
+		slog.Warn("determining hostname failed", "err", err)
 	}
 
-	// --------------------------------------------------------------------------------
-	modules := statexp.DefaultModules()
-	files := make(map[string]*os.File)
-	for _, mod := range modules {
-		// When a stats module implements the FileContents() interface, we
-		// ensure all returned file contents are read and passed to
-		// ProcessAndFormat.
-		fc, ok := mod.(interface{ FileContents() []string })
-		if !ok {
-			continue
+	hostTmpl, err := parseHostTemplate(*hostTemplateFile)
+	if err != nil {
+		return nil, err
+	}
+	hostVars, err := parseHostVars(*hostVarsFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := parsePages(*pagesFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	if thresholds, err = parseThresholds(*thresholdsFlag); err != nil {
+		return nil, err
+	}
+	if statFormats, err = parseStatFormats(*statFormatFlag); err != nil {
+		return nil, err
+	}
+
+	var gus *gusChecker
+	if *gusServer != "" {
+		gus, err = newGUSChecker(*gusServer)
+		if err != nil {
+			slog.Warn("not checking GUS for updates", "err", err)
 		}
-		for _, f := range fc.FileContents() {
-			if _, ok := files[f]; ok {
-				continue // already requested
-			}
-			fl, err := os.Open(f)
-			if err != nil {
-				return nil, err
-			}
-			files[f] = fl
+	}
+
+	var gpio *gpioInputs
+	if *gpioLines != "" {
+		gpio, err = newGPIOInputs(*gpioLines)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	probes, err := startProbes(*probesFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	tls, err := newTLSCertificates(*tlsEndpoints)
+	if err != nil {
+		return nil, err
+	}
+
+	nftCounters, err := parseNFTCounters(*nftCountersFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	irBindings, err := newIRBindings(*irKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenance, err := parseMaintenanceSchedule(*maintenanceFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	control := newDisplayControl()
+	if control.schedule, err = parseDisplaySchedule(*displayScheduleFlag); err != nil {
+		return nil, err
+	}
+	if control.presence, err = startPresence(*presenceFlag); err != nil {
+		return nil, err
+	}
+
+	var splash *splashScreen
+	if *splashFlag {
+		splash, err = newSplashScreen(w, h)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkTransition(*transitionFlag); err != nil {
+		return nil, err
+	}
+	var shown, frame *image.RGBA
+	if *transitionFlag != "none" {
+		shown = image.NewRGBA(bounds)
+		frame = image.NewRGBA(bounds)
+	}
+
+	var update *poller[updateStatus]
+	if *updateOverlay {
+		update, err = newUpdatePoller()
+		if err != nil {
+			slog.Warn("not detecting updates", "err", err)
+		}
+	}
+
+	var alerts *alertBanner
+	if *alertBannerFlag {
+		alerts = newAlertBanner()
+	}
+
+	var wan *wanMonitor
+	if *wanInterface != "" {
+		wan = newWANMonitor(*wanInterface)
+	}
+
+	var ipv6 *ipv6Monitor
+	if *ipv6Flag {
+		ipv6 = newIPv6Monitor()
+	}
+
+	var mdns *mdnsAdvertiser
+	if *mdnsFlag && *httpListen != "" && hostname != "" {
+		mdns, err = newMDNSAdvertiser(hostname, *httpListen)
+		if err != nil {
+			return nil, err
 		}
 	}
 
 	// --------------------------------------------------------------------------------
+	modules := statexp.DefaultModules()
+	files, unavailable := openStatFiles(modules)
 
-	return &statusDrawer{
+	// --------------------------------------------------------------------------------
+
+	d := &statusDrawer{
 		img:         img,
 		bounds:      bounds,
 		w:           w,
 		h:           h,
 		scaleFactor: scaleFactor,
+		areas:       areas,
 		buffer:      buffer,
+		background:  background,
+		shown:       shown,
+		frame:       frame,
 		modules:     modules,
+		temperature: newTemperatureSensor(),
+		gus:         gus,
+		clock:       newClockStatus(),
+		throttled:   newThrottledSensor(),
+		gpio:        gpio,
+		probes:      probes,
+		network:     newNetworkConfig(),
+		ifaceErrors: newIfaceErrorTracker(),
+		links:       newLinkStatePoller(),
+		tls:         tls,
+		services:    newServicesPoller(5*time.Second, true),
+		nftCounters: nftCounters,
+		wan:         wan,
+		ipv6:        ipv6,
+		mdns:        mdns,
+		pstore:      newPstoreCrash(),
+		watchdogs:   newWatchdogOwnersPoller(),
+		fileShares:  &fileShares{},
+		alerts:      alerts,
+		alertHook:   newAlertmanagerReceiver(),
+		notifier:    &notifier{},
+		reboot:      &rebootNotice{},
+		maintenance: maintenance,
+		control:     control,
+		irBindings:  irBindings,
+		splash:      splash,
+		update:      update,
 		hostname:    hostname,
+		hostTmpl:    hostTmpl,
+		hostVars:    hostVars,
 		files:       files,
+		unavailable: unavailable,
 		bgcolor:     bgcolor,
+		backdrop:    backdrop,
+		dither:      backdrop != nil,
+		curve:       curve,
+		night:       night,
+		auto:        auto,
 		g:           g,
 		gstat:       gstat,
 		ggopher:     ggopher,
-
-		last: make([][][]string, 10),
-	}, nil
+		face:        face,
+		monoface:    monoface,
+		italicface:  italicface,
+		regular:     font,
+		overlay:     overlay,
+		faces:       faces,
+		fallbacks:   fallbacks,
+		pages:       pages,
+		started:     time.Now(),
+		health:      newHealthState(time.Now()),
+
+		last:    make([][][]string, 10),
+		summary: newStatSummary(),
+	}
+	d.source = hostSource{d}
+	return d, nil
 }
 
-func (d *statusDrawer) draw1(ctx context.Context) error {
-	const lineSpacing = 1.5
+// openStatFiles opens the files which the stats modules read (see
+// FileContents). Modules for which a file cannot be opened (e.g. on kernels
+// without certain /proc entries) are marked as unavailable.
+func openStatFiles(modules []statexp.ProcessAndFormatter) (map[string]*os.File, []bool) {
+	files := make(map[string]*os.File)
+	failed := make(map[string]bool)
+	unavailable := make([]bool, len(modules))
+	for modIdx, mod := range modules {
+		// When a stats module implements the FileContents() interface, we
+		// ensure all returned file contents are read and passed to
+		// ProcessAndFormat.
+		fc, ok := mod.(interface{ FileContents() []string })
+		if !ok {
+			continue
+		}
+		for _, f := range fc.FileContents() {
+			if failed[f] {
+				unavailable[modIdx] = true
+				continue
+			}
+			if _, ok := files[f]; ok {
+				continue // already requested
+			}
+			fl, err := os.Open(f)
+			if err != nil {
+				slog.Warn("resource usage unavailable", "module", fmt.Sprintf("%T", mod), "err", err)
+				failed[f] = true
+				unavailable[modIdx] = true
+				continue
+			}
+			files[f] = fl
+		}
+	}
+	return files, unavailable
+}
 
-	statArea := image.Rect(0, d.h/2, d.w, d.h)
+// statPlaceholderCol returns the column which replaces the columns of a module
+// in the resource usage table when its data is not available: text in the
+// named color, which statLayout aligns to span the entire module.
+func statPlaceholderCol(color, text string) string {
+	return "$" + color + "$" + text
+}
 
-	// --------------------------------------------------------------------------------
+// readStatFiles reads the files which the stats modules process. Files which
+// cannot be read are omitted and retried with backoff.
+func (d *statusDrawer) readStatFiles(now time.Time) map[string][]byte {
+	if d.statRetry == nil {
+		d.statRetry = make(map[string]*backoff)
+	}
 	contents := make(map[string][]byte)
 	for path, fl := range d.files {
-		if _, err := fl.Seek(0, io.SeekStart); err != nil {
-			return err
+		b := d.statRetry[path]
+		if b == nil {
+			b = &backoff{}
+			d.statRetry[path] = b
+		}
+		if b.failures > 0 {
+			if !b.due(now) {
+				continue
+			}
+			// the file might work again when reopened
+			if f, err := os.Open(path); err == nil {
+				fl.Close()
+				fl = f
+				d.files[path] = f
+			}
+		}
+		_, err := fl.Seek(0, io.SeekStart)
+		var content []byte
+		if err == nil {
+			content, err = ioutil.ReadAll(fl)
 		}
-		b, err := ioutil.ReadAll(fl)
 		if err != nil {
-			return err
+			if b.failures == 0 {
+				slog.Warn("reading resource usage failed", "err", err)
+			}
+			b.fail(err, now)
+			continue
 		}
-		contents[path] = b
-	}
-
-	{
-		r, gg, b, a := d.bgcolor.RGBA()
-		d.gstat.SetRGBA(
-			float64(r)/0xffff,
-			float64(gg)/0xffff,
-			float64(b)/0xffff,
-			float64(a)/0xffff)
+		if b.failures > 0 {
+			slog.Info("resource usage readable again", "path", path)
+			b.succeed()
+		}
+		contents[path] = content
 	}
-	d.gstat.Clear()
-	d.gstat.SetRGB(1, 1, 1)
-
-	em, _ := d.gstat.MeasureString("m")
+	return contents
+}
 
-	// render header
-	statx := 3 * em
-	// TODO: look into why MeasureString/DrawString are not monospace-correct
-	for _, hdr := range []string{
-		" usr",
-		" sys",
-		" idl",
-		" wai",
-		" stl",
-		" | ",
-		" read ",
-		" writ ",
-		" | ",
-		" int  ",
-		" csw  ",
-		" | ",
-		" recv ",
-		" send ",
-		" | ",
-		" used ",
-		" free ",
-		" buff ",
-		" cach",
-	} {
-		d.gstat.DrawString(hdr, statx, 3*em)
-		statx += float64(len(hdr)) * em
-	}
-
-	staty := 6 * em
-	statx = 3 * em
+// collect reads all data sources which need to be sampled continuously (e.g.
+// to compute rates), regardless of which page is currently displayed.
+func (d *statusDrawer) collect() {
+	// --------------------------------------------------------------------------------
+	now := d.source.now()
+	contents := d.source.statFiles(now)
 
 	for idx := range d.last {
 		if idx == len(d.last)-1 {
@@ -304,70 +596,149 @@ func (d *statusDrawer) draw1(ctx context.Context) error {
 	}
 
 	var lastrow [][]string
-	for _, mod := range d.modules {
+	d.resources = make(map[string]float64)
+	for modIdx, mod := range d.modules {
+		if d.unavailable[modIdx] {
+			lastrow = append(lastrow, []string{statPlaceholderCol("darkgray", "unavailable")})
+			continue
+		}
+		if !statFilesRead(mod, contents) {
+			lastrow = append(lastrow, []string{statPlaceholderCol("red", "read error")})
+			continue
+		}
 		var modcols []string
 		cols := mod.ProcessAndFormat(contents)
-		for _, col := range cols {
-			colored := col.RenderCustom(func(color, text string) string {
-				return "$" + color + "$" + text
-			})
-			modcols = append(modcols, colored)
+		for colIdx, col := range cols {
+			var metric string
+			if modIdx < len(statColumns) && colIdx < len(statColumns[modIdx]) {
+				metric = statColumns[modIdx][colIdx]
+			}
+			modcols = append(modcols, renderStatCol(metric, col))
+			if metric != "" {
+				d.resources[metric] = statColValue(col)
+				d.summary.add(metric, col, now)
+			}
 		}
 		lastrow = append(lastrow, modcols)
 	}
 	d.last[len(d.last)-1] = lastrow
+	d.statAverages, d.statPeaks = d.summary.rows(now)
+	d.statLayout = newStatLayout(append(append([][][]string{}, d.last...), d.statAverages, d.statPeaks))
 
-	for _, lastrow := range d.last {
-		statx = 3 * em
-		for _, modcols := range lastrow {
-			for _, colored := range modcols {
-				statx += em
-				for idx, field := range strings.Split(strings.TrimPrefix(colored, "$"), "$") {
-
-					if idx%2 == 0 {
-						col := colorNameToRGBA[field]
-						d.gstat.SetRGB255(int(col.R), int(col.G), int(col.B))
-					} else {
-						d.gstat.DrawString(field, statx, staty)
-						statx += float64(len(field)) * em
-					}
-				}
+	d.celsius, d.celsiusErr = d.temperature.read()
 
-			}
-			statx += 3 * em
+	if len(contents) > 0 {
+		d.health.collected(time.Now())
+	}
+}
+
+// statFilesRead reports whether contents contains all files which mod
+// processes.
+func statFilesRead(mod statexp.ProcessAndFormatter, contents map[string][]byte) bool {
+	fc, ok := mod.(interface{ FileContents() []string })
+	if !ok {
+		return true
+	}
+	for _, f := range fc.FileContents() {
+		if _, ok := contents[f]; !ok {
+			return false
 		}
-		staty += d.gstat.FontHeight() * lineSpacing
 	}
+	return true
+}
 
-	// --------------------------------------------------------------------------------
+// hostLines returns the host information shown in the top left of the status
+// view, one line per element in $color$text markup.
+func (d *statusDrawer) hostLines() []string {
+	info := d.source.hostInfo()
+	tmpl := d.hostTmpl
+	if tmpl == nil {
+		tmpl = defaultHostTmpl
+	}
+	lines, err := renderHostLines(tmpl, info)
+	if err != nil {
+		// keep showing the default information, which is more useful than
+		// just the error
+		lines, _ = renderHostLines(defaultHostTmpl, info)
+		lines = append([]string{"$$host template: $red$" + err.Error()}, lines...)
+	}
+	return lines
+}
 
-	t2 := time.Now()
-	{
-		r, gg, b, a := d.bgcolor.RGBA()
-		d.g.SetRGBA(
-			float64(r)/0xffff,
-			float64(gg)/0xffff,
-			float64(b)/0xffff,
-			float64(a)/0xffff)
-	}
-	d.g.Clear()
-	d.g.SetRGB(1, 1, 1)
-	lines := []string{
-		"host “" + d.hostname + "” (" + gokrazy.Model() + ")",
-		"time: " + time.Now().Format(time.RFC3339),
-	}
-	if up, err := uptime(); err == nil {
-		last := len(lines) - 1
-		lines[last] += ", up for " + up
-	}
-	if d.lastRender > 0 || d.lastCopy > 0 {
-		last := len(lines) - 1
-		lines[last] += fmt.Sprintf(", fb: draw %v, cp %v",
-			d.lastRender.Round(time.Millisecond),
-			d.lastCopy.Round(time.Millisecond))
-	}
-	lines = append(lines, "")
-	lines = append(lines, "Private IP addresses:")
+// infoLines returns the host information lines (in $color$text markup)
+// following the hostname and time, e.g. clock, load and /perm usage.
+func (d *statusDrawer) infoLines() []string {
+	lines := []string{d.clock.line()}
+	if line, err := loadavgLine(); err == nil {
+		lines = append(lines, line)
+	}
+	if d.celsiusErr == nil {
+		lines = append(lines, fmt.Sprintf("$$SoC temperature: $%s$%.1f °C",
+			metricColor("temperature", d.celsius, ""),
+			d.celsius))
+	}
+	lines = append(lines, permLine())
+	if line := breakglassLine(); line != "" {
+		lines = append(lines, line)
+	}
+	if throttled, ok := d.throttled.read(); ok {
+		lines = append(lines, throttledLine(throttled))
+	}
+	if watchdogs, err := readWatchdogs("/sys/class/watchdog"); err == nil {
+		owners, _, _ := d.watchdogs.get()
+		for _, w := range watchdogs {
+			w.setOwners(owners)
+			lines = append(lines, watchdogLine(w))
+		}
+	}
+	if fans, err := readFans("/sys/class/hwmon"); err == nil && len(fans) > 0 {
+		lines = append(lines, fanLine(fans, d.celsius, d.celsiusErr))
+	}
+	if d.gpio != nil {
+		lines = append(lines, d.gpio.line())
+	}
+	if len(d.probes) > 0 {
+		lines = append(lines, d.probes.line())
+	}
+	if line := d.fileShares.line(); line != "" {
+		lines = append(lines, line)
+	}
+	if line := conntrackLine(); line != "" {
+		lines = append(lines, line)
+	}
+	if len(d.nftCounters) > 0 {
+		lines = append(lines, nftCountersLine(d.nftCounters))
+	}
+	if len(d.tls) > 0 {
+		lines = append(lines, d.tls.line())
+	}
+	if d.gus != nil {
+		lines = append(lines, d.gus.line())
+	}
+	lines = append(lines, d.network.lines()...)
+	if links, updated, _ := d.links.get(); !updated.IsZero() {
+		if line := linkLine(links); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if line := d.ifaceErrors.line(); line != "" {
+		lines = append(lines, line)
+	}
+	if d.wan != nil {
+		lines = append(lines, d.wan.line())
+	}
+	if d.ipv6 != nil {
+		lines = append(lines, d.ipv6.lines()...)
+	}
+	if d.mdns != nil {
+		lines = append(lines, d.mdns.line())
+	}
+	return lines
+}
+
+// interfaceAddrs returns the sorted private and public IP addresses of all
+// network interfaces.
+func interfaceAddrs() (private, public []string) {
 	if addrs, err := gokrazy.PrivateInterfaceAddrs(); err == nil {
 		sort.Strings(addrs)
 		for _, addr := range addrs {
@@ -377,107 +748,450 @@ func (d *statusDrawer) draw1(ctx context.Context) error {
 				continue
 			}
 
-			lines = append(lines, addr)
+			private = append(private, addr)
 		}
 	}
-	lines = append(lines, "")
-	lines = append(lines, "Public IP addresses:")
 	if addrs, err := gokrazy.PublicInterfaceAddrs(); err == nil {
 		sort.Strings(addrs)
-		lines = append(lines, addrs...)
+		public = addrs
 	}
+	return private, public
+}
+
+// drawStatus renders the classic fbstatus view: host information in the top
+// left, the gokrazy logo in the top right and resource usage at the bottom.
+func (d *statusDrawer) drawStatus() error {
+	statArea := d.areas.stats
+
+	d.clear(d.gstat, statArea.Min)
+
+	em, _ := d.gstat.MeasureString("m")
+
+	// TODO: look into why MeasureString/DrawString are not monospace-correct
+	drawLine := func(line string, y float64) {
+		statx := 3 * em
+		for idx, field := range strings.Split(strings.TrimPrefix(line, "$"), "$") {
+			if idx%2 == 0 {
+				setColor(d.gstat, field)
+				continue
+			}
+			for _, r := range field {
+				d.gstat.DrawString(string(r), statx, y)
+				statx += em
+			}
+		}
+	}
+
+	// render header, followed by the units of the columns
+	lineHeight := d.gstat.FontHeight() * lineSpacing
+	drawLine("$$"+d.statLayout.header(), 3*em)
+	drawLine("$darkgray$"+d.statLayout.units(), 3*em+lineHeight)
+
+	staty := 6*em + lineHeight
+	drawRow := func(row [][]string, label string) {
+		line := d.statLayout.row(row)
+		if label != "" {
+			line += "$darkgray$" + label
+		}
+		drawLine(line, staty)
+		staty += lineHeight
+	}
+
+	// Show as many of the most recent rows as fit above the 1-minute
+	// averages and session peaks, which are separated by half a line.
+	rows := d.last
+	if fit := int((float64(d.gstat.Height())-7*em-lineHeight-lineHeight/2)/lineHeight) + 1 - 2; fit < len(rows) {
+		if fit < 0 {
+			fit = 0
+		}
+		rows = rows[len(rows)-fit:]
+	}
+	for _, lastrow := range rows {
+		drawRow(lastrow, "")
+	}
+	staty += lineHeight / 2
+	drawRow(d.statAverages, "avg 1m")
+	drawRow(d.statPeaks, "peak")
+
+	// --------------------------------------------------------------------------------
+
+	d.clear(d.g, d.areas.info.Min)
+	lines := d.hostLines()
 	texty := int(6 * em)
 
 	for _, line := range lines {
-		d.g.DrawString(line, 3*em, float64(texty))
+		drawMarkup(d.g, line, 3*em, float64(texty))
 		texty += int(d.g.FontHeight() * lineSpacing)
 	}
-	leftHalf := image.Rect(0, 0, d.w/2, d.h)
-	draw.Draw(d.buffer, leftHalf, d.g.Image(), image.ZP, draw.Src)
+	if d.celsiusErr == nil {
+		// graph the temperature history below the textual information, if
+		// there is enough space left
+		graphH := 4 * d.g.FontHeight()
+		if float64(texty)+graphH < float64(d.g.Height()) {
+			graphW := float64(d.g.Width()) - 6*em
+			drawGraph(d.g, d.temperature.hist, 3*em, float64(texty), graphW, graphH, "%.f °C")
+			d.g.SetRGB(1, 1, 1)
+		}
+	}
+	d.drawBadges(d.g, false)
+	draw.Draw(d.buffer, d.areas.info, d.g.Image(), image.ZP, draw.Src)
 
-	rightHalf := image.Rect(d.w/2, 0, d.w, int(50*d.scaleFactor))
-	draw.Draw(d.buffer, rightHalf, d.ggopher.Image(), image.ZP, draw.Src)
+	ga := d.areas.gopher
+	title := image.Rect(ga.Min.X, ga.Min.Y, ga.Max.X, ga.Min.Y+int(50*d.scaleFactor))
+	draw.Draw(d.buffer, title, d.ggopher.Image(), image.ZP, draw.Src)
 
 	// display stat output in the bottom half
 	draw.Draw(d.buffer, statArea, d.gstat.Image(), image.ZP, draw.Src)
 
+	return nil
+}
+
+func (d *statusDrawer) draw1(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	start := time.Now()
+	defer func() {
+		d.health.frame(time.Now())
+		d.stats.frames++
+		if elapsed := time.Since(start); elapsed > frameInterval {
+			// The next frames are dropped (time.Tick drops ticks for slow
+			// receivers).
+			d.stats.dropped += uint64(elapsed / frameInterval)
+		}
+	}()
+	d.collect()
+	if d.auto != nil || d.night != nil {
+		now := time.Now()
+		var adjust lightAdjust
+		if d.auto != nil {
+			adjust = d.auto.adjust(now)
+			d.curve = d.auto.curve
+		}
+		if d.night != nil {
+			d.curve = d.night.colorCurve(now, adjust)
+		}
+	}
+
+	if msg := d.reboot.active(time.Now()); msg != "" {
+		// like the update overlay, wake up the display if blanked
+		d.drawRebootScreen(msg)
+		d.lastPage = nil
+		d.shownPage = nil
+		d.blanked = false
+		return d.copyBuffer()
+	}
+
+	update, updating := d.updateInProgress()
+	if d.control.isBlanked() && !updating {
+		if !d.blanked {
+			draw.Draw(d.img, d.bounds, image.Black, image.Point{}, draw.Src)
+			d.blanked = true
+			d.shownPage = nil
+		}
+		return nil
+	}
+	if d.blanked {
+		// restore the static background when unblanking
+		d.lastPage = nil
+		d.blanked = false
+	}
+
+	t2 := time.Now()
+	if d.splash != nil {
+		if d.drawSplash() {
+			d.lastRender = time.Since(t2)
+			d.stats.render += d.lastRender
+			return d.present(nil)
+		}
+		d.splash = nil
+		d.lastPage = nil
+		d.control.restartRotation()
+	}
+	alerts := d.alertsToShow()
+	notifications := d.notifier.active(time.Now())
+	maintenance := d.maintenanceBanner(time.Now())
+	if d.overlayShown {
+		// Overlays cover parts of the static background.
+		d.lastPage = nil
+	}
+	pg := d.currentPage()
+	d.marquees = nil
+	if pg != d.lastPage {
+		// restore the static background (e.g. the gokrazy logo)
+		copy(d.buffer.Pix, d.background.Pix)
+		d.lastPage = pg
+	}
+	if pg.panels == nil {
+		if err := d.drawStatus(); err != nil {
+			return err
+		}
+	} else {
+		d.drawPanels(pg)
+	}
+	config := d.control.configShown()
+	if config {
+		d.drawConfigOverlay(pg)
+	}
+	if len(notifications) > 0 {
+		d.drawNotifications(notifications)
+	}
+	if maintenance != "" {
+		d.drawMaintenanceBanner(maintenance)
+	}
+	if len(alerts) > 0 {
+		d.drawAlertBanner(alerts)
+	}
+	if updating {
+		d.drawUpdateOverlay(update)
+	}
+	legend := d.control.legendShown()
+	if legend {
+		d.drawLegendOverlay()
+	}
+	hud := d.control.hudShown()
+	if hud {
+		d.drawHUD(pg)
+	}
+	d.overlayShown = config || len(notifications) > 0 || maintenance != "" || len(alerts) > 0 || updating || legend || hud
 	d.lastRender = time.Since(t2)
+	d.stats.render += d.lastRender
+
+	return d.present(pg)
+}
 
+// copyBuffer copies the buffer to the framebuffer.
+func (d *statusDrawer) copyBuffer() error {
+	return d.copyFrame(d.buffer)
+}
+
+// copyFrame copies src (the buffer or a frame of a transition, see
+// transition.go) to the framebuffer.
+func (d *statusDrawer) copyFrame(src *image.RGBA) error {
 	t3 := time.Now()
+	d.copyRect(src, d.bounds)
+	d.lastCopy = time.Since(t3)
+	d.stats.copy += d.lastCopy
+	if d.hud != nil {
+		d.hud.frame(time.Now())
+	}
+	return nil
+}
+
+// copyRect copies the rectangle r of src to the framebuffer.
+func (d *statusDrawer) copyRect(src *image.RGBA, r image.Rectangle) {
 	// NOTE: This code path is NOT using double buffering (which is done
 	// using the pan ioctl when using the frame buffer), but in practice
 	// updates seem smooth enough, most likely because we are only
 	// updating timestamps.
 	switch x := d.img.(type) {
 	case *fbimage.BGR565:
-		copyRGBAtoBGR565(x, d.buffer)
+		copyRGBAtoBGR565(x, src, r, d.curve, d.dither)
 	case *fbimage.BGRA:
-		copyRGBAtoBGRA(x, d.buffer)
+		copyRGBAtoBGRA(x, src, r, d.curve)
 	default:
 		if !d.slowPathNotified {
-			log.Printf("framebuffer not using pixel format BGR565, falling back to slow path for img type %T", d.img)
+			slog.Info("framebuffer not using pixel format BGR565, falling back to slow path", "type", fmt.Sprintf("%T", d.img))
 			d.slowPathNotified = true
 		}
-		draw.Draw(d.img, d.bounds, d.buffer, image.Point{}, draw.Src)
+		if d.curve != nil {
+			if d.curved == nil {
+				d.curved = image.NewRGBA(d.bounds)
+			}
+			d.curve.apply(d.curved, src, r)
+			src = d.curved
+		}
+		draw.Draw(d.img, r, src, r.Min, draw.Src)
 	}
-	d.lastCopy = time.Since(t3)
-	return nil
 }
 
+var force = flag.Bool("force",
+	false,
+	"keep running if the console cannot be switched into graphics mode (KDSETMODE fails, e.g. with EPERM in restricted environments), accepting that the kernel might draw the cursor or messages over the display")
+
+var onExit = flag.String("on-exit",
+	"restore",
+	"what to leave on the display when fbstatus exits: restore (what was displayed before fbstatus started, e.g. a boot logo or another program's output), clear (black, before switching back to the previous console) or keep (the last frame, staying in graphics mode, e.g. for kiosks)")
+
 func fbstatus() error {
 	ctx := context.Background()
 
-	// Cancel the context instead of exiting the program:
-	ctx, canc := signal.NotifyContext(ctx, os.Interrupt)
+	if *onExit != "restore" && *onExit != "clear" && *onExit != "keep" {
+		return fmt.Errorf("unknown -on-exit=%q, expected restore, clear or keep", *onExit)
+	}
+
+	// Cancel the context instead of exiting the program (e.g. when gokrazy
+	// stops fbstatus), so that -on-exit applies:
+	ctx, canc := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
 	defer canc()
 
-	cons, err := console.LeaseForGraphics()
+	gate, err := parseBootGate(*waitForFlag)
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err := cons.Cleanup(); err != nil {
-			log.Print(err)
+	if gate != nil {
+		if gate.services && *splashFlag {
+			return fmt.Errorf("-wait-for=services and -splash are mutually exclusive: the boot splash shows the services starting")
 		}
-	}()
+		if err := gate.wait(ctx); err != nil {
+			return err
+		}
+	}
 
 	dev, err := fb.Open("/dev/fb0")
+	if err != nil {
+		if *serialDevice == "" {
+			return err
+		}
+		slog.Warn("no framebuffer, showing the status on the serial device only", "err", err)
+		return serialOnly(ctx)
+	}
+
+	// save what is displayed before drawing anything
+	var saved *fb.Snapshot
+	if *onExit == "restore" {
+		saved, err = dev.Save()
+		if err != nil {
+			// not worth failing over: clear the display instead
+			slog.Warn("saving the framebuffer contents failed", "err", err)
+		}
+	}
+
+	cons, err := console.LeaseForGraphics(*force)
 	if err != nil {
 		return err
 	}
+	var (
+		img    draw.Image    // set below
+		drawer *statusDrawer // set below
+	)
+	defer func() {
+		exit := *onExit
+		if drawer != nil {
+			if msg := drawer.rebootMessage(); msg != "" {
+				// leave the reboot screen up until the kernel reboots
+				if err := drawer.showRebootScreen(msg); err != nil {
+					slog.Warn("showing the reboot screen failed", "err", err)
+				} else {
+					exit = "keep"
+				}
+			}
+		}
+		if exit == "keep" {
+			if err := cons.Keep(); err != nil {
+				slog.Warn("releasing the console failed", "err", err)
+			}
+			return
+		}
+		if saved != nil {
+			if err := dev.Restore(saved); err != nil {
+				slog.Warn("restoring the framebuffer contents failed", "err", err)
+			}
+		} else if img != nil {
+			draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+		}
+		if err := cons.Cleanup(); err != nil {
+			slog.Warn("restoring the console failed", "err", err)
+		}
+	}()
 
+	var widthMM uint32
 	if info, err := dev.VarScreeninfo(); err == nil {
-		log.Printf("framebuffer screeninfo: %+v", info)
+		slog.Debug("framebuffer screeninfo", "info", fmt.Sprintf("%+v", info))
+		widthMM = info.Width
 	}
 
-	img, err := dev.Image()
+	img, err = dev.Image()
 	if err != nil {
 		return err
 	}
 
-	drawer, err := newStatusDrawer(img)
+	drawer, err = newStatusDrawer(img, widthMM)
 	if err != nil {
 		return err
 	}
 
-	tick := time.Tick(1 * time.Second)
+	if *serialDevice != "" {
+		port, screen, err := openSerialScreen()
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := drawer.serialStatus(ctx, port, screen, false); err != nil && err != ctx.Err() {
+				slog.Warn("serial status failed", "err", err)
+			}
+		}()
+	}
+	if *httpListen != "" {
+		go func() {
+			slog.Info("serving HTTP endpoints", "addr", *httpListen)
+			fatal(http.ListenAndServe(*httpListen, drawer.httpHandler()))
+		}()
+	}
+	if drawer.mdns != nil {
+		go drawer.mdns.run(ctx)
+	}
+	quitc := make(chan struct{})
+	var quitOnce sync.Once
+	quit := func() { quitOnce.Do(func() { close(quitc) }) }
+	if *keyboardInput || *touchInput || *irInput {
+		go drawer.readInput(ctx, cons.Visible, quit)
+	}
+	if *timelapseDir != "" {
+		go drawer.recordTimelapse(*timelapseDir)
+	}
+	if *mqttControlTopic != "" {
+		go drawer.mqttControl(*mqttControlTopic)
+	}
+	if *homeAssistantURL != "" {
+		metrics, err := parseHomeAssistantSensors(*homeAssistantSensors)
+		if err != nil {
+			return err
+		}
+		go drawer.pushHomeAssistant(metrics)
+	}
+
+	tick := time.Tick(frameInterval)
 	for {
 		if cons.Visible() {
 			if err := drawer.draw1(ctx); err != nil {
 				return err
 			}
+			if err := drawer.animate(); err != nil {
+				return err
+			}
+		} else {
+			drawer.health.frame(time.Now())
 		}
 
-		select {
-		case <-ctx.Done():
-			// return to trigger the deferred cleanup function
-			return ctx.Err()
+		// scroll the marquees (if any) until the next frame is due
+		marquee := drawer.marqueeTick()
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				// return to trigger the deferred cleanup function
+				return ctx.Err()
 
-		case <-cons.Redraw():
-			break // next iteration
+			case <-quitc:
+				return errQuit
 
-		case <-tick:
-			break
+			case <-cons.Redraw():
+				break wait // next iteration
+
+			case <-drawer.control.changed:
+				break wait
+
+			case <-tick:
+				break wait
+
+			case <-marquee:
+				if cons.Visible() {
+					if err := drawer.scrollMarquees(); err != nil {
+						return err
+					}
+				}
+				marquee = drawer.marqueeTick()
+			}
 		}
 	}
 }
@@ -487,10 +1201,14 @@ func fbstatus() error {
 //
 // This specialization brings down copying time to 137ms (from 1.8s!) on the
 // Raspberry Pi 4.
-func copyRGBAtoBGR565(dst *fbimage.BGR565, src *image.RGBA) {
-	bounds := dst.Bounds()
-	for y := 0; y < bounds.Max.Y; y++ {
-		for x := 0; x < bounds.Max.X; x++ {
+//
+// If curve is non-nil, it is applied to the colors (see -gamma). If dither is
+// true, the colors are dithered to avoid banding in gradients (see
+// -background).
+func copyRGBAtoBGR565(dst *fbimage.BGR565, src *image.RGBA, r image.Rectangle, curve *colorCurve, dither bool) {
+	r = r.Intersect(dst.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
 			var c color.NRGBA
 
 			i := src.PixOffset(x, y)
@@ -518,6 +1236,13 @@ func copyRGBAtoBGR565(dst *fbimage.BGR565, src *image.RGBA) {
 				b = (b * 0xffff) / a
 				c = color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), uint8(a >> 8)}
 			}
+			if curve != nil {
+				c.R, c.G, c.B = curve.r[c.R], curve.g[c.G], curve.b[c.B]
+			}
+			if dither {
+				t := bayer4[y&3][x&3]
+				c.R, c.G, c.B = ditherAdd(c.R, t, 5), ditherAdd(c.G, t, 6), ditherAdd(c.B, t, 5)
+			}
 
 			pix := dst.Pix[dst.PixOffset(x, y):]
 			pix[0] = (c.B >> 3) | ((c.G >> 2) << 5)
@@ -531,11 +1256,28 @@ func copyRGBAtoBGR565(dst *fbimage.BGR565, src *image.RGBA) {
 //
 // This specialization brings down copying time to 5ms (from 60-70ms) on an
 // amd64 qemu VM with virtio VGA.
-func copyRGBAtoBGRA(dst *fbimage.BGRA, src *image.RGBA) {
-	for i := 0; i < len(src.Pix); i += 4 {
-		s := src.Pix[i : i+4 : i+4]
-		d := dst.Pix[i : i+4 : i+4]
-		d[0], d[1], d[2], d[3] = s[2], s[1], s[0], s[3]
+//
+// If curve is non-nil, it is applied to the colors (see -gamma).
+func copyRGBAtoBGRA(dst *fbimage.BGRA, src *image.RGBA, r image.Rectangle, curve *colorCurve) {
+	r = r.Intersect(dst.Bounds())
+	if curve != nil {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			si, di := src.PixOffset(r.Min.X, y), dst.PixOffset(r.Min.X, y)
+			for end := si + 4*r.Dx(); si < end; si, di = si+4, di+4 {
+				s := src.Pix[si : si+4 : si+4]
+				d := dst.Pix[di : di+4 : di+4]
+				d[0], d[1], d[2], d[3] = curve.b[s[2]], curve.g[s[1]], curve.r[s[0]], s[3]
+			}
+		}
+		return
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		si, di := src.PixOffset(r.Min.X, y), dst.PixOffset(r.Min.X, y)
+		for end := si + 4*r.Dx(); si < end; si, di = si+4, di+4 {
+			s := src.Pix[si : si+4 : si+4]
+			d := dst.Pix[di : di+4 : di+4]
+			d[0], d[1], d[2], d[3] = s[2], s[1], s[0], s[3]
+		}
 	}
 }
 
@@ -546,11 +1288,14 @@ func main() {
 	var cpuprofile = flag.String("cpuprofile", "", "cpu profile")
 	var debugListen = flag.String("debug-listen", "", "if non-empty, listen address for debug pprof server")
 	flag.Parse()
+	if err := setupLogging(os.Stderr); err != nil {
+		fatal(err)
+	}
 
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 		pprof.StartCPUProfile(f)
 		defer pprof.StopCPUProfile()
@@ -558,16 +1303,34 @@ func main() {
 
 	if *debugListen != "" {
 		go func() {
-			log.Printf("Running debug server on %v ...", *debugListen)
+			slog.Info("running debug server", "addr", *debugListen)
 			http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 				http.Redirect(w, r, "/debug/pprof", http.StatusFound)
 			})
-			log.Fatal(http.ListenAndServe(*debugListen, nil))
+			fatal(http.ListenAndServe(*debugListen, nil))
 		}()
 	}
 
+	if flag.Arg(0) == "term" {
+		if err := term(flag.Args()[1:]); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	if flag.Arg(0) == "screenshot" {
+		if err := screenshot(flag.Args()[1:]); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
 	if err := fbstatus(); err != nil {
-		log.Fatal(err)
+		if err == errQuit {
+			// Exit status 125 tells gokrazy not to restart fbstatus.
+			os.Exit(125)
+		}
+		fatal(err)
 	}
 }
 
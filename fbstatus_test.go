@@ -1,19 +1,29 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/jpeg"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/gokrazy/fbstatus/internal/fbimage"
+	"github.com/gokrazy/stat"
+	"github.com/gokrazy/stat/statexp"
 )
 
 func drawToFile(w, h int) error {
 	ctx := context.Background()
 	img := image.NewRGBA(image.Rect(0, 0, w, h))
 
-	drawer, err := newStatusDrawer(img)
+	drawer, err := newStatusDrawer(img, 0)
 	if err != nil {
 		return err
 	}
@@ -21,7 +31,11 @@ func drawToFile(w, h int) error {
 		return err
 	}
 
-	out, err := os.Create(fmt.Sprintf("/tmp/fbstatus-%dx%d.jpg", w, h))
+	fn := fmt.Sprintf("/tmp/fbstatus-%dx%d.jpg", w, h)
+	if *pagesFlag != "status" {
+		fn = fmt.Sprintf("/tmp/fbstatus-%s-%dx%d.jpg", *pagesFlag, w, h)
+	}
+	out, err := os.Create(fn)
 	if err != nil {
 		return err
 	}
@@ -50,3 +64,100 @@ func TestDraw(t *testing.T) {
 		}
 	}
 }
+
+func TestDrawPanels(t *testing.T) {
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	for _, name := range panelNames() {
+		*pagesFlag = name
+		if err := drawToFile(1920, 1080); err != nil {
+			t.Fatalf("panel %s: %v", name, err)
+		}
+	}
+}
+
+type fakeStatModule struct {
+	files []string
+}
+
+func (m *fakeStatModule) FileContents() []string { return m.files }
+
+func (m *fakeStatModule) ProcessAndFormat(map[string][]byte) []stat.Col { return nil }
+
+func TestOpenStatFiles(t *testing.T) {
+	present := filepath.Join(t.TempDir(), "stat")
+	if err := os.WriteFile(present, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	files, unavailable := openStatFiles([]statexp.ProcessAndFormatter{
+		&fakeStatModule{files: []string{present}},
+		&fakeStatModule{files: []string{present, missing}},
+		&fakeStatModule{files: []string{missing}},
+	})
+	if _, ok := files[present]; !ok || len(files) != 1 {
+		t.Errorf("files = %v, want only %s", files, present)
+	}
+	if got, want := fmt.Sprint(unavailable), "[false true true]"; got != want {
+		t.Errorf("unavailable = %v, want %v", got, want)
+	}
+
+	col := statPlaceholderCol("darkgray", "unavailable")
+	if !strings.HasPrefix(col, "$darkgray$") {
+		t.Errorf("statPlaceholderCol = %q, want dark gray", col)
+	}
+	// the placeholder spans the disk module
+	row := make([][]string, len(statColumns))
+	row[1] = []string{col}
+	l := newStatLayout([][][]string{row})
+	if got, want := markupWidth(l.row(row)), len(l.header()+"   "); got != want {
+		t.Errorf("row with placeholder is %d characters wide, want %d", got, want)
+	}
+}
+
+// randomRGBA returns an image of random (valid, i.e. premultiplied) colors,
+// a quarter of which are translucent or transparent.
+func randomRGBA(rnd *rand.Rand, r image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			a := uint8(0xff)
+			if rnd.Intn(4) == 0 {
+				a = uint8(rnd.Intn(0x100))
+			}
+			c := color.NRGBA{uint8(rnd.Intn(0x100)), uint8(rnd.Intn(0x100)), uint8(rnd.Intn(0x100)), a}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestCopyMatchesDraw(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	bounds := image.Rect(0, 0, 37, 23)
+	for i := 0; i < 20; i++ {
+		src := randomRGBA(rnd, bounds)
+		// a random rectangle, which might extend beyond the bounds
+		min := image.Pt(rnd.Intn(bounds.Dx()), rnd.Intn(bounds.Dy()))
+		r := image.Rectangle{min, min.Add(image.Pt(1+rnd.Intn(bounds.Dx()), 1+rnd.Intn(bounds.Dy())))}
+
+		newBGR565 := func() *fbimage.BGR565 {
+			return &fbimage.BGR565{Pix: make([]byte, 2*bounds.Dx()*bounds.Dy()), Rect: bounds, Stride: 2 * bounds.Dx()}
+		}
+		want565, got565 := newBGR565(), newBGR565()
+		draw.Draw(want565, r, src, r.Min, draw.Src)
+		copyRGBAtoBGR565(got565, src, r, nil, false)
+		if !bytes.Equal(got565.Pix, want565.Pix) {
+			t.Errorf("copyRGBAtoBGR565(%v) differs from draw.Draw", r)
+		}
+
+		newBGRA := func() *fbimage.BGRA {
+			return &fbimage.BGRA{Pix: make([]byte, 4*bounds.Dx()*bounds.Dy()), Rect: bounds, Stride: 4 * bounds.Dx()}
+		}
+		wantBGRA, gotBGRA := newBGRA(), newBGRA()
+		draw.Draw(wantBGRA, r, src, r.Min, draw.Src)
+		copyRGBAtoBGRA(gotBGRA, src, r, nil)
+		if !bytes.Equal(gotBGRA.Pix, wantBGRA.Pix) {
+			t.Errorf("copyRGBAtoBGRA(%v) differs from draw.Draw", r)
+		}
+	}
+}
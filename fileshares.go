@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Well-known ports of the SMB and NFS servers.
+const (
+	smbPort = 445
+	nfsPort = 2049
+)
+
+// parseNFSDIO returns the number of bytes read and written by the kernel NFS
+// server from the io line of /proc/net/rpc/nfsd.
+func parseNFSDIO(b []byte) (read, written uint64, _ error) {
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] != "io" {
+			continue
+		}
+		read, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		written, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		return read, written, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	return 0, 0, fmt.Errorf("no io line found")
+}
+
+// parseProcIO returns the number of bytes the process caused to be read from
+// and written to storage, from the contents of /proc/[pid]/io.
+func parseProcIO(b []byte) (uint64, error) {
+	var total uint64
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok || (key != "read_bytes" && key != "write_bytes") {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, scanner.Err()
+}
+
+// smbdBytes returns the storage I/O of all Samba server processes.
+func smbdBytes() uint64 {
+	matches, _ := filepath.Glob("/proc/[0-9]*/comm")
+	var total uint64
+	for _, m := range matches {
+		comm, err := os.ReadFile(m)
+		if err != nil || strings.TrimSpace(string(comm)) != "smbd" {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(filepath.Dir(m), "io"))
+		if err != nil {
+			continue // process exited in the meantime
+		}
+		if n, err := parseProcIO(b); err == nil {
+			total += n
+		}
+	}
+	return total
+}
+
+// fileShares tracks the SMB and NFS servers of file server appliances, so
+// that one can tell whether it is safe to reboot.
+type fileShares struct {
+	prevBytes uint64
+	prevTime  time.Time
+}
+
+func clientsDesc(n int) string {
+	switch n {
+	case 0:
+		return "$green$idle$$"
+	case 1:
+		return "$yellow$1 client$$"
+	default:
+		return fmt.Sprintf("$yellow$%d clients$$", n)
+	}
+}
+
+// line returns a host information line (in $color$text markup) with the
+// number of connected SMB and NFS clients and the aggregate throughput, or
+// the empty string if neither server is running.
+func (f *fileShares) line() string {
+	var parts []string
+	var total uint64
+	if peers, listening, err := tcpPeers(smbPort); err == nil && listening {
+		parts = append(parts, "SMB "+clientsDesc(len(peers)))
+		total += smbdBytes()
+	}
+	if peers, listening, err := tcpPeers(nfsPort); err == nil && listening {
+		parts = append(parts, "NFS "+clientsDesc(len(peers)))
+		if b, err := os.ReadFile("/proc/net/rpc/nfsd"); err == nil {
+			if read, written, err := parseNFSDIO(b); err == nil {
+				total += read + written
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	line := "$$file sharing: " + strings.Join(parts, ", ")
+	now := time.Now()
+	if !f.prevTime.IsZero() && total >= f.prevBytes {
+		rate := float64(total-f.prevBytes) / now.Sub(f.prevTime).Seconds()
+		line += ", " + formatBytes(uint64(rate)) + "/s"
+	}
+	f.prevBytes, f.prevTime = total, now
+	return line
+}
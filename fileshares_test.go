@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestParseNFSDIO(t *testing.T) {
+	const nfsd = `rc 0 31 102
+fh 0 0 0 0 0
+io 1048576 4096
+th 8 0 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000 0.000
+`
+	read, written, err := parseNFSDIO([]byte(nfsd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read != 1048576 || written != 4096 {
+		t.Errorf("parseNFSDIO() = %d, %d, want 1048576, 4096", read, written)
+	}
+}
+
+func TestParseProcIO(t *testing.T) {
+	const io = `rchar: 323934931
+wchar: 323929600
+syscr: 632687
+syscw: 632675
+read_bytes: 8192
+write_bytes: 2048
+cancelled_write_bytes: 0
+`
+	got, err := parseProcIO([]byte(io))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(8192 + 2048); got != want {
+		t.Errorf("parseProcIO() = %d, want %d", got, want)
+	}
+}
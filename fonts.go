@@ -0,0 +1,257 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"log/slog"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+var fallbackFonts = flag.String("fallback-fonts",
+	"",
+	"comma-separated list of font files (.ttf, .otf or .ttc, of which the first font is used; e.g. /perm/fonts/NotoSansCJK-Regular.ttc,/perm/fonts/NotoEmoji-Regular.ttf) to draw characters which the Go fonts do not contain, e.g. CJK characters or emoji in hostnames, SSIDs or MQTT payloads, instead of boxes. The fonts are tried in order. Color emoji fonts are not supported")
+
+const (
+	// glyphCacheSize bounds the number of rasterized glyphs each face
+	// caches. fbstatus draws mostly the same characters in every frame,
+	// so this is only reached with many fallback characters.
+	glyphCacheSize = 1024
+
+	// subpixelSteps is the number of horizontal positions within a pixel at
+	// which glyphs are rasterized (and cached).
+	subpixelSteps = 4
+)
+
+// parseFont parses an OpenType font (with TrueType or PostScript outlines),
+// or the first font of a font collection.
+func parseFont(b []byte) (*opentype.Font, error) {
+	if strings.HasPrefix(string(b), "ttcf") {
+		c, err := opentype.ParseCollection(b)
+		if err != nil {
+			return nil, err
+		}
+		return c.Font(0)
+	}
+	return opentype.Parse(b)
+}
+
+// loadFallbackFonts loads the fonts listed in spec (as per -fallback-fonts).
+// Fonts which cannot be loaded are skipped, so that a missing font does not
+// prevent fbstatus from displaying anything.
+func loadFallbackFonts(spec string) []*opentype.Font {
+	var fonts []*opentype.Font
+	for _, path := range strings.Split(spec, ",") {
+		if path == "" {
+			continue
+		}
+		f, err := loadFont(path)
+		if err != nil {
+			slog.Warn("loading fallback font failed", "err", err)
+			continue
+		}
+		fonts = append(fonts, f)
+	}
+	return fonts
+}
+
+func loadFont(path string) (*opentype.Font, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseFont(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return f, nil
+}
+
+type glyphKey struct {
+	r rune
+	x fixed.Int26_6 // sub-pixel position, a multiple of 64/subpixelSteps
+}
+
+type cachedGlyph struct {
+	dr      image.Rectangle // relative to the dot
+	mask    *image.Alpha
+	advance fixed.Int26_6
+	ok      bool
+}
+
+// otFace is a font.Face for an OpenType font, which is rasterized by the
+// golang.org/x/image/vector rasterizer (via opentype.Face). Unlike
+// opentype.Face, otFace caches the rasterized glyphs, as fbstatus draws the
+// same text in every frame. gg only lays out and composites the glyphs of
+// the faces it is given, so freetype is no longer used for text.
+type otFace struct {
+	font.Face // *opentype.Face
+	f         *opentype.Font
+	ppem      fixed.Int26_6
+	hinting   font.Hinting
+	buf       sfnt.Buffer
+	glyphs    map[glyphKey]cachedGlyph
+}
+
+// newOTFace returns a face for f at size (in pixels).
+func newOTFace(f *opentype.Font, size float64) *otFace {
+	// Full hinting rounds the advances to whole pixels, which keeps the
+	// glyphs of a string at the same sub-pixel position (and hence sharp
+	// and cached), and monospace columns aligned.
+	const hinting = font.HintingFull
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72, // i.e. size is in pixels
+		Hinting: hinting,
+	})
+	if err != nil {
+		// opentype.NewFace only fails for invalid options
+		panic(fmt.Sprintf("opentype.NewFace: %v", err))
+	}
+	return &otFace{
+		Face:    face,
+		f:       f,
+		ppem:    fixed.Int26_6(0.5 + size*64), // as per opentype.NewFace
+		hinting: hinting,
+		glyphs:  make(map[glyphKey]cachedGlyph),
+	}
+}
+
+// hasGlyph returns whether the font of f contains a glyph for r.
+func (f *otFace) hasGlyph(r rune) bool {
+	x, err := f.f.GlyphIndex(&f.buf, r)
+	return err == nil && x != 0
+}
+
+func (f *otFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	// quantize the dot to the nearest sub-pixel step (horizontally) and to
+	// the nearest pixel (vertically)
+	const step = 64 / subpixelSteps
+	x := dot.X + step/2
+	px, py := x.Floor(), (dot.Y + 32).Floor()
+	key := glyphKey{r: r, x: (x - fixed.I(px)) / step * step}
+	g, cached := f.glyphs[key]
+	if !cached {
+		var m image.Image
+		var mp image.Point
+		g.dr, m, mp, g.advance, g.ok = f.Face.Glyph(fixed.Point26_6{X: key.x}, r)
+		if g.ok {
+			// opentype.Face re-uses its mask for the next glyph
+			g.mask = image.NewAlpha(image.Rect(0, 0, g.dr.Dx(), g.dr.Dy()))
+			draw.Draw(g.mask, g.mask.Rect, m, mp, draw.Src)
+		}
+		if len(f.glyphs) >= glyphCacheSize {
+			f.glyphs = make(map[glyphKey]cachedGlyph)
+		}
+		f.glyphs[key] = g
+	}
+	if !g.ok {
+		return image.Rectangle{}, nil, image.Point{}, 0, false
+	}
+	return g.dr.Add(image.Pt(px, py)), g.mask, image.Point{}, g.advance, true
+}
+
+// Kern returns the kerning for r0 and r1 at the size of the face. (The Kern
+// method of opentype.Face scales the kerning as if the face was unitsPerEm
+// pixels large.)
+func (f *otFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	x0, err := f.f.GlyphIndex(&f.buf, r0)
+	if err != nil {
+		return 0
+	}
+	x1, err := f.f.GlyphIndex(&f.buf, r1)
+	if err != nil {
+		return 0
+	}
+	k, err := f.f.Kern(&f.buf, x0, x1, f.ppem, f.hinting)
+	if err != nil {
+		return 0
+	}
+	return k
+}
+
+// glyphIndexer is implemented by *otFace.
+type glyphIndexer interface {
+	hasGlyph(r rune) bool
+}
+
+// fallbackFace is a font.Face which draws each character in the first of its
+// faces whose font contains a glyph for the character. The first face
+// determines the metrics and draws characters which no font contains.
+type fallbackFace struct {
+	faces []font.Face
+	fonts []glyphIndexer // of faces
+}
+
+// newFace returns a face for f (one of the Go fonts) at size, which falls
+// back to -fallback-fonts for characters f does not contain.
+func (d *statusDrawer) newFace(f *opentype.Font, size float64) font.Face {
+	return newFallbackFace(f, d.fallbacks, size)
+}
+
+// newFallbackFace returns a face for f at size, which falls back to
+// fallbacks for characters f does not contain.
+func newFallbackFace(f *opentype.Font, fallbacks []*opentype.Font, size float64) font.Face {
+	face := newOTFace(f, size)
+	if len(fallbacks) == 0 {
+		return face
+	}
+	ff := &fallbackFace{
+		faces: []font.Face{face},
+		fonts: []glyphIndexer{face},
+	}
+	for _, fallback := range fallbacks {
+		face := newOTFace(fallback, size)
+		ff.faces = append(ff.faces, face)
+		ff.fonts = append(ff.fonts, face)
+	}
+	return ff
+}
+
+// face returns the face which draws r.
+func (f *fallbackFace) face(r rune) font.Face {
+	for idx, fnt := range f.fonts {
+		if fnt.hasGlyph(r) {
+			return f.faces[idx]
+		}
+	}
+	return f.faces[0]
+}
+
+func (f *fallbackFace) Close() error {
+	for _, face := range f.faces {
+		face.Close()
+	}
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (dr image.Rectangle, mask image.Image, maskp image.Point, advance fixed.Int26_6, ok bool) {
+	return f.face(r).Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (bounds fixed.Rectangle26_6, advance fixed.Int26_6, ok bool) {
+	return f.face(r).GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (advance fixed.Int26_6, ok bool) {
+	return f.face(r).GlyphAdvance(r)
+}
+
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := f.face(r0)
+	if face != f.face(r1) {
+		return 0 // kerning is only defined within one font
+	}
+	return face.Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// fakeFont contains glyphs only for its runes.
+type fakeFont string
+
+func (f fakeFont) hasGlyph(r rune) bool {
+	return strings.ContainsRune(string(f), r)
+}
+
+func TestFallbackFace(t *testing.T) {
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary := newOTFace(regular, 16)
+	cjk := newOTFace(regular, 16)
+	emoji := newOTFace(regular, 16)
+	ff := &fallbackFace{
+		faces: []font.Face{primary, cjk, emoji},
+		fonts: []glyphIndexer{primary, fakeFont("日本a"), fakeFont("🙂日")},
+	}
+	for _, tt := range []struct {
+		r    rune
+		want font.Face
+		name string
+	}{
+		{'a', primary, "primary"},
+		{'日', cjk, "cjk"},
+		{'🙂', emoji, "emoji"},
+		{'☃', primary, "primary"}, // in no font
+	} {
+		if got := ff.face(tt.r); got != tt.want {
+			t.Errorf("face(%q) is not the %s face", tt.r, tt.name)
+		}
+	}
+	if got := ff.Kern('a', '日'); got != 0 {
+		t.Errorf("Kern across fonts = %v, want 0", got)
+	}
+	if got, want := ff.Metrics(), primary.Metrics(); got != want {
+		t.Errorf("Metrics() = %+v, want %+v (of the primary face)", got, want)
+	}
+}
+
+func TestLoadFallbackFonts(t *testing.T) {
+	dir := t.TempDir()
+	ttf := filepath.Join(dir, "mono.ttf")
+	if err := os.WriteFile(ttf, gomono.TTF, 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.otf")
+	if err := os.WriteFile(invalid, []byte("OTTO\x00\x0a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// invalid and missing fonts are skipped
+	fonts := loadFallbackFonts(strings.Join([]string{invalid, filepath.Join(dir, "missing.ttf"), ttf}, ","))
+	if got, want := len(fonts), 1; got != want {
+		t.Fatalf("loadFallbackFonts() returned %d fonts, want %d", got, want)
+	}
+	if !newOTFace(fonts[0], 16).hasGlyph('a') {
+		t.Errorf("fallback font has no glyph for a")
+	}
+	if _, err := loadFont(invalid); err == nil || !strings.Contains(err.Error(), invalid) {
+		t.Errorf("loadFont(%s) = %v, want error mentioning the file", invalid, err)
+	}
+}
+
+func TestOTFaceGlyphCache(t *testing.T) {
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face := newOTFace(regular, 16)
+	dr1, mask1, _, adv1, ok := face.Glyph(fixed.P(10, 20), 'g')
+	if !ok {
+		t.Fatal("Glyph('g') not ok")
+	}
+	// the same glyph at another position is served from the cache
+	dr2, mask2, _, adv2, _ := face.Glyph(fixed.P(110, 40), 'g')
+	if mask1 != mask2 {
+		t.Errorf("Glyph('g') was rasterized twice")
+	}
+	if got, want := dr2, dr1.Add(image.Pt(100, 20)); got != want {
+		t.Errorf("Glyph('g') at 110,40: dr = %v, want %v", got, want)
+	}
+	if adv1 != adv2 || adv1 != fixed.I(adv1.Round()) {
+		t.Errorf("advances %v, %v, want equal whole pixels", adv1, adv2)
+	}
+	// other glyphs do not overwrite the cached mask
+	face.Glyph(fixed.P(0, 0), 'W')
+	if got, want := mask1.Bounds(), image.Rect(0, 0, dr1.Dx(), dr1.Dy()); got != want {
+		t.Errorf("cached mask bounds = %v, want %v", got, want)
+	}
+
+}
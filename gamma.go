@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"math"
+)
+
+var (
+	gammaFlag = flag.Float64("gamma",
+		1,
+		"gamma correction applied when copying to the framebuffer: values above 1 brighten dark colors (e.g. when a display crushes the dark gray background to black), values below 1 darken them (e.g. when the background looks washed out). Screenshots are not affected")
+
+	brightnessFlag = flag.Float64("brightness",
+		1,
+		"factor applied to all colors when copying to the framebuffer (after -gamma), e.g. 0.8 to dim a display without backlight control")
+)
+
+// colorCurve maps the value of each color channel before copying to the
+// framebuffer, see -gamma, -brightness, -night-temperature and
+// -auto-brightness.
+type colorCurve struct {
+	r, g, b [256]uint8
+}
+
+// noTint leaves the colors unchanged, see newColorCurve.
+var noTint = [3]float64{1, 1, 1}
+
+// newColorCurve returns the curve for gamma and brightness, with the red,
+// green and blue channels scaled by tint (e.g. for night mode), or nil if
+// the curve would not change any colors.
+func newColorCurve(gamma, brightness float64, tint [3]float64) (*colorCurve, error) {
+	return newContrastColorCurve(gamma, brightness, 1, tint)
+}
+
+// newContrastColorCurve is like newColorCurve, but additionally scales the
+// contrast around mid-gray by the factor contrast (after gamma), e.g. to
+// darken the background and keep the text white in a bright room.
+func newContrastColorCurve(gamma, brightness, contrast float64, tint [3]float64) (*colorCurve, error) {
+	if gamma <= 0 {
+		return nil, fmt.Errorf("-gamma must be positive, got %v", gamma)
+	}
+	if brightness < 0 {
+		return nil, fmt.Errorf("-brightness must not be negative, got %v", brightness)
+	}
+	if gamma == 1 && brightness == 1 && contrast == 1 && tint == noTint {
+		return nil, nil
+	}
+	var c colorCurve
+	for i := 0; i < 256; i++ {
+		v := math.Pow(float64(i)/255, 1/gamma)
+		v = math.Max(0, math.Min(1, 0.5+contrast*(v-0.5)))
+		v *= brightness * 255
+		c.r[i] = uint8(math.Round(math.Min(tint[0]*v, 255)))
+		c.g[i] = uint8(math.Round(math.Min(tint[1]*v, 255)))
+		c.b[i] = uint8(math.Round(math.Min(tint[2]*v, 255)))
+	}
+	return &c, nil
+}
+
+// apply maps the colors of the rectangle r of src into dst.
+func (c *colorCurve) apply(dst, src *image.RGBA, r image.Rectangle) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		si, di := src.PixOffset(r.Min.X, y), dst.PixOffset(r.Min.X, y)
+		for end := si + 4*r.Dx(); si < end; si, di = si+4, di+4 {
+			s := src.Pix[si : si+4 : si+4]
+			d := dst.Pix[di : di+4 : di+4]
+			d[0], d[1], d[2], d[3] = c.r[s[0]], c.g[s[1]], c.b[s[2]], s[3]
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/gokrazy/fbstatus/internal/fbimage"
+)
+
+func TestNewColorCurve(t *testing.T) {
+	if c, err := newColorCurve(1, 1, noTint); err != nil || c != nil {
+		t.Errorf("newColorCurve(1, 1, noTint) = %v, %v, want nil, nil", c, err)
+	}
+	for _, tt := range []struct {
+		gamma, brightness float64
+		in, want          uint8
+	}{
+		{2, 1, 50, 113}, // the background gets brighter
+		{2, 1, 0, 0},
+		{2, 1, 255, 255},
+		{0.5, 1, 50, 10}, // the background gets darker
+		{1, 0.5, 255, 128},
+		{1, 2, 200, 255}, // clamped
+	} {
+		c, err := newColorCurve(tt.gamma, tt.brightness, noTint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.r[tt.in]; got != tt.want {
+			t.Errorf("newColorCurve(%v, %v)[%d] = %d, want %d", tt.gamma, tt.brightness, tt.in, got, tt.want)
+		}
+	}
+	if _, err := newColorCurve(0, 1, noTint); err == nil {
+		t.Errorf("newColorCurve(0, 1, noTint) did not return an error")
+	}
+	if _, err := newColorCurve(1, -1, noTint); err == nil {
+		t.Errorf("newColorCurve(1, -1, noTint) did not return an error")
+	}
+
+	// more contrast darkens the background and keeps white text white
+	c, err := newContrastColorCurve(1, 1, 1.3, noTint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.r[50], uint8(27); got != want {
+		t.Errorf("newContrastColorCurve(1, 1, 1.3)[50] = %d, want %d", got, want)
+	}
+	if got, want := c.r[255], uint8(255); got != want {
+		t.Errorf("newContrastColorCurve(1, 1, 1.3)[255] = %d, want %d", got, want)
+	}
+}
+
+func TestCopyWithColorCurve(t *testing.T) {
+	curve, err := newColorCurve(2, 1, noTint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := image.Rect(0, 0, 4, 4)
+	src := uniformRGBA(r, color.RGBA{R: 50, G: 50, B: 50, A: 0xff})
+
+	bgra := &fbimage.BGRA{Pix: make([]byte, 4*r.Dx()*r.Dy()), Rect: r, Stride: 4 * r.Dx()}
+	copyRGBAtoBGRA(bgra, src, r, curve)
+	if got, want := bgra.At(1, 1), (color.RGBA{R: 113, G: 113, B: 113, A: 0xff}); got != want {
+		t.Errorf("BGRA pixel = %v, want %v", got, want)
+	}
+
+	bgr565 := &fbimage.BGR565{Pix: make([]byte, 2*r.Dx()*r.Dy()), Rect: r, Stride: 2 * r.Dx()}
+	copyRGBAtoBGR565(bgr565, src, r, curve, false)
+	// 113 is 0b01110001, of which BGR565 keeps the top 5 (red, blue) and 6
+	// (green) bits
+	if got, want := bgr565.At(1, 1), (color.NRGBA{R: 0b01110000, G: 0b01110000, B: 0b01110000, A: 0xff}); got != want {
+		t.Errorf("BGR565 pixel = %v, want %v", got, want)
+	}
+
+	curved := image.NewRGBA(r)
+	curve.apply(curved, src, image.Rect(0, 0, 2, 2))
+	if got, want := curved.RGBAAt(1, 1), (color.RGBA{R: 113, G: 113, B: 113, A: 0xff}); got != want {
+		t.Errorf("apply: pixel = %v, want %v", got, want)
+	}
+	if got := curved.RGBAAt(3, 3); got != (color.RGBA{}) {
+		t.Errorf("apply: pixel outside of the rectangle = %v, want unchanged", got)
+	}
+}
@@ -1,18 +1,19 @@
 module github.com/gokrazy/fbstatus
 
-go 1.18
+go 1.21
 
 require (
 	github.com/fogleman/gg v1.3.0
 	github.com/gokrazy/gokrazy v0.0.0-20220813173554-0d5434aefff7
+	github.com/gokrazy/internal v0.0.0-20220807084007-5675ab8eae51
 	github.com/gokrazy/stat v0.1.1-0.20210830201256-f0fd5b4d0995
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
 	golang.org/x/sys v0.0.0-20220818161305-2296e01440c6
 )
 
 require (
-	github.com/gokrazy/internal v0.0.0-20220807084007-5675ab8eae51 // indirect
+	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/google/renameio/v2 v2.0.0 // indirect
 	github.com/mdlayher/watchdog v0.0.0-20201005150459-8bdc4f41966b // indirect
+	golang.org/x/text v0.3.6 // indirect
 )
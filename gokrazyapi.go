@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// gokrazyService is a supervised service as returned by the gokrazy HTTP API.
+type gokrazyService struct {
+	Stopped   bool
+	StartTime time.Time
+	Pid       int
+	Path      string
+	Args      []string
+	Diverted  string
+}
+
+// gokrazyStatus is the system status as returned by the gokrazy HTTP API.
+type gokrazyStatus struct {
+	Services       []gokrazyService
+	BuildTimestamp string
+	Hostname       string
+	Model          string
+	Kernel         string
+}
+
+// readGokrazyConfigFile reads a configuration file the same way gokrazy
+// itself does: /perm takes precedence over /etc, which takes precedence over
+// the root directory.
+func readGokrazyConfigFile(fileName string) (string, error) {
+	b, err := os.ReadFile("/perm/" + fileName)
+	if err != nil {
+		b, err = os.ReadFile("/etc/" + fileName)
+	}
+	if err != nil && os.IsNotExist(err) {
+		b, err = os.ReadFile("/" + fileName)
+	}
+	return strings.TrimSpace(string(b)), err
+}
+
+// gokrazyRequest requests path from the local gokrazy HTTP API. The caller
+// must close the response body.
+func gokrazyRequest(ctx context.Context, path string) (*http.Response, error) {
+	pw, err := readGokrazyConfigFile("gokr-pw.txt")
+	if err != nil {
+		return nil, err
+	}
+	port, err := readGokrazyConfigFile("http-port.txt")
+	if err != nil {
+		port = "80"
+	}
+	u := "http://" + net.JoinHostPort("localhost", port) + path
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth("gokrazy", pw)
+	// gokrazy checks the Content-Type header to decide whether to respond
+	// with JSON, so set both.
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: unexpected HTTP status: got %v, want %v", path, resp.Status, want)
+	}
+	return resp, nil
+}
+
+// gokrazyAPI requests path from the local gokrazy HTTP API and decodes the JSON
+// response into v.
+func gokrazyAPI(ctx context.Context, path string, v interface{}) error {
+	resp, err := gokrazyRequest(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
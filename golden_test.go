@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update",
+	false,
+	"update the golden images in testdata/golden instead of comparing against them")
+
+// cannedSource is a statusSource which feeds the snapshots of /proc files in
+// testdata/golden/proc/<frame> and fixed host information.
+type cannedSource struct {
+	dir   string
+	frame int
+	start time.Time
+}
+
+func (s *cannedSource) now() time.Time {
+	return s.start.Add(time.Duration(s.frame) * time.Second)
+}
+
+func (s *cannedSource) statFiles(time.Time) map[string][]byte {
+	contents := make(map[string][]byte)
+	for _, path := range []string{"/proc/stat", "/proc/diskstats", "/proc/meminfo", "/proc/net/dev"} {
+		b, err := os.ReadFile(filepath.Join(s.dir, fmt.Sprint(s.frame), strings.TrimPrefix(path, "/proc/")))
+		if err != nil {
+			continue // rendered as a read error
+		}
+		contents[path] = b
+	}
+	return contents
+}
+
+func (s *cannedSource) hostInfo() hostInfo {
+	return hostInfo{
+		Hostname: "gokrazy",
+		Model:    "Raspberry Pi 4 Model B Rev 1.4",
+		Time:     s.now(),
+		Uptime:   "3h25m0s",
+		Info: []string{
+			"$$clock: $green$synchronized$$ (offset 1ms)",
+			"$$load: $green$0.42 0.37 0.30$$ (4 CPUs), runnable: 2/142",
+			"$$SoC temperature: $green$48.3 °C",
+			"$$/perm: $green$1.2 GiB$$ of 28.5 GiB used (4%)",
+			"$$network: default 10.0.0.1 via eth0, DNS 10.0.0.1",
+		},
+		PrivateAddrs: []string{"10.0.0.42", "fe80::dea6:32ff:fe01:2345"},
+		Version:      "v0.0.0-golden",
+	}
+}
+
+// drawGolden renders frames frames of the status view at w×h from canned
+// data, so that the result does not depend on the machine or time.
+func drawGolden(w, h, frames int) (*image.RGBA, error) {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		return nil, err
+	}
+	src := &cannedSource{
+		dir:   filepath.Join("testdata", "golden", "proc"),
+		start: time.Date(2022, 8, 13, 17, 35, 54, 0, time.UTC),
+	}
+	d.source = src
+	d.unavailable = make([]bool, len(d.modules))
+	d.temperature.path = filepath.Join("testdata", "golden", "temp")
+	// neither crash badges of this machine nor its services
+	d.pstore = &pstoreCrash{}
+	d.services = newPoller(time.Hour, func(context.Context) ([]serviceState, error) {
+		return nil, nil
+	})
+	for ; src.frame < frames; src.frame++ {
+		if err := d.draw1(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+// TestGolden compares the status view against the golden images in
+// testdata/golden. The images were rendered on amd64: other architectures
+// might round differently (e.g. fused multiply-add on arm64).
+func TestGolden(t *testing.T) {
+	if runtime.GOARCH != "amd64" && !*updateGolden {
+		t.Skipf("the golden images were rendered on amd64, not %s", runtime.GOARCH)
+	}
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	*pagesFlag = "status"
+	for _, resolution := range []struct {
+		w, h int
+	}{
+		{w: 800, h: 600},
+		{w: 1920, h: 1080},
+	} {
+		name := fmt.Sprintf("status-%dx%d.png", resolution.w, resolution.h)
+		t.Run(name, func(t *testing.T) {
+			img, err := drawGolden(resolution.w, resolution.h, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err != nil {
+				t.Fatal(err)
+			}
+			fn := filepath.Join("testdata", "golden", name)
+			if *updateGolden {
+				if err := os.WriteFile(fn, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			f, err := os.Open(fn)
+			if err != nil {
+				t.Fatalf("%v (run go test -run=TestGolden -update to create)", err)
+			}
+			defer f.Close()
+			golden, err := png.Decode(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := image.NewRGBA(golden.Bounds())
+			draw.Draw(want, want.Rect, golden, image.Point{}, draw.Src)
+			dirty, _ := dirtyRect(want, img)
+			if dirty.Empty() {
+				return
+			}
+			got := filepath.Join(os.TempDir(), "fbstatus-golden-"+name)
+			if err := os.WriteFile(got, buf.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+			t.Errorf("rendering differs from %s in %v, see %s (run go test -run=TestGolden -update to accept)", fn, dirty, got)
+		})
+	}
+}
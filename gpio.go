@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/gokrazy/fbstatus/internal/gpiocdev"
+)
+
+var gpioLines = flag.String("gpio",
+	"",
+	"comma-separated list of GPIO input lines to display, each specified as [chip:]offset=label, e.g. 17=door,gpiochip1:4=flow. chip defaults to gpiochip0")
+
+type gpioLine struct {
+	chip   string // e.g. gpiochip0
+	offset int
+	label  string
+}
+
+func parseGPIOLines(spec string) ([]gpioLine, error) {
+	var lines []gpioLine
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		line, label, ok := strings.Cut(s, "=")
+		if !ok || label == "" {
+			return nil, fmt.Errorf("malformed GPIO line %q: expected [chip:]offset=label", s)
+		}
+		chip := "gpiochip0"
+		if idx := strings.LastIndexByte(line, ':'); idx > -1 {
+			chip, line = line[:idx], line[idx+1:]
+		}
+		offset, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, fmt.Errorf("malformed GPIO line %q: %v", s, err)
+		}
+		lines = append(lines, gpioLine{
+			chip:   chip,
+			offset: offset,
+			label:  label,
+		})
+	}
+	return lines, nil
+}
+
+// gpioInputs displays the level of the GPIO input lines specified in -gpio,
+// e.g. door or flow sensors.
+type gpioInputs struct {
+	lines    []gpioLine
+	requests map[string]*gpiocdev.Lines // by chip
+	offsets  map[string][]int           // by chip, in request order
+}
+
+func newGPIOInputs(spec string) (*gpioInputs, error) {
+	lines, err := parseGPIOLines(spec)
+	if err != nil {
+		return nil, err
+	}
+	g := &gpioInputs{
+		lines:    lines,
+		requests: make(map[string]*gpiocdev.Lines),
+		offsets:  make(map[string][]int),
+	}
+	for _, l := range lines {
+		g.offsets[l.chip] = append(g.offsets[l.chip], l.offset)
+	}
+	for chip, offsets := range g.offsets {
+		req, err := gpiocdev.RequestInputs("/dev/"+chip, offsets, "fbstatus")
+		if err != nil {
+			// Not fatal: the remaining lines can still be displayed.
+			slog.Warn("requesting GPIO lines failed", "chip", chip, "err", err)
+			continue
+		}
+		g.requests[chip] = req
+	}
+	return g, nil
+}
+
+// line returns a host information line (in $color$text markup) with the
+// level of each configured GPIO line.
+func (g *gpioInputs) line() string {
+	levels := make(map[string][]bool)
+	for chip, req := range g.requests {
+		vals, err := req.Values()
+		if err != nil {
+			continue
+		}
+		levels[chip] = vals
+	}
+	idx := make(map[string]int)
+	descs := make([]string, 0, len(g.lines))
+	for _, l := range g.lines {
+		i := idx[l.chip]
+		idx[l.chip]++
+		level := "$darkgray$unknown"
+		if vals, ok := levels[l.chip]; ok {
+			if vals[i] {
+				level = "$green$high"
+			} else {
+				level = "$$low"
+			}
+		}
+		descs = append(descs, "$$"+l.label+" "+level)
+	}
+	return "$$gpio: " + strings.Join(descs, "$$, ")
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGPIOLines(t *testing.T) {
+	got, err := parseGPIOLines("17=door,gpiochip1:4=flow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gpioLine{
+		{chip: "gpiochip0", offset: 17, label: "door"},
+		{chip: "gpiochip1", offset: 4, label: "flow"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseGPIOLines() = %+v, want %+v", got, want)
+	}
+
+	for _, spec := range []string{"17", "door=17", "17="} {
+		if _, err := parseGPIOLines(spec); err == nil {
+			t.Errorf("parseGPIOLines(%q) unexpectedly succeeded", spec)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+var (
+	grafanaPanelURL = flag.String("grafana-panel-url",
+		"",
+		"URL of a Grafana panel rendering (render API), e.g. https://grafana.example.net/render/d-solo/<dashboard-uid>/<slug>?orgId=1&panelId=2&from=now-6h. width and height are set to the size of the grafana panel")
+
+	grafanaToken = flag.String("grafana-token",
+		"",
+		"Grafana service account token (or API key) to authenticate render requests with")
+
+	grafanaInterval = flag.Duration("grafana-interval",
+		time.Minute,
+		"how often the grafana panel fetches a new rendering")
+)
+
+// grafanaRenderURL returns rawurl with the width and height parameters set
+// to the specified size in pixels.
+func grafanaRenderURL(rawurl string, size image.Point) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("width", strconv.Itoa(size.X))
+	q.Set("height", strconv.Itoa(size.Y))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func fetchGrafanaPanel(ctx context.Context, rawurl, token string, size image.Point) (image.Image, error) {
+	renderURL, err := grafanaRenderURL(rawurl, size)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", renderURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		return nil, fmt.Errorf("unexpected HTTP status: got %v, want %v", resp.Status, want)
+	}
+	img, _, err := image.Decode(resp.Body)
+	return img, err
+}
+
+// grafanaPanel shows a panel rendered by Grafana, which is an easy way to get
+// rich graphs of data fbstatus does not collect itself.
+type grafanaPanel struct {
+	rendering *poller[image.Image] // nil if -grafana-panel-url is not set
+
+	mu   sync.Mutex
+	size image.Point // of the most recent draw, zero before the first draw
+}
+
+func newGrafanaPanel() (panel, error) {
+	p := &grafanaPanel{}
+	if *grafanaPanelURL == "" {
+		return p, nil
+	}
+	if _, err := url.Parse(*grafanaPanelURL); err != nil {
+		return nil, fmt.Errorf("-grafana-panel-url: %v", err)
+	}
+	p.rendering = newPoller(*grafanaInterval, func(ctx context.Context) (image.Image, error) {
+		p.mu.Lock()
+		size := p.size
+		p.mu.Unlock()
+		return fetchGrafanaPanel(ctx, *grafanaPanelURL, *grafanaToken, size)
+	})
+	return p, nil
+}
+
+func (p *grafanaPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Grafana")
+	if p.rendering == nil {
+		d.drawMessage(dc, y, "-grafana-panel-url not set")
+		return nil
+	}
+	em, _ := dc.MeasureString("m")
+	p.mu.Lock()
+	p.size = image.Pt(dc.Width()-int(6*em), dc.Height()-int(y-dc.FontHeight()+em))
+	p.mu.Unlock()
+	img, updated, err := p.rendering.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	drawImage(dc, y, img)
+	return nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestFetchGrafanaPanel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "Bearer glsa_secret"; got != want {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if got, want := r.URL.Query().Get("panelId"), "2"; got != want {
+			http.Error(w, "panelId = "+got, http.StatusBadRequest)
+			return
+		}
+		width, _ := strconv.Atoi(r.URL.Query().Get("width"))
+		height, _ := strconv.Atoi(r.URL.Query().Get("height"))
+		png.Encode(w, image.NewRGBA(image.Rect(0, 0, width, height)))
+	}))
+	defer srv.Close()
+
+	url := srv.URL + "/render/d-solo/abc/home?orgId=1&panelId=2&width=1000"
+	if _, err := fetchGrafanaPanel(context.Background(), url, "", image.Pt(320, 200)); err == nil {
+		t.Errorf("fetchGrafanaPanel(without token) did not return an error")
+	}
+	img, err := fetchGrafanaPanel(context.Background(), url, "glsa_secret", image.Pt(320, 200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 320, 200); got != want {
+		t.Errorf("image bounds = %v, want %v", got, want)
+	}
+}
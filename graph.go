@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fogleman/gg"
+)
+
+// history is a fixed-size ring buffer of samples, from which graphs are drawn.
+type history struct {
+	samples []float64
+	next    int
+	full    bool
+}
+
+func newHistory(size int) *history {
+	return &history{samples: make([]float64, size)}
+}
+
+func (h *history) add(v float64) {
+	h.samples[h.next] = v
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// values returns all recorded samples, oldest first.
+func (h *history) values() []float64 {
+	if !h.full {
+		return append([]float64(nil), h.samples[:h.next]...)
+	}
+	return append(append([]float64(nil), h.samples[h.next:]...), h.samples[:h.next]...)
+}
+
+// clone returns a copy of h, e.g. to draw it while samples are added to h
+// in the background.
+func (h *history) clone() *history {
+	return &history{
+		samples: append([]float64(nil), h.samples...),
+		next:    h.next,
+		full:    h.full,
+	}
+}
+
+// drawGraph draws the samples of hist as a line graph into the rectangle
+// starting at x, y (top left corner) of size w×h. The vertical axis spans the
+// minimum and maximum value, which are labeled using format.
+func drawGraph(dc *gg.Context, hist *history, x, y, w, h float64, format string) {
+	vals := hist.values()
+	dc.SetRGB255(0x55, 0x57, 0x53) // darkgray
+	dc.SetLineWidth(1)
+	dc.DrawRectangle(x, y, w, h)
+	dc.Stroke()
+	if len(vals) < 2 {
+		return
+	}
+
+	min, max := vals[0], vals[0]
+	for _, v := range vals {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	span := max - min
+	if span == 0 {
+		span = 1
+	}
+
+	// Scale the horizontal axis to the full capacity (as opposed to the
+	// number of values) so that the graph grows from left to right.
+	step := w / float64(len(hist.samples)-1)
+	for idx, v := range vals {
+		px := x + float64(idx)*step
+		py := y + h - (v-min)/span*h
+		if idx == 0 {
+			dc.MoveTo(px, py)
+		} else {
+			dc.LineTo(px, py)
+		}
+	}
+	dc.SetRGB(1, 1, 1)
+	dc.SetLineWidth(2)
+	dc.Stroke()
+
+	dc.SetRGB255(0x55, 0x57, 0x53) // darkgray
+	dc.DrawStringAnchored(fmt.Sprintf(format, max), x+w, y, 1, 1)
+	dc.DrawStringAnchored(fmt.Sprintf(format, min), x+w, y+h, 1, 0)
+}
+
+// drawBar draws a horizontal progress bar into the rectangle starting at x, y
+// (top left corner) of size w×h, filled to fraction (0 to 1) in the named
+// color.
+func drawBar(dc *gg.Context, x, y, w, h, fraction float64, color string) {
+	if fraction < 0 {
+		fraction = 0
+	} else if fraction > 1 {
+		fraction = 1
+	}
+	setColor(dc, color)
+	dc.DrawRectangle(x, y, w*fraction, h)
+	dc.Fill()
+	dc.SetRGB255(0x55, 0x57, 0x53) // darkgray
+	dc.SetLineWidth(1)
+	dc.DrawRectangle(x, y, w, h)
+	dc.Stroke()
+	dc.SetRGB(1, 1, 1)
+}
+
+// drawSparkline draws vals as a small line graph without axes into the
+// rectangle starting at x, y (top left corner) of size w×h, in the named
+// color.
+func drawSparkline(dc *gg.Context, vals []float64, x, y, w, h float64, color string) {
+	if len(vals) < 2 {
+		return
+	}
+	min, max := vals[0], vals[0]
+	for _, v := range vals {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	span := max - min
+	if span == 0 {
+		span = 1
+	}
+	step := w / float64(len(vals)-1)
+	for idx, v := range vals {
+		px := x + float64(idx)*step
+		py := y + h - (v-min)/span*h
+		if idx == 0 {
+			dc.MoveTo(px, py)
+		} else {
+			dc.LineTo(px, py)
+		}
+	}
+	setColor(dc, color)
+	dc.SetLineWidth(2)
+	dc.Stroke()
+	dc.SetRGB(1, 1, 1)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	h := newHistory(3)
+	if got := h.values(); len(got) != 0 {
+		t.Errorf("values() = %v, want []", got)
+	}
+	h.add(1)
+	h.add(2)
+	if got, want := h.values(), []float64{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values() = %v, want %v", got, want)
+	}
+	h.add(3)
+	h.add(4)
+	if got, want := h.values(), []float64{2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values() = %v, want %v", got, want)
+	}
+}
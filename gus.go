@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	gusServer = flag.String("gus-server",
+		"",
+		"if non-empty, URL of the gokrazy update service (GUS) to query for available updates, e.g. https://gus.example.net")
+
+	gusMachineID = flag.String("gus-machine-id",
+		"",
+		"machine ID to identify as towards GUS. Defaults to the contents of machine-id (read from /perm, /etc or /)")
+)
+
+// gusStateFile records which image (identified by its SBOM hash) fbstatus saw
+// running, so that it can tell when the last self-update happened.
+const gusStateFile = "/perm/fbstatus/gus.json"
+
+type gusState struct {
+	SBOMHash  string    `json:"sbom_hash"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+type gusUpdateRequest struct {
+	MachineID string `json:"machine_id"`
+}
+
+type gusUpdateResponse struct {
+	SBOMHash     string `json:"sbom_hash"`
+	RegistryType string `json:"registry_type"`
+	DownloadLink string `json:"download_link"`
+}
+
+// runningSBOMHash returns the SBOM hash of the running gokrazy image, which
+// the gokrazy packer stores in /etc/gokrazy/sbom.json.
+func runningSBOMHash() (string, error) {
+	b, err := os.ReadFile("/etc/gokrazy/sbom.json")
+	if err != nil {
+		return "", err
+	}
+	var sbom struct {
+		SBOMHash string `json:"sbom_hash"`
+	}
+	if err := json.Unmarshal(b, &sbom); err != nil {
+		return "", err
+	}
+	return sbom.SBOMHash, nil
+}
+
+// recordRunningImage updates gusStateFile if the running image changed and
+// returns when the running image was first seen.
+func recordRunningImage(sbomHash string) (time.Time, error) {
+	var state gusState
+	if b, err := os.ReadFile(gusStateFile); err == nil {
+		if err := json.Unmarshal(b, &state); err != nil {
+			return time.Time{}, err
+		}
+	}
+	if state.SBOMHash == sbomHash {
+		return state.FirstSeen, nil
+	}
+	state = gusState{
+		SBOMHash:  sbomHash,
+		FirstSeen: time.Now(),
+	}
+	b, err := json.Marshal(&state)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(gusStateFile), 0755); err != nil {
+		return time.Time{}, err
+	}
+	if err := os.WriteFile(gusStateFile, b, 0644); err != nil {
+		return time.Time{}, err
+	}
+	return state.FirstSeen, nil
+}
+
+// gusChecker periodically asks GUS which image the device should run.
+type gusChecker struct {
+	sbomHash    string
+	lastUpdated time.Time // zero if unknown
+	desired     *poller[gusUpdateResponse]
+}
+
+func newGUSChecker(server string) (*gusChecker, error) {
+	machineID := *gusMachineID
+	if machineID == "" {
+		var err error
+		machineID, err = readGokrazyConfigFile("machine-id")
+		if err != nil {
+			return nil, fmt.Errorf("-gus-server requires a machine ID: %v", err)
+		}
+	}
+	sbomHash, err := runningSBOMHash()
+	if err != nil {
+		return nil, fmt.Errorf("determining running SBOM hash: %v", err)
+	}
+	lastUpdated, err := recordRunningImage(sbomHash)
+	if err != nil {
+		slog.Warn("recording running image failed", "err", err)
+	}
+	updateURL := strings.TrimSuffix(server, "/") + "/api/v1/update"
+	return &gusChecker{
+		sbomHash:    sbomHash,
+		lastUpdated: lastUpdated,
+		desired: newPoller(10*time.Minute, func(ctx context.Context) (gusUpdateResponse, error) {
+			b, err := json.Marshal(&gusUpdateRequest{MachineID: machineID})
+			if err != nil {
+				return gusUpdateResponse{}, err
+			}
+			req, err := http.NewRequestWithContext(ctx, "POST", updateURL, bytes.NewReader(b))
+			if err != nil {
+				return gusUpdateResponse{}, err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return gusUpdateResponse{}, err
+			}
+			defer resp.Body.Close()
+			if got, want := resp.StatusCode, http.StatusOK; got != want {
+				return gusUpdateResponse{}, fmt.Errorf("%s: unexpected HTTP status: got %v, want %v", updateURL, resp.Status, want)
+			}
+			var desired gusUpdateResponse
+			if err := json.NewDecoder(resp.Body).Decode(&desired); err != nil {
+				return gusUpdateResponse{}, err
+			}
+			return desired, nil
+		}),
+	}, nil
+}
+
+// line returns a host information line (in $color$text markup) describing
+// whether an update is available.
+func (g *gusChecker) line() string {
+	line := "$$update: "
+	desired, updated, err := g.desired.get()
+	switch {
+	case updated.IsZero() && err != nil:
+		line += "$red$unknown (" + err.Error() + ")"
+	case updated.IsZero():
+		line += "$darkgray$checking…"
+	case desired.SBOMHash == "" || desired.SBOMHash == g.sbomHash:
+		line += "$green$up to date"
+	default:
+		line += "$yellow$available"
+	}
+	if !g.lastUpdated.IsZero() {
+		line += "$$, last self-update " + g.lastUpdated.Format("2006-01-02 15:04")
+	}
+	return line
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var healthzMaxAge = flag.Duration("healthz-max-age",
+	30*time.Second,
+	"how long ago the last frame may have been drawn (or the last data collected) before /healthz reports fbstatus as unhealthy, e.g. to restart it when rendering stalls")
+
+// healthState records when fbstatus last drew a frame and collected data.
+// It is guarded by its own mutex instead of statusDrawer.mu, so that /healthz
+// responds even (and especially) when drawing hangs while holding mu.
+type healthState struct {
+	mu          sync.Mutex
+	started     time.Time
+	lastFrame   time.Time
+	lastCollect time.Time
+}
+
+func newHealthState(now time.Time) *healthState {
+	return &healthState{started: now}
+}
+
+// frame records that a frame was drawn at t, or intentionally skipped (e.g.
+// because the display is blanked or another console is visible).
+func (h *healthState) frame(t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastFrame = t
+}
+
+// collected records that the resource usage was collected at t.
+func (h *healthState) collected(t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastCollect = t
+}
+
+// healthReport is the response of /healthz.
+type healthReport struct {
+	Healthy     bool      `json:"healthy"`
+	Problems    []string  `json:"problems,omitempty"`
+	LastFrame   time.Time `json:"last_frame"`
+	LastCollect time.Time `json:"last_collect"`
+}
+
+// report returns the health at now. Until the first frame and collection,
+// the time fbstatus started counts instead, so that it is not reported as
+// unhealthy while starting up.
+func (h *healthState) report(now time.Time, maxAge time.Duration) healthReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r := healthReport{
+		Healthy:     true,
+		LastFrame:   h.lastFrame,
+		LastCollect: h.lastCollect,
+	}
+	check := func(what string, last time.Time) {
+		if last.IsZero() {
+			last = h.started
+		}
+		if age := now.Sub(last); age > maxAge {
+			r.Healthy = false
+			r.Problems = append(r.Problems, what+" "+age.Round(time.Second).String()+" ago")
+		}
+	}
+	check("last frame", h.lastFrame)
+	check("last data collection", h.lastCollect)
+	return r
+}
+
+// serveHealthz responds with 200 OK if fbstatus is drawing frames and
+// collecting data, and with 503 Service Unavailable otherwise.
+func (d *statusDrawer) serveHealthz(w http.ResponseWriter, r *http.Request) {
+	report := d.health.report(time.Now(), *healthzMaxAge)
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !report.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(b)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthReport(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := newHealthState(start)
+	if r := h.report(start.Add(10*time.Second), 30*time.Second); !r.Healthy {
+		t.Errorf("starting up: report = %+v, want healthy", r)
+	}
+	if r := h.report(start.Add(time.Minute), 30*time.Second); r.Healthy || len(r.Problems) != 2 {
+		t.Errorf("never drawn: report = %+v, want unhealthy with 2 problems", r)
+	}
+
+	h.frame(start.Add(time.Minute))
+	h.collected(start.Add(time.Minute))
+	if r := h.report(start.Add(80*time.Second), 30*time.Second); !r.Healthy {
+		t.Errorf("drawing: report = %+v, want healthy", r)
+	}
+
+	// data collection continues, but drawing stalled
+	h.collected(start.Add(2 * time.Minute))
+	r := h.report(start.Add(2*time.Minute), 30*time.Second)
+	if r.Healthy {
+		t.Errorf("stalled: report = %+v, want unhealthy", r)
+	}
+	if want := []string{"last frame 1m0s ago"}; len(r.Problems) != 1 || r.Problems[0] != want[0] {
+		t.Errorf("stalled: problems = %q, want %q", r.Problems, want)
+	}
+}
+
+func TestHealthzEndpoint(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.draw1(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	handler := d.httpHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /healthz: status %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var report healthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Healthy || report.LastFrame.IsZero() || report.LastCollect.IsZero() {
+		t.Errorf("GET /healthz = %+v, want healthy with frame and collection times", report)
+	}
+
+	// pretend the last frame was drawn long ago
+	d.health.frame(time.Now().Add(-time.Hour))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /healthz after stall: status %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
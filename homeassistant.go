@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	homeAssistantURL = flag.String("homeassistant-url",
+		"",
+		"if non-empty, base URL of a Home Assistant instance (e.g. http://homeassistant.local:8123) to push the metrics in -homeassistant-sensors to as sensors")
+
+	homeAssistantToken = flag.String("homeassistant-token",
+		"",
+		"Home Assistant long-lived access token (created on the Home Assistant profile page)")
+
+	homeAssistantSensors = flag.String("homeassistant-sensors",
+		"temperature,perm,services",
+		"comma-separated list of metrics to push to Home Assistant: temperature, perm (percentage used), load, services (number of running services) or any column of the resource usage table (e.g. cpu.usr, mem.free)")
+
+	homeAssistantInterval = flag.Duration("homeassistant-interval",
+		time.Minute,
+		"how often to push sensor states to Home Assistant")
+)
+
+// haSensor is the state of one Home Assistant sensor, as accepted by the
+// POST /api/states/<entity_id> endpoint of the Home Assistant REST API.
+type haSensor struct {
+	entityID   string
+	State      string                 `json:"state"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// haEntityID returns the entity ID for metric of host, e.g.
+// sensor.fbstatus_scan2drive_cpu_usr. Entity IDs consist of lowercase
+// letters, digits and underscores.
+func haEntityID(host, metric string) string {
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '_'
+	}, "fbstatus_"+host+"_"+metric)
+	return "sensor." + id
+}
+
+// statColumnUnit returns the unit of a column of the resource usage table,
+// which statexp samples once per second.
+func statColumnUnit(metric string) string {
+	switch {
+	case strings.HasPrefix(metric, "cpu."):
+		return "%"
+	case strings.HasPrefix(metric, "disk."), strings.HasPrefix(metric, "net."):
+		return "B/s"
+	case strings.HasPrefix(metric, "mem."):
+		return "B"
+	}
+	return "" // sys.int and sys.csw are per second counts
+}
+
+func validHomeAssistantSensor(metric string) bool {
+	switch metric {
+	case "temperature", "perm", "load", "services":
+		return true
+	}
+	return statColumnUnit(metric) != "" || metric == "sys.int" || metric == "sys.csw"
+}
+
+func parseHomeAssistantSensors(spec string) ([]string, error) {
+	var metrics []string
+	for _, metric := range strings.Split(spec, ",") {
+		metric = strings.TrimSpace(metric)
+		if metric == "" {
+			continue
+		}
+		if !validHomeAssistantSensor(metric) {
+			return nil, fmt.Errorf("unknown Home Assistant sensor %q", metric)
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+// homeAssistantSensors returns the current state of the sensors for metrics.
+// Metrics whose value is currently unavailable are skipped.
+func (d *statusDrawer) homeAssistantSensors(metrics []string) []haSensor {
+	d.mu.Lock()
+	celsius, celsiusErr := d.celsius, d.celsiusErr
+	resources := d.resources
+	d.mu.Unlock()
+
+	var sensors []haSensor
+	for _, metric := range metrics {
+		sensor := haSensor{
+			entityID: haEntityID(d.hostname, metric),
+			Attributes: map[string]interface{}{
+				"friendly_name": d.hostname + " " + metric,
+				"state_class":   "measurement",
+			},
+		}
+		switch metric {
+		case "temperature":
+			if celsiusErr != nil {
+				continue
+			}
+			sensor.State = fmt.Sprintf("%.1f", celsius)
+			sensor.Attributes["unit_of_measurement"] = "°C"
+			sensor.Attributes["device_class"] = "temperature"
+
+		case "perm":
+			used, err := permUsage()
+			if err != nil {
+				continue
+			}
+			sensor.State = fmt.Sprintf("%.1f", used)
+			sensor.Attributes["unit_of_measurement"] = "%"
+
+		case "load":
+			b, err := os.ReadFile("/proc/loadavg")
+			if err != nil {
+				continue
+			}
+			la, err := parseLoadavg(b)
+			if err != nil {
+				continue
+			}
+			sensor.State = fmt.Sprintf("%.2f", la.load1)
+
+		case "services":
+			services, updated, _ := d.services.get()
+			if updated.IsZero() {
+				continue
+			}
+			running := 0
+			var stopped, crashLooping []string
+			for _, svc := range services {
+				switch {
+				case svc.crashLooping:
+					crashLooping = append(crashLooping, svc.name)
+				case svc.state == "running":
+					running++
+				default:
+					stopped = append(stopped, svc.name)
+				}
+			}
+			sensor.State = fmt.Sprint(running)
+			sensor.Attributes["total"] = len(services)
+			sensor.Attributes["stopped"] = stopped
+			sensor.Attributes["crash_looping"] = crashLooping
+
+		default:
+			v, ok := resources[metric]
+			if !ok {
+				continue
+			}
+			sensor.State = fmt.Sprintf("%.f", v)
+			if unit := statColumnUnit(metric); unit != "" {
+				sensor.Attributes["unit_of_measurement"] = unit
+			}
+		}
+		sensors = append(sensors, sensor)
+	}
+	return sensors
+}
+
+func postHomeAssistantState(ctx context.Context, baseURL, token string, sensor haSensor) error {
+	b, err := json.Marshal(&sensor)
+	if err != nil {
+		return err
+	}
+	u := strings.TrimSuffix(baseURL, "/") + "/api/states/" + sensor.entityID
+	req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Home Assistant responds with 201 Created for new entities.
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("%s: unexpected HTTP status: %v", u, resp.Status)
+	}
+	return nil
+}
+
+// pushHomeAssistant pushes the -homeassistant-sensors metrics to Home
+// Assistant every -homeassistant-interval.
+func (d *statusDrawer) pushHomeAssistant(metrics []string) {
+	for {
+		ctx, canc := context.WithTimeout(context.Background(), *homeAssistantInterval)
+		for _, sensor := range d.homeAssistantSensors(metrics) {
+			if err := postHomeAssistantState(ctx, *homeAssistantURL, *homeAssistantToken, sensor); err != nil {
+				slog.Warn("pushing to Home Assistant failed", "err", err)
+				break // likely the same error for all sensors
+			}
+		}
+		canc()
+		time.Sleep(*homeAssistantInterval)
+	}
+}
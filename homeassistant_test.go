@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHAEntityID(t *testing.T) {
+	if got, want := haEntityID("scan2drive", "cpu.usr"), "sensor.fbstatus_scan2drive_cpu_usr"; got != want {
+		t.Errorf("haEntityID() = %q, want %q", got, want)
+	}
+	if got, want := haEntityID("Living-Room", "temperature"), "sensor.fbstatus_living_room_temperature"; got != want {
+		t.Errorf("haEntityID() = %q, want %q", got, want)
+	}
+}
+
+func TestParseHomeAssistantSensors(t *testing.T) {
+	got, err := parseHomeAssistantSensors("temperature, mem.free,sys.csw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[1] != "mem.free" {
+		t.Errorf("parseHomeAssistantSensors() = %q", got)
+	}
+	if _, err := parseHomeAssistantSensors("temperature,humidity"); err == nil {
+		t.Errorf("parseHomeAssistantSensors(humidity) unexpectedly succeeded")
+	}
+}
+
+func TestHomeAssistantPush(t *testing.T) {
+	d, err := newStatusDrawer(image.NewRGBA(image.Rect(0, 0, 800, 600)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.hostname = "scan2drive"
+	d.celsius, d.celsiusErr = 47.5, nil
+	d.resources = map[string]float64{"mem.free": 1 << 30}
+	sensors := d.homeAssistantSensors([]string{"temperature", "mem.free", "cpu.usr"})
+	if got, want := len(sensors), 2; got != want {
+		t.Fatalf("homeAssistantSensors() = %+v, want %d sensors (cpu.usr unavailable)", sensors, want)
+	}
+	if got, want := sensors[0].State, "47.5"; got != want {
+		t.Errorf("temperature state = %q, want %q", got, want)
+	}
+
+	var gotPath, gotAuth string
+	var gotBody map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	if err := postHomeAssistantState(context.Background(), srv.URL+"/", "secret", sensors[1]); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gotPath, "/api/states/sensor.fbstatus_scan2drive_mem_free"; got != want {
+		t.Errorf("path = %q, want %q", got, want)
+	}
+	if got, want := gotAuth, "Bearer secret"; got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	if got, want := gotBody["state"], "1073741824"; got != want {
+		t.Errorf("state = %v, want %v", got, want)
+	}
+	attrs, _ := gotBody["attributes"].(map[string]interface{})
+	if got, want := attrs["unit_of_measurement"], "B"; got != want {
+		t.Errorf("unit_of_measurement = %v, want %v", got, want)
+	}
+
+	d.celsiusErr = errors.New("no sensor")
+	if got := d.homeAssistantSensors([]string{"temperature"}); len(got) != 0 {
+		t.Errorf("homeAssistantSensors() = %+v, want none without temperature sensor", got)
+	}
+}
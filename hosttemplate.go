@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	hostTemplateFile = flag.String("host-template",
+		"",
+		"if non-empty, file containing a Go text/template for the host information block, which produces one line (in $color$text markup, e.g. $red$text) per line of output. Available fields: .Hostname, .Model, .Time, .Uptime, .Render, .Info (the default information lines), .PrivateAddrs, .PublicAddrs, .Version and .Vars (see -host-vars)")
+
+	hostVarsFlag = flag.String("host-vars",
+		"",
+		"comma-separated list of key=value pairs which -host-template can refer to as {{.Vars.key}}, e.g. asset=A-1234,contact=ops@example.net")
+)
+
+// defaultHostTemplate produces the host information block unless
+// -host-template is set.
+const defaultHostTemplate = `host “{{.Hostname}}” ({{.Model}})
+time: {{.Time.Format "2006-01-02T15:04:05Z07:00"}}{{with .Uptime}}, up for {{.}}{{end}}{{with .Render}}, {{.}}{{end}}
+{{range .Info}}{{.}}
+{{end}}
+Private IP addresses:
+{{range .PrivateAddrs}}{{.}}
+{{end}}
+Public IP addresses:
+{{range .PublicAddrs}}{{.}}
+{{end}}`
+
+var defaultHostTmpl = template.Must(template.New("host").Parse(defaultHostTemplate))
+
+// hostInfo is the data available to the host information template.
+type hostInfo struct {
+	Hostname     string
+	Model        string
+	Time         time.Time
+	Uptime       string // empty if unknown
+	Render       string // e.g. fb: draw 12ms, cp 3ms, empty before the first frame
+	Info         []string
+	PrivateAddrs []string
+	PublicAddrs  []string
+	Version      string
+	Vars         map[string]string
+}
+
+func parseHostVars(spec string) (map[string]string, error) {
+	vars := make(map[string]string)
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(s, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("malformed host variable %q: expected key=value", s)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// parseHostTemplate parses the host information template from -host-template,
+// or the default template if the flag is empty.
+func parseHostTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return defaultHostTmpl, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// missingkey=zero makes {{.Vars.unset}} render as an empty string
+	// instead of “<no value>”.
+	tmpl, err := template.New("host").Option("missingkey=zero").Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("-host-template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// renderHostLines executes tmpl and returns its output lines.
+func renderHostLines(tmpl *template.Template, info hostInfo) ([]string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, info); err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testHostInfo = hostInfo{
+	Hostname:     "scan2drive",
+	Model:        "Raspberry Pi 4 Model B Rev 1.4",
+	Time:         time.Date(2022, 8, 20, 10, 5, 12, 0, time.UTC),
+	Uptime:       "3h2m1s",
+	Info:         []string{"$$load: 0.12", "$$/perm: $green$12%"},
+	PrivateAddrs: []string{"10.0.0.5"},
+	Version:      "v0.0.0",
+	Vars:         map[string]string{"asset": "A-1234"},
+}
+
+func TestDefaultHostTemplate(t *testing.T) {
+	got, err := renderHostLines(defaultHostTmpl, testHostInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"host “scan2drive” (Raspberry Pi 4 Model B Rev 1.4)",
+		"time: 2022-08-20T10:05:12Z, up for 3h2m1s",
+		"$$load: 0.12",
+		"$$/perm: $green$12%",
+		"",
+		"Private IP addresses:",
+		"10.0.0.5",
+		"",
+		"Public IP addresses:",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("renderHostLines(default) =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestCustomHostTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "host.tmpl")
+	const text = `{{.Hostname}} ({{.Time.Format "15:04"}})
+$darkgray$asset: $${{.Vars.asset}}{{with .Vars.contact}}, contact {{.}}{{end}}
+`
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := parseHostTemplate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := renderHostLines(tmpl, testHostInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"scan2drive (10:05)",
+		"$darkgray$asset: $$A-1234",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("renderHostLines(custom) = %q, want %q", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("{{.Hostname"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseHostTemplate(path); err == nil {
+		t.Errorf("parseHostTemplate(malformed) succeeded unexpectedly")
+	}
+}
+
+func TestParseHostVars(t *testing.T) {
+	got, err := parseHostVars("asset=A-1234,contact=ops@example.net,empty=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"asset": "A-1234", "contact": "ops@example.net", "empty": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHostVars() = %v, want %v", got, want)
+	}
+	if _, err := parseHostVars("asset"); err == nil {
+		t.Errorf("parseHostVars(no value) succeeded unexpectedly")
+	}
+}
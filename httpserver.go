@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/subtle"
+	"flag"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+var httpListen = flag.String("http-listen",
+	"",
+	"if non-empty, listen address (e.g. :8318) for the status page and the other HTTP endpoints of fbstatus (see README)")
+
+// httpPassword is the password of the gokrazy web interface, which requests
+// that change the display require (see authorized). It is empty when not
+// running on gokrazy.
+var httpPassword = readGokrazyPassword()
+
+// httpHandler returns the handler for the HTTP endpoints of fbstatus.
+func (d *statusDrawer) httpHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.serveStatus)
+	mux.HandleFunc("/screenshot.png", d.serveScreenshot)
+	mux.HandleFunc("/status.json", d.serveStatusJSON)
+	mux.HandleFunc("/metrics", d.serveMetrics)
+	mux.HandleFunc("/healthz", d.serveHealthz)
+	mux.HandleFunc("/timelapse.gif", d.serveTimelapse)
+	mux.Handle("/alertmanager", d.alertHook)
+	if d.alerts != nil {
+		mux.Handle("/alerts", d.alerts)
+	}
+	mux.Handle("/notify", d.notifier)
+	mux.Handle("/pstore", d.pstore)
+	mux.Handle("/page", controlHandler("page", d.setPage))
+	mux.Handle("/blank", controlHandler("blank", d.setBlank))
+	mux.Handle("/hud", controlHandler("hud", d.setHUD))
+	mux.Handle("/rebooting", controlHandler("message", d.announceReboot))
+	mux.HandleFunc("/maintenance", d.serveMaintenance)
+	return authorized(httpPassword, mux)
+}
+
+// gokrazyPasswordPaths are where gokrazy reads the password of its web
+// interface from, in order.
+var gokrazyPasswordPaths = []string{
+	"/perm/gokr-pw.txt",
+	"/etc/gokr-pw.txt",
+	"/gokr-pw.txt",
+}
+
+// readGokrazyPassword returns the password of the gokrazy web interface, or
+// the empty string when not running on gokrazy.
+func readGokrazyPassword() string {
+	for _, path := range gokrazyPasswordPaths {
+		if b, err := os.ReadFile(path); err == nil {
+			return strings.TrimSpace(string(b))
+		}
+	}
+	return ""
+}
+
+// authorized wraps h so that requests which change the display (i.e. all
+// but GET and HEAD requests) require the credentials of the gokrazy web
+// interface: user gokrazy and password (from gokr-pw.txt). Without a
+// password, e.g. when not running on gokrazy, they are only accepted from
+// localhost.
+func authorized(password string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+		if password == "" {
+			host, _, _ := net.SplitHostPort(r.RemoteAddr)
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				http.Error(w, "no gokrazy password (gokr-pw.txt) found: only requests from localhost may change the display", http.StatusForbidden)
+				return
+			}
+			h.ServeHTTP(w, r)
+			return
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok ||
+			user != "gokrazy" ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="fbstatus"`)
+			http.Error(w, "invalid username/password", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorized(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range []struct {
+		desc       string
+		password   string
+		method     string
+		remoteAddr string
+		user, pass string
+		want       int
+	}{
+		{"GET", "secret", "GET", "192.0.2.1:1234", "", "", http.StatusOK},
+		{"no credentials", "secret", "POST", "192.0.2.1:1234", "", "", http.StatusUnauthorized},
+		{"wrong password", "secret", "POST", "192.0.2.1:1234", "gokrazy", "wrong", http.StatusUnauthorized},
+		{"wrong user", "secret", "POST", "192.0.2.1:1234", "root", "secret", http.StatusUnauthorized},
+		{"credentials", "secret", "POST", "192.0.2.1:1234", "gokrazy", "secret", http.StatusOK},
+		{"no password, remote", "", "POST", "192.0.2.1:1234", "", "", http.StatusForbidden},
+		{"no password, localhost", "", "POST", "127.0.0.1:1234", "", "", http.StatusOK},
+		{"no password, localhost (IPv6)", "", "POST", "[::1]:1234", "", "", http.StatusOK},
+	} {
+		req := httptest.NewRequest(tt.method, "/alertmanager", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.user != "" {
+			req.SetBasicAuth(tt.user, tt.pass)
+		}
+		rec := httptest.NewRecorder()
+		authorized(tt.password, ok).ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.desc, rec.Code, tt.want)
+		}
+	}
+}
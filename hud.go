@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// hudWindow is the period over which the debug HUD averages the frame rate.
+const hudWindow = 5 * time.Second
+
+// hudState holds what the debug HUD needs across frames.
+type hudState struct {
+	frames []time.Time // frames copied to the display within hudWindow
+	prev   *image.RGBA // previous frame, without the HUD
+}
+
+// frame records that a frame was copied to the display at now.
+func (h *hudState) frame(now time.Time) {
+	h.frames = append(h.frames, now)
+	for len(h.frames) > 0 && now.Sub(h.frames[0]) > hudWindow {
+		h.frames = h.frames[1:]
+	}
+}
+
+// fps returns the average frame rate of the recorded frames.
+func (h *hudState) fps() float64 {
+	if len(h.frames) < 2 {
+		return 0
+	}
+	span := h.frames[len(h.frames)-1].Sub(h.frames[0])
+	return float64(len(h.frames)-1) / span.Seconds()
+}
+
+// dirtyRect returns the bounding rectangle of all pixels which differ between
+// a and b, and the share of these pixels.
+func dirtyRect(a, b *image.RGBA) (image.Rectangle, float64) {
+	bounds := a.Bounds()
+	var dirty image.Rectangle
+	changed := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		off := a.PixOffset(bounds.Min.X, y)
+		end := off + 4*bounds.Dx()
+		if bytes.Equal(a.Pix[off:end], b.Pix[off:end]) {
+			continue // fast path: unchanged row
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x, off = x+1, off+4 {
+			if bytes.Equal(a.Pix[off:off+4], b.Pix[off:off+4]) {
+				continue
+			}
+			changed++
+			dirty = dirty.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	return dirty, float64(changed) / float64(bounds.Dx()*bounds.Dy())
+}
+
+// hudLines returns the lines of the debug HUD.
+func (d *statusDrawer) hudLines(pg *page, dirty image.Rectangle, share float64) []string {
+	lines := []string{
+		fmt.Sprintf("fps %.1f, %d frames dropped", d.hud.fps(), d.stats.dropped),
+		fmt.Sprintf("render %v, copy %v", d.lastRender.Round(10*time.Microsecond), d.lastCopy.Round(10*time.Microsecond)),
+	}
+	if pg != nil && pg.panels != nil {
+		names := strings.Split(pg.name, "+")
+		for idx, took := range pg.renderTimes {
+			lines = append(lines, fmt.Sprintf("  %s %v", names[idx], took.Round(10*time.Microsecond)))
+		}
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	mem := "heap " + formatBytes(ms.HeapAlloc)
+	if b, err := os.ReadFile("/proc/self/stat"); err == nil {
+		if sample, err := parseProcStat(b); err == nil {
+			mem = "rss " + formatBytes(sample.rss) + ", " + mem
+		}
+	}
+	lines = append(lines, fmt.Sprintf("%s, %d goroutines", mem, runtime.NumGoroutine()))
+	if dirty.Empty() {
+		lines = append(lines, "dirty: none")
+	} else {
+		lines = append(lines, fmt.Sprintf("dirty: %.1f%% of pixels, %dx%d+%d+%d",
+			100*share, dirty.Dx(), dirty.Dy(), dirty.Min.X, dirty.Min.Y))
+	}
+	return lines
+}
+
+// drawHUD draws the debug HUD, which helps to diagnose slow rendering, over
+// the top left corner of the display.
+func (d *statusDrawer) drawHUD(pg *page) {
+	if d.hud == nil {
+		d.hud = &hudState{}
+	}
+	h := d.hud
+	var (
+		dirty image.Rectangle
+		share float64
+	)
+	if h.prev == nil {
+		h.prev = image.NewRGBA(d.bounds)
+		dirty, share = d.bounds, 1
+	} else {
+		dirty, share = dirtyRect(h.prev, d.buffer)
+	}
+	copy(h.prev.Pix, d.buffer.Pix)
+
+	lines := d.hudLines(pg, dirty, share)
+	measure := gg.NewContext(1, 1)
+	measure.SetFontFace(d.monoface)
+	em, _ := measure.MeasureString("m")
+	lineHeight := measure.FontHeight() * 1.2
+	var width float64
+	for _, line := range lines {
+		if w, _ := measure.MeasureString(line); w > width {
+			width = w
+		}
+	}
+	r := image.Rect(0, 0, int(width+2*em), int(float64(len(lines))*lineHeight+em))
+	dc := gg.NewContext(r.Dx(), r.Dy())
+	dc.SetFontFace(d.monoface)
+	dc.SetRGBA(0, 0, 0, 0.9)
+	dc.DrawRectangle(0, 0, float64(r.Dx()), float64(r.Dy()))
+	dc.Fill()
+	setColor(dc, "green")
+	for idx, line := range lines {
+		dc.DrawString(line, em, float64(idx+1)*lineHeight)
+	}
+	draw.Draw(d.buffer, r, dc.Image(), image.Point{}, draw.Over)
+}
+
+// setHUD shows (value on) or hides (value off) the debug HUD.
+func (d *statusDrawer) setHUD(value string) error {
+	show, err := parseSwitch(value)
+	if err != nil {
+		return err
+	}
+	d.control.showHUD(show)
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestDirtyRect(t *testing.T) {
+	r := image.Rect(0, 0, 100, 50)
+	a := image.NewRGBA(r)
+	b := image.NewRGBA(r)
+	if dirty, share := dirtyRect(a, b); !dirty.Empty() || share != 0 {
+		t.Errorf("dirtyRect(unchanged) = %v, %v, want empty, 0", dirty, share)
+	}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	b.SetRGBA(10, 20, white)
+	b.SetRGBA(29, 24, white)
+	dirty, share := dirtyRect(a, b)
+	if want := image.Rect(10, 20, 30, 25); dirty != want {
+		t.Errorf("dirtyRect() = %v, want %v", dirty, want)
+	}
+	if want := 2.0 / 5000; share != want {
+		t.Errorf("dirtyRect() share = %v, want %v", share, want)
+	}
+}
+
+func TestHUDFPS(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := &hudState{}
+	if got := h.fps(); got != 0 {
+		t.Errorf("fps() without frames = %v, want 0", got)
+	}
+	// 2 frames per second for 10 seconds
+	for i := 0; i <= 20; i++ {
+		h.frame(start.Add(time.Duration(i) * 500 * time.Millisecond))
+	}
+	if got := len(h.frames); got != 11 {
+		t.Errorf("%d frames recorded, want the 11 frames within %v", got, hudWindow)
+	}
+	if got, want := h.fps(), 2.0; got != want {
+		t.Errorf("fps() = %v, want %v", got, want)
+	}
+}
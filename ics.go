@@ -0,0 +1,677 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+var icsFeeds = flag.String("ics-feeds",
+	"",
+	"comma-separated list of iCalendar (ICS) feed URLs whose events of today and tomorrow the agenda panel shows, each specified as [label=]url")
+
+// icsMaxOccurrences bounds the expansion of recurring events, so that a
+// malformed rule cannot keep fbstatus busy.
+const icsMaxOccurrences = 10000
+
+type calendarEvent struct {
+	calendar string
+	summary  string
+	start    time.Time
+	end      time.Time
+	allDay   bool
+}
+
+// icsProperty is one content line of an iCalendar file, e.g.
+// DTSTART;TZID=Europe/Zurich:20221012T100000.
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// unfoldICS returns the content lines of an iCalendar file, with folded
+// (continuation) lines joined, as per RFC 5545 section 3.1.
+func unfoldICS(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseICSProperty(line string) (icsProperty, bool) {
+	// Parameter values can be quoted and contain colons, so find the first
+	// colon outside of quotes.
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon == -1 {
+		return icsProperty{}, false
+	}
+	parts := strings.Split(line[:colon], ";")
+	prop := icsProperty{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string),
+		value:  line[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, true
+}
+
+var icsTextReplacer = strings.NewReplacer(`\\`, `\`, `\;`, `;`, `\,`, `,`, `\n`, " ", `\N`, " ")
+
+// parseICSTime parses a DATE or DATE-TIME value. Times without time zone are
+// interpreted in the time zone given by the TZID parameter, or in loc.
+func parseICSTime(prop icsProperty, loc *time.Location) (t time.Time, allDay bool, _ error) {
+	if tzid := prop.params["TZID"]; tzid != "" {
+		// Outlook uses Windows time zone names, which are not in the IANA
+		// database, so fall back to loc.
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	value := prop.value
+	if len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// icsRule is a (subset of an) RRULE: FREQ, INTERVAL, COUNT, UNTIL and, for
+// daily, weekly and monthly rules, BYDAY.
+type icsRule struct {
+	freq     string
+	interval int
+	count    int // 0 means unlimited
+	until    time.Time
+	byDay    []icsWeekdayNum
+}
+
+// icsWeekdayNum is an entry of BYDAY, e.g. 2TU (the second Tuesday) or -1FR
+// (the last Friday). Without an ordinal (n is 0), it means every such day.
+type icsWeekdayNum struct {
+	n       int
+	weekday time.Weekday
+}
+
+// matchesDay reports whether t is on one of the days of BYDAY, of which the
+// ordinals count within the month of t.
+func (r *icsRule) matchesDay(t time.Time) bool {
+	for _, wd := range r.byDay {
+		if wd.weekday != t.Weekday() {
+			continue
+		}
+		switch {
+		case wd.n == 0:
+			return true
+		case wd.n > 0:
+			if (t.Day()-1)/7+1 == wd.n {
+				return true
+			}
+		default:
+			last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+			if -((last-t.Day())/7 + 1) == wd.n {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+func parseICSRule(value string, loc *time.Location) (*icsRule, error) {
+	rule := &icsRule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", val)
+			}
+			rule.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid COUNT %q", val)
+			}
+			rule.count = n
+		case "UNTIL":
+			t, _, err := parseICSTime(icsProperty{value: val}, loc)
+			if err != nil {
+				return nil, err
+			}
+			rule.until = t
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				if len(day) < 2 {
+					return nil, fmt.Errorf("invalid BYDAY %q", val)
+				}
+				wd, ok := icsWeekdays[strings.ToUpper(day[len(day)-2:])]
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %q", val)
+				}
+				num := icsWeekdayNum{weekday: wd}
+				if ord := day[:len(day)-2]; ord != "" {
+					n, err := strconv.Atoi(ord)
+					if err != nil || n == 0 || n < -5 || n > 5 {
+						return nil, fmt.Errorf("invalid BYDAY %q", val)
+					}
+					num.n = n
+				}
+				rule.byDay = append(rule.byDay, num)
+			}
+		}
+	}
+	switch rule.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %q", rule.freq)
+	}
+	for _, wd := range rule.byDay {
+		// Rather than showing an event on the wrong days, reject rules we
+		// cannot expand: ordinals only make sense for monthly rules here,
+		// and yearly rules with BYDAY need BYMONTH or BYWEEKNO.
+		if wd.n != 0 && rule.freq != "MONTHLY" || rule.freq == "YEARLY" {
+			return nil, fmt.Errorf("unsupported BYDAY for FREQ=%s", rule.freq)
+		}
+	}
+	return rule, nil
+}
+
+// firstPeriod returns the number of periods (of FREQ and INTERVAL) after
+// dtstart before the first one which can contain an occurrence starting at
+// or after t.
+func (r *icsRule) firstPeriod(dtstart, t time.Time) int {
+	if !t.After(dtstart) {
+		return 0
+	}
+	var periods int
+	switch r.freq {
+	case "DAILY":
+		periods = int(t.Sub(dtstart).Hours()/24) / r.interval
+	case "WEEKLY":
+		periods = int(t.Sub(dtstart).Hours()/24/7) / r.interval
+	case "MONTHLY":
+		periods = ((t.Year()-dtstart.Year())*12 + int(t.Month()-dtstart.Month())) / r.interval
+	case "YEARLY":
+		periods = (t.Year() - dtstart.Year()) / r.interval
+	}
+	// one period of slack for daylight saving time changes
+	if periods > 0 {
+		periods--
+	}
+	return periods
+}
+
+// occurrences calls fn with the start of each occurrence of an event
+// starting at dtstart, in order, until fn returns false. Occurrences
+// starting before after may be skipped.
+func (r *icsRule) occurrences(dtstart, after time.Time, fn func(time.Time) bool) {
+	n := 0
+	emit := func(t time.Time) bool {
+		if !r.until.IsZero() && t.After(r.until) {
+			return false
+		}
+		if r.count > 0 && n >= r.count {
+			return false
+		}
+		n++
+		return fn(t)
+	}
+	// Skip the periods before after, unless the occurrences in them count
+	// towards COUNT. icsMaxOccurrences only bounds the periods from there.
+	skip := r.firstPeriod(dtstart, after)
+	first := skip
+	if r.count > 0 {
+		first = 0
+	}
+	for period := first; period < skip+icsMaxOccurrences; period++ {
+		step := period * r.interval
+		switch r.freq {
+		case "DAILY":
+			t := dtstart.AddDate(0, 0, step)
+			if len(r.byDay) > 0 && !r.matchesDay(t) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		case "WEEKLY":
+			if len(r.byDay) == 0 {
+				if !emit(dtstart.AddDate(0, 0, 7*step)) {
+					return
+				}
+				continue
+			}
+			// weeks start on Monday (WKST default)
+			offset := (int(dtstart.Weekday()) + 6) % 7
+			monday := dtstart.AddDate(0, 0, 7*step-offset)
+			for day := 0; day < 7; day++ {
+				t := monday.AddDate(0, 0, day)
+				if t.Before(dtstart) || !r.matchesDay(t) {
+					continue
+				}
+				if !emit(t) {
+					return
+				}
+			}
+		case "MONTHLY":
+			if len(r.byDay) > 0 {
+				month := time.Date(dtstart.Year(), dtstart.Month()+time.Month(step), 1, dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+				for t := month; t.Month() == month.Month(); t = t.AddDate(0, 0, 1) {
+					if t.Before(dtstart) || !r.matchesDay(t) {
+						continue
+					}
+					if !emit(t) {
+						return
+					}
+				}
+				continue
+			}
+			t := dtstart.AddDate(0, step, 0)
+			if t.Day() != dtstart.Day() {
+				continue // e.g. the 31st in a month with 30 days
+			}
+			if !emit(t) {
+				return
+			}
+		case "YEARLY":
+			t := dtstart.AddDate(step, 0, 0)
+			if t.Day() != dtstart.Day() {
+				continue // February 29th
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// parseICS returns the events (with recurring events expanded) of an
+// iCalendar file which overlap the interval [from, to).
+func parseICS(r io.Reader, from, to time.Time) ([]calendarEvent, error) {
+	lines, err := unfoldICS(r)
+	if err != nil {
+		return nil, err
+	}
+	loc := from.Location()
+	var (
+		events  []calendarEvent
+		inEvent bool
+		props   []icsProperty
+	)
+	for _, line := range lines {
+		switch strings.ToUpper(line) {
+		case "BEGIN:VEVENT":
+			inEvent = true
+			props = nil
+			continue
+		case "END:VEVENT":
+			inEvent = false
+			evs, err := expandICSEvent(props, loc, from, to)
+			if err != nil {
+				continue // skip events we cannot make sense of
+			}
+			events = append(events, evs...)
+			continue
+		}
+		if !inEvent {
+			continue
+		}
+		if prop, ok := parseICSProperty(line); ok {
+			props = append(props, prop)
+		}
+	}
+	return events, nil
+}
+
+func expandICSEvent(props []icsProperty, loc *time.Location, from, to time.Time) ([]calendarEvent, error) {
+	var (
+		ev       calendarEvent
+		end      time.Time
+		duration time.Duration
+		rrule    string
+		exdates  = make(map[int64]bool) // Unix time
+	)
+	for _, prop := range props {
+		switch prop.name {
+		case "SUMMARY":
+			ev.summary = icsTextReplacer.Replace(prop.value)
+		case "DTSTART":
+			t, allDay, err := parseICSTime(prop, loc)
+			if err != nil {
+				return nil, err
+			}
+			ev.start, ev.allDay = t, allDay
+		case "DTEND":
+			t, _, err := parseICSTime(prop, loc)
+			if err != nil {
+				return nil, err
+			}
+			end = t
+		case "DURATION":
+			d, err := parseICSDuration(prop.value)
+			if err != nil {
+				return nil, err
+			}
+			duration = d
+		case "RRULE":
+			rrule = prop.value
+		case "EXDATE":
+			for _, value := range strings.Split(prop.value, ",") {
+				t, _, err := parseICSTime(icsProperty{params: prop.params, value: value}, loc)
+				if err == nil {
+					exdates[t.Unix()] = true
+				}
+			}
+		case "STATUS":
+			if strings.EqualFold(prop.value, "CANCELLED") {
+				return nil, nil
+			}
+		}
+	}
+	if ev.start.IsZero() {
+		return nil, errors.New("event without DTSTART")
+	}
+	switch {
+	case !end.IsZero():
+		duration = end.Sub(ev.start)
+	case duration == 0 && ev.allDay:
+		duration = 24 * time.Hour
+	}
+
+	overlaps := func(start time.Time) bool {
+		return start.Before(to) && start.Add(duration).After(from) ||
+			// zero-length events, e.g. reminders
+			duration == 0 && !start.Before(from) && start.Before(to)
+	}
+	if rrule == "" {
+		if !overlaps(ev.start) {
+			return nil, nil
+		}
+		ev.end = ev.start.Add(duration)
+		return []calendarEvent{ev}, nil
+	}
+	rule, err := parseICSRule(rrule, loc)
+	if err != nil {
+		return nil, err
+	}
+	var events []calendarEvent
+	// occurrences starting before from-duration cannot overlap from
+	rule.occurrences(ev.start, from.Add(-duration), func(start time.Time) bool {
+		if !start.Before(to) {
+			return false
+		}
+		if overlaps(start) && !exdates[start.Unix()] {
+			occurrence := ev
+			occurrence.start = start
+			occurrence.end = start.Add(duration)
+			events = append(events, occurrence)
+		}
+		return true
+	})
+	return events, nil
+}
+
+// parseICSDuration parses durations like PT1H30M or P1D (RFC 5545 section
+// 3.3.6).
+func parseICSDuration(s string) (time.Duration, error) {
+	orig := s
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "+"), "P")
+	var d time.Duration
+	inTime := false
+	for s != "" {
+		if s[0] == 'T' {
+			inTime = true
+			s = s[1:]
+			continue
+		}
+		idx := strings.IndexAny(s, "WDHMS")
+		if idx < 1 {
+			return 0, fmt.Errorf("malformed duration %q", orig)
+		}
+		n, err := strconv.Atoi(s[:idx])
+		if err != nil {
+			return 0, fmt.Errorf("malformed duration %q", orig)
+		}
+		unit := map[byte]time.Duration{
+			'W': 7 * 24 * time.Hour,
+			'D': 24 * time.Hour,
+			'H': time.Hour,
+			'M': time.Minute,
+			'S': time.Second,
+		}[s[idx]]
+		if s[idx] == 'M' && !inTime {
+			return 0, fmt.Errorf("malformed duration %q", orig)
+		}
+		d += time.Duration(n) * unit
+		s = s[idx+1:]
+	}
+	return d, nil
+}
+
+type icsFeed struct {
+	label string
+	url   string
+}
+
+func parseICSFeeds(spec string) ([]icsFeed, error) {
+	var feeds []icsFeed
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		label, rawurl, ok := strings.Cut(s, "=")
+		if !ok || strings.Contains(label, "://") {
+			// no label, the = is part of the URL
+			label, rawurl = "", s
+		}
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("malformed ICS feed URL %q: %v", rawurl, err)
+		}
+		if u.Scheme == "webcal" {
+			// as used by many calendar services for subscription links
+			u.Scheme = "https"
+		}
+		if label == "" {
+			label = u.Host
+		}
+		feeds = append(feeds, icsFeed{
+			label: label,
+			url:   u.String(),
+		})
+	}
+	return feeds, nil
+}
+
+func fetchICSFeed(ctx context.Context, feed icsFeed, from, to time.Time) ([]calendarEvent, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", feed.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		return nil, fmt.Errorf("%s: unexpected HTTP status: got %v, want %v", feed.label, resp.Status, want)
+	}
+	events, err := parseICS(resp.Body, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", feed.label, err)
+	}
+	for idx := range events {
+		events[idx].calendar = feed.label
+	}
+	return events, nil
+}
+
+// agendaPanel shows today's and tomorrow's events of the -ics-feeds
+// calendars.
+type agendaPanel struct {
+	feeds  []icsFeed
+	events *poller[[]calendarEvent] // nil if no feeds are configured
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func newAgendaPanel() (panel, error) {
+	feeds, err := parseICSFeeds(*icsFeeds)
+	if err != nil {
+		return nil, err
+	}
+	p := &agendaPanel{feeds: feeds}
+	if len(feeds) == 0 {
+		return p, nil
+	}
+	p.events = newPoller(15*time.Minute, func(ctx context.Context) ([]calendarEvent, error) {
+		from := startOfDay(time.Now())
+		to := from.AddDate(0, 0, 2)
+		var (
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			events []calendarEvent
+			errs   []string
+		)
+		for _, feed := range feeds {
+			feed := feed // copy
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				evs, err := fetchICSFeed(ctx, feed, from, to)
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, err.Error())
+					return
+				}
+				events = append(events, evs...)
+			}()
+		}
+		wg.Wait()
+		if len(errs) > 0 && len(events) == 0 {
+			return nil, errors.New(strings.Join(errs, "; "))
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			if events[i].allDay != events[j].allDay {
+				return events[i].allDay
+			}
+			return events[i].start.Before(events[j].start)
+		})
+		return events, nil
+	})
+	return p, nil
+}
+
+func (p *agendaPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Agenda")
+	if p.events == nil {
+		d.drawMessage(dc, y, "-ics-feeds not set")
+		return nil
+	}
+	events, updated, err := p.events.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	lineHeight := dc.FontHeight() * lineSpacing
+	today := startOfDay(time.Now())
+	for _, day := range []struct {
+		title string
+		start time.Time
+	}{
+		{"Today", today},
+		{"Tomorrow", today.AddDate(0, 0, 1)},
+	} {
+		end := day.start.AddDate(0, 0, 1)
+		var rows [][]cell
+		for _, ev := range events {
+			if !ev.start.Before(end) || !ev.end.After(day.start) && !ev.start.Equal(day.start) {
+				continue
+			}
+			when := "all day"
+			if !ev.allDay {
+				when = ev.start.In(time.Local).Format("15:04") + "–" + ev.end.In(time.Local).Format("15:04")
+			}
+			row := []cell{
+				{text: when, color: "darkgray"},
+				{text: ev.summary},
+			}
+			if len(p.feeds) > 1 {
+				row = append(row, cell{text: ev.calendar, color: "darkgray"})
+			}
+			rows = append(rows, row)
+		}
+		d.drawMessage(dc, y, day.title+", "+day.start.Format("Monday, January 2"))
+		y += lineHeight
+		if len(rows) == 0 {
+			d.drawMessage(dc, y, "no events")
+			y += lineHeight * 1.5
+			continue
+		}
+		header := []string{"time", "event"}
+		if len(p.feeds) > 1 {
+			header = append(header, "calendar")
+		}
+		y = d.drawTable(dc, y, header, rows) + lineHeight/2
+	}
+	return nil
+}
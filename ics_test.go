@@ -0,0 +1,174 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Dentist\\, Dr. Example\r\n" +
+	"DTSTART:20221012T080000Z\r\n" +
+	"DTEND:20221012T090000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Team meeting with a very long description which is folded onto\r\n" +
+	"  the next line\r\n" +
+	"DTSTART;TZID=Europe/Zurich:20220905T140000\r\n" +
+	"DURATION:PT30M\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE\r\n" +
+	"EXDATE;TZID=Europe/Zurich:20221012T140000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Garbage collection\r\n" +
+	"DTSTART;VALUE=DATE:20221013\r\n" +
+	"RRULE:FREQ=DAILY;INTERVAL=7\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Cancelled party\r\n" +
+	"DTSTART:20221013T180000Z\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Last week\r\n" +
+	"DTSTART:20221005T180000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICS(t *testing.T) {
+	zurich, err := time.LoadLocation("Europe/Zurich")
+	if err != nil {
+		t.Skip(err)
+	}
+	from := time.Date(2022, 10, 12, 0, 0, 0, 0, zurich)
+	events, err := parseICS(strings.NewReader(testICS), from, from.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []calendarEvent{
+		{
+			summary: "Dentist, Dr. Example",
+			start:   time.Date(2022, 10, 12, 10, 0, 0, 0, zurich),
+			end:     time.Date(2022, 10, 12, 11, 0, 0, 0, zurich),
+		},
+		// The occurrence on Wednesday, 2022-10-12 is excluded via EXDATE.
+		{
+			summary: "Garbage collection",
+			start:   time.Date(2022, 10, 13, 0, 0, 0, 0, zurich),
+			end:     time.Date(2022, 10, 14, 0, 0, 0, 0, zurich),
+			allDay:  true,
+		},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("parseICS() = %+v, want %+v", events, want)
+	}
+	for idx, ev := range events {
+		w := want[idx]
+		if ev.summary != w.summary || !ev.start.Equal(w.start) || !ev.end.Equal(w.end) || ev.allDay != w.allDay {
+			t.Errorf("event %d = %+v, want %+v", idx, ev, w)
+		}
+	}
+
+	// The weekly meeting occurs on Monday, 2022-10-17.
+	from = time.Date(2022, 10, 17, 0, 0, 0, 0, zurich)
+	events, err = parseICS(strings.NewReader(testICS), from, from.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("parseICS() = %+v, want the team meeting", events)
+	}
+	if got, want := events[0].summary, "Team meeting with a very long description which is folded onto the next line"; got != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+	if got, want := events[0].start, time.Date(2022, 10, 17, 14, 0, 0, 0, zurich); !got.Equal(want) {
+		t.Errorf("start = %v, want %v", got, want)
+	}
+	if got, want := events[0].end.Sub(events[0].start), 30*time.Minute; got != want {
+		t.Errorf("duration = %v, want %v", got, want)
+	}
+}
+
+func TestParseICSDuration(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want time.Duration
+	}{
+		{"PT1H30M", 90 * time.Minute},
+		{"P1D", 24 * time.Hour},
+		{"P1W", 7 * 24 * time.Hour},
+		{"P1DT12H", 36 * time.Hour},
+	} {
+		got, err := parseICSDuration(tt.in)
+		if err != nil {
+			t.Errorf("parseICSDuration(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseICSDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	if _, err := parseICSDuration("P1M"); err == nil {
+		t.Errorf("parseICSDuration(P1M) did not return an error")
+	}
+}
+
+func TestICSRuleOccurrences(t *testing.T) {
+	for _, tt := range []struct {
+		rule    string
+		dtstart time.Time
+		after   time.Time
+		want    []string
+	}{
+		{
+			rule:    "FREQ=MONTHLY;BYDAY=2TU",
+			dtstart: time.Date(2022, 10, 11, 19, 0, 0, 0, time.UTC),
+			want:    []string{"2022-10-11", "2022-11-08", "2022-12-13"},
+		},
+		{
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR",
+			dtstart: time.Date(2022, 9, 30, 17, 0, 0, 0, time.UTC),
+			want:    []string{"2022-09-30", "2022-10-28", "2022-11-25"},
+		},
+		{
+			rule:    "FREQ=DAILY;BYDAY=MO,WE",
+			dtstart: time.Date(2022, 10, 10, 8, 0, 0, 0, time.UTC),
+			want:    []string{"2022-10-10", "2022-10-12", "2022-10-17"},
+		},
+		{
+			// more than icsMaxOccurrences days ago
+			rule:    "FREQ=DAILY",
+			dtstart: time.Date(1980, 1, 1, 8, 0, 0, 0, time.UTC),
+			after:   time.Date(2022, 10, 12, 0, 0, 0, 0, time.UTC),
+			want:    []string{"2022-10-12", "2022-10-13", "2022-10-14"},
+		},
+	} {
+		rule, err := parseICSRule(tt.rule, time.UTC)
+		if err != nil {
+			t.Fatalf("parseICSRule(%q): %v", tt.rule, err)
+		}
+		var got []string
+		rule.occurrences(tt.dtstart, tt.after, func(t time.Time) bool {
+			if t.Before(tt.after) {
+				return true
+			}
+			got = append(got, t.Format("2006-01-02"))
+			return len(got) < len(tt.want)
+		})
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: occurrences = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+
+	for _, rule := range []string{
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=MONTHLY;BYDAY=XX",
+	} {
+		if _, err := parseICSRule(rule, time.UTC); err == nil {
+			t.Errorf("parseICSRule(%q) succeeded unexpectedly", rule)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ifaceErrorWindow is for how long increases of the error counters of a
+// network interface are shown.
+const ifaceErrorWindow = 5 * time.Minute
+
+// ifaceCounters are the counters in /sys/class/net/<iface>/statistics which
+// indicate problems, e.g. of a flaky USB network adapter. Drops are less
+// severe, as some drivers count benign drops (e.g. of unknown protocols).
+var ifaceCounters = []struct {
+	file, label, color string
+}{
+	{"rx_errors", "rx errors", "red"},
+	{"tx_errors", "tx errors", "red"},
+	{"rx_dropped", "rx drops", "yellow"},
+	{"tx_dropped", "tx drops", "yellow"},
+	{"collisions", "collisions", "red"},
+}
+
+type ifaceSample struct {
+	t      time.Time
+	counts []uint64 // in the order of ifaceCounters
+}
+
+// ifaceErrorTracker samples the error counters of all network interfaces,
+// so that increases (which are otherwise invisible) can be shown.
+type ifaceErrorTracker struct {
+	root    string                   // /sys/class/net, except in tests
+	samples map[string][]ifaceSample // by interface, oldest first
+}
+
+func newIfaceErrorTracker() *ifaceErrorTracker {
+	return &ifaceErrorTracker{
+		root:    "/sys/class/net",
+		samples: make(map[string][]ifaceSample),
+	}
+}
+
+func readIfaceCounters(dir string) ([]uint64, error) {
+	counts := make([]uint64, len(ifaceCounters))
+	for idx, c := range ifaceCounters {
+		b, err := os.ReadFile(filepath.Join(dir, "statistics", c.file))
+		if err != nil {
+			return nil, err
+		}
+		counts[idx], err = strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+// sample reads the counters of all interfaces (except loopback) at now.
+func (t *ifaceErrorTracker) sample(now time.Time) {
+	dirs, err := filepath.Glob(filepath.Join(t.root, "*"))
+	if err != nil {
+		return
+	}
+	present := make(map[string]bool)
+	for _, dir := range dirs {
+		iface := filepath.Base(dir)
+		if iface == "lo" {
+			continue
+		}
+		counts, err := readIfaceCounters(dir)
+		if err != nil {
+			continue
+		}
+		present[iface] = true
+		samples := append(t.samples[iface], ifaceSample{t: now, counts: counts})
+		for len(samples) > 1 && now.Sub(samples[0].t) > ifaceErrorWindow {
+			samples = samples[1:]
+		}
+		t.samples[iface] = samples
+	}
+	for iface := range t.samples {
+		if !present[iface] {
+			delete(t.samples, iface) // unplugged
+		}
+	}
+}
+
+// increases returns by how much each counter of iface increased within
+// ifaceErrorWindow.
+func (t *ifaceErrorTracker) increases(iface string) []uint64 {
+	samples := t.samples[iface]
+	deltas := make([]uint64, len(ifaceCounters))
+	if len(samples) < 2 {
+		return deltas
+	}
+	first, last := samples[0].counts, samples[len(samples)-1].counts
+	for idx := range deltas {
+		if last[idx] >= first[idx] {
+			deltas[idx] = last[idx] - first[idx]
+		} else {
+			deltas[idx] = last[idx] // counters were reset, e.g. by re-plugging
+		}
+	}
+	return deltas
+}
+
+// line samples the counters and returns a host information line (in
+// $color$text markup) listing the interfaces whose error counters increased
+// recently, or the empty string if there were no errors.
+func (t *ifaceErrorTracker) line() string {
+	t.sample(time.Now())
+	ifaces := make([]string, 0, len(t.samples))
+	for iface := range t.samples {
+		ifaces = append(ifaces, iface)
+	}
+	sort.Strings(ifaces)
+	var parts []string
+	for _, iface := range ifaces {
+		var counters []string
+		for idx, delta := range t.increases(iface) {
+			if delta == 0 {
+				continue
+			}
+			c := ifaceCounters[idx]
+			counters = append(counters, fmt.Sprintf("$%s$+%d %s", c.color, delta, c.label))
+		}
+		if len(counters) > 0 {
+			parts = append(parts, "$$"+iface+" "+strings.Join(counters, "$$, "))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "$$interface errors: " + strings.Join(parts, "$$; ") + fmt.Sprintf("$$ (last %d min)", int(ifaceErrorWindow/time.Minute))
+}
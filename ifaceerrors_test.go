@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeIfaceCounters(t *testing.T, root, iface string, counts map[string]uint64) {
+	t.Helper()
+	dir := filepath.Join(root, iface, "statistics")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range ifaceCounters {
+		if err := os.WriteFile(filepath.Join(dir, c.file), []byte(strconv.FormatUint(counts[c.file], 10)+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIfaceErrorTracker(t *testing.T) {
+	root := t.TempDir()
+	tr := newIfaceErrorTracker()
+	tr.root = root
+	// line samples at the current time, after all other samples
+	start := time.Now().Add(-2 * ifaceErrorWindow)
+
+	writeIfaceCounters(t, root, "lo", map[string]uint64{"rx_errors": 1})
+	writeIfaceCounters(t, root, "eth0", map[string]uint64{"rx_errors": 5, "rx_dropped": 100})
+	writeIfaceCounters(t, root, "eth1", nil)
+	tr.sample(start)
+	if _, ok := tr.samples["lo"]; ok {
+		t.Errorf("loopback interface unexpectedly sampled")
+	}
+
+	// counters which were non-zero at start are not errors by themselves
+	writeIfaceCounters(t, root, "eth0", map[string]uint64{"rx_errors": 8, "rx_dropped": 100, "tx_dropped": 2})
+	tr.sample(start.Add(time.Minute))
+	if got, want := tr.increases("eth0"), []uint64{3, 0, 0, 2, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("increases(eth0) = %v, want %v", got, want)
+	}
+	if got := tr.increases("eth1"); !reflect.DeepEqual(got, []uint64{0, 0, 0, 0, 0}) {
+		t.Errorf("increases(eth1) = %v, want none", got)
+	}
+
+	// increases drop out of the window
+	tr.sample(start.Add(time.Minute + ifaceErrorWindow + time.Second))
+	if got := tr.increases("eth0"); !reflect.DeepEqual(got, []uint64{0, 0, 0, 0, 0}) {
+		t.Errorf("increases(eth0) after the window = %v, want none", got)
+	}
+
+	// unplugged interfaces are forgotten
+	if err := os.RemoveAll(filepath.Join(root, "eth1")); err != nil {
+		t.Fatal(err)
+	}
+	writeIfaceCounters(t, root, "eth0", map[string]uint64{"rx_errors": 9, "rx_dropped": 100, "tx_dropped": 2})
+	line := tr.line()
+	if _, ok := tr.samples["eth1"]; ok {
+		t.Errorf("unplugged interface eth1 still tracked")
+	}
+	if !strings.Contains(line, "eth0 $red$+1 rx errors") {
+		t.Errorf("line() = %q, want eth0 with 1 rx error", line)
+	}
+}
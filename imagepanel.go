@@ -0,0 +1,56 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/fogleman/gg"
+	xdraw "golang.org/x/image/draw"
+)
+
+// fitRect returns the largest rectangle with the aspect ratio of src which
+// fits into dst, centered within dst.
+func fitRect(src, dst image.Rectangle) image.Rectangle {
+	if src.Dx() == 0 || src.Dy() == 0 {
+		return image.Rectangle{}
+	}
+	w, h := dst.Dx(), src.Dy()*dst.Dx()/src.Dx()
+	if h > dst.Dy() {
+		w, h = src.Dx()*dst.Dy()/src.Dy(), dst.Dy()
+	}
+	min := dst.Min.Add(image.Pt((dst.Dx()-w)/2, (dst.Dy()-h)/2))
+	return image.Rectangle{Min: min, Max: min.Add(image.Pt(w, h))}
+}
+
+// drawImage draws img scaled to fit (preserving its aspect ratio) into the
+// area of dc below vertical position y.
+func drawImage(dc *gg.Context, y float64, img image.Image) {
+	em, _ := dc.MeasureString("m")
+	area := image.Rect(int(3*em), int(y-dc.FontHeight()), dc.Width()-int(3*em), dc.Height()-int(em))
+	dst, ok := dc.Image().(draw.Image)
+	if !ok || area.Empty() {
+		return
+	}
+	xdraw.BiLinear.Scale(dst, fitRect(img.Bounds(), area), img, img.Bounds(), draw.Src, nil)
+}
+
+// imagePanel shows a periodically refreshed image, e.g. a camera snapshot.
+type imagePanel struct {
+	title string
+	img   *poller[image.Image]
+}
+
+func (p *imagePanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, p.title)
+	img, updated, err := p.img.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	drawImage(dc, y, img)
+	return nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestFitRect(t *testing.T) {
+	for _, tt := range []struct {
+		src, dst, want image.Rectangle
+	}{
+		// wider than the destination: full width, centered vertically
+		{image.Rect(0, 0, 640, 480), image.Rect(0, 0, 320, 320), image.Rect(0, 40, 320, 280)},
+		// taller than the destination: full height, centered horizontally
+		{image.Rect(0, 0, 480, 640), image.Rect(10, 10, 330, 330), image.Rect(50, 10, 290, 330)},
+		// upscaling
+		{image.Rect(0, 0, 16, 9), image.Rect(0, 0, 1920, 1200), image.Rect(0, 60, 1920, 1140)},
+	} {
+		if got := fitRect(tt.src, tt.dst); got != tt.want {
+			t.Errorf("fitRect(%v, %v) = %v, want %v", tt.src, tt.dst, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gokrazy/fbstatus/internal/evdev"
+)
+
+var keyboardInput = flag.Bool("keyboard",
+	true,
+	"handle keyboards in /dev/input while the display is visible: left/right, up/down and PgUp/PgDn switch pages, Home resumes rotating pages, b blanks the display, h toggles the debug HUD, l toggles the legend of the resource usage table, a acknowledges the alert banner and the “previous boot crashed” badge, q exits")
+
+// errQuit is returned by fbstatus when q was pressed.
+var errQuit = errors.New("quit via keyboard")
+
+// inputScanInterval is how often /dev/input is scanned for devices which were
+// plugged in.
+const inputScanInterval = 5 * time.Second
+
+// handleKey applies the key with the specified code, which was just pressed.
+// It returns true if fbstatus should exit.
+func (d *statusDrawer) handleKey(code uint16) (quit bool) {
+	var err error
+	switch code {
+	case evdev.KeyRight, evdev.KeyDown, evdev.KeyPageDown, evdev.KeySpace:
+		err = d.setPage("next")
+	case evdev.KeyLeft, evdev.KeyUp, evdev.KeyPageUp:
+		err = d.setPage("prev")
+	case evdev.KeyHome:
+		err = d.setPage("auto")
+	case evdev.KeyB:
+		d.toggleBlank()
+	case evdev.KeyH:
+		d.control.showHUD(!d.control.hudShown())
+	case evdev.KeyL:
+		d.control.showLegend(!d.control.legendShown())
+	case evdev.KeyA:
+		if d.alerts != nil {
+			d.alerts.acknowledge()
+		}
+		err = d.pstore.acknowledge()
+	case evdev.KeyQ:
+		return true
+	}
+	if err != nil {
+		slog.Warn("keyboard input failed", "err", err)
+	}
+	return false
+}
+
+// inputHandler returns a function handling the events of dev, or nil if dev
+// is not a device fbstatus handles.
+func (d *statusDrawer) inputHandler(path string, dev *evdev.Device, quit func()) func(evdev.Event) {
+	if isRemoteControl(path) {
+		if !*irInput {
+			return nil
+		}
+		return d.irHandler()
+	}
+	if *touchInput {
+		if t := newTouchTracker(dev); t != nil {
+			return func(ev evdev.Event) {
+				d.handleGesture(t.feed(ev))
+			}
+		}
+	}
+	// Only consider devices with letter keys as keyboards, not e.g. power
+	// buttons.
+	if ok, err := dev.HasKeys(evdev.KeyQ, evdev.KeyB); err == nil && ok && *keyboardInput {
+		return func(ev evdev.Event) {
+			if ev.Type != evdev.EvKey || ev.Value != evdev.KeyPressed {
+				return
+			}
+			if d.control.wake() {
+				return
+			}
+			if d.handleKey(ev.Code) {
+				quit()
+			}
+		}
+	}
+	// Any other buttons (e.g. GPIO buttons or mouse buttons) wake up the
+	// display.
+	if ok, err := dev.HasEventType(evdev.EvKey); err != nil || !ok {
+		return nil
+	}
+	return func(ev evdev.Event) {
+		if ev.Type == evdev.EvKey && ev.Value == evdev.KeyPressed {
+			d.control.wake()
+		}
+	}
+}
+
+func inode(path string) uint64 {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino)
+	}
+	return 0
+}
+
+// readInput handles the input devices in /dev/input, including devices which
+// are plugged in later, until ctx is canceled. Events are ignored while
+// visible returns false, i.e. while another VT is active.
+func (d *statusDrawer) readInput(ctx context.Context, visible func() bool, quit func()) {
+	var mu sync.Mutex
+	// seen contains the inode of each device node which was inspected, so
+	// that device nodes are only inspected again once they were re-created
+	// for a different device.
+	seen := make(map[string]uint64)
+	for {
+		paths, _ := filepath.Glob("/dev/input/event*")
+		for _, path := range paths {
+			ino := inode(path)
+			mu.Lock()
+			known := seen[path] == ino
+			seen[path] = ino
+			mu.Unlock()
+			if known {
+				continue
+			}
+			dev, err := evdev.Open(path)
+			if err != nil {
+				slog.Warn("opening input device failed", "err", err)
+				continue
+			}
+			handle := d.inputHandler(path, dev, quit)
+			if handle == nil {
+				dev.Close()
+				continue
+			}
+			if name, err := dev.Name(); err == nil {
+				slog.Info("using input device", "path", path, "name", name)
+			}
+			go func(path string) {
+				defer dev.Close()
+				for {
+					events, err := dev.ReadEvents()
+					if err != nil {
+						// e.g. ENODEV when unplugged
+						slog.Warn("reading input device failed", "path", path, "err", err)
+						mu.Lock()
+						delete(seen, path)
+						mu.Unlock()
+						return
+					}
+					if !visible() {
+						continue
+					}
+					for _, ev := range events {
+						handle(ev)
+					}
+				}
+			}(path)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(inputScanInterval):
+		}
+	}
+}
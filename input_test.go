@@ -0,0 +1,64 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/gokrazy/fbstatus/internal/evdev"
+)
+
+func TestHandleKey(t *testing.T) {
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	*pagesFlag = "status,clock,version"
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		code uint16
+		want string // pinned page name, empty for rotation
+	}{
+		{evdev.KeyRight, "clock"},
+		{evdev.KeyPageDown, "version"},
+		{evdev.KeyDown, "status"},
+		{evdev.KeyLeft, "version"},
+		{evdev.KeyPageUp, "clock"},
+		{evdev.KeyHome, ""},
+	} {
+		if d.handleKey(tt.code) {
+			t.Fatalf("handleKey(%d) unexpectedly requested to quit", tt.code)
+		}
+		idx, ok := d.control.pinnedPage()
+		got := ""
+		if ok {
+			got = d.pages[idx].name
+		}
+		if got != tt.want {
+			t.Errorf("handleKey(%d): pinned page = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+
+	d.handleKey(evdev.KeyB)
+	if !d.control.isBlanked() {
+		t.Errorf("display not blanked after pressing b")
+	}
+	d.handleKey(evdev.KeyB)
+	if d.control.isBlanked() {
+		t.Errorf("display still blanked after pressing b again")
+	}
+
+	d.handleKey(evdev.KeyL)
+	if !d.control.legendShown() {
+		t.Errorf("legend not shown after pressing l")
+	}
+	d.handleKey(evdev.KeyL)
+	if d.control.legendShown() {
+		t.Errorf("legend still shown after pressing l again")
+	}
+
+	if !d.handleKey(evdev.KeyQ) {
+		t.Errorf("handleKey(q) did not request to quit")
+	}
+}
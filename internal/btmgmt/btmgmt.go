@@ -0,0 +1,209 @@
+// Package btmgmt queries Bluetooth controllers via the BlueZ management
+// interface of the Linux kernel (the HCI control channel), which works
+// without bluetoothd or D-Bus.
+//
+// See https://git.kernel.org/pub/scm/bluetooth/bluez.git/tree/doc/mgmt-api.txt
+package btmgmt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Constants from the management API documentation, which
+// golang.org/x/sys/unix does not define.
+const (
+	hciDevNone = 0xffff // MGMT_INDEX_NONE
+
+	opReadIndexList  = 0x0003
+	opReadInfo       = 0x0004
+	opGetConnections = 0x0015
+
+	evCmdComplete = 0x0001
+	evCmdStatus   = 0x0002
+
+	settingPowered = 1 << 0
+
+	headerSize = 6
+)
+
+// Address is a Bluetooth device address with its address type (0 for
+// BR/EDR, 1 for LE public, 2 for LE random).
+type Address struct {
+	Addr [6]byte // little endian, as on the wire
+	Type uint8
+}
+
+// String returns the address in the usual notation, e.g. 00:1A:7D:DA:71:13.
+func (a Address) String() string {
+	return fmt.Sprintf("%02X:%02X:%02X:%02X:%02X:%02X",
+		a.Addr[5], a.Addr[4], a.Addr[3], a.Addr[2], a.Addr[1], a.Addr[0])
+}
+
+// Info describes a controller.
+type Info struct {
+	Index   uint16
+	Address Address
+	Name    string
+	Powered bool
+}
+
+// Conn is a management socket.
+type Conn struct {
+	fd int
+}
+
+// Open opens a management socket. It fails if the kernel was built without
+// Bluetooth support.
+func Open() (*Conn, error) {
+	fd, err := unix.Socket(unix.AF_BLUETOOTH, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.BTPROTO_HCI)
+	if err != nil {
+		return nil, fmt.Errorf("socket(AF_BLUETOOTH): %v", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrHCI{Dev: hciDevNone, Channel: unix.HCI_CHANNEL_CONTROL}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("bind(HCI_CHANNEL_CONTROL): %v", err)
+	}
+	tv := unix.NsecToTimeval(int64(2 * time.Second))
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	return &Conn{fd: fd}, nil
+}
+
+func (c *Conn) Close() error {
+	return unix.Close(c.fd)
+}
+
+// command sends a command and returns the return parameters of its
+// completion event, skipping any unrelated events which the kernel sends to
+// all management sockets.
+func (c *Conn) command(op, index uint16, params []byte) ([]byte, error) {
+	req := make([]byte, headerSize+len(params))
+	binary.LittleEndian.PutUint16(req[0:], op)
+	binary.LittleEndian.PutUint16(req[2:], index)
+	binary.LittleEndian.PutUint16(req[4:], uint16(len(params)))
+	copy(req[headerSize:], params)
+	if _, err := unix.Write(c.fd, req); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	for {
+		n, err := unix.Read(c.fd, buf)
+		if err != nil {
+			return nil, err
+		}
+		rp, ok, err := parseReply(buf[:n], op, index)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return rp, nil
+		}
+	}
+}
+
+// parseReply returns the return parameters if b is the reply to the
+// specified command.
+func parseReply(b []byte, op, index uint16) (rp []byte, ok bool, _ error) {
+	if len(b) < headerSize {
+		return nil, false, errors.New("short management event")
+	}
+	ev := binary.LittleEndian.Uint16(b[0:])
+	evIndex := binary.LittleEndian.Uint16(b[2:])
+	params := b[headerSize:]
+	if n := int(binary.LittleEndian.Uint16(b[4:])); n < len(params) {
+		params = params[:n]
+	}
+	if (ev != evCmdComplete && ev != evCmdStatus) || evIndex != index || len(params) < 3 {
+		return nil, false, nil
+	}
+	if binary.LittleEndian.Uint16(params[0:]) != op {
+		return nil, false, nil
+	}
+	if status := params[2]; status != 0 {
+		return nil, true, fmt.Errorf("management command 0x%04x failed with status 0x%02x", op, status)
+	}
+	return params[3:], true, nil
+}
+
+// Controllers returns the indexes of all controllers (hci0 has index 0).
+func (c *Conn) Controllers() ([]uint16, error) {
+	rp, err := c.command(opReadIndexList, hciDevNone, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseIndexList(rp)
+}
+
+func parseIndexList(rp []byte) ([]uint16, error) {
+	if len(rp) < 2 {
+		return nil, errors.New("short index list")
+	}
+	n := int(binary.LittleEndian.Uint16(rp))
+	if len(rp) < 2+2*n {
+		return nil, errors.New("truncated index list")
+	}
+	indexes := make([]uint16, n)
+	for i := range indexes {
+		indexes[i] = binary.LittleEndian.Uint16(rp[2+2*i:])
+	}
+	return indexes, nil
+}
+
+// Info returns information about the controller with the specified index.
+func (c *Conn) Info(index uint16) (Info, error) {
+	rp, err := c.command(opReadInfo, index, nil)
+	if err != nil {
+		return Info{}, err
+	}
+	info, err := parseInfo(rp)
+	info.Index = index
+	return info, err
+}
+
+func parseInfo(rp []byte) (Info, error) {
+	// bdaddr[6], version, manufacturer[2], supported_settings[4],
+	// current_settings[4], class[3], name[249], short_name[11]
+	const size = 6 + 1 + 2 + 4 + 4 + 3 + 249 + 11
+	if len(rp) < size {
+		return Info{}, fmt.Errorf("short controller information (%d bytes)", len(rp))
+	}
+	var info Info
+	copy(info.Address.Addr[:], rp[0:6])
+	info.Powered = binary.LittleEndian.Uint32(rp[13:])&settingPowered != 0
+	info.Name = unix.ByteSliceToString(rp[20 : 20+249])
+	return info, nil
+}
+
+// Connections returns the addresses of all devices currently connected to
+// the controller with the specified index.
+func (c *Conn) Connections(index uint16) ([]Address, error) {
+	rp, err := c.command(opGetConnections, index, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseConnections(rp)
+}
+
+func parseConnections(rp []byte) ([]Address, error) {
+	if len(rp) < 2 {
+		return nil, errors.New("short connection list")
+	}
+	n := int(binary.LittleEndian.Uint16(rp))
+	if len(rp) < 2+7*n {
+		return nil, errors.New("truncated connection list")
+	}
+	addrs := make([]Address, n)
+	for i := range addrs {
+		entry := rp[2+7*i:]
+		copy(addrs[i].Addr[:], entry[:6])
+		addrs[i].Type = entry[6]
+	}
+	return addrs, nil
+}
@@ -0,0 +1,70 @@
+package btmgmt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReply(t *testing.T) {
+	// An unrelated event (New Settings for hci0) must be skipped.
+	if _, ok, err := parseReply([]byte{0x06, 0x00, 0x00, 0x00, 0x04, 0x00, 0x01, 0x00, 0x00, 0x00}, opGetConnections, 0); ok || err != nil {
+		t.Errorf("parseReply(New Settings) = %v, %v, want false, nil", ok, err)
+	}
+
+	reply := []byte{
+		0x01, 0x00, // Command Complete
+		0x00, 0x00, // hci0
+		0x0c, 0x00, // length
+		0x15, 0x00, // Get Connections
+		0x00,       // success
+		0x01, 0x00, // one connection
+		0x13, 0x71, 0xda, 0x7d, 0x1a, 0x00, 0x00,
+	}
+	rp, ok, err := parseReply(reply, opGetConnections, 0)
+	if err != nil || !ok {
+		t.Fatalf("parseReply() = %v, %v, want true, nil", ok, err)
+	}
+	addrs, err := parseConnections(rp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Address{{Addr: [6]byte{0x13, 0x71, 0xda, 0x7d, 0x1a, 0x00}}}
+	if !reflect.DeepEqual(addrs, want) {
+		t.Errorf("parseConnections() = %v, want %v", addrs, want)
+	}
+	if got, want := addrs[0].String(), "00:1A:7D:DA:71:13"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestParseReplyStatus(t *testing.T) {
+	reply := []byte{
+		0x02, 0x00, // Command Status
+		0x00, 0x00, // hci0
+		0x03, 0x00, // length
+		0x04, 0x00, // Read Controller Information
+		0x11, // invalid index
+	}
+	if _, ok, err := parseReply(reply, opReadInfo, 0); !ok || err == nil {
+		t.Errorf("parseReply() = %v, %v, want true, error", ok, err)
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	rp := make([]byte, 280)
+	copy(rp, []byte{0x13, 0x71, 0xda, 0x7d, 0x1a, 0x00})
+	rp[13] = settingPowered
+	copy(rp[20:], "gokrazy")
+	got, err := parseInfo(rp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Info{
+		Address: Address{Addr: [6]byte{0x13, 0x71, 0xda, 0x7d, 0x1a, 0x00}},
+		Name:    "gokrazy",
+		Powered: true,
+	}
+	if got != want {
+		t.Errorf("parseInfo() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,169 @@
+// Package btrfs queries btrfs file system health via ioctls, like the btrfs
+// device stats and btrfs scrub status commands do.
+//
+// See include/uapi/linux/btrfs.h for the structs and ioctl numbers.
+package btrfs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const ioctlMagic = 0x94
+
+func ior(nr, size uintptr) uintptr  { return 2<<30 | size<<16 | ioctlMagic<<8 | nr }
+func iowr(nr, size uintptr) uintptr { return 3<<30 | size<<16 | ioctlMagic<<8 | nr }
+
+type fsInfoArgs struct {
+	MaxID          uint64
+	NumDevices     uint64
+	FSID           [16]byte
+	Nodesize       uint32
+	Sectorsize     uint32
+	CloneAlignment uint32
+	CsumType       uint16
+	CsumSize       uint16
+	Flags          uint64
+	Generation     uint64
+	MetadataUUID   [16]byte
+	Reserved       [944]byte
+}
+
+type devInfoArgs struct {
+	DevID      uint64
+	UUID       [16]byte
+	BytesUsed  uint64
+	TotalBytes uint64
+	Unused     [379]uint64
+	Path       [1024]byte
+}
+
+const devStatValuesMax = 5
+
+type getDevStatsArgs struct {
+	DevID   uint64
+	NrItems uint64
+	Flags   uint64
+	Values  [devStatValuesMax]uint64
+	Unused  [128 - 2 - 1 - devStatValuesMax]uint64
+}
+
+// ScrubProgress is struct btrfs_scrub_progress.
+type ScrubProgress struct {
+	DataExtentsScrubbed uint64
+	TreeExtentsScrubbed uint64
+	DataBytesScrubbed   uint64
+	TreeBytesScrubbed   uint64
+	ReadErrors          uint64
+	CsumErrors          uint64
+	VerifyErrors        uint64
+	NoCsum              uint64
+	CsumDiscards        uint64
+	SuperErrors         uint64
+	MallocErrors        uint64
+	UncorrectableErrors uint64
+	CorrectedErrors     uint64
+	LastPhysical        uint64
+	UnverifiedErrors    uint64
+}
+
+type scrubArgs struct {
+	DevID    uint64
+	Start    uint64
+	End      uint64
+	Flags    uint64
+	Progress ScrubProgress
+	Unused   [(1024 - 32 - unsafe.Sizeof(ScrubProgress{})) / 8]uint64
+}
+
+var (
+	ioctlScrubProgress = iowr(29, unsafe.Sizeof(scrubArgs{}))
+	ioctlDevInfo       = iowr(30, unsafe.Sizeof(devInfoArgs{}))
+	ioctlFSInfo        = ior(31, unsafe.Sizeof(fsInfoArgs{}))
+	ioctlGetDevStats   = iowr(52, unsafe.Sizeof(getDevStatsArgs{}))
+)
+
+// Device is one device of a btrfs file system.
+type Device struct {
+	ID         uint64
+	Path       string
+	TotalBytes uint64
+
+	// Error counters, as displayed by btrfs device stats.
+	WriteErrs      uint64
+	ReadErrs       uint64
+	FlushErrs      uint64
+	CorruptionErrs uint64
+	GenerationErrs uint64
+
+	// Scrub is the progress of the currently running scrub, if any.
+	Scrub *ScrubProgress
+}
+
+// Errors returns the sum of all error counters.
+func (d Device) Errors() uint64 {
+	return d.WriteErrs + d.ReadErrs + d.FlushErrs + d.CorruptionErrs + d.GenerationErrs
+}
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	_, _, eno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), req, uintptr(arg))
+	if eno != 0 {
+		return eno
+	}
+	return nil
+}
+
+// Devices returns all devices of the btrfs file system mounted at
+// mountpoint.
+func Devices(mountpoint string) ([]Device, error) {
+	f, err := os.Open(mountpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var fsInfo fsInfoArgs
+	if err := ioctl(f, ioctlFSInfo, unsafe.Pointer(&fsInfo)); err != nil {
+		return nil, fmt.Errorf("BTRFS_IOC_FS_INFO: %v", err)
+	}
+	var devices []Device
+	// Device IDs can have gaps (e.g. after removing a device).
+	for id := uint64(1); id <= fsInfo.MaxID; id++ {
+		devInfo := devInfoArgs{DevID: id}
+		if err := ioctl(f, ioctlDevInfo, unsafe.Pointer(&devInfo)); err != nil {
+			if errors.Is(err, unix.ENODEV) {
+				continue
+			}
+			return nil, fmt.Errorf("BTRFS_IOC_DEV_INFO: %v", err)
+		}
+		dev := Device{
+			ID:         id,
+			Path:       unix.ByteSliceToString(devInfo.Path[:]),
+			TotalBytes: devInfo.TotalBytes,
+		}
+		stats := getDevStatsArgs{DevID: id, NrItems: devStatValuesMax}
+		if err := ioctl(f, ioctlGetDevStats, unsafe.Pointer(&stats)); err != nil {
+			return nil, fmt.Errorf("BTRFS_IOC_GET_DEV_STATS: %v", err)
+		}
+		dev.WriteErrs = stats.Values[0]
+		dev.ReadErrs = stats.Values[1]
+		dev.FlushErrs = stats.Values[2]
+		dev.CorruptionErrs = stats.Values[3]
+		dev.GenerationErrs = stats.Values[4]
+		scrub := scrubArgs{DevID: id}
+		switch err := ioctl(f, ioctlScrubProgress, unsafe.Pointer(&scrub)); {
+		case err == nil:
+			progress := scrub.Progress
+			dev.Scrub = &progress
+		case errors.Is(err, unix.ENOTCONN):
+			// no scrub running
+		default:
+			return nil, fmt.Errorf("BTRFS_IOC_SCRUB_PROGRESS: %v", err)
+		}
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
@@ -4,7 +4,7 @@ package console
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"sync"
@@ -52,10 +52,10 @@ func handleSwitches(fd uintptr, hdl *Handle) error {
 	signal.Notify(usr1, unix.SIGUSR1)
 	go func() {
 		for range usr1 {
-			log.Printf("user switched to different VT, no longer visible")
+			slog.Debug("user switched to different VT, no longer visible")
 			hdl.setVisible(false)
 			if err := unix.IoctlSetInt(int(fd), linuxvt.VT_RELDISP, 1); err != nil {
-				log.Printf("VT_RELDISP: %v", err)
+				slog.Warn("VT_RELDISP failed", "err", err)
 			}
 		}
 	}()
@@ -64,10 +64,10 @@ func handleSwitches(fd uintptr, hdl *Handle) error {
 	signal.Notify(usr2, unix.SIGUSR2)
 	go func() {
 		for range usr2 {
-			log.Printf("user switched back, now visible")
+			slog.Debug("user switched back, now visible")
 			hdl.setVisible(true)
 			if err := unix.IoctlSetInt(int(fd), linuxvt.VT_RELDISP, linuxvt.VT_ACKACQ); err != nil {
-				log.Printf("VT_RELDISP: %v", err)
+				slog.Warn("VT_RELDISP failed", "err", err)
 			}
 			select {
 			case hdl.redraw <- struct{}{}:
@@ -115,17 +115,24 @@ type Handle struct {
 
 	visibleMu sync.Mutex
 	visible   bool
+
+	textMode bool // KDSETMODE failed, see LeaseForGraphics
 }
 
 // LeaseForGraphics opens the next free Linux console in graphics mode. You must
 // call Cleanup() when done to switch back to the previous Linux console.
-func LeaseForGraphics() (*Handle, error) {
+//
+// If allowTextMode is true and the console cannot be switched into graphics
+// mode (e.g. KDSETMODE fails with EPERM in restricted environments), the
+// console stays in text mode with the cursor hidden, in which the kernel might
+// still draw the cursor or messages over the frame buffer contents.
+func LeaseForGraphics(allowTextMode bool) (*Handle, error) {
 	// Modeled after https://github.com/g0hl1n/psplash/blob/master/psplash-linuxvt.c
 	free, err := nextFreeConsole()
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("opening next free console /dev/tty%d", free)
+	slog.Debug("opening next free console", "tty", free)
 
 	// open next free console
 	//
@@ -164,12 +171,26 @@ func LeaseForGraphics() (*Handle, error) {
 
 	// switch console into graphics mode
 	if err := unix.IoctlSetInt(int(f.Fd()), linuxvt.KDSETMODE, linuxvt.KD_GRAPHICS); err != nil {
-		return nil, fmt.Errorf("KDSETMODE: %v", err)
+		if !allowTextMode {
+			return nil, fmt.Errorf("KDSETMODE: %v", err)
+		}
+		slog.Warn("KDSETMODE failed, continuing in text mode (the cursor might flicker)", "err", err)
+		hdl.textMode = true
+		// hide the cursor
+		if _, err := f.WriteString("\x1b[?25l"); err != nil {
+			slog.Warn("hiding cursor failed", "err", err)
+		}
 	}
 
 	return hdl, nil
 }
 
+// TextMode returns whether the console could not be switched into graphics
+// mode, see LeaseForGraphics.
+func (h *Handle) TextMode() bool {
+	return h.textMode
+}
+
 func (h *Handle) setVisible(v bool) {
 	h.visibleMu.Lock()
 	defer h.visibleMu.Unlock()
@@ -190,12 +211,33 @@ func (h *Handle) Redraw() <-chan struct{} {
 	return h.redraw
 }
 
+// Keep releases the console, but leaves it in graphics mode and active, so
+// that the frame buffer contents stay visible (e.g. for kiosks) after the
+// program exits. Call either Keep or Cleanup.
+func (h *Handle) Keep() error {
+	if err := unhandleSwitches(h.f.Fd()); err != nil {
+		return err
+	}
+	if err := h.f.Close(); err != nil {
+		return err
+	}
+	close(h.redraw)
+	return nil
+}
+
 // Cleanup switches the current console from graphics mode back to text mode,
 // then switches to the previous console, and finally disallocates the console.
 func (h *Handle) Cleanup() error {
-	// switch back to text mode
-	if err := unix.IoctlSetInt(int(h.f.Fd()), linuxvt.KDSETMODE, linuxvt.KD_TEXT); err != nil {
-		return fmt.Errorf("KDSETMODE: %v", err)
+	if h.textMode {
+		// show the cursor again
+		if _, err := h.f.WriteString("\x1b[?25h"); err != nil {
+			return err
+		}
+	} else {
+		// switch back to text mode
+		if err := unix.IoctlSetInt(int(h.f.Fd()), linuxvt.KDSETMODE, linuxvt.KD_TEXT); err != nil {
+			return fmt.Errorf("KDSETMODE: %v", err)
+		}
 	}
 
 	// ignore switches
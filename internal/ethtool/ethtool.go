@@ -0,0 +1,272 @@
+// Package ethtool reads the link state, speed and duplex of network
+// interfaces via the ethtool generic netlink family, like “ethtool <iface>”
+// does.
+package ethtool
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const nlaTypeMask = 0x3fff // strips NLA_F_NESTED and NLA_F_NET_BYTEORDER
+
+// Values of ETHTOOL_A_LINKMODES_SPEED and ETHTOOL_A_LINKMODES_DUPLEX from
+// include/uapi/linux/ethtool.h.
+const (
+	speedUnknown = 0xffffffff
+	duplexHalf   = 0
+	duplexFull   = 1
+)
+
+// linkModeSpeeds maps the bits of enum ethtool_link_mode_bit_indices from
+// include/uapi/linux/ethtool.h to their speed in Mb/s, for the link modes of
+// copper and fiber ports up to 10 Gb/s.
+var linkModeSpeeds = map[int]int{
+	0:  10,    // 10baseT_Half
+	1:  10,    // 10baseT_Full
+	2:  100,   // 100baseT_Half
+	3:  100,   // 100baseT_Full
+	4:  1000,  // 1000baseT_Half
+	5:  1000,  // 1000baseT_Full
+	12: 10000, // 10000baseT_Full
+	15: 2500,  // 2500baseX_Full
+	17: 1000,  // 1000baseKX_Full
+	18: 10000, // 10000baseKX4_Full
+	19: 10000, // 10000baseKR_Full
+	41: 1000,  // 1000baseX_Full
+	42: 10000, // 10000baseCR_Full
+	43: 10000, // 10000baseSR_Full
+	44: 10000, // 10000baseLR_Full
+	45: 10000, // 10000baseLRM_Full
+	46: 10000, // 10000baseER_Full
+	47: 2500,  // 2500baseT_Full
+	48: 5000,  // 5000baseT_Full
+}
+
+// Link is the state of the link of a network interface.
+type Link struct {
+	Carrier bool
+
+	// Speed is the negotiated speed in Mb/s, or 0 if unknown (e.g. without
+	// carrier).
+	Speed int
+
+	// Duplex is full, half, or empty if unknown.
+	Duplex string
+
+	// MaxSpeed is the highest speed in Mb/s which the interface advertises
+	// to its link partner, or 0 if unknown.
+	MaxSpeed int
+}
+
+func align(n int) int {
+	return (n + unix.NLA_ALIGNTO - 1) &^ (unix.NLA_ALIGNTO - 1)
+}
+
+func appendAttr(b []byte, typ uint16, data []byte) []byte {
+	var hdr [unix.SizeofNlAttr]byte
+	binary.LittleEndian.PutUint16(hdr[0:], uint16(unix.SizeofNlAttr+len(data)))
+	binary.LittleEndian.PutUint16(hdr[2:], typ)
+	b = append(b, hdr[:]...)
+	b = append(b, data...)
+	for len(b)%unix.NLA_ALIGNTO != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// parseAttrs returns the netlink attributes in b by type.
+func parseAttrs(b []byte) (map[uint16][]byte, error) {
+	attrs := make(map[uint16][]byte)
+	for len(b) >= unix.SizeofNlAttr {
+		n := int(binary.LittleEndian.Uint16(b[0:]))
+		typ := binary.LittleEndian.Uint16(b[2:]) & nlaTypeMask
+		if n < unix.SizeofNlAttr || n > len(b) {
+			return nil, errors.New("malformed netlink attribute")
+		}
+		attrs[typ] = b[unix.SizeofNlAttr:n]
+		if align(n) > len(b) {
+			break
+		}
+		b = b[align(n):]
+	}
+	return attrs, nil
+}
+
+// request returns a generic netlink request for family with command cmd and
+// the attributes attrs.
+func request(family uint16, cmd uint8, attrs []byte, seq uint32) []byte {
+	body := []byte{cmd, unix.ETHTOOL_GENL_VERSION, 0, 0} // struct genlmsghdr
+	body = append(body, attrs...)
+	msg := make([]byte, unix.NLMSG_HDRLEN, unix.NLMSG_HDRLEN+len(body))
+	binary.LittleEndian.PutUint32(msg[0:], uint32(unix.NLMSG_HDRLEN+len(body)))
+	binary.LittleEndian.PutUint16(msg[4:], family)
+	binary.LittleEndian.PutUint16(msg[6:], unix.NLM_F_REQUEST)
+	binary.LittleEndian.PutUint32(msg[8:], seq)
+	return append(msg, body...)
+}
+
+// headerAttr returns the request header attribute (of type typ) selecting
+// the interface iface, with bitsets in compact form.
+func headerAttr(typ uint16, iface string) []byte {
+	var hdr []byte
+	hdr = appendAttr(hdr, unix.ETHTOOL_A_HEADER_DEV_NAME, append([]byte(iface), 0))
+	flags := make([]byte, 4)
+	binary.LittleEndian.PutUint32(flags, unix.ETHTOOL_FLAG_COMPACT_BITSETS)
+	hdr = appendAttr(hdr, unix.ETHTOOL_A_HEADER_FLAGS, flags)
+	return appendAttr(nil, typ|unix.NLA_F_NESTED, hdr)
+}
+
+// conn is a generic netlink socket.
+type conn struct {
+	fd  int
+	seq uint32
+}
+
+func dial() (*conn, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_GENERIC)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	return &conn{fd: fd}, nil
+}
+
+func (c *conn) close() error {
+	return unix.Close(c.fd)
+}
+
+// roundTrip sends a request and returns the attributes of its reply.
+func (c *conn) roundTrip(family uint16, cmd uint8, attrs []byte) (map[uint16][]byte, error) {
+	c.seq++
+	if err := unix.Sendto(c.fd, request(family, cmd, attrs, c.seq), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, os.NewSyscallError("sendto", err)
+	}
+	buf := make([]byte, os.Getpagesize())
+	for {
+		n, _, err := unix.Recvfrom(c.fd, buf, 0)
+		if err != nil {
+			return nil, os.NewSyscallError("recvfrom", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != c.seq {
+				continue
+			}
+			if m.Header.Type == unix.NLMSG_ERROR {
+				if len(m.Data) >= 4 {
+					if errno := int32(binary.LittleEndian.Uint32(m.Data)); errno != 0 {
+						return nil, unix.Errno(-errno)
+					}
+				}
+				continue
+			}
+			if len(m.Data) < unix.GENL_HDRLEN {
+				return nil, errors.New("short generic netlink message")
+			}
+			return parseAttrs(m.Data[unix.GENL_HDRLEN:])
+		}
+	}
+}
+
+// family resolves the ID of the ethtool generic netlink family.
+func (c *conn) family() (uint16, error) {
+	attrs := appendAttr(nil, unix.CTRL_ATTR_FAMILY_NAME, append([]byte(unix.ETHTOOL_GENL_NAME), 0))
+	reply, err := c.roundTrip(unix.GENL_ID_CTRL, unix.CTRL_CMD_GETFAMILY, attrs)
+	if err != nil {
+		return 0, fmt.Errorf("resolving generic netlink family %s: %v", unix.ETHTOOL_GENL_NAME, err)
+	}
+	id := reply[unix.CTRL_ATTR_FAMILY_ID]
+	if len(id) != 2 {
+		return 0, fmt.Errorf("resolving generic netlink family %s: no ID", unix.ETHTOOL_GENL_NAME)
+	}
+	return binary.LittleEndian.Uint16(id), nil
+}
+
+// maxSpeed returns the highest speed of the link modes in a compact bitset
+// (ETHTOOL_A_BITSET_VALUE).
+func maxSpeed(bitmap []byte) int {
+	max := 0
+	for bit, speed := range linkModeSpeeds {
+		// the bitmap consists of 32 bit words in host byte order
+		word := bit / 32 * 4
+		if word+4 > len(bitmap) {
+			continue
+		}
+		if binary.LittleEndian.Uint32(bitmap[word:])&(1<<(bit%32)) != 0 && speed > max {
+			max = speed
+		}
+	}
+	return max
+}
+
+// parseLinkModes fills in the speed, duplex and maximum speed of l from the
+// attributes of an ETHTOOL_MSG_LINKMODES_GET reply.
+func parseLinkModes(attrs map[uint16][]byte, l *Link) error {
+	if b := attrs[unix.ETHTOOL_A_LINKMODES_SPEED]; len(b) == 4 {
+		if speed := binary.LittleEndian.Uint32(b); speed != speedUnknown {
+			l.Speed = int(speed)
+		}
+	}
+	if b := attrs[unix.ETHTOOL_A_LINKMODES_DUPLEX]; len(b) == 1 {
+		switch b[0] {
+		case duplexHalf:
+			l.Duplex = "half"
+		case duplexFull:
+			l.Duplex = "full"
+		}
+	}
+	if ours, ok := attrs[unix.ETHTOOL_A_LINKMODES_OURS]; ok {
+		bitset, err := parseAttrs(ours)
+		if err != nil {
+			return err
+		}
+		// the value of our link modes are the advertised ones
+		l.MaxSpeed = maxSpeed(bitset[unix.ETHTOOL_A_BITSET_VALUE])
+	}
+	return nil
+}
+
+// Get returns the link state of the network interface iface. Interfaces
+// without link settings (e.g. WiFi or virtual interfaces) result in an error.
+func Get(iface string) (Link, error) {
+	c, err := dial()
+	if err != nil {
+		return Link{}, err
+	}
+	defer c.close()
+	family, err := c.family()
+	if err != nil {
+		return Link{}, err
+	}
+	var l Link
+	state, err := c.roundTrip(family, unix.ETHTOOL_MSG_LINKSTATE_GET, headerAttr(unix.ETHTOOL_A_LINKSTATE_HEADER, iface))
+	if err != nil {
+		return Link{}, fmt.Errorf("%s: link state: %v", iface, err)
+	}
+	if b := state[unix.ETHTOOL_A_LINKSTATE_LINK]; len(b) == 1 {
+		l.Carrier = b[0] != 0
+	}
+	modes, err := c.roundTrip(family, unix.ETHTOOL_MSG_LINKMODES_GET, headerAttr(unix.ETHTOOL_A_LINKMODES_HEADER, iface))
+	if err != nil {
+		return Link{}, fmt.Errorf("%s: link modes: %v", iface, err)
+	}
+	if err := parseLinkModes(modes, &l); err != nil {
+		return Link{}, fmt.Errorf("%s: link modes: %v", iface, err)
+	}
+	if !l.Carrier {
+		l.Speed, l.Duplex = 0, ""
+	}
+	return l, nil
+}
@@ -0,0 +1,57 @@
+package ethtool
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseLinkModes(t *testing.T) {
+	u32 := func(v uint32) []byte {
+		b := make([]byte, 4)
+		binary.LittleEndian.PutUint32(b, v)
+		return b
+	}
+	// advertised: 10baseT_Half/Full, 100baseT_Half/Full, 1000baseT_Full
+	var ours []byte
+	ours = appendAttr(ours, unix.ETHTOOL_A_BITSET_SIZE, u32(92))
+	ours = appendAttr(ours, unix.ETHTOOL_A_BITSET_VALUE, append(u32(0x2f), make([]byte, 8)...))
+	ours = appendAttr(ours, unix.ETHTOOL_A_BITSET_MASK, append(u32(0x3f), make([]byte, 8)...))
+
+	var body []byte
+	body = appendAttr(body, unix.ETHTOOL_A_LINKMODES_HEADER|unix.NLA_F_NESTED, nil)
+	body = appendAttr(body, unix.ETHTOOL_A_LINKMODES_OURS|unix.NLA_F_NESTED, ours)
+	body = appendAttr(body, unix.ETHTOOL_A_LINKMODES_SPEED, u32(100))
+	body = appendAttr(body, unix.ETHTOOL_A_LINKMODES_DUPLEX, []byte{duplexHalf})
+
+	attrs, err := parseAttrs(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Link{Carrier: true}
+	if err := parseLinkModes(attrs, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := Link{Carrier: true, Speed: 100, Duplex: "half", MaxSpeed: 1000}
+	if got != want {
+		t.Errorf("parseLinkModes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseLinkModesUnknown(t *testing.T) {
+	var body []byte
+	body = appendAttr(body, unix.ETHTOOL_A_LINKMODES_SPEED, []byte{0xff, 0xff, 0xff, 0xff})
+	body = appendAttr(body, unix.ETHTOOL_A_LINKMODES_DUPLEX, []byte{0xff})
+	attrs, err := parseAttrs(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Link
+	if err := parseLinkModes(attrs, &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Link{}); got != want {
+		t.Errorf("parseLinkModes() = %+v, want %+v", got, want)
+	}
+}
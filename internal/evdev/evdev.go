@@ -0,0 +1,211 @@
+// Package evdev reads events from Linux input devices (/dev/input/eventN),
+// e.g. keyboards, touchscreens and IR receivers.
+//
+// See https://docs.kernel.org/input/input.html
+package evdev
+
+import (
+	"bytes"
+	"os"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Event types from include/uapi/linux/input-event-codes.h.
+const (
+	EvSyn = 0x00
+	EvKey = 0x01
+	EvAbs = 0x03
+	EvMsc = 0x04
+
+	evMax = 0x1f
+)
+
+// MscScan is the code of EvMsc events carrying the scancode of a key, e.g. of
+// a remote control.
+const MscScan = 0x04
+
+// Key codes from include/uapi/linux/input-event-codes.h.
+const (
+	KeyEsc      = 1
+	KeyQ        = 16
+	KeyA        = 30
+	KeyH        = 35
+	KeyL        = 38
+	KeyB        = 48
+	KeySpace    = 57
+	KeyHome     = 102
+	KeyUp       = 103
+	KeyPageUp   = 104
+	KeyLeft     = 105
+	KeyRight    = 106
+	KeyEnd      = 107
+	KeyDown     = 108
+	KeyPageDown = 109
+
+	BtnTouch = 0x14a
+
+	keyMax = 0x2ff
+)
+
+// Absolute axes from include/uapi/linux/input-event-codes.h.
+const (
+	AbsX = 0x00
+	AbsY = 0x01
+)
+
+// Values of EvKey events.
+const (
+	KeyReleased = 0
+	KeyPressed  = 1
+	KeyRepeated = 2
+)
+
+// inputEvent is struct input_event. The time is a struct timeval, i.e. two
+// longs, which unix.Timeval matches on all architectures.
+type inputEvent struct {
+	Time  unix.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+const eventSize = int(unsafe.Sizeof(inputEvent{}))
+
+// An Event is one input event, e.g. a key press.
+type Event struct {
+	Time  time.Time
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+func ioc(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'E'<<8 | nr
+}
+
+const iocRead = 2
+
+func eviocgname(size uintptr) uintptr    { return ioc(iocRead, 0x06, size) }
+func eviocgbit(ev, size uintptr) uintptr { return ioc(iocRead, 0x20+ev, size) }
+func eviocgabs(abs uintptr) uintptr      { return ioc(iocRead, 0x40+abs, unsafe.Sizeof(AbsInfo{})) }
+
+// AbsInfo is struct input_absinfo, describing an absolute axis.
+type AbsInfo struct {
+	Value      int32
+	Minimum    int32
+	Maximum    int32
+	Fuzz       int32
+	Flat       int32
+	Resolution int32
+}
+
+// Device is an open input device.
+type Device struct {
+	f *os.File
+}
+
+// Open opens the input device at path, e.g. /dev/input/event0.
+func Open(path string) (*Device, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Device{f: f}, nil
+}
+
+func (d *Device) Close() error {
+	return d.f.Close()
+}
+
+func (d *Device) ioctl(req uintptr, buf []byte) error {
+	sc, err := d.f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno unix.Errno
+	if err := sc.Control(func(fd uintptr) {
+		_, _, errno = unix.Syscall(unix.SYS_IOCTL, fd, req, uintptr(unsafe.Pointer(&buf[0])))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// Name returns the name of the device, e.g. "Logitech USB Keyboard".
+func (d *Device) Name() (string, error) {
+	buf := make([]byte, 256)
+	if err := d.ioctl(eviocgname(uintptr(len(buf))), buf); err != nil {
+		return "", err
+	}
+	if idx := bytes.IndexByte(buf, 0); idx > -1 {
+		buf = buf[:idx]
+	}
+	return string(buf), nil
+}
+
+// HasEventType reports whether the device emits events of type typ (e.g.
+// EvKey).
+func (d *Device) HasEventType(typ uint16) (bool, error) {
+	bits := make([]byte, evMax/8+1)
+	if err := d.ioctl(eviocgbit(0, uintptr(len(bits))), bits); err != nil {
+		return false, err
+	}
+	return bits[typ/8]&(1<<(typ%8)) != 0, nil
+}
+
+// HasKeys reports whether the device can emit all of the specified key
+// codes.
+func (d *Device) HasKeys(codes ...uint16) (bool, error) {
+	bits := make([]byte, keyMax/8+1)
+	if err := d.ioctl(eviocgbit(EvKey, uintptr(len(bits))), bits); err != nil {
+		return false, err
+	}
+	for _, code := range codes {
+		if bits[code/8]&(1<<(code%8)) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// AbsInfo returns the range of the absolute axis abs (e.g. AbsX).
+func (d *Device) AbsInfo(abs uint16) (AbsInfo, error) {
+	var info AbsInfo
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(&info)), unsafe.Sizeof(info))
+	if err := d.ioctl(eviocgabs(uintptr(abs)), buf); err != nil {
+		return AbsInfo{}, err
+	}
+	return info, nil
+}
+
+// ReadEvents blocks until events are available and returns them.
+func (d *Device) ReadEvents() ([]Event, error) {
+	buf := make([]byte, 64*eventSize)
+	n, err := d.f.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return decodeEvents(buf[:n]), nil
+}
+
+// decodeEvents decodes consecutive struct input_event. The kernel only
+// returns whole events.
+func decodeEvents(b []byte) []Event {
+	events := make([]Event, 0, len(b)/eventSize)
+	for ; len(b) >= eventSize; b = b[eventSize:] {
+		ev := (*inputEvent)(unsafe.Pointer(&b[0]))
+		events = append(events, Event{
+			Time:  time.Unix(int64(ev.Time.Sec), int64(ev.Time.Usec)*1000),
+			Type:  ev.Type,
+			Code:  ev.Code,
+			Value: ev.Value,
+		})
+	}
+	return events
+}
@@ -0,0 +1,27 @@
+package evdev
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestDecodeEvents(t *testing.T) {
+	raw := []inputEvent{
+		{Time: unix.NsecToTimeval(int64(1630324800 * time.Second)), Type: EvKey, Code: KeyQ, Value: KeyPressed},
+		{Time: unix.NsecToTimeval(int64(1630324800*time.Second + 500*time.Microsecond)), Type: EvSyn},
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(&raw[0])), len(raw)*eventSize)
+	// a trailing partial event is ignored
+	b = append(append([]byte(nil), b...), 1, 2, 3)
+	want := []Event{
+		{Time: time.Unix(1630324800, 0), Type: EvKey, Code: KeyQ, Value: KeyPressed},
+		{Time: time.Unix(1630324800, 500000), Type: EvSyn},
+	}
+	if got := decodeEvents(b); !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeEvents() = %+v, want %+v", got, want)
+	}
+}
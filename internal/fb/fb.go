@@ -32,13 +32,43 @@ import (
 )
 
 type Device struct {
-	fd    uintptr
-	mmap  []byte
-	finfo FixScreeninfo
+	fd       uintptr
+	mmap     []byte
+	finfo    FixScreeninfo
+	readOnly bool
 }
 
+// errReadOnly is returned by the methods which change the display when the
+// device was opened with OpenReadOnly.
+var errReadOnly = errors.New("framebuffer opened read-only")
+
 func Open(dev string) (*Device, error) {
-	fd, err := unix.Open(dev, unix.O_RDWR|unix.O_CLOEXEC, 0)
+	return open(dev, false)
+}
+
+// OpenReadOnly opens dev like Open, but maps the framebuffer memory
+// read-only, e.g. to capture what is currently displayed (even if drawn by
+// other programs) without risking writes. Writing to the images returned by
+// Image crashes the program, and the methods which change the display return
+// an error.
+func OpenReadOnly(dev string) (*Device, error) {
+	return open(dev, true)
+}
+
+// prot returns the memory protection for mapping the framebuffer memory.
+func (d *Device) prot() int {
+	if d.readOnly {
+		return unix.PROT_READ
+	}
+	return unix.PROT_READ | unix.PROT_WRITE
+}
+
+func open(dev string, readOnly bool) (*Device, error) {
+	mode := unix.O_RDWR
+	if readOnly {
+		mode = unix.O_RDONLY
+	}
+	fd, err := unix.Open(dev, mode|unix.O_CLOEXEC, 0)
 	if err != nil {
 		return nil, fmt.Errorf("open %s: %v", dev, err)
 	}
@@ -46,7 +76,7 @@ func Open(dev string) (*Device, error) {
 		unix.Close(fd)
 		return nil, errors.New("fd overflows")
 	}
-	d := &Device{fd: uintptr(fd)}
+	d := &Device{fd: uintptr(fd), readOnly: readOnly}
 
 	_, _, eno := unix.Syscall(unix.SYS_IOCTL, d.fd, FBIOGET_FSCREENINFO, uintptr(unsafe.Pointer(&d.finfo)))
 	if eno != 0 {
@@ -54,7 +84,7 @@ func Open(dev string) (*Device, error) {
 		return nil, fmt.Errorf("FBIOGET_FSCREENINFO: %v", eno)
 	}
 
-	d.mmap, err = unix.Mmap(fd, 0, int(d.finfo.Smem_len), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	d.mmap, err = unix.Mmap(fd, 0, int(d.finfo.Smem_len), d.prot(), unix.MAP_SHARED)
 	if err != nil {
 		unix.Close(fd)
 		return nil, fmt.Errorf("mmap: %v", err)
@@ -71,6 +101,166 @@ func (d *Device) VarScreeninfo() (VarScreeninfo, error) {
 	return vinfo, nil
 }
 
+// SetVarScreeninfo changes the variable screen information (e.g. the
+// resolution, or the virtual resolution for double buffering via Pan) and
+// returns it as applied by the driver, which may round the values.
+//
+// Images obtained via Image before are invalid afterwards.
+func (d *Device) SetVarScreeninfo(vinfo VarScreeninfo) (VarScreeninfo, error) {
+	if d.readOnly {
+		return vinfo, errReadOnly
+	}
+	_, _, eno := unix.Syscall(unix.SYS_IOCTL, d.fd, FBIOPUT_VSCREENINFO, uintptr(unsafe.Pointer(&vinfo)))
+	if eno != 0 {
+		return vinfo, fmt.Errorf("FBIOPUT_VSCREENINFO: %v", eno)
+	}
+	// The line length and the size of the framebuffer memory might have
+	// changed with the resolution.
+	var finfo FixScreeninfo
+	_, _, eno = unix.Syscall(unix.SYS_IOCTL, d.fd, FBIOGET_FSCREENINFO, uintptr(unsafe.Pointer(&finfo)))
+	if eno != 0 {
+		return vinfo, fmt.Errorf("FBIOGET_FSCREENINFO: %v", eno)
+	}
+	if finfo.Smem_len != d.finfo.Smem_len {
+		if err := unix.Munmap(d.mmap); err != nil {
+			return vinfo, fmt.Errorf("munmap: %v", err)
+		}
+		var err error
+		d.mmap, err = unix.Mmap(int(d.fd), 0, int(finfo.Smem_len), d.prot(), unix.MAP_SHARED)
+		if err != nil {
+			return vinfo, fmt.Errorf("mmap: %v", err)
+		}
+	}
+	d.finfo = finfo
+	return vinfo, nil
+}
+
+// Pan displays the part of the virtual resolution at x, y (e.g. the second
+// of two buffers below each other for double buffering). Most drivers
+// support panning only vertically, in steps of FixScreeninfo.Ypanstep.
+func (d *Device) Pan(x, y uint32) error {
+	if d.readOnly {
+		return errReadOnly
+	}
+	vinfo, err := d.VarScreeninfo()
+	if err != nil {
+		return err
+	}
+	vinfo.Xoffset = x
+	vinfo.Yoffset = y
+	_, _, eno := unix.Syscall(unix.SYS_IOCTL, d.fd, FBIOPAN_DISPLAY, uintptr(unsafe.Pointer(&vinfo)))
+	if eno != 0 {
+		return fmt.Errorf("FBIOPAN_DISPLAY: %v", eno)
+	}
+	return nil
+}
+
+// Blanking levels for Blank, as per enum fb_blank in linux/fb.h.
+const (
+	FB_BLANK_UNBLANK       = 0 // screen on
+	FB_BLANK_NORMAL        = 1 // screen blanked, but the display stays on
+	FB_BLANK_VSYNC_SUSPEND = 2
+	FB_BLANK_HSYNC_SUSPEND = 3
+	FB_BLANK_POWERDOWN     = 4 // display powered off
+)
+
+// Blank blanks (or with FB_BLANK_UNBLANK, unblanks) the display. Not all
+// drivers support all levels: many only distinguish blanked and unblanked.
+func (d *Device) Blank(level int) error {
+	if d.readOnly {
+		return errReadOnly
+	}
+	_, _, eno := unix.Syscall(unix.SYS_IOCTL, d.fd, FBIOBLANK, uintptr(level))
+	if eno != 0 {
+		return fmt.Errorf("FBIOBLANK(%d): %v", level, eno)
+	}
+	return nil
+}
+
+// WaitForVSync blocks until the next vertical blanking interval, so that
+// updates (e.g. Pan) do not tear. Many drivers do not support this, in which
+// case WaitForVSync returns an error immediately.
+func (d *Device) WaitForVSync() error {
+	var crtc uint32 // the first (and typically only) display
+	_, _, eno := unix.Syscall(unix.SYS_IOCTL, d.fd, FBIO_WAITFORVSYNC, uintptr(unsafe.Pointer(&crtc)))
+	if eno != 0 {
+		return fmt.Errorf("FBIO_WAITFORVSYNC: %v", eno)
+	}
+	return nil
+}
+
+// FixScreeninfo returns the fixed screen information, e.g. the line length
+// and the pan steps.
+func (d *Device) FixScreeninfo() FixScreeninfo {
+	return d.finfo
+}
+
+// A Snapshot is what a framebuffer displayed at one point in time, see Save.
+type Snapshot struct {
+	vinfo VarScreeninfo
+	// offset is the offset of pix in the framebuffer memory.
+	offset int
+	// pix are the lines of the framebuffer memory which were displayed,
+	// starting at the first visible pixel.
+	pix []byte
+}
+
+// Save returns a copy of what the framebuffer currently displays (e.g. a boot
+// logo or another program's output), to be restored with Restore later. Only
+// the visible lines are copied, not the entire (possibly much larger)
+// framebuffer memory.
+func (d *Device) Save() (*Snapshot, error) {
+	vinfo, err := d.VarScreeninfo()
+	if err != nil {
+		return nil, err
+	}
+	stride := int(d.finfo.Line_length)
+	// With a horizontal pan offset, every visible line starts Xoffset pixels
+	// into its line of framebuffer memory.
+	offset := int(vinfo.Yoffset)*stride + int(vinfo.Xoffset)*int(vinfo.Bits_per_pixel)/8
+	end := offset + int(vinfo.Yres)*stride
+	if end > len(d.mmap) {
+		return nil, errors.New("framebuffer is too small")
+	}
+	return &Snapshot{
+		vinfo:  vinfo,
+		offset: offset,
+		pix:    append([]byte(nil), d.mmap[offset:end]...),
+	}, nil
+}
+
+// Restore displays s (as returned by Save) again. If the resolution or the
+// pan offset were changed since, they are reset to those of s first.
+//
+// Images obtained via Image before are invalid afterwards.
+func (d *Device) Restore(s *Snapshot) error {
+	if d.readOnly {
+		return errReadOnly
+	}
+	vinfo, err := d.VarScreeninfo()
+	if err != nil {
+		return err
+	}
+	if vinfo.Xres != s.vinfo.Xres ||
+		vinfo.Yres != s.vinfo.Yres ||
+		vinfo.Xres_virtual != s.vinfo.Xres_virtual ||
+		vinfo.Yres_virtual != s.vinfo.Yres_virtual ||
+		vinfo.Bits_per_pixel != s.vinfo.Bits_per_pixel {
+		if _, err := d.SetVarScreeninfo(s.vinfo); err != nil {
+			return err
+		}
+	} else if vinfo.Xoffset != s.vinfo.Xoffset || vinfo.Yoffset != s.vinfo.Yoffset {
+		if err := d.Pan(s.vinfo.Xoffset, s.vinfo.Yoffset); err != nil {
+			return err
+		}
+	}
+	if s.offset+len(s.pix) > len(d.mmap) {
+		return fmt.Errorf("framebuffer shrank to %d bytes, cannot restore %d bytes at offset %d", len(d.mmap), len(s.pix), s.offset)
+	}
+	copy(d.mmap[s.offset:], s.pix)
+	return nil
+}
+
 func (d *Device) Image() (draw.Image, error) {
 	vinfo, err := d.VarScreeninfo()
 	if err != nil {
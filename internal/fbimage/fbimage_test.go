@@ -0,0 +1,127 @@
+package fbimage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func newBGR565(r image.Rectangle, stride int) *BGR565 {
+	return &BGR565{Pix: make([]byte, stride*r.Dy()), Rect: r, Stride: stride}
+}
+
+func newBGRA(r image.Rectangle, stride int) *BGRA {
+	return &BGRA{Pix: make([]byte, stride*r.Dy()), Rect: r, Stride: stride}
+}
+
+func TestBGR565RoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		set  color.Color
+		want color.NRGBA
+	}{
+		{"black", color.Black, color.NRGBA{0, 0, 0, 255}},
+		{"white", color.White, color.NRGBA{0xf8, 0xfc, 0xf8, 255}},
+		{"red", color.RGBA{R: 0xff, A: 0xff}, color.NRGBA{0xf8, 0, 0, 255}},
+		{"green", color.RGBA{G: 0xff, A: 0xff}, color.NRGBA{0, 0xfc, 0, 255}},
+		{"blue", color.RGBA{B: 0xff, A: 0xff}, color.NRGBA{0, 0, 0xf8, 255}},
+		// only the top 5 (red, blue) and 6 (green) bits are kept
+		{"truncated", color.RGBA{R: 0x37, G: 0x37, B: 0x37, A: 0xff}, color.NRGBA{0x30, 0x34, 0x30, 255}},
+		// the green bits are split across both bytes
+		{"green split", color.RGBA{G: 0b10100100, A: 0xff}, color.NRGBA{0, 0b10100100, 0, 255}},
+		// BGR565 has no alpha channel: colors are stored un-premultiplied
+		{"translucent", color.NRGBA{R: 0xc8, G: 0x64, B: 0x32, A: 0x80}, color.NRGBA{0xc8, 0x64, 0x30, 255}},
+		{"transparent", color.Transparent, color.NRGBA{0, 0, 0, 255}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			img := newBGR565(image.Rect(0, 0, 2, 2), 4)
+			img.Set(1, 1, tt.set)
+			if got := img.At(1, 1); got != tt.want {
+				t.Errorf("At after Set(%v) = %v, want %v", tt.set, got, tt.want)
+			}
+			// setting the stored color again must not change it
+			img.Set(1, 1, img.At(1, 1))
+			if got := img.At(1, 1); got != tt.want {
+				t.Errorf("At after second Set = %v, want %v", got, tt.want)
+			}
+			if got := img.At(0, 0); got != (color.NRGBA{A: 255}) {
+				t.Errorf("neighboring pixel = %v, want unchanged", got)
+			}
+		})
+	}
+}
+
+func TestBGRARoundTrip(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		set  color.Color
+		want color.RGBA
+	}{
+		{"black", color.Black, color.RGBA{0, 0, 0, 255}},
+		{"white", color.White, color.RGBA{255, 255, 255, 255}},
+		{"color", color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}, color.RGBA{0x11, 0x22, 0x33, 0xff}},
+		// stored premultiplied, like image.RGBA
+		{"translucent", color.NRGBA{R: 0xff, G: 0x80, B: 0, A: 0x80}, color.RGBA{0x80, 0x40, 0, 0x80}},
+		{"transparent", color.Transparent, color.RGBA{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			img := newBGRA(image.Rect(0, 0, 2, 2), 8)
+			img.Set(1, 1, tt.set)
+			if got := img.At(1, 1); got != tt.want {
+				t.Errorf("At after Set(%v) = %v, want %v", tt.set, got, tt.want)
+			}
+			off := img.PixOffset(1, 1)
+			if got, want := img.Pix[off:off+4], []byte{tt.want.B, tt.want.G, tt.want.R, tt.want.A}; !bytes.Equal(got, want) {
+				t.Errorf("Pix = %x, want %x (BGRA order)", got, want)
+			}
+		})
+	}
+}
+
+func TestClipping(t *testing.T) {
+	// a non-zero origin and padding at the end of each line, as with
+	// framebuffers whose line length exceeds the visible width
+	r := image.Rect(10, 20, 14, 23)
+	outside := []image.Point{
+		{9, 20}, {14, 20}, {10, 19}, {10, 23}, {-1, -1}, {0, 0}, {100, 100},
+	}
+	bgr565 := newBGR565(r, 2*r.Dx()+6)
+	bgra := newBGRA(r, 4*r.Dx()+8)
+	for _, tt := range []struct {
+		name          string
+		img           draw.Image
+		pix           []byte
+		stride, width int // in bytes
+		zero          color.Color
+	}{
+		{"BGR565", bgr565, bgr565.Pix, bgr565.Stride, 2 * r.Dx(), color.NRGBA{}},
+		{"BGRA", bgra, bgra.Pix, bgra.Stride, 4 * r.Dx(), color.RGBA{}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, p := range outside {
+				tt.img.Set(p.X, p.Y, color.White)
+				if got := tt.img.At(p.X, p.Y); got != tt.zero {
+					t.Errorf("At(%v) outside of %v = %v, want %v", p, r, got, tt.zero)
+				}
+			}
+			if !bytes.Equal(tt.pix, make([]byte, len(tt.pix))) {
+				t.Errorf("Set outside of %v modified Pix", r)
+			}
+
+			// the corners are within the image and must not touch the padding
+			for _, p := range []image.Point{r.Min, {r.Max.X - 1, r.Min.Y}, {r.Min.X, r.Max.Y - 1}, r.Max.Sub(image.Pt(1, 1))} {
+				tt.img.Set(p.X, p.Y, color.White)
+				if got := tt.img.At(p.X, p.Y); got == tt.zero {
+					t.Errorf("At(%v) after Set = %v, want white", p, got)
+				}
+			}
+			for y := 0; y < r.Dy(); y++ {
+				if padding := tt.pix[y*tt.stride+tt.width : (y+1)*tt.stride]; !bytes.Equal(padding, make([]byte, len(padding))) {
+					t.Errorf("line %d: padding modified: %x", y, padding)
+				}
+			}
+		})
+	}
+}
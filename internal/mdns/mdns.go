@@ -0,0 +1,382 @@
+// Package mdns implements a minimal multicast DNS (RFC 6762) responder, which
+// advertises one DNS-SD (RFC 6763) service instance and the address records
+// of its host name in the .local domain.
+//
+// Unlike a full responder, it neither probes for name conflicts nor
+// suppresses known answers, which is fine for names as unique as the
+// hostnames of gokrazy devices.
+package mdns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// DNS resource record types and classes as per RFC 1035, RFC 2782 (SRV) and
+// RFC 3596 (AAAA).
+const (
+	typeA    = 1
+	typePTR  = 12
+	typeTXT  = 16
+	typeAAAA = 28
+	typeSRV  = 33
+	typeANY  = 255
+
+	classIN = 1
+
+	// cacheFlush is set in the class of records which are unique to this
+	// host, unicastResponse in the class of questions whose asker prefers
+	// a unicast response (RFC 6762, sections 10.2 and 5.4).
+	cacheFlush      = 0x8000
+	unicastResponse = 0x8000
+)
+
+const (
+	hostTTL   = 120     // seconds, for records containing the host name
+	otherTTL  = 75 * 60 // seconds, for all other records
+	legacyTTL = 10      // seconds, for responses to legacy unicast queries
+	port      = 5353
+	maxMsg    = 9000    // RFC 6762, section 17
+	flagsQR   = 1 << 15 // response
+	flagsAA   = 1 << 10 // authoritative answer
+	flagsOpc  = 0xf << 11
+)
+
+var group = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: port}
+
+// Service is a DNS-SD service instance.
+type Service struct {
+	Instance string   // instance name, e.g. scan2drive
+	Service  string   // service type, e.g. _fbstatus._tcp
+	Host     string   // host name without .local, e.g. scan2drive
+	Port     uint16   // port of the service
+	TXT      []string // key=value pairs
+	// Addrs returns the current addresses of the host.
+	Addrs func() []net.IP
+}
+
+func (s *Service) serviceName() []string {
+	return append(strings.Split(s.Service, "."), "local")
+}
+
+func (s *Service) instanceName() []string {
+	// the instance name is a single label, even if it contains dots
+	return append([]string{s.Instance}, s.serviceName()...)
+}
+
+func (s *Service) hostName() []string {
+	return []string{s.Host, "local"}
+}
+
+var servicesName = []string{"_services", "_dns-sd", "_udp", "local"}
+
+type record struct {
+	name  []string
+	typ   uint16
+	flush bool
+	ttl   uint32
+	data  []byte
+}
+
+type question struct {
+	name    []string
+	typ     uint16
+	unicast bool
+}
+
+func appendName(b []byte, name []string) []byte {
+	for _, label := range name {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+func equalNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if !strings.EqualFold(a[idx], b[idx]) {
+			return false
+		}
+	}
+	return true
+}
+
+// records returns all records of the service. With goodbye set, their TTL is
+// zero, which tells other hosts to remove them from their caches.
+func (s *Service) records(goodbye bool) []record {
+	ttl := func(ttl uint32) uint32 {
+		if goodbye {
+			return 0
+		}
+		return ttl
+	}
+	srv := make([]byte, 6)
+	binary.BigEndian.PutUint16(srv[4:], s.Port)
+	srv = appendName(srv, s.hostName())
+	var txt []byte
+	for _, kv := range s.TXT {
+		if len(kv) > 255 {
+			continue
+		}
+		txt = append(txt, byte(len(kv)))
+		txt = append(txt, kv...)
+	}
+	if len(txt) == 0 {
+		txt = []byte{0} // RFC 6763, section 6.1
+	}
+	records := []record{
+		{name: servicesName, typ: typePTR, ttl: ttl(otherTTL), data: appendName(nil, s.serviceName())},
+		{name: s.serviceName(), typ: typePTR, ttl: ttl(otherTTL), data: appendName(nil, s.instanceName())},
+		{name: s.instanceName(), typ: typeSRV, flush: true, ttl: ttl(hostTTL), data: srv},
+		{name: s.instanceName(), typ: typeTXT, flush: true, ttl: ttl(otherTTL), data: txt},
+	}
+	if s.Addrs != nil {
+		for _, ip := range s.Addrs() {
+			if ip4 := ip.To4(); ip4 != nil {
+				records = append(records, record{name: s.hostName(), typ: typeA, flush: true, ttl: ttl(hostTTL), data: ip4})
+			} else {
+				records = append(records, record{name: s.hostName(), typ: typeAAAA, flush: true, ttl: ttl(hostTTL), data: ip.To16()})
+			}
+		}
+	}
+	return records
+}
+
+// answers returns the records answering questions, and the additional
+// records which the asker will likely query next (e.g. the SRV, TXT and
+// address records when browsing for the service).
+func (s *Service) answers(questions []question) (answers, additionals []record) {
+	all := s.records(false)
+	answered := make([]bool, len(all))
+	for _, q := range questions {
+		for idx, r := range all {
+			if !answered[idx] && equalNames(q.name, r.name) && (q.typ == typeANY || q.typ == r.typ) {
+				answered[idx] = true
+				answers = append(answers, r)
+			}
+		}
+	}
+	if len(answers) == 0 {
+		return nil, nil
+	}
+	for _, r := range answers {
+		if r.typ == typePTR && equalNames(r.name, s.serviceName()) ||
+			equalNames(r.name, s.instanceName()) {
+			// the asker is interested in the service instance itself
+			for idx, r := range all {
+				if !answered[idx] && !equalNames(r.name, servicesName) && !equalNames(r.name, s.serviceName()) {
+					answered[idx] = true
+					additionals = append(additionals, r)
+				}
+			}
+			break
+		}
+	}
+	return answers, additionals
+}
+
+// message returns a DNS response message. Responses to legacy unicast
+// queries (RFC 6762, section 6.7) repeat the id and questions of the query
+// and use short TTLs, as the asker does not implement mDNS.
+func message(legacy bool, id uint16, questions []question, answers, additionals []record) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint16(b[0:], id)
+	binary.BigEndian.PutUint16(b[2:], flagsQR|flagsAA)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(questions)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(answers)))
+	binary.BigEndian.PutUint16(b[10:], uint16(len(additionals)))
+	for _, q := range questions {
+		b = appendName(b, q.name)
+		var typeClass [4]byte
+		binary.BigEndian.PutUint16(typeClass[0:], q.typ)
+		binary.BigEndian.PutUint16(typeClass[2:], classIN)
+		b = append(b, typeClass[:]...)
+	}
+	for _, r := range append(answers, additionals...) {
+		b = appendName(b, r.name)
+		class, ttl := uint16(classIN), r.ttl
+		if legacy {
+			if ttl > legacyTTL {
+				ttl = legacyTTL
+			}
+		} else if r.flush {
+			class |= cacheFlush
+		}
+		var hdr [10]byte
+		binary.BigEndian.PutUint16(hdr[0:], r.typ)
+		binary.BigEndian.PutUint16(hdr[2:], class)
+		binary.BigEndian.PutUint32(hdr[4:], ttl)
+		binary.BigEndian.PutUint16(hdr[8:], uint16(len(r.data)))
+		b = append(b, hdr[:]...)
+		b = append(b, r.data...)
+	}
+	return b
+}
+
+var errMalformed = errors.New("malformed DNS message")
+
+// readName reads the (possibly compressed) domain name at offset off of msg
+// and returns it along with the offset following it.
+func readName(msg []byte, off int) ([]string, int, error) {
+	var name []string
+	next := -1
+	for hops := 0; ; hops++ {
+		if off >= len(msg) || hops > 64 {
+			return nil, 0, errMalformed
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if next == -1 {
+				next = off + 1
+			}
+			return name, next, nil
+		case n&0xc0 == 0xc0:
+			if off+1 >= len(msg) {
+				return nil, 0, errMalformed
+			}
+			if next == -1 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+		case n&0xc0 == 0:
+			if off+1+n > len(msg) {
+				return nil, 0, errMalformed
+			}
+			name = append(name, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		default:
+			return nil, 0, errMalformed
+		}
+	}
+}
+
+// parseQuery returns the id and questions of the DNS query msg.
+func parseQuery(msg []byte) (uint16, []question, error) {
+	if len(msg) < 12 {
+		return 0, nil, errMalformed
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	if flags&flagsQR != 0 || flags&flagsOpc != 0 {
+		return 0, nil, errors.New("not a standard query")
+	}
+	id := binary.BigEndian.Uint16(msg[0:])
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	questions := make([]question, 0, qdcount)
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil {
+			return 0, nil, err
+		}
+		if next+4 > len(msg) {
+			return 0, nil, errMalformed
+		}
+		class := binary.BigEndian.Uint16(msg[next+2:])
+		questions = append(questions, question{
+			name:    name,
+			typ:     binary.BigEndian.Uint16(msg[next:]),
+			unicast: class&unicastResponse != 0,
+		})
+		off = next + 4
+	}
+	return id, questions, nil
+}
+
+// joinGroup joins the mDNS multicast group on all multicast capable
+// interfaces, not just the one of the default route, and sets the TTL
+// which RFC 6762, section 11 requires.
+func joinGroup(conn *net.UDPConn) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return
+	}
+	ifaces, _ := net.Interfaces()
+	raw.Control(func(fd uintptr) {
+		unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MULTICAST_TTL, 255)
+		for _, iface := range ifaces {
+			if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
+				continue
+			}
+			// fails with EADDRINUSE for the interface which
+			// net.ListenMulticastUDP already joined
+			unix.SetsockoptIPMreqn(int(fd), unix.IPPROTO_IP, unix.IP_ADD_MEMBERSHIP, &unix.IPMreqn{
+				Multiaddr: [4]byte{224, 0, 0, 251},
+				Ifindex:   int32(iface.Index),
+			})
+		}
+	})
+}
+
+// Advertise announces svc and answers queries for it until ctx is done, when
+// it sends goodbye announcements. It requires the mDNS port, i.e. no other
+// mDNS responder may be running.
+func Advertise(ctx context.Context, svc Service) error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	joinGroup(conn)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// RFC 6762, section 8.3: announce at least twice, one second apart
+		announce := message(false, 0, nil, svc.records(false), nil)
+		conn.WriteTo(announce, group)
+		select {
+		case <-time.After(time.Second):
+			conn.WriteTo(announce, group)
+		case <-ctx.Done():
+		case <-done:
+			return
+		}
+		select {
+		case <-ctx.Done():
+			conn.WriteTo(message(false, 0, nil, svc.records(true), nil), group)
+			conn.Close() // unblocks ReadFromUDP
+		case <-done:
+		}
+	}()
+	buf := make([]byte, maxMsg)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		id, questions, err := parseQuery(buf[:n])
+		if err != nil {
+			continue
+		}
+		answers, additionals := svc.answers(questions)
+		if len(answers) == 0 {
+			continue
+		}
+		if from.Port != port {
+			// legacy unicast query, e.g. from a stub resolver
+			conn.WriteTo(message(true, id, questions, answers, additionals), from)
+			continue
+		}
+		unicast := true
+		for _, q := range questions {
+			unicast = unicast && q.unicast
+		}
+		if unicast {
+			conn.WriteTo(message(false, 0, nil, answers, additionals), from)
+		} else {
+			conn.WriteTo(message(false, 0, nil, answers, additionals), group)
+		}
+	}
+}
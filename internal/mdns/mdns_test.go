@@ -0,0 +1,130 @@
+package mdns
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[0:], 0x1234)
+	binary.BigEndian.PutUint16(msg[4:], 2)
+	msg = appendName(msg, []string{"_fbstatus", "_tcp", "local"})
+	msg = append(msg, 0, typePTR, 0x80, classIN) // QU bit set
+	// scan2drive.local, with local compressed (pointing into the first name)
+	msg = append(msg, 10)
+	msg = append(msg, "scan2drive"...)
+	msg = append(msg, 0xc0, byte(12+1+len("_fbstatus")+1+len("_tcp")))
+	msg = append(msg, 0, typeA, 0, classIN)
+
+	id, questions, err := parseQuery(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 0x1234 {
+		t.Errorf("parseQuery(): id = %#x, want 0x1234", id)
+	}
+	want := []question{
+		{name: []string{"_fbstatus", "_tcp", "local"}, typ: typePTR, unicast: true},
+		{name: []string{"scan2drive", "local"}, typ: typeA},
+	}
+	if !reflect.DeepEqual(questions, want) {
+		t.Errorf("parseQuery() = %+v, want %+v", questions, want)
+	}
+
+	// a compression pointer loop must not hang
+	loop := make([]byte, 12)
+	binary.BigEndian.PutUint16(loop[4:], 1)
+	loop = append(loop, 0xc0, 12)
+	if _, _, err := parseQuery(loop); err == nil {
+		t.Errorf("parseQuery(pointer loop) succeeded unexpectedly")
+	}
+}
+
+func TestAnswers(t *testing.T) {
+	svc := &Service{
+		Instance: "scan2drive",
+		Service:  "_fbstatus._tcp",
+		Host:     "scan2drive",
+		Port:     8318,
+		TXT:      []string{"status=http://scan2drive.local:8318/status.json"},
+		Addrs: func() []net.IP {
+			return []net.IP{net.ParseIP("10.0.0.5")}
+		},
+	}
+	type rr struct {
+		name string
+		typ  uint16
+	}
+	summarize := func(records []record) []rr {
+		var s []rr
+		for _, r := range records {
+			name := ""
+			for _, label := range r.name {
+				name += label + "."
+			}
+			s = append(s, rr{name, r.typ})
+		}
+		return s
+	}
+
+	// browsing for the service returns the instance and everything needed
+	// to connect to it
+	answers, additionals := svc.answers([]question{
+		{name: []string{"_FBSTATUS", "_tcp", "local"}, typ: typePTR},
+	})
+	if got, want := summarize(answers), []rr{{"_fbstatus._tcp.local.", typePTR}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("answers = %v, want %v", got, want)
+	}
+	want := []rr{
+		{"scan2drive._fbstatus._tcp.local.", typeSRV},
+		{"scan2drive._fbstatus._tcp.local.", typeTXT},
+		{"scan2drive.local.", typeA},
+	}
+	if got := summarize(additionals); !reflect.DeepEqual(got, want) {
+		t.Errorf("additionals = %v, want %v", got, want)
+	}
+
+	answers, additionals = svc.answers([]question{
+		{name: []string{"scan2drive", "local"}, typ: typeA},
+	})
+	if got, want := summarize(answers), []rr{{"scan2drive.local.", typeA}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("answers = %v, want %v", got, want)
+	}
+	if len(additionals) != 0 {
+		t.Errorf("additionals = %v, want none", summarize(additionals))
+	}
+
+	if answers, _ := svc.answers([]question{{name: []string{"other", "local"}, typ: typeA}}); len(answers) != 0 {
+		t.Errorf("answers for other host = %v, want none", summarize(answers))
+	}
+}
+
+func TestMessage(t *testing.T) {
+	r := record{name: []string{"scan2drive", "local"}, typ: typeA, flush: true, ttl: hostTTL, data: []byte{10, 0, 0, 5}}
+	q := question{name: []string{"scan2drive", "local"}, typ: typeA}
+
+	msg := message(false, 0, nil, []record{r}, nil)
+	// header, name, type, class, TTL, data length, data
+	if want := 12 + 18 + 2 + 2 + 4 + 2 + 4; len(msg) != want {
+		t.Fatalf("len(message()) = %d, want %d", len(msg), want)
+	}
+	if class := binary.BigEndian.Uint16(msg[12+18+2:]); class != classIN|cacheFlush {
+		t.Errorf("class = %#x, want %#x", class, classIN|cacheFlush)
+	}
+
+	// legacy unicast responses repeat the question, without cache flush
+	msg = message(true, 0x1234, []question{q}, []record{r}, nil)
+	if id := binary.BigEndian.Uint16(msg); id != 0x1234 {
+		t.Errorf("id = %#x, want 0x1234", id)
+	}
+	off := 12 + 18 + 4 + 18
+	if class := binary.BigEndian.Uint16(msg[off+2:]); class != classIN {
+		t.Errorf("class = %#x, want %#x", class, classIN)
+	}
+	if ttl := binary.BigEndian.Uint32(msg[off+4:]); ttl != legacyTTL {
+		t.Errorf("ttl = %d, want %d", ttl, legacyTTL)
+	}
+}
@@ -0,0 +1,288 @@
+// Package mqtt implements a minimal MQTT 3.1.1 client, sufficient for
+// subscribing to and publishing QoS 0 messages.
+//
+// See https://docs.oasis-open.org/mqtt/mqtt/v3.1.1/mqtt-v3.1.1.html
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Control packet types.
+const (
+	typeConnect    = 1
+	typeConnack    = 2
+	typePublish    = 3
+	typeSubscribe  = 8
+	typeSuback     = 9
+	typePingreq    = 12
+	typePingresp   = 13
+	typeDisconnect = 14
+)
+
+// Options configures the connection to the broker.
+type Options struct {
+	ClientID  string
+	Username  string // optional
+	Password  string // optional
+	KeepAlive time.Duration
+}
+
+// Message is a received PUBLISH packet.
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// Client is a connection to an MQTT broker.
+type Client struct {
+	conn      net.Conn
+	r         *bufio.Reader
+	keepAlive time.Duration
+	done      chan struct{}
+
+	mu       sync.Mutex // guards writes to conn and nextID
+	nextID   uint16
+	closeErr error
+	closed   bool
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// appendPacket appends a packet with the specified fixed header byte and
+// body, encoding the remaining length as per section 2.2.3.
+func appendPacket(b []byte, header byte, body []byte) []byte {
+	b = append(b, header)
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(b, body...)
+}
+
+// readPacket reads one control packet and returns its fixed header byte and
+// body.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var n, multiplier int = 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n += int(digit&0x7f) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// Dial connects to the broker at addr (host:port) and completes the MQTT
+// handshake.
+func Dial(ctx context.Context, addr string, opts Options) (*Client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c := &Client{
+		conn:      conn,
+		r:         bufio.NewReader(conn),
+		keepAlive: opts.KeepAlive,
+		done:      make(chan struct{}),
+	}
+	if err := c.connect(opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	if c.keepAlive > 0 {
+		go c.ping()
+	}
+	return c, nil
+}
+
+func (c *Client) connect(opts Options) error {
+	flags := byte(0x02) // clean session
+	if opts.Username != "" {
+		flags |= 0x80
+	}
+	if opts.Password != "" {
+		flags |= 0x40
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, 4 /* protocol level 3.1.1 */, flags)
+	body = appendUint16(body, uint16(opts.KeepAlive/time.Second))
+	body = appendString(body, opts.ClientID)
+	if opts.Username != "" {
+		body = appendString(body, opts.Username)
+	}
+	if opts.Password != "" {
+		body = appendString(body, opts.Password)
+	}
+	if _, err := c.conn.Write(appendPacket(nil, typeConnect<<4, body)); err != nil {
+		return err
+	}
+	header, ack, err := readPacket(c.r)
+	if err != nil {
+		return err
+	}
+	if header>>4 != typeConnack || len(ack) != 2 {
+		return fmt.Errorf("unexpected packet %#x, expected CONNACK", header)
+	}
+	if code := ack[1]; code != 0 {
+		return fmt.Errorf("connection refused by broker: return code %d", code)
+	}
+	return nil
+}
+
+func (c *Client) write(packet []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(packet)
+	return err
+}
+
+// ping sends PINGREQ packets so that the broker does not consider the
+// connection dead while no messages are published.
+func (c *Client) ping() {
+	ticker := time.NewTicker(c.keepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.write(appendPacket(nil, typePingreq<<4, nil)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Subscribe subscribes to the specified topic filters with QoS 0.
+func (c *Client) Subscribe(topics ...string) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.mu.Unlock()
+	body := appendUint16(nil, id)
+	for _, topic := range topics {
+		body = appendString(body, topic)
+		body = append(body, 0 /* QoS */)
+	}
+	return c.write(appendPacket(nil, typeSubscribe<<4|0x02, body))
+}
+
+// Publish publishes payload to topic with QoS 0.
+func (c *Client) Publish(topic string, payload []byte, retain bool) error {
+	header := byte(typePublish << 4)
+	if retain {
+		header |= 0x01
+	}
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	return c.write(appendPacket(nil, header, body))
+}
+
+// ReadMessage returns the next message published to one of the subscribed
+// topics. Other packets (SUBACK, PINGRESP) are processed transparently.
+func (c *Client) ReadMessage() (Message, error) {
+	for {
+		if c.keepAlive > 0 {
+			// The broker responds to our PINGREQs, so at least one packet
+			// must arrive within the keep alive interval.
+			c.conn.SetReadDeadline(time.Now().Add(c.keepAlive * 3 / 2))
+		}
+		header, body, err := readPacket(c.r)
+		if err != nil {
+			return Message{}, err
+		}
+		switch header >> 4 {
+		case typeSuback:
+			if len(body) > 2 {
+				for _, code := range body[2:] {
+					if code == 0x80 {
+						return Message{}, errors.New("subscription refused by broker")
+					}
+				}
+			}
+		case typePingresp:
+		case typePublish:
+			if len(body) < 2 {
+				return Message{}, errors.New("malformed PUBLISH packet")
+			}
+			n := int(binary.BigEndian.Uint16(body))
+			if len(body) < 2+n {
+				return Message{}, errors.New("malformed PUBLISH packet")
+			}
+			topic, payload := string(body[2:2+n]), body[2+n:]
+			if qos := header >> 1 & 3; qos > 0 {
+				// skip packet identifier (we subscribe with QoS 0, so the
+				// broker should never send these)
+				if len(payload) < 2 {
+					return Message{}, errors.New("malformed PUBLISH packet")
+				}
+				payload = payload[2:]
+			}
+			return Message{
+				Topic:   topic,
+				Payload: payload,
+				Retain:  header&0x01 != 0,
+			}, nil
+		}
+	}
+}
+
+// Close disconnects from the broker.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return c.closeErr
+	}
+	c.closed = true
+	close(c.done)
+	c.conn.Write(appendPacket(nil, typeDisconnect<<4, nil))
+	c.closeErr = c.conn.Close()
+	return c.closeErr
+}
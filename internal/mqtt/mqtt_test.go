@@ -0,0 +1,75 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeBroker accepts one connection, acknowledges the CONNECT and SUBSCRIBE
+// packets and then echoes the first PUBLISH packet back to the client.
+func fakeBroker(t *testing.T, ln net.Listener) {
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch header >> 4 {
+		case typeConnect:
+			if !bytes.HasPrefix(body, []byte("\x00\x04MQTT\x04")) {
+				t.Errorf("unexpected CONNECT body %q", body)
+			}
+			conn.Write(appendPacket(nil, typeConnack<<4, []byte{0, 0}))
+		case typeSubscribe:
+			conn.Write(appendPacket(nil, typeSuback<<4, append(body[:2:2], 0)))
+		case typePublish:
+			conn.Write(appendPacket(nil, header, body))
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go fakeBroker(t, ln)
+
+	ctx, canc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer canc()
+	c, err := Dial(ctx, ln.Addr().String(), Options{
+		ClientID:  "fbstatus-test",
+		KeepAlive: 30 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Subscribe("home/#"); err != nil {
+		t.Fatal(err)
+	}
+	// A payload larger than 127 bytes exercises the variable length
+	// encoding of the remaining length.
+	payload := bytes.Repeat([]byte("x"), 300)
+	if err := c.Publish("home/temperature", payload, true); err != nil {
+		t.Fatal(err)
+	}
+	msg, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Topic != "home/temperature" || !bytes.Equal(msg.Payload, payload) || !msg.Retain {
+		t.Errorf("ReadMessage() = %q (retain=%v), want topic home/temperature with %d byte payload", msg.Topic, msg.Retain, len(payload))
+	}
+}
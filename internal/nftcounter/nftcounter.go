@@ -0,0 +1,155 @@
+// Package nftcounter reads named nftables counter objects via netlink, like
+// “nft list counter <family> <table> <name>” does.
+package nftcounter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// nftObjectCounter is NFT_OBJECT_COUNTER from
+// include/uapi/linux/netfilter/nf_tables.h, which golang.org/x/sys/unix does
+// not define.
+const nftObjectCounter = 1
+
+const nlaTypeMask = 0x3fff // strips NLA_F_NESTED and NLA_F_NET_BYTEORDER
+
+// Families maps the nft family names to their protocol numbers.
+var Families = map[string]uint8{
+	"ip":     unix.NFPROTO_IPV4,
+	"ip6":    unix.NFPROTO_IPV6,
+	"inet":   unix.NFPROTO_INET,
+	"arp":    unix.NFPROTO_ARP,
+	"bridge": unix.NFPROTO_BRIDGE,
+	"netdev": unix.NFPROTO_NETDEV,
+}
+
+// Counter is the value of a counter object.
+type Counter struct {
+	Packets uint64
+	Bytes   uint64
+}
+
+func align(n int) int {
+	return (n + unix.NLA_ALIGNTO - 1) &^ (unix.NLA_ALIGNTO - 1)
+}
+
+func appendAttr(b []byte, typ uint16, data []byte) []byte {
+	var hdr [unix.SizeofNlAttr]byte
+	binary.LittleEndian.PutUint16(hdr[0:], uint16(unix.SizeofNlAttr+len(data)))
+	binary.LittleEndian.PutUint16(hdr[2:], typ)
+	b = append(b, hdr[:]...)
+	b = append(b, data...)
+	for len(b)%unix.NLA_ALIGNTO != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// parseAttrs returns the netlink attributes in b by type.
+func parseAttrs(b []byte) (map[uint16][]byte, error) {
+	attrs := make(map[uint16][]byte)
+	for len(b) >= unix.SizeofNlAttr {
+		n := int(binary.LittleEndian.Uint16(b[0:]))
+		typ := binary.LittleEndian.Uint16(b[2:]) & nlaTypeMask
+		if n < unix.SizeofNlAttr || n > len(b) {
+			return nil, errors.New("malformed netlink attribute")
+		}
+		attrs[typ] = b[unix.SizeofNlAttr:n]
+		if align(n) > len(b) {
+			break
+		}
+		b = b[align(n):]
+	}
+	return attrs, nil
+}
+
+func request(family uint8, table, name string, seq uint32) []byte {
+	body := []byte{family, unix.NFNETLINK_V0, 0, 0} // struct nfgenmsg
+	body = appendAttr(body, unix.NFTA_OBJ_TABLE, append([]byte(table), 0))
+	body = appendAttr(body, unix.NFTA_OBJ_NAME, append([]byte(name), 0))
+	typ := make([]byte, 4)
+	binary.BigEndian.PutUint32(typ, nftObjectCounter)
+	body = appendAttr(body, unix.NFTA_OBJ_TYPE, typ)
+
+	msg := make([]byte, unix.NLMSG_HDRLEN, unix.NLMSG_HDRLEN+len(body))
+	binary.LittleEndian.PutUint32(msg[0:], uint32(unix.NLMSG_HDRLEN+len(body)))
+	binary.LittleEndian.PutUint16(msg[4:], unix.NFNL_SUBSYS_NFTABLES<<8|unix.NFT_MSG_GETOBJ)
+	binary.LittleEndian.PutUint16(msg[6:], unix.NLM_F_REQUEST)
+	binary.LittleEndian.PutUint32(msg[8:], seq)
+	return append(msg, body...)
+}
+
+// parseObj extracts the counter values from the body of a NFT_MSG_NEWOBJ
+// message.
+func parseObj(body []byte) (Counter, error) {
+	if len(body) < 4 {
+		return Counter{}, errors.New("short NFT_MSG_NEWOBJ message")
+	}
+	attrs, err := parseAttrs(body[4:]) // skip struct nfgenmsg
+	if err != nil {
+		return Counter{}, err
+	}
+	data, ok := attrs[unix.NFTA_OBJ_DATA]
+	if !ok {
+		return Counter{}, errors.New("NFTA_OBJ_DATA missing")
+	}
+	counter, err := parseAttrs(data)
+	if err != nil {
+		return Counter{}, err
+	}
+	var c Counter
+	if b := counter[unix.NFTA_COUNTER_PACKETS]; len(b) == 8 {
+		c.Packets = binary.BigEndian.Uint64(b)
+	}
+	if b := counter[unix.NFTA_COUNTER_BYTES]; len(b) == 8 {
+		c.Bytes = binary.BigEndian.Uint64(b)
+	}
+	return c, nil
+}
+
+// Get returns the current value of the named counter object.
+func Get(family uint8, table, name string) (Counter, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_NETFILTER)
+	if err != nil {
+		return Counter{}, os.NewSyscallError("socket", err)
+	}
+	defer unix.Close(fd)
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return Counter{}, os.NewSyscallError("bind", err)
+	}
+	const seq = 1
+	if err := unix.Sendto(fd, request(family, table, name, seq), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return Counter{}, os.NewSyscallError("sendto", err)
+	}
+	buf := make([]byte, os.Getpagesize())
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return Counter{}, os.NewSyscallError("recvfrom", err)
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return Counter{}, err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != seq {
+				continue
+			}
+			if m.Header.Type == unix.NLMSG_ERROR {
+				if len(m.Data) >= 4 {
+					if errno := int32(binary.LittleEndian.Uint32(m.Data)); errno != 0 {
+						return Counter{}, fmt.Errorf("counter %s %s: %v", table, name, unix.Errno(-errno))
+					}
+				}
+				continue
+			}
+			return parseObj(m.Data)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package nftcounter
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseObj(t *testing.T) {
+	be64 := func(v uint64) []byte {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, v)
+		return b
+	}
+	var counter []byte
+	counter = appendAttr(counter, unix.NFTA_COUNTER_BYTES, be64(123456))
+	counter = appendAttr(counter, unix.NFTA_COUNTER_PACKETS, be64(789))
+
+	body := []byte{unix.NFPROTO_INET, unix.NFNETLINK_V0, 0, 0}
+	body = appendAttr(body, unix.NFTA_OBJ_TABLE, []byte("filter\x00"))
+	body = appendAttr(body, unix.NFTA_OBJ_NAME, []byte("dropped\x00"))
+	body = appendAttr(body, unix.NFTA_OBJ_DATA|unix.NLA_F_NESTED, counter)
+
+	got, err := parseObj(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Counter{Packets: 789, Bytes: 123456}); got != want {
+		t.Errorf("parseObj() = %+v, want %+v", got, want)
+	}
+}
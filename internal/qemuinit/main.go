@@ -0,0 +1,161 @@
+// Program qemuinit is the init process of the QEMU integration test (see
+// qemu_test.go): it starts fbstatus, waits for it to draw and prints the
+// framebuffer contents as a base64-encoded PNG to the console, then powers
+// off the VM.
+//
+// Arguments after -- are passed to fbstatus, e.g. via the kernel command line
+//
+//	console=ttyS0 -- -wait=10s -- -pages=status
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/gokrazy/fbstatus/internal/fb"
+	"golang.org/x/sys/unix"
+)
+
+// The QEMU integration test parses these lines from the console output.
+const (
+	beginMarker = "fbstatus-qemu: begin png"
+	endMarker   = "fbstatus-qemu: end png"
+	errorMarker = "fbstatus-qemu: error: "
+)
+
+var (
+	fbstatusPath = flag.String("fbstatus",
+		"/fbstatus",
+		"path to the fbstatus binary")
+
+	wait = flag.Duration("wait",
+		10*time.Second,
+		"how long fbstatus runs before the framebuffer is captured")
+)
+
+func mount() error {
+	for _, m := range []struct {
+		source, target, fstype string
+	}{
+		{"proc", "/proc", "proc"},
+		{"sysfs", "/sys", "sysfs"},
+		{"devtmpfs", "/dev", "devtmpfs"},
+		{"tmpfs", "/tmp", "tmpfs"},
+	} {
+		if err := os.MkdirAll(m.target, 0755); err != nil {
+			return err
+		}
+		if err := unix.Mount(m.source, m.target, m.fstype, 0, ""); err != nil {
+			return fmt.Errorf("mount %s: %v", m.target, err)
+		}
+	}
+	return nil
+}
+
+// waitForFramebuffer waits until the kernel has created /dev/fb0, which can
+// take a moment after boot for DRM drivers like virtio-gpu.
+func waitForFramebuffer(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := os.Stat("/dev/fb0")
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("no framebuffer after %v: %v", timeout, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// capture returns the contents of /dev/fb0 as PNG.
+func capture() ([]byte, error) {
+	dev, err := fb.Open("/dev/fb0")
+	if err != nil {
+		return nil, err
+	}
+	defer dev.Close()
+	if info, err := dev.VarScreeninfo(); err == nil {
+		fmt.Fprintf(os.Stderr, "qemuinit: framebuffer screeninfo: %+v\n", info)
+	}
+	fbimg, err := dev.Image()
+	if err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(fbimg.Bounds())
+	draw.Draw(img, img.Rect, fbimg, img.Rect.Min, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func run() error {
+	if err := mount(); err != nil {
+		return err
+	}
+	if err := waitForFramebuffer(30 * time.Second); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(*fbstatusPath, flag.Args()...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		if err == nil {
+			err = errors.New("exited")
+		}
+		return fmt.Errorf("fbstatus: %v", err)
+	case <-time.After(*wait):
+	}
+
+	b, err := capture()
+	cmd.Process.Kill()
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprintln(w, beginMarker)
+	enc := base64.StdEncoding.EncodeToString(b)
+	for len(enc) > 0 {
+		n := 76
+		if n > len(enc) {
+			n = len(enc)
+		}
+		fmt.Fprintln(w, enc[:n])
+		enc = enc[n:]
+	}
+	fmt.Fprintln(w, endMarker)
+	return w.Flush()
+}
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Println(errorMarker + err.Error())
+	}
+	unix.Sync()
+	// as PID 1, exiting would panic the kernel
+	if err := unix.Reboot(unix.LINUX_REBOOT_CMD_POWER_OFF); err != nil {
+		fmt.Fprintf(os.Stderr, "qemuinit: power off: %v\n", err)
+		select {}
+	}
+}
@@ -0,0 +1,462 @@
+// Package snmp implements a minimal SNMPv2c client, sufficient for
+// requesting the values of OIDs via GetRequest.
+//
+// See RFC 3416 (protocol operations) and X.690 (BER encoding).
+package snmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BER and SNMP tags.
+const (
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagNull        = 0x05
+	tagOID         = 0x06
+	tagSequence    = 0x30
+
+	tagIPAddress = 0x40
+	tagCounter32 = 0x41
+	tagGauge32   = 0x42
+	tagTimeTicks = 0x43
+	tagOpaque    = 0x44
+	tagCounter64 = 0x46
+
+	tagNoSuchObject   = 0x80
+	tagNoSuchInstance = 0x81
+	tagEndOfMibView   = 0x82
+
+	tagGetRequest = 0xa0
+	tagResponse   = 0xa2
+)
+
+const version2c = 1
+
+// Application types of variable values.
+type (
+	Counter32 uint32
+	Gauge32   uint32
+	TimeTicks uint32 // hundredths of a second
+	Counter64 uint64
+)
+
+// ErrNoSuchObject is returned for OIDs which the agent does not know.
+var ErrNoSuchObject = errors.New("no such object")
+
+// A Variable is one OID and its value, which is one of int64, string (for
+// OCTET STRING), net.IP, Counter32, Gauge32, TimeTicks, Counter64 or an OID
+// in dotted notation (for OBJECT IDENTIFIER, as string). Err is set instead
+// if the agent has no value for the OID.
+type Variable struct {
+	OID   string
+	Value interface{}
+	Err   error
+}
+
+func appendLength(b []byte, n int) []byte {
+	if n < 0x80 {
+		return append(b, byte(n))
+	}
+	var buf []byte
+	for ; n > 0; n >>= 8 {
+		buf = append([]byte{byte(n)}, buf...)
+	}
+	b = append(b, 0x80|byte(len(buf)))
+	return append(b, buf...)
+}
+
+func appendTLV(b []byte, tag byte, value []byte) []byte {
+	b = append(b, tag)
+	b = appendLength(b, len(value))
+	return append(b, value...)
+}
+
+func appendInteger(b []byte, tag byte, v int64) []byte {
+	var buf []byte
+	for {
+		buf = append([]byte{byte(v)}, buf...)
+		// stop once the remaining value is fully represented by the sign
+		// bit of the most significant byte
+		if (v >= -0x80 && v < 0x80) || len(buf) == 8 {
+			break
+		}
+		v >>= 8
+	}
+	return appendTLV(b, tag, buf)
+}
+
+func appendUnsigned(b []byte, tag byte, v uint64) []byte {
+	var buf []byte
+	for {
+		buf = append([]byte{byte(v)}, buf...)
+		v >>= 8
+		if v == 0 {
+			break
+		}
+	}
+	if buf[0]&0x80 != 0 {
+		// prevent interpretation as a negative number
+		buf = append([]byte{0}, buf...)
+	}
+	return appendTLV(b, tag, buf)
+}
+
+func appendBase128(b []byte, v uint64) []byte {
+	var buf []byte
+	buf = append(buf, byte(v&0x7f))
+	for v >>= 7; v > 0; v >>= 7 {
+		buf = append([]byte{0x80 | byte(v&0x7f)}, buf...)
+	}
+	return append(b, buf...)
+}
+
+// encodeOID encodes an OID in dotted notation (with optional leading dot).
+func encodeOID(oid string) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(oid, "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID %q", oid)
+	}
+	arcs := make([]uint64, len(parts))
+	for idx, part := range parts {
+		arc, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OID %q: %v", oid, err)
+		}
+		arcs[idx] = arc
+	}
+	if arcs[0] > 2 || (arcs[0] < 2 && arcs[1] >= 40) {
+		return nil, fmt.Errorf("invalid OID %q", oid)
+	}
+	b := appendBase128(nil, arcs[0]*40+arcs[1])
+	for _, arc := range arcs[2:] {
+		b = appendBase128(b, arc)
+	}
+	return b, nil
+}
+
+func decodeOID(b []byte) (string, error) {
+	var arcs []string
+	var v uint64
+	for idx, c := range b {
+		v = v<<7 | uint64(c&0x7f)
+		if c&0x80 != 0 {
+			if idx == len(b)-1 {
+				return "", errors.New("truncated OID")
+			}
+			continue
+		}
+		if arcs == nil {
+			first := v / 40
+			if first > 2 {
+				first = 2
+			}
+			arcs = append(arcs,
+				strconv.FormatUint(first, 10),
+				strconv.FormatUint(v-first*40, 10))
+		} else {
+			arcs = append(arcs, strconv.FormatUint(v, 10))
+		}
+		v = 0
+	}
+	if arcs == nil {
+		return "", errors.New("empty OID")
+	}
+	return strings.Join(arcs, "."), nil
+}
+
+// EncodeGetRequest encodes an SNMPv2c GetRequest message.
+func EncodeGetRequest(community string, requestID int32, oids []string) ([]byte, error) {
+	var varbinds []byte
+	for _, oid := range oids {
+		enc, err := encodeOID(oid)
+		if err != nil {
+			return nil, err
+		}
+		varbind := appendTLV(nil, tagOID, enc)
+		varbind = appendTLV(varbind, tagNull, nil)
+		varbinds = appendTLV(varbinds, tagSequence, varbind)
+	}
+	return encodeMessage(community, tagGetRequest, requestID, 0, varbinds), nil
+}
+
+func encodeMessage(community string, pduType byte, requestID int32, errorStatus int64, varbinds []byte) []byte {
+	var pdu []byte
+	pdu = appendInteger(pdu, tagInteger, int64(requestID))
+	pdu = appendInteger(pdu, tagInteger, errorStatus)
+	pdu = appendInteger(pdu, tagInteger, 0) // error-index
+	pdu = appendTLV(pdu, tagSequence, varbinds)
+
+	var msg []byte
+	msg = appendInteger(msg, tagInteger, version2c)
+	msg = appendTLV(msg, tagOctetString, []byte(community))
+	msg = appendTLV(msg, pduType, pdu)
+	return appendTLV(nil, tagSequence, msg)
+}
+
+// EncodeResponse encodes an SNMPv2c Response message with the specified
+// variables, as an agent would send it. It is used for testing.
+func EncodeResponse(community string, requestID int32, vars []Variable) ([]byte, error) {
+	var varbinds []byte
+	for _, v := range vars {
+		enc, err := encodeOID(v.OID)
+		if err != nil {
+			return nil, err
+		}
+		varbind := appendTLV(nil, tagOID, enc)
+		switch x := v.Value.(type) {
+		case nil:
+			tag := byte(tagNull)
+			if errors.Is(v.Err, ErrNoSuchObject) {
+				tag = tagNoSuchObject
+			}
+			varbind = appendTLV(varbind, tag, nil)
+		case int64:
+			varbind = appendInteger(varbind, tagInteger, x)
+		case string:
+			varbind = appendTLV(varbind, tagOctetString, []byte(x))
+		case net.IP:
+			varbind = appendTLV(varbind, tagIPAddress, x.To4())
+		case Counter32:
+			varbind = appendUnsigned(varbind, tagCounter32, uint64(x))
+		case Gauge32:
+			varbind = appendUnsigned(varbind, tagGauge32, uint64(x))
+		case TimeTicks:
+			varbind = appendUnsigned(varbind, tagTimeTicks, uint64(x))
+		case Counter64:
+			varbind = appendUnsigned(varbind, tagCounter64, uint64(x))
+		default:
+			return nil, fmt.Errorf("unsupported value type %T", v.Value)
+		}
+		varbinds = appendTLV(varbinds, tagSequence, varbind)
+	}
+	return encodeMessage(community, tagResponse, requestID, 0, varbinds), nil
+}
+
+// readTLV splits the first TLV off b.
+func readTLV(b []byte) (tag byte, value, rest []byte, err error) {
+	if len(b) < 2 {
+		return 0, nil, nil, errors.New("truncated message")
+	}
+	tag = b[0]
+	n := int(b[1])
+	b = b[2:]
+	if n&0x80 != 0 {
+		octets := n & 0x7f
+		if octets == 0 || octets > 4 || len(b) < octets {
+			return 0, nil, nil, errors.New("invalid length")
+		}
+		n = 0
+		for _, c := range b[:octets] {
+			n = n<<8 | int(c)
+		}
+		b = b[octets:]
+	}
+	if n < 0 || len(b) < n {
+		return 0, nil, nil, errors.New("truncated message")
+	}
+	return tag, b[:n], b[n:], nil
+}
+
+func expectTLV(b []byte, want byte) (value, rest []byte, err error) {
+	tag, value, rest, err := readTLV(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tag != want {
+		return nil, nil, fmt.Errorf("unexpected tag 0x%02x, want 0x%02x", tag, want)
+	}
+	return value, rest, nil
+}
+
+func decodeInteger(b []byte) (int64, error) {
+	if len(b) == 0 || len(b) > 8 {
+		return 0, fmt.Errorf("invalid integer length %d", len(b))
+	}
+	v := int64(int8(b[0])) // sign extension
+	for _, c := range b[1:] {
+		v = v<<8 | int64(c)
+	}
+	return v, nil
+}
+
+func decodeUnsigned(b []byte) (uint64, error) {
+	if len(b) == 0 || len(b) > 9 || (len(b) == 9 && b[0] != 0) {
+		return 0, fmt.Errorf("invalid unsigned integer length %d", len(b))
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func decodeValue(tag byte, b []byte) (interface{}, error) {
+	switch tag {
+	case tagInteger:
+		return decodeInteger(b)
+	case tagOctetString, tagOpaque:
+		return string(b), nil
+	case tagOID:
+		return decodeOID(b)
+	case tagIPAddress:
+		return net.IP(append([]byte(nil), b...)), nil
+	}
+	v, err := decodeUnsigned(b)
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case tagCounter32:
+		return Counter32(v), nil
+	case tagGauge32:
+		return Gauge32(v), nil
+	case tagTimeTicks:
+		return TimeTicks(v), nil
+	case tagCounter64:
+		return Counter64(v), nil
+	}
+	return nil, fmt.Errorf("unsupported value type 0x%02x", tag)
+}
+
+// errorStatusText names the error-status values of RFC 3416.
+var errorStatusText = []string{
+	"noError", "tooBig", "noSuchName", "badValue", "readOnly", "genErr",
+	"noAccess", "wrongType", "wrongLength", "wrongEncoding", "wrongValue",
+	"noCreation", "inconsistentValue", "resourceUnavailable", "commitFailed",
+	"undoFailed", "authorizationError", "notWritable", "inconsistentName",
+}
+
+// DecodeResponse decodes an SNMPv2c Response message.
+func DecodeResponse(b []byte) (requestID int32, vars []Variable, err error) {
+	msg, _, err := expectTLV(b, tagSequence)
+	if err != nil {
+		return 0, nil, err
+	}
+	version, msg, err := expectTLV(msg, tagInteger)
+	if err != nil {
+		return 0, nil, err
+	}
+	if v, _ := decodeInteger(version); v != version2c {
+		return 0, nil, fmt.Errorf("unexpected SNMP version %d", v)
+	}
+	_, msg, err = expectTLV(msg, tagOctetString) // community
+	if err != nil {
+		return 0, nil, err
+	}
+	pdu, _, err := expectTLV(msg, tagResponse)
+	if err != nil {
+		return 0, nil, err
+	}
+	var fields [3]int64 // request-id, error-status, error-index
+	for idx := range fields {
+		var value []byte
+		value, pdu, err = expectTLV(pdu, tagInteger)
+		if err != nil {
+			return 0, nil, err
+		}
+		if fields[idx], err = decodeInteger(value); err != nil {
+			return 0, nil, err
+		}
+	}
+	requestID = int32(fields[0])
+	if status := fields[1]; status != 0 {
+		text := strconv.FormatInt(status, 10)
+		if status > 0 && status < int64(len(errorStatusText)) {
+			text = errorStatusText[status]
+		}
+		return requestID, nil, fmt.Errorf("agent returned error %s (index %d)", text, fields[2])
+	}
+	varbinds, _, err := expectTLV(pdu, tagSequence)
+	if err != nil {
+		return 0, nil, err
+	}
+	for len(varbinds) > 0 {
+		var varbind []byte
+		varbind, varbinds, err = expectTLV(varbinds, tagSequence)
+		if err != nil {
+			return 0, nil, err
+		}
+		enc, rest, err := expectTLV(varbind, tagOID)
+		if err != nil {
+			return 0, nil, err
+		}
+		oid, err := decodeOID(enc)
+		if err != nil {
+			return 0, nil, err
+		}
+		tag, value, _, err := readTLV(rest)
+		if err != nil {
+			return 0, nil, err
+		}
+		v := Variable{OID: oid}
+		switch tag {
+		case tagNull, tagNoSuchObject, tagNoSuchInstance, tagEndOfMibView:
+			v.Err = ErrNoSuchObject
+		default:
+			if v.Value, err = decodeValue(tag, value); err != nil {
+				v.Err = err
+			}
+		}
+		vars = append(vars, v)
+	}
+	return requestID, vars, nil
+}
+
+// Get requests the values of oids from the agent at addr (host:port) using
+// community, retrying until ctx is done.
+func Get(ctx context.Context, addr, community string, oids []string) ([]Variable, error) {
+	requestID := rand.Int31()
+	req, err := EncodeGetRequest(community, requestID, oids)
+	if err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(10 * time.Second)
+	}
+	buf := make([]byte, 65535)
+	// SNMP uses UDP, so retransmit the request until a response arrives.
+	const retransmit = 2 * time.Second
+	for time.Now().Before(deadline) {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		readDeadline := time.Now().Add(retransmit)
+		if readDeadline.After(deadline) {
+			readDeadline = deadline
+		}
+		conn.SetReadDeadline(readDeadline)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				if ne, ok := err.(net.Error); ok && ne.Timeout() {
+					break // retransmit
+				}
+				return nil, err
+			}
+			id, vars, err := DecodeResponse(buf[:n])
+			if id != requestID {
+				continue // response to an earlier request
+			}
+			if err != nil {
+				return nil, err
+			}
+			return vars, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: no SNMP response (wrong community?)", addr)
+}
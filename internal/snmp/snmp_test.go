@@ -0,0 +1,142 @@
+package snmp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeGetRequest(t *testing.T) {
+	// as sent by snmpget -v2c -c public localhost sysDescr.0 (with request
+	// ID 1)
+	want := []byte{
+		0x30, 0x26, // SEQUENCE
+		0x02, 0x01, 0x01, // version: 2c
+		0x04, 0x06, 'p', 'u', 'b', 'l', 'i', 'c', // community
+		0xa0, 0x19, // GetRequest-PDU
+		0x02, 0x01, 0x01, // request-id
+		0x02, 0x01, 0x00, // error-status
+		0x02, 0x01, 0x00, // error-index
+		0x30, 0x0e, // variable-bindings
+		0x30, 0x0c, // VarBind
+		0x06, 0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, // 1.3.6.1.2.1.1.1.0
+		0x05, 0x00, // NULL
+	}
+	got, err := EncodeGetRequest("public", 1, []string{".1.3.6.1.2.1.1.1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("EncodeGetRequest() =\n% x\nwant\n% x", got, want)
+	}
+}
+
+func TestOID(t *testing.T) {
+	for _, oid := range []string{
+		"1.3.6.1.2.1.1.1.0",
+		"1.3.6.1.2.1.2.2.1.10.10101",
+		"1.3.6.1.4.1.318.1.1.1.2.2.1.0",
+		"2.999.3",
+	} {
+		enc, err := encodeOID(oid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeOID(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != oid {
+			t.Errorf("decodeOID(encodeOID(%q)) = %q", oid, got)
+		}
+	}
+	if _, err := encodeOID("1.3.six"); err == nil {
+		t.Errorf("encodeOID(1.3.six) unexpectedly succeeded")
+	}
+}
+
+func TestInteger(t *testing.T) {
+	for _, v := range []int64{0, 1, 127, 128, 255, 256, -1, -128, -129, 1 << 40, -1 << 63} {
+		_, value, _, err := readTLV(appendInteger(nil, tagInteger, v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeInteger(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != v {
+			t.Errorf("decodeInteger(appendInteger(%d)) = %d (% x)", v, got, value)
+		}
+	}
+}
+
+func TestResponse(t *testing.T) {
+	vars := []Variable{
+		{OID: "1.3.6.1.2.1.1.5.0", Value: "switch"},
+		{OID: "1.3.6.1.2.1.1.3.0", Value: TimeTicks(123456)},
+		{OID: "1.3.6.1.2.1.2.2.1.8.1", Value: int64(1)},
+		{OID: "1.3.6.1.2.1.2.2.1.10.1", Value: Counter32(4000000000)},
+		{OID: "1.3.6.1.2.1.31.1.1.1.6.1", Value: Counter64(1 << 60)},
+		{OID: "1.3.6.1.2.1.4.20.1.1.10.0.0.2", Value: net.IP{10, 0, 0, 2}},
+		{OID: "1.3.6.1.4.1.318.1.1.1.2.2.1.0", Value: Gauge32(100)},
+		{OID: "1.3.6.1.2.1.1.99.0", Err: ErrNoSuchObject},
+	}
+	b, err := EncodeResponse("public", 42, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, got, err := DecodeResponse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 42 {
+		t.Errorf("request ID = %d, want 42", id)
+	}
+	if !reflect.DeepEqual(got, vars) {
+		t.Errorf("DecodeResponse() =\n%+v\nwant\n%+v", got, vars)
+	}
+}
+
+func TestGet(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 65535)
+		for first := true; ; first = false {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if first {
+				continue // simulate a lost packet
+			}
+			// The request ID is the first INTEGER within the PDU, see
+			// TestEncodeGetRequest for the layout.
+			req := buf[:n]
+			pduStart := bytes.IndexByte(req, tagGetRequest)
+			_, value, _, _ := readTLV(req[pduStart+2:])
+			id, _ := decodeInteger(value)
+			resp, _ := EncodeResponse("public", int32(id), []Variable{
+				{OID: "1.3.6.1.2.1.1.5.0", Value: "switch"},
+			})
+			pc.WriteTo(resp, addr)
+		}
+	}()
+
+	ctx, canc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer canc()
+	vars, err := Get(ctx, pc.LocalAddr().String(), "public", []string{"1.3.6.1.2.1.1.5.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vars) != 1 || vars[0].Value != "switch" {
+		t.Errorf("Get() = %+v, want sysName switch", vars)
+	}
+}
@@ -0,0 +1,296 @@
+// Package v4l2 captures single frames from Video4Linux2 devices (e.g. USB
+// webcams) using memory-mapped streaming I/O, which all capture drivers
+// support.
+//
+// See https://docs.kernel.org/userspace-api/media/v4l/v4l2.html
+package v4l2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Constants from include/uapi/linux/videodev2.h.
+const (
+	bufTypeVideoCapture = 1
+	memoryMmap          = 1
+	fieldAny            = 0
+	capVideoCapture     = 0x00000001
+	capStreaming        = 0x04000000
+	capDeviceCaps       = 0x80000000
+)
+
+func fourcc(s string) uint32 {
+	return uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16 | uint32(s[3])<<24
+}
+
+var (
+	pixFmtMJPEG = fourcc("MJPG")
+	pixFmtYUYV  = fourcc("YUYV")
+)
+
+type capability struct {
+	Driver       [16]byte
+	Card         [32]byte
+	BusInfo      [32]byte
+	Version      uint32
+	Capabilities uint32
+	DeviceCaps   uint32
+	Reserved     [3]uint32
+}
+
+type pixFormat struct {
+	Width        uint32
+	Height       uint32
+	PixelFormat  uint32
+	Field        uint32
+	BytesPerLine uint32
+	SizeImage    uint32
+	Colorspace   uint32
+	Priv         uint32
+	Flags        uint32
+	YCbCrEnc     uint32
+	Quantization uint32
+	XferFunc     uint32
+}
+
+// format is struct v4l2_format. The union contains pointers (struct
+// v4l2_window), so it is pointer-aligned.
+type format struct {
+	Type uint32
+	Fmt  [200 / unsafe.Sizeof(uintptr(0))]uintptr
+}
+
+func (f *format) pix() *pixFormat {
+	return (*pixFormat)(unsafe.Pointer(&f.Fmt))
+}
+
+type requestBuffers struct {
+	Count        uint32
+	Type         uint32
+	Memory       uint32
+	Capabilities uint32
+	Flags        uint8
+	Reserved     [3]uint8
+}
+
+type timecode struct {
+	Type     uint32
+	Flags    uint32
+	Frames   uint8
+	Seconds  uint8
+	Minutes  uint8
+	Hours    uint8
+	Userbits [4]uint8
+}
+
+// buffer is struct v4l2_buffer, whose layout differs between 32-bit and
+// 64-bit architectures (struct timeval and the m union).
+type buffer struct {
+	Index     uint32
+	Type      uint32
+	BytesUsed uint32
+	Flags     uint32
+	Field     uint32
+	Timestamp unix.Timeval
+	Timecode  timecode
+	Sequence  uint32
+	Memory    uint32
+	M         uintptr // union of offset, userptr, planes and fd
+	Length    uint32
+	Reserved2 uint32
+	RequestFD int32
+}
+
+func ioc(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'V'<<8 | nr
+}
+
+const (
+	iocWrite = 1
+	iocRead  = 2
+)
+
+var (
+	vidiocQuerycap  = ioc(iocRead, 0, unsafe.Sizeof(capability{}))
+	vidiocSFmt      = ioc(iocRead|iocWrite, 5, unsafe.Sizeof(format{}))
+	vidiocReqbufs   = ioc(iocRead|iocWrite, 8, unsafe.Sizeof(requestBuffers{}))
+	vidiocQuerybuf  = ioc(iocRead|iocWrite, 9, unsafe.Sizeof(buffer{}))
+	vidiocQbuf      = ioc(iocRead|iocWrite, 15, unsafe.Sizeof(buffer{}))
+	vidiocDqbuf     = ioc(iocRead|iocWrite, 17, unsafe.Sizeof(buffer{}))
+	vidiocStreamon  = ioc(iocWrite, 18, unsafe.Sizeof(int32(0)))
+	vidiocStreamoff = ioc(iocWrite, 19, unsafe.Sizeof(int32(0)))
+)
+
+func ioctl(fd uintptr, req uintptr, arg unsafe.Pointer) error {
+	for {
+		_, _, eno := unix.Syscall(unix.SYS_IOCTL, fd, req, uintptr(arg))
+		if eno == unix.EINTR {
+			continue
+		}
+		if eno != 0 {
+			return eno
+		}
+		return nil
+	}
+}
+
+// numBuffers is the number of capture buffers to request.
+const numBuffers = 2
+
+// warmupFrames is the number of frames to discard before the captured one,
+// giving automatic exposure and white balance a chance to settle.
+const warmupFrames = 5
+
+// Capture opens device (e.g. /dev/video0), captures a single frame of
+// (approximately) the requested size and closes the device again, so that
+// other programs can use the camera in between captures. Motion JPEG is
+// preferred, YUYV is supported as a fallback.
+func Capture(device string, width, height int) (image.Image, error) {
+	f, err := os.OpenFile(device, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fd := f.Fd()
+
+	var caps capability
+	if err := ioctl(fd, vidiocQuerycap, unsafe.Pointer(&caps)); err != nil {
+		return nil, fmt.Errorf("VIDIOC_QUERYCAP: %v", err)
+	}
+	devCaps := caps.Capabilities
+	if devCaps&capDeviceCaps != 0 {
+		devCaps = caps.DeviceCaps
+	}
+	if devCaps&capVideoCapture == 0 || devCaps&capStreaming == 0 {
+		return nil, fmt.Errorf("%s is not a streaming video capture device", device)
+	}
+
+	var pix pixFormat
+	for _, pf := range []uint32{pixFmtMJPEG, pixFmtYUYV} {
+		vf := format{Type: bufTypeVideoCapture}
+		*vf.pix() = pixFormat{
+			Width:       uint32(width),
+			Height:      uint32(height),
+			PixelFormat: pf,
+			Field:       fieldAny,
+		}
+		if err := ioctl(fd, vidiocSFmt, unsafe.Pointer(&vf)); err != nil {
+			continue
+		}
+		// Drivers adjust the format to what they support instead of failing.
+		if vf.pix().PixelFormat == pf {
+			pix = *vf.pix()
+			break
+		}
+	}
+	if pix.PixelFormat == 0 {
+		return nil, fmt.Errorf("%s supports neither MJPEG nor YUYV", device)
+	}
+
+	req := requestBuffers{
+		Count:  numBuffers,
+		Type:   bufTypeVideoCapture,
+		Memory: memoryMmap,
+	}
+	if err := ioctl(fd, vidiocReqbufs, unsafe.Pointer(&req)); err != nil {
+		return nil, fmt.Errorf("VIDIOC_REQBUFS: %v", err)
+	}
+	defer func() {
+		// free the buffers
+		req := requestBuffers{Type: bufTypeVideoCapture, Memory: memoryMmap}
+		ioctl(fd, vidiocReqbufs, unsafe.Pointer(&req))
+	}()
+	mapped := make([][]byte, req.Count)
+	for idx := range mapped {
+		buf := buffer{
+			Index:  uint32(idx),
+			Type:   bufTypeVideoCapture,
+			Memory: memoryMmap,
+		}
+		if err := ioctl(fd, vidiocQuerybuf, unsafe.Pointer(&buf)); err != nil {
+			return nil, fmt.Errorf("VIDIOC_QUERYBUF: %v", err)
+		}
+		b, err := unix.Mmap(int(fd), int64(buf.M), int(buf.Length), unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+		if err != nil {
+			return nil, err
+		}
+		defer unix.Munmap(b)
+		mapped[idx] = b
+		if err := ioctl(fd, vidiocQbuf, unsafe.Pointer(&buf)); err != nil {
+			return nil, fmt.Errorf("VIDIOC_QBUF: %v", err)
+		}
+	}
+
+	typ := int32(bufTypeVideoCapture)
+	if err := ioctl(fd, vidiocStreamon, unsafe.Pointer(&typ)); err != nil {
+		return nil, fmt.Errorf("VIDIOC_STREAMON: %v", err)
+	}
+	defer ioctl(fd, vidiocStreamoff, unsafe.Pointer(&typ))
+
+	for frame := 0; ; frame++ {
+		pfd := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(pfd, 5000)
+		if err != nil && err != unix.EINTR {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, errors.New("timeout waiting for frame")
+		}
+		buf := buffer{Type: bufTypeVideoCapture, Memory: memoryMmap}
+		if err := ioctl(fd, vidiocDqbuf, unsafe.Pointer(&buf)); err != nil {
+			if err == unix.EAGAIN {
+				continue
+			}
+			return nil, fmt.Errorf("VIDIOC_DQBUF: %v", err)
+		}
+		if frame >= warmupFrames {
+			data := mapped[buf.Index][:buf.BytesUsed]
+			return decode(data, pix)
+		}
+		if err := ioctl(fd, vidiocQbuf, unsafe.Pointer(&buf)); err != nil {
+			return nil, fmt.Errorf("VIDIOC_QBUF: %v", err)
+		}
+	}
+}
+
+// decode converts a frame in the specified format to an image. The returned
+// image does not reference data.
+func decode(data []byte, pix pixFormat) (image.Image, error) {
+	switch pix.PixelFormat {
+	case pixFmtMJPEG:
+		return jpeg.Decode(bytes.NewReader(data))
+	case pixFmtYUYV:
+		return decodeYUYV(data, int(pix.Width), int(pix.Height), int(pix.BytesPerLine))
+	default:
+		return nil, fmt.Errorf("unsupported pixel format %08x", pix.PixelFormat)
+	}
+}
+
+// decodeYUYV converts packed YUV 4:2:2 (Y0 Cb Y1 Cr) to planar YCbCr.
+func decodeYUYV(data []byte, width, height, stride int) (image.Image, error) {
+	if stride == 0 {
+		stride = 2 * width
+	}
+	if len(data) < stride*(height-1)+2*width {
+		return nil, fmt.Errorf("short YUYV frame: %d bytes for %dx%d", len(data), width, height)
+	}
+	img := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio422)
+	for y := 0; y < height; y++ {
+		line := data[y*stride:]
+		for x := 0; x+1 < width; x += 2 {
+			img.Y[y*img.YStride+x] = line[2*x]
+			img.Y[y*img.YStride+x+1] = line[2*x+2]
+			img.Cb[y*img.CStride+x/2] = line[2*x+1]
+			img.Cr[y*img.CStride+x/2] = line[2*x+3]
+		}
+	}
+	return img, nil
+}
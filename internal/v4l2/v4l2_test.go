@@ -0,0 +1,37 @@
+package v4l2
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDecodeYUYV(t *testing.T) {
+	// 2×2 pixels with a stride of 6 bytes (2 bytes of padding per line)
+	data := []byte{
+		10, 100, 20, 200, 0, 0,
+		30, 110, 40, 210, 0, 0,
+	}
+	img, err := decodeYUYV(data, 2, 2, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ycbcr := img.(*image.YCbCr)
+	for _, tt := range []struct {
+		x, y       int
+		yy, cb, cr uint8
+	}{
+		{0, 0, 10, 100, 200},
+		{1, 0, 20, 100, 200},
+		{0, 1, 30, 110, 210},
+		{1, 1, 40, 110, 210},
+	} {
+		got := ycbcr.YCbCrAt(tt.x, tt.y)
+		if got.Y != tt.yy || got.Cb != tt.cb || got.Cr != tt.cr {
+			t.Errorf("YCbCrAt(%d, %d) = %+v, want Y=%d Cb=%d Cr=%d", tt.x, tt.y, got, tt.yy, tt.cb, tt.cr)
+		}
+	}
+
+	if _, err := decodeYUYV(data[:8], 2, 2, 6); err == nil {
+		t.Errorf("decodeYUYV(short frame) did not return an error")
+	}
+}
@@ -0,0 +1,77 @@
+// Package vcio implements the Raspberry Pi VideoCore mailbox property
+// interface via /dev/vcio, which is what vcgencmd uses under the hood.
+//
+// See https://github.com/raspberrypi/firmware/wiki/Mailbox-property-interface
+package vcio
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// ioctlMboxProperty is _IOWR(100, 0, char *), whose value depends on the
+// pointer size of the architecture.
+const ioctlMboxProperty = 3<<30 | unsafe.Sizeof(uintptr(0))<<16 | 100<<8 | 0
+
+const (
+	processRequest   = 0x00000000
+	responseSuccess  = 0x80000000
+	tagGetThrottled  = 0x00030046
+	tagResponseFlag  = 0x80000000
+	endTag           = 0x00000000
+	propertyBufWords = 7
+)
+
+// Device is an open /dev/vcio.
+type Device struct {
+	f *os.File
+}
+
+// Open opens /dev/vcio, which is only present on the Raspberry Pi.
+func Open() (*Device, error) {
+	f, err := os.OpenFile("/dev/vcio", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Device{f: f}, nil
+}
+
+// property sends a single property tag with a one-word value buffer and
+// returns the one-word response.
+func (d *Device) property(tag, value uint32) (uint32, error) {
+	buf := [propertyBufWords]uint32{
+		propertyBufWords * 4, // buffer size in bytes
+		processRequest,
+		tag,
+		4, // value buffer size in bytes
+		0, // request/response indicator and value length
+		value,
+		endTag,
+	}
+	_, _, eno := unix.Syscall(unix.SYS_IOCTL, d.f.Fd(), ioctlMboxProperty, uintptr(unsafe.Pointer(&buf[0])))
+	if eno != 0 {
+		return 0, fmt.Errorf("IOCTL_MBOX_PROPERTY: %v", eno)
+	}
+	if buf[1] != responseSuccess {
+		return 0, fmt.Errorf("mailbox property request failed: code %#x", buf[1])
+	}
+	if buf[4]&tagResponseFlag == 0 {
+		return 0, fmt.Errorf("tag %#x not processed by firmware", tag)
+	}
+	return buf[5], nil
+}
+
+// GetThrottled returns the firmware throttling bits, as displayed by
+// vcgencmd get_throttled.
+func (d *Device) GetThrottled() (uint32, error) {
+	// Like the Linux raspberrypi-hwmon driver, request with a zero value so
+	// that the sticky “occurred” bits are not cleared.
+	return d.property(tagGetThrottled, 0)
+}
+
+func (d *Device) Close() error {
+	return d.f.Close()
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+var (
+	ipCameras = flag.String("ip-cameras",
+		"",
+		"comma-separated list of network cameras for the ip-cameras panel, each specified as [label=]url. http(s) URLs must return a JPEG or PNG still (credentials can be given as user:password@ in the URL); rtsp URLs require ffmpeg to be installed")
+
+	ipCameraInterval = flag.Duration("ip-camera-interval",
+		10*time.Second,
+		"how long the ip-cameras panel shows each camera")
+)
+
+type ipCamera struct {
+	label string
+	url   string
+}
+
+func parseIPCameras(spec string) ([]ipCamera, error) {
+	var cameras []ipCamera
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		label, rawurl, ok := strings.Cut(s, "=")
+		if !ok || strings.Contains(label, "://") {
+			// no label, the = is part of the URL
+			rawurl = s
+			label = ""
+		}
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("malformed camera URL %q: %v", rawurl, err)
+		}
+		switch u.Scheme {
+		case "http", "https", "rtsp":
+		default:
+			return nil, fmt.Errorf("camera URL %q: unsupported scheme %q", u.Redacted(), u.Scheme)
+		}
+		if label == "" {
+			label = u.Host
+		}
+		cameras = append(cameras, ipCamera{
+			label: label,
+			url:   rawurl,
+		})
+	}
+	return cameras, nil
+}
+
+func fetchHTTPStill(ctx context.Context, url string) (image.Image, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		return nil, fmt.Errorf("unexpected HTTP status: got %v, want %v", resp.Status, want)
+	}
+	img, _, err := image.Decode(resp.Body)
+	return img, err
+}
+
+// redactPassword removes the password of rawurl (user:password@) from s, e.g.
+// from error messages which echo the URL.
+func redactPassword(s, rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return s
+	}
+	pass, ok := u.User.Password()
+	if !ok || pass == "" {
+		return s
+	}
+	s = strings.ReplaceAll(s, rawurl, u.Redacted())
+	return strings.ReplaceAll(s, pass, "xxxxx")
+}
+
+// fetchRTSPStill extracts a single frame from an RTSP stream using ffmpeg,
+// as decoding H.264 or H.265 keyframes is out of scope for fbstatus.
+func fetchRTSPStill(ctx context.Context, rawurl string) (image.Image, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, errors.New("rtsp requires ffmpeg")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg,
+		"-loglevel", "error",
+		"-rtsp_transport", "tcp",
+		"-i", rawurl,
+		"-frames:v", "1",
+		"-f", "image2pipe",
+		"-c:v", "mjpeg",
+		"-")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		// ffmpeg echoes the URL, including its credentials
+		return nil, fmt.Errorf("ffmpeg: %v: %s", err, redactPassword(strings.TrimSpace(stderr.String()), rawurl))
+	}
+	return jpeg.Decode(bytes.NewReader(out))
+}
+
+type ipCameraSnapshot struct {
+	camera ipCamera
+	img    image.Image
+	err    error
+}
+
+// ipCamerasPanel shows stills of network cameras, one camera at a time.
+type ipCamerasPanel struct {
+	snapshot *poller[ipCameraSnapshot] // nil if no cameras are configured
+}
+
+func newIPCamerasPanel() (panel, error) {
+	cameras, err := parseIPCameras(*ipCameras)
+	if err != nil {
+		return nil, err
+	}
+	if len(cameras) == 0 {
+		return &ipCamerasPanel{}, nil
+	}
+	next := 0
+	return &ipCamerasPanel{
+		snapshot: newPoller(*ipCameraInterval, func(ctx context.Context) (ipCameraSnapshot, error) {
+			camera := cameras[next]
+			next = (next + 1) % len(cameras)
+			fetch := fetchHTTPStill
+			if strings.HasPrefix(camera.url, "rtsp:") {
+				fetch = fetchRTSPStill
+			}
+			img, err := fetch(ctx, camera.url)
+			// Errors are part of the snapshot so that the rotation continues
+			// and the error is shown for the camera it belongs to.
+			return ipCameraSnapshot{camera: camera, img: img, err: err}, nil
+		}),
+	}, nil
+}
+
+func (p *ipCamerasPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	if p.snapshot == nil {
+		y := d.drawTitle(dc, "Cameras")
+		d.drawMessage(dc, y, "-ip-cameras not set")
+		return nil
+	}
+	snapshot, updated, _ := p.snapshot.get()
+	if updated.IsZero() {
+		y := d.drawTitle(dc, "Cameras")
+		d.drawMessage(dc, y, "loading…")
+		return nil
+	}
+	y := d.drawTitle(dc, "Camera "+snapshot.camera.label)
+	if snapshot.err != nil {
+		d.drawMessage(dc, y, "unavailable: "+snapshot.err.Error())
+		return nil
+	}
+	drawImage(dc, y, snapshot.img)
+	return nil
+}
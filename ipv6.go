@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+var ipv6Flag = flag.Bool("ipv6",
+	false,
+	"show IPv6 information: the delegated prefix (from the router7 DHCPv6 client lease), and the default router lifetime, prefixes and DNS servers from router advertisements")
+
+var dhcp6LeaseFile = flag.String("dhcp6-lease",
+	"/perm/dhcp6/wire/lease.json",
+	"router7 DHCPv6 client lease file from which to display the delegated prefix, if -ipv6 is enabled")
+
+// ICMPv6 message and option types as per RFC 4861 and RFC 8106.
+const (
+	icmpv6RouterSolicitation   = 133
+	icmpv6RouterAdvertisement  = 134
+	ndOptionPrefixInformation  = 3
+	ndOptionRecursiveDNSServer = 25
+)
+
+type raPrefix struct {
+	prefix *net.IPNet
+	valid  time.Duration
+}
+
+type routerAdvertisement struct {
+	router   string // link-local address with zone, e.g. fe80::1%uplink0
+	received time.Time
+	lifetime time.Duration // as default router, 0 if not a default router
+	prefixes []raPrefix
+	dns      []string
+	dnsValid time.Duration
+}
+
+// parseRouterAdvertisement parses an ICMPv6 router advertisement message as
+// per RFC 4861, section 4.2 (the Recursive DNS Server option is specified in
+// RFC 8106).
+func parseRouterAdvertisement(b []byte) (routerAdvertisement, error) {
+	if len(b) < 16 || b[0] != icmpv6RouterAdvertisement {
+		return routerAdvertisement{}, fmt.Errorf("not a router advertisement")
+	}
+	ra := routerAdvertisement{
+		lifetime: time.Duration(binary.BigEndian.Uint16(b[6:])) * time.Second,
+	}
+	opts := b[16:]
+	for len(opts) >= 2 {
+		n := int(opts[1]) * 8
+		if n == 0 || n > len(opts) {
+			return routerAdvertisement{}, fmt.Errorf("malformed option")
+		}
+		opt := opts[:n]
+		opts = opts[n:]
+		switch opt[0] {
+		case ndOptionPrefixInformation:
+			if n != 32 {
+				continue
+			}
+			bits := int(opt[2])
+			if bits > 128 {
+				continue
+			}
+			ip := make(net.IP, net.IPv6len)
+			copy(ip, opt[16:32])
+			ra.prefixes = append(ra.prefixes, raPrefix{
+				prefix: &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, 128)},
+				valid:  time.Duration(binary.BigEndian.Uint32(opt[4:])) * time.Second,
+			})
+		case ndOptionRecursiveDNSServer:
+			if n < 24 {
+				continue
+			}
+			ra.dnsValid = time.Duration(binary.BigEndian.Uint32(opt[4:])) * time.Second
+			for addrs := opt[8:]; len(addrs) >= net.IPv6len; addrs = addrs[net.IPv6len:] {
+				ra.dns = append(ra.dns, net.IP(addrs[:net.IPv6len]).String())
+			}
+		}
+	}
+	return ra, nil
+}
+
+// dhcp6Lease is the lease which the router7 DHCPv6 client persists.
+type dhcp6Lease struct {
+	RenewAfter time.Time   `json:"valid_until"`
+	Prefixes   []net.IPNet `json:"prefixes"`
+	DNS        []string    `json:"dns"`
+}
+
+func readDHCP6Lease(path string) (*dhcp6Lease, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lease dhcp6Lease
+	if err := json.Unmarshal(b, &lease); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &lease, nil
+}
+
+// ipv6Monitor listens for router advertisements on all interfaces. As the
+// kernel does not retain the RDNSS option, nor tell when it last received an
+// advertisement, this is as observed by fbstatus.
+type ipv6Monitor struct {
+	mu  sync.Mutex
+	ras map[string]routerAdvertisement // by router
+	err error
+}
+
+func newIPv6Monitor() *ipv6Monitor {
+	m := &ipv6Monitor{ras: make(map[string]routerAdvertisement)}
+	// Receiving router advertisements requires a raw socket, i.e. root
+	// privileges (or CAP_NET_RAW), which gokrazy services have.
+	conn, err := net.ListenPacket("ip6:ipv6-icmp", "")
+	if err != nil {
+		m.err = err
+		return m
+	}
+	go func() {
+		defer conn.Close()
+		solicitRouters(conn)
+		if err := m.listen(conn); err != nil {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.err = err
+		}
+	}()
+	return m
+}
+
+// solicitRouters sends a router solicitation on all multicast capable
+// interfaces, so that routers advertise right away instead of at their next
+// periodic advertisement, which can be many minutes away.
+func solicitRouters(conn net.PacketConn) {
+	if sc, ok := conn.(syscall.Conn); ok {
+		if raw, err := sc.SyscallConn(); err == nil {
+			raw.Control(func(fd uintptr) {
+				// routers ignore solicitations which could have been
+				// forwarded, i.e. whose hop limit is not 255
+				unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MULTICAST_HOPS, 255)
+			})
+		}
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return
+	}
+	// The kernel computes the ICMPv6 checksum for raw sockets.
+	rs := []byte{icmpv6RouterSolicitation, 0, 0, 0, 0, 0, 0, 0}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 ||
+			iface.Flags&net.FlagLoopback != 0 ||
+			iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		conn.WriteTo(rs, &net.IPAddr{IP: net.ParseIP("ff02::2"), Zone: iface.Name})
+	}
+}
+
+func (m *ipv6Monitor) listen(conn net.PacketConn) error {
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		// The raw socket receives all ICMPv6 messages, so skip those which
+		// are not router advertisements.
+		if n < 1 || buf[0] != icmpv6RouterAdvertisement {
+			continue
+		}
+		ra, err := parseRouterAdvertisement(buf[:n])
+		if err != nil {
+			continue
+		}
+		ra.router = from.String()
+		ra.received = time.Now()
+		m.mu.Lock()
+		m.ras[ra.router] = ra
+		m.mu.Unlock()
+	}
+}
+
+// remaining returns how much of lifetime is left at now, for a lifetime
+// which was advertised at received.
+func remaining(lifetime time.Duration, received, now time.Time) time.Duration {
+	return lifetime - now.Sub(received)
+}
+
+// ipv6Lines returns host information lines (in $color$text markup) for the
+// delegated prefix (lease may be nil) and the router advertisements.
+func ipv6Lines(lease *dhcp6Lease, leaseErr error, ras []routerAdvertisement, now time.Time) []string {
+	var lines []string
+	switch {
+	case leaseErr != nil && !os.IsNotExist(leaseErr):
+		lines = append(lines, "$$ipv6 delegated prefix: $red$"+leaseErr.Error())
+	case lease != nil && len(lease.Prefixes) == 0:
+		lines = append(lines, "$$ipv6 delegated prefix: $red$none in DHCPv6 lease")
+	case lease != nil:
+		prefixes := make([]string, 0, len(lease.Prefixes))
+		for _, p := range lease.Prefixes {
+			prefixes = append(prefixes, p.String())
+		}
+		line := "$$ipv6 delegated prefix: $green$" + strings.Join(prefixes, " ")
+		if renew := lease.RenewAfter.Sub(now); renew > 0 {
+			line += "$$, renewal in " + renew.Round(time.Minute).String()
+		} else {
+			line += "$$, renewal $red$overdue"
+		}
+		lines = append(lines, line)
+	}
+
+	if len(ras) == 0 {
+		return append(lines, "$$ipv6 router: $darkgray$no router advertisement received")
+	}
+	for _, ra := range ras {
+		line := "$$ipv6 router: " + ra.router + " "
+		switch left := remaining(ra.lifetime, ra.received, now); {
+		case ra.lifetime == 0:
+			line += "$yellow$not a default router"
+		case left <= 0:
+			line += "$red$default route expired"
+		default:
+			line += "$green$default for " + left.Round(time.Second).String()
+		}
+		for _, p := range ra.prefixes {
+			if remaining(p.valid, ra.received, now) > 0 {
+				line += "$$, prefix " + p.prefix.String()
+			}
+		}
+		if len(ra.dns) > 0 && remaining(ra.dnsValid, ra.received, now) > 0 {
+			line += "$$, DNS " + strings.Join(ra.dns, " ")
+		}
+		line += "$$ (RA " + now.Sub(ra.received).Round(time.Second).String() + " ago)"
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// lines returns host information lines (in $color$text markup).
+func (m *ipv6Monitor) lines() []string {
+	lease, leaseErr := readDHCP6Lease(*dhcp6LeaseFile)
+	m.mu.Lock()
+	err := m.err
+	ras := make([]routerAdvertisement, 0, len(m.ras))
+	for _, ra := range m.ras {
+		ras = append(ras, ra)
+	}
+	m.mu.Unlock()
+	sort.Slice(ras, func(i, j int) bool { return ras[i].router < ras[j].router })
+	lines := ipv6Lines(lease, leaseErr, ras, time.Now())
+	if err != nil && len(ras) == 0 {
+		lines[len(lines)-1] = "$$ipv6 router: $red$" + err.Error()
+	}
+	return lines
+}
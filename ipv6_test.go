@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRouterAdvertisement(t *testing.T) {
+	ra := make([]byte, 16)
+	ra[0] = icmpv6RouterAdvertisement
+	binary.BigEndian.PutUint16(ra[6:], 1800)
+
+	pio := make([]byte, 32)
+	pio[0], pio[1], pio[2], pio[3] = ndOptionPrefixInformation, 4, 64, 0xc0
+	binary.BigEndian.PutUint32(pio[4:], 86400)
+	binary.BigEndian.PutUint32(pio[8:], 14400)
+	copy(pio[16:], net.ParseIP("2001:db8:1:2::"))
+	ra = append(ra, pio...)
+
+	rdnss := make([]byte, 8)
+	rdnss[0], rdnss[1] = ndOptionRecursiveDNSServer, 3
+	binary.BigEndian.PutUint32(rdnss[4:], 600)
+	rdnss = append(rdnss, net.ParseIP("2001:db8::53")...)
+	ra = append(ra, rdnss...)
+
+	// source link-layer address option, which is ignored
+	ra = append(ra, 1, 1, 0xde, 0xad, 0xbe, 0xef, 0x00, 0x01)
+
+	got, err := parseRouterAdvertisement(ra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, prefix, _ := net.ParseCIDR("2001:db8:1:2::/64")
+	want := routerAdvertisement{
+		lifetime: 30 * time.Minute,
+		prefixes: []raPrefix{{prefix: prefix, valid: 24 * time.Hour}},
+		dns:      []string{"2001:db8::53"},
+		dnsValid: 10 * time.Minute,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRouterAdvertisement() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseRouterAdvertisement(append(ra, 3, 0)); err == nil {
+		t.Errorf("parseRouterAdvertisement(zero length option) succeeded unexpectedly")
+	}
+}
+
+func TestReadDHCP6Lease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lease.json")
+	const lease = `{"valid_until":"2022-08-20T12:00:00Z","prefixes":[{"IP":"2001:db8:1200::","Mask":"/////////wAAAAAAAAAAAA=="}],"dns":["2001:db8::53"]}`
+	if err := os.WriteFile(path, []byte(lease), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readDHCP6Lease(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Prefixes) != 1 || got.Prefixes[0].String() != "2001:db8:1200::/56" {
+		t.Errorf("readDHCP6Lease(): prefixes = %v, want [2001:db8:1200::/56]", got.Prefixes)
+	}
+	if want := time.Date(2022, 8, 20, 12, 0, 0, 0, time.UTC); !got.RenewAfter.Equal(want) {
+		t.Errorf("readDHCP6Lease(): renew after %v, want %v", got.RenewAfter, want)
+	}
+}
+
+func TestIPv6Lines(t *testing.T) {
+	now := time.Date(2022, 8, 20, 10, 0, 0, 0, time.UTC)
+	_, prefix, _ := net.ParseCIDR("2001:db8:1:2::/64")
+	_, delegated, _ := net.ParseCIDR("2001:db8:1200::/56")
+	lease := &dhcp6Lease{
+		RenewAfter: now.Add(2 * time.Hour),
+		Prefixes:   []net.IPNet{*delegated},
+	}
+	ras := []routerAdvertisement{
+		{
+			router:   "fe80::1%uplink0",
+			received: now.Add(-time.Minute),
+			lifetime: 30 * time.Minute,
+			prefixes: []raPrefix{{prefix: prefix, valid: 24 * time.Hour}},
+			dns:      []string{"2001:db8::53"},
+			dnsValid: 10 * time.Minute,
+		},
+		{
+			router:   "fe80::2%lan0",
+			received: now.Add(-time.Hour),
+			lifetime: 30 * time.Minute,
+			dns:      []string{"2001:db8::54"},
+			dnsValid: 10 * time.Minute,
+		},
+		{
+			router:   "fe80::3%lan0",
+			received: now.Add(-time.Second),
+		},
+	}
+	got := ipv6Lines(lease, nil, ras, now)
+	want := []string{
+		"$$ipv6 delegated prefix: $green$2001:db8:1200::/56$$, renewal in 2h0m0s",
+		"$$ipv6 router: fe80::1%uplink0 $green$default for 29m0s$$, prefix 2001:db8:1:2::/64$$, DNS 2001:db8::53$$ (RA 1m0s ago)",
+		"$$ipv6 router: fe80::2%lan0 $red$default route expired$$ (RA 1h0m0s ago)",
+		"$$ipv6 router: fe80::3%lan0 $yellow$not a default router$$ (RA 1s ago)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ipv6Lines() =\n%q\nwant\n%q", got, want)
+	}
+
+	got = ipv6Lines(nil, os.ErrNotExist, nil, now)
+	want = []string{"$$ipv6 router: $darkgray$no router advertisement received"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ipv6Lines(no lease) = %q, want %q", got, want)
+	}
+
+	got = ipv6Lines(nil, errors.New("lease.json: unexpected end of JSON input"), nil, now)
+	if want := "$$ipv6 delegated prefix: $red$lease.json: unexpected end of JSON input"; got[0] != want {
+		t.Errorf("ipv6Lines(broken lease)[0] = %q, want %q", got[0], want)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gokrazy/fbstatus/internal/evdev"
+)
+
+var (
+	irInput = flag.Bool("ir",
+		true,
+		"handle infrared remote controls decoded by the kernel (rc-core, see ir-keytable(1)) while the display is visible, see -ir-keys")
+
+	irKeys = flag.String("ir-keys",
+		"",
+		"comma-separated list of additional remote control key bindings, each specified as key=action. key is a key name as in the kernel keymaps (e.g. KEY_CHANNELUP) or a scancode (e.g. 0x40, for keys the keymap does not contain). action is next, prev, auto, blank, config or a page name or number. These override the default bindings: arrow keys, channel up/down and next/previous switch pages, OK/home resumes rotating, power blanks, info/menu shows the configuration overlay and the digits select pages")
+)
+
+// irKeyCodes maps the names of common remote control keys to their codes,
+// see include/uapi/linux/input-event-codes.h.
+var irKeyCodes = map[string]uint16{
+	"KEY_1":           2,
+	"KEY_2":           3,
+	"KEY_3":           4,
+	"KEY_4":           5,
+	"KEY_5":           6,
+	"KEY_6":           7,
+	"KEY_7":           8,
+	"KEY_8":           9,
+	"KEY_9":           10,
+	"KEY_ENTER":       28,
+	"KEY_HOME":        102,
+	"KEY_UP":          103,
+	"KEY_PAGEUP":      104,
+	"KEY_LEFT":        105,
+	"KEY_RIGHT":       106,
+	"KEY_DOWN":        108,
+	"KEY_PAGEDOWN":    109,
+	"KEY_POWER":       116,
+	"KEY_MENU":        139,
+	"KEY_SLEEP":       142,
+	"KEY_BACK":        158,
+	"KEY_REWIND":      168,
+	"KEY_EXIT":        174,
+	"KEY_FASTFORWARD": 208,
+	"KEY_OK":          0x160,
+	"KEY_SELECT":      0x161,
+	"KEY_INFO":        0x166,
+	"KEY_RED":         0x18e,
+	"KEY_GREEN":       0x18f,
+	"KEY_YELLOW":      0x190,
+	"KEY_BLUE":        0x191,
+	"KEY_CHANNELUP":   0x192,
+	"KEY_CHANNELDOWN": 0x193,
+	"KEY_NEXT":        0x197,
+	"KEY_PREVIOUS":    0x19c,
+	"KEY_NUMERIC_1":   0x201,
+	"KEY_NUMERIC_2":   0x202,
+	"KEY_NUMERIC_3":   0x203,
+	"KEY_NUMERIC_4":   0x204,
+	"KEY_NUMERIC_5":   0x205,
+	"KEY_NUMERIC_6":   0x206,
+	"KEY_NUMERIC_7":   0x207,
+	"KEY_NUMERIC_8":   0x208,
+	"KEY_NUMERIC_9":   0x209,
+}
+
+const defaultIRKeys = "KEY_RIGHT=next,KEY_DOWN=next,KEY_CHANNELUP=next,KEY_NEXT=next,KEY_FASTFORWARD=next," +
+	"KEY_LEFT=prev,KEY_UP=prev,KEY_CHANNELDOWN=prev,KEY_PREVIOUS=prev,KEY_REWIND=prev," +
+	"KEY_OK=auto,KEY_ENTER=auto,KEY_SELECT=auto,KEY_HOME=auto," +
+	"KEY_POWER=blank,KEY_SLEEP=blank," +
+	"KEY_INFO=config,KEY_MENU=config," +
+	"KEY_1=1,KEY_2=2,KEY_3=3,KEY_4=4,KEY_5=5,KEY_6=6,KEY_7=7,KEY_8=8,KEY_9=9," +
+	"KEY_NUMERIC_1=1,KEY_NUMERIC_2=2,KEY_NUMERIC_3=3,KEY_NUMERIC_4=4,KEY_NUMERIC_5=5,KEY_NUMERIC_6=6,KEY_NUMERIC_7=7,KEY_NUMERIC_8=8,KEY_NUMERIC_9=9"
+
+// irRepeatInterval is the minimum time between two presses of the same key
+// bound by scancode. Remotes repeat the scancode while a key is held (e.g.
+// every 110ms with the NEC protocol).
+const irRepeatInterval = 300 * time.Millisecond
+
+// irBindings maps remote control keys to actions.
+type irBindings struct {
+	keys      map[uint16]string // by key code
+	scancodes map[int32]string
+}
+
+func parseIRKeys(spec string, b *irBindings) error {
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		key, action, ok := strings.Cut(s, "=")
+		if !ok || key == "" || action == "" {
+			return fmt.Errorf("malformed remote control key binding %q: expected key=action", s)
+		}
+		if code, ok := irKeyCodes[strings.ToUpper(key)]; ok {
+			b.keys[code] = action
+			continue
+		}
+		scancode, err := strconv.ParseInt(key, 0, 32)
+		if err != nil {
+			return fmt.Errorf("remote control key binding %q: unknown key %q", s, key)
+		}
+		b.scancodes[int32(scancode)] = action
+	}
+	return nil
+}
+
+func newIRBindings(spec string) (*irBindings, error) {
+	b := &irBindings{
+		keys:      make(map[uint16]string),
+		scancodes: make(map[int32]string),
+	}
+	if err := parseIRKeys(defaultIRKeys, b); err != nil {
+		return nil, err
+	}
+	if err := parseIRKeys(spec, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// irRemote translates the events of a remote control receiver into actions.
+type irRemote struct {
+	bindings *irBindings
+
+	lastScancode int32
+	lastScan     time.Time
+}
+
+// feed returns whether ev is a key press and the action bound to the key, if
+// any.
+func (r *irRemote) feed(ev evdev.Event) (pressed bool, action string) {
+	switch {
+	case ev.Type == evdev.EvKey && ev.Value == evdev.KeyPressed:
+		return true, r.bindings.keys[ev.Code]
+
+	case ev.Type == evdev.EvMsc && ev.Code == evdev.MscScan:
+		repeated := ev.Value == r.lastScancode && ev.Time.Sub(r.lastScan) < irRepeatInterval
+		r.lastScancode, r.lastScan = ev.Value, ev.Time
+		if repeated {
+			return false, ""
+		}
+		// Keys in the keymap are handled by their EvKey event.
+		action, ok := r.bindings.scancodes[ev.Value]
+		return ok, action
+	}
+	return false, ""
+}
+
+// handleRemoteAction applies an action bound to a remote control key.
+func (d *statusDrawer) handleRemoteAction(action string) error {
+	switch action {
+	case "":
+		return nil
+	case "blank":
+		d.toggleBlank()
+		return nil
+	case "config":
+		d.control.showConfig(!d.control.configShown())
+		return nil
+	}
+	if d.control.configShown() {
+		d.control.showConfig(false)
+	}
+	return d.setPage(action)
+}
+
+// isRemoteControl reports whether the input device at path (e.g.
+// /dev/input/event3) belongs to a remote control receiver of the kernel's
+// rc-core.
+func isRemoteControl(path string) bool {
+	matches, _ := filepath.Glob("/sys/class/rc/*/input*/" + filepath.Base(path))
+	return len(matches) > 0
+}
+
+// irHandler returns a function handling the events of a remote control
+// receiver.
+func (d *statusDrawer) irHandler() func(evdev.Event) {
+	r := &irRemote{bindings: d.irBindings}
+	return func(ev evdev.Event) {
+		pressed, action := r.feed(ev)
+		if !pressed || d.control.wake() {
+			return
+		}
+		if err := d.handleRemoteAction(action); err != nil {
+			slog.Warn("remote control action failed", "err", err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"image"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gokrazy/fbstatus/internal/evdev"
+)
+
+func TestIRBindings(t *testing.T) {
+	b, err := newIRBindings("KEY_RIGHT=prev,0x40=services,key_info=2,KEY_RED=blank")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		code uint16
+		want string
+	}{
+		{irKeyCodes["KEY_RIGHT"], "prev"}, // overridden
+		{irKeyCodes["KEY_CHANNELUP"], "next"},
+		{irKeyCodes["KEY_INFO"], "2"},
+		{irKeyCodes["KEY_POWER"], "blank"},
+		{irKeyCodes["KEY_RED"], "blank"},
+	} {
+		if got := b.keys[tt.code]; got != tt.want {
+			t.Errorf("binding of key %d = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+	if got, want := b.scancodes[0x40], "services"; got != want {
+		t.Errorf("binding of scancode 0x40 = %q, want %q", got, want)
+	}
+
+	for _, spec := range []string{"KEY_OK", "=next", "KEY_OK=", "KEY_MUTE=blank"} {
+		if _, err := newIRBindings(spec); err == nil {
+			t.Errorf("newIRBindings(%q) did not return an error", spec)
+		}
+	}
+}
+
+func TestIRRemote(t *testing.T) {
+	b, err := newIRBindings("0x40=services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &irRemote{bindings: b}
+	start := time.Unix(1630324800, 0)
+	var got []string
+	for _, ev := range []evdev.Event{
+		// KEY_CHANNELUP pressed, held (repeated) and released
+		{Time: start, Type: evdev.EvMsc, Code: evdev.MscScan, Value: 0x10},
+		{Time: start, Type: evdev.EvKey, Code: irKeyCodes["KEY_CHANNELUP"], Value: evdev.KeyPressed},
+		{Time: start, Type: evdev.EvSyn},
+		{Time: start.Add(500 * time.Millisecond), Type: evdev.EvKey, Code: irKeyCodes["KEY_CHANNELUP"], Value: evdev.KeyRepeated},
+		{Time: start.Add(600 * time.Millisecond), Type: evdev.EvKey, Code: irKeyCodes["KEY_CHANNELUP"], Value: evdev.KeyReleased},
+		// a key not in the keymap, repeated while held, then pressed again
+		{Time: start.Add(2 * time.Second), Type: evdev.EvMsc, Code: evdev.MscScan, Value: 0x40},
+		{Time: start.Add(2110 * time.Millisecond), Type: evdev.EvMsc, Code: evdev.MscScan, Value: 0x40},
+		{Time: start.Add(2220 * time.Millisecond), Type: evdev.EvMsc, Code: evdev.MscScan, Value: 0x40},
+		{Time: start.Add(3 * time.Second), Type: evdev.EvMsc, Code: evdev.MscScan, Value: 0x40},
+	} {
+		if _, action := r.feed(ev); action != "" {
+			got = append(got, action)
+		}
+	}
+	if want := []string{"next", "services", "services"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %q, want %q", got, want)
+	}
+}
+
+func TestHandleRemoteAction(t *testing.T) {
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	*pagesFlag = "status,clock,version"
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.handleRemoteAction("config"); err != nil {
+		t.Fatal(err)
+	}
+	if !d.control.configShown() {
+		t.Errorf("configuration overlay not shown")
+	}
+	if err := d.handleRemoteAction("3"); err != nil {
+		t.Fatal(err)
+	}
+	if d.control.configShown() {
+		t.Errorf("configuration overlay still shown after switching pages")
+	}
+	if idx, ok := d.control.pinnedPage(); !ok || d.pages[idx].name != "version" {
+		t.Errorf("page 3 not selected")
+	}
+	if err := d.handleRemoteAction("blank"); err != nil {
+		t.Fatal(err)
+	}
+	if !d.control.isBlanked() {
+		t.Errorf("display not blanked")
+	}
+	if err := d.handleRemoteAction("4"); err == nil {
+		t.Errorf("handleRemoteAction(4) unexpectedly succeeded with 3 pages")
+	}
+}
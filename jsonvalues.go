@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+var (
+	jsonValues = flag.String("json-values",
+		"",
+		"comma-separated list of values to extract from JSON APIs for the json panel, each specified as label[:unit[:warn:crit]]=url#path, where path selects the value, e.g. solar:W=http://inverter/api/status#data.ac[0].power. Values at or above warn (crit) are shown in yellow (red); if warn > crit, lower values are worse")
+
+	jsonInterval = flag.Duration("json-interval",
+		time.Minute,
+		"how often the json panel fetches its URLs")
+)
+
+type jsonValue struct {
+	label     string
+	unit      string
+	threshold *threshold // nil if none was specified
+	url       string     // without fragment
+	path      string
+}
+
+func parseJSONValues(spec string) ([]jsonValue, error) {
+	var values []jsonValue
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		label, rawurl, ok := strings.Cut(s, "=")
+		if !ok || label == "" || rawurl == "" {
+			return nil, fmt.Errorf("malformed JSON value %q: expected label[:unit[:warn:crit]]=url#path", s)
+		}
+		label, unit, _ := strings.Cut(label, ":")
+		unit, thresh, _ := strings.Cut(unit, ":")
+		v := jsonValue{
+			label: label,
+			unit:  unit,
+		}
+		if thresh != "" {
+			t, err := parseThreshold(thresh)
+			if err != nil {
+				return nil, fmt.Errorf("JSON value %q: %v", label, err)
+			}
+			v.threshold = t
+		}
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("JSON value %q: %v", label, err)
+		}
+		v.path = u.Fragment
+		u.Fragment = ""
+		v.url = u.String()
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// jsonPath selects a value from a decoded JSON document. path consists of
+// object keys separated by dots, with optional array indexes, e.g.
+// data.ac[0].power (an index can also be written as .0). An empty path (or
+// $) selects the whole document.
+func jsonPath(doc interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+	if path == "" {
+		return doc, nil
+	}
+	v := doc
+	for _, elem := range strings.Split(path, ".") {
+		switch x := v.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if v, ok = x[elem]; !ok {
+				return nil, fmt.Errorf("key %q not found", elem)
+			}
+		case []interface{}:
+			idx, err := strconv.Atoi(elem)
+			if err != nil {
+				return nil, fmt.Errorf("cannot index array with %q", elem)
+			}
+			if idx < 0 || idx >= len(x) {
+				return nil, fmt.Errorf("index %d out of range", idx)
+			}
+			v = x[idx]
+		default:
+			return nil, fmt.Errorf("cannot select %q from %T", elem, v)
+		}
+	}
+	return v, nil
+}
+
+// formatJSONValue renders a value selected by jsonPath for display.
+func formatJSONValue(v interface{}, unit string, t *threshold) cell {
+	switch x := v.(type) {
+	case float64:
+		text := strconv.FormatFloat(x, 'f', -1, 64)
+		if unit != "" {
+			text += " " + unit
+		}
+		return cell{text: text, color: t.color(x)}
+	case string:
+		if f, err := strconv.ParseFloat(x, 64); err == nil {
+			return formatJSONValue(f, unit, t)
+		}
+		return formatMQTTValue(x, unit)
+	case bool:
+		return formatMQTTValue(strconv.FormatBool(x), unit)
+	case nil:
+		return cell{text: "null", color: "darkgray"}
+	default:
+		b, _ := json.Marshal(x)
+		return cell{text: string(b)}
+	}
+}
+
+func fetchJSON(ctx context.Context, url string) (interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		return nil, fmt.Errorf("unexpected HTTP status: got %v, want %v", resp.Status, want)
+	}
+	var doc interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// jsonPanel shows values extracted from arbitrary JSON APIs, as a catch-all
+// for data sources fbstatus does not support natively.
+type jsonPanel struct {
+	values []jsonValue
+	docs   map[string]*poller[interface{}] // by URL
+}
+
+func newJSONPanel() (panel, error) {
+	values, err := parseJSONValues(*jsonValues)
+	if err != nil {
+		return nil, err
+	}
+	p := &jsonPanel{
+		values: values,
+		docs:   make(map[string]*poller[interface{}]),
+	}
+	for _, v := range values {
+		if _, ok := p.docs[v.url]; ok {
+			continue // fetch each URL only once
+		}
+		url := v.url
+		p.docs[url] = newPoller(*jsonInterval, func(ctx context.Context) (interface{}, error) {
+			return fetchJSON(ctx, url)
+		})
+	}
+	return p, nil
+}
+
+func (p *jsonPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Values")
+	if len(p.values) == 0 {
+		d.drawMessage(dc, y, "-json-values not set")
+		return nil
+	}
+	rows := make([][]cell, 0, len(p.values))
+	for _, v := range p.values {
+		value := cell{text: "loading…", color: "darkgray"}
+		doc, updated, err := p.docs[v.url].get()
+		switch {
+		case !updated.IsZero():
+			if selected, err := jsonPath(doc, v.path); err != nil {
+				value = cell{text: err.Error(), color: "red"}
+			} else {
+				value = formatJSONValue(selected, v.unit, v.threshold)
+			}
+		case err != nil:
+			value = cell{text: "unavailable: " + err.Error(), color: "darkgray"}
+		}
+		rows = append(rows, []cell{{text: v.label}, value})
+	}
+	d.drawTable(dc, y, []string{"name", "value"}, rows)
+	return nil
+}
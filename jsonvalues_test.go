@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONValues(t *testing.T) {
+	got, err := parseJSONValues("solar:W=http://inverter/api/status?v=1#data.ac[0].power,battery:%:30:10=http://inverter/api/status?v=1#data.soc,uptime=http://10.0.0.1/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []jsonValue{
+		{label: "solar", unit: "W", url: "http://inverter/api/status?v=1", path: "data.ac[0].power"},
+		{label: "battery", unit: "%", threshold: &threshold{warn: 30, crit: 10}, url: "http://inverter/api/status?v=1", path: "data.soc"},
+		{label: "uptime", url: "http://10.0.0.1/"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJSONValues() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseJSONValues("solar:W:3000=http://inverter/"); err == nil {
+		t.Errorf("parseJSONValues(incomplete threshold) did not return an error")
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(`{"data": {"ac": [{"power": 1234.5}, {"power": 17}], "state": "on"}}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		path string
+		want interface{}
+	}{
+		{"data.ac[0].power", 1234.5},
+		{"$.data.ac[1].power", 17.0},
+		{"data.ac.1.power", 17.0},
+		{"data.state", "on"},
+	} {
+		got, err := jsonPath(doc, tt.path)
+		if err != nil {
+			t.Errorf("jsonPath(%q): %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("jsonPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	for _, path := range []string{"data.dc", "data.ac[2]", "data.state.x"} {
+		if _, err := jsonPath(doc, path); err == nil {
+			t.Errorf("jsonPath(%q) did not return an error", path)
+		}
+	}
+}
+
+func TestFormatJSONValue(t *testing.T) {
+	up := &threshold{warn: 70, crit: 90}
+	down := &threshold{warn: 30, crit: 10}
+	for _, tt := range []struct {
+		v    interface{}
+		unit string
+		t    *threshold
+		want cell
+	}{
+		{42.0, "%", up, cell{text: "42 %"}},
+		{75.0, "%", up, cell{text: "75 %", color: "yellow"}},
+		{"95", "%", up, cell{text: "95 %", color: "red"}},
+		{25.0, "%", down, cell{text: "25 %", color: "yellow"}},
+		{5.0, "%", down, cell{text: "5 %", color: "red"}},
+		{true, "", nil, cell{text: "true", color: "green"}},
+		{nil, "", nil, cell{text: "null", color: "darkgray"}},
+	} {
+		if got := formatJSONValue(tt.v, tt.unit, tt.t); got != tt.want {
+			t.Errorf("formatJSONValue(%v) = %+v, want %+v", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+var kmsgLevel = flag.Int("kmsg-level",
+	4,
+	"maximum syslog level (0=emerg … 7=debug) of kernel messages to show in the kmsg panel; the default of 4 shows warnings and above")
+
+// kmsgRecord is one kernel log message, see
+// https://www.kernel.org/doc/Documentation/ABI/testing/dev-kmsg
+type kmsgRecord struct {
+	level   int
+	seq     uint64
+	ts      time.Duration // since boot
+	message string
+}
+
+func parseKmsgRecord(b []byte) (kmsgRecord, error) {
+	prefix, message, ok := bytes.Cut(b, []byte{';'})
+	if !ok {
+		return kmsgRecord{}, fmt.Errorf("malformed kmsg record: %q", b)
+	}
+	// Continuation lines (key/value pairs, each starting with a space) follow
+	// the message.
+	if idx := bytes.IndexByte(message, '\n'); idx > -1 {
+		message = message[:idx]
+	}
+	fields := bytes.Split(prefix, []byte{','})
+	if len(fields) < 3 {
+		return kmsgRecord{}, fmt.Errorf("malformed kmsg record prefix: %q", prefix)
+	}
+	prio, err := strconv.ParseUint(string(fields[0]), 10, 64)
+	if err != nil {
+		return kmsgRecord{}, err
+	}
+	seq, err := strconv.ParseUint(string(fields[1]), 10, 64)
+	if err != nil {
+		return kmsgRecord{}, err
+	}
+	usec, err := strconv.ParseInt(string(fields[2]), 10, 64)
+	if err != nil {
+		return kmsgRecord{}, err
+	}
+	return kmsgRecord{
+		level:   int(prio & 7), // the remaining bits are the facility
+		seq:     seq,
+		ts:      time.Duration(usec) * time.Microsecond,
+		message: string(message),
+	}, nil
+}
+
+// kmsgPanel shows the most recent kernel messages of level -kmsg-level and
+// above, as read from /dev/kmsg.
+type kmsgPanel struct {
+	mu      sync.Mutex
+	records []kmsgRecord // most recent last
+	err     error
+}
+
+// kmsgRetain is the number of kernel messages to retain, which is more than
+// fit on the screen even at high resolutions.
+const kmsgRetain = 100
+
+func newKmsgPanel() (panel, error) {
+	p := &kmsgPanel{}
+	f, err := os.Open("/dev/kmsg")
+	if err != nil {
+		p.err = err
+		return p, nil
+	}
+	go func() {
+		defer f.Close()
+		if err := p.tail(f); err != nil {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.err = err
+		}
+	}()
+	return p, nil
+}
+
+func (p *kmsgPanel) tail(f *os.File) error {
+	// Each read returns exactly one record, which the kernel limits to 8 KiB.
+	buf := make([]byte, 8192)
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			if errors.Is(err, syscall.EPIPE) {
+				// Messages were overwritten in the ring buffer before we
+				// could read them, skip ahead.
+				continue
+			}
+			return err
+		}
+		rec, err := parseKmsgRecord(buf[:n])
+		if err != nil {
+			continue
+		}
+		if rec.level > *kmsgLevel {
+			continue
+		}
+		p.mu.Lock()
+		p.records = append(p.records, rec)
+		if len(p.records) > kmsgRetain {
+			p.records = p.records[len(p.records)-kmsgRetain:]
+		}
+		p.mu.Unlock()
+	}
+}
+
+func kmsgLevelColor(level int) string {
+	switch {
+	case level <= 3: // err and worse
+		return "red"
+	case level == 4: // warning
+		return "yellow"
+	default:
+		return ""
+	}
+}
+
+func (p *kmsgPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Kernel messages")
+	p.mu.Lock()
+	records := append([]kmsgRecord(nil), p.records...)
+	err := p.err
+	p.mu.Unlock()
+	if len(records) == 0 {
+		msg := "no messages"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+
+	dc.Push()
+	defer dc.Pop()
+	dc.SetFontFace(d.monoface)
+	em, _ := dc.MeasureString("m")
+	lineHeight := dc.FontHeight() * lineSpacing
+	// show as many of the most recent messages as fit
+	if n := int((float64(dc.Height()) - y) / lineHeight); n < len(records) {
+		if n < 0 {
+			n = 0
+		}
+		records = records[len(records)-n:]
+	}
+	maxWidth := float64(dc.Width()) - 6*em
+	for _, rec := range records {
+		line := fmt.Sprintf("[%12.6f] %s", rec.ts.Seconds(), rec.message)
+		setColor(dc, kmsgLevelColor(rec.level))
+		dc.DrawString(fitString(dc, line, maxWidth), 3*em, y)
+		y += lineHeight
+	}
+	return nil
+}
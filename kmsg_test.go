@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseKmsgRecord(t *testing.T) {
+	rec, err := parseKmsgRecord([]byte("4,1234,5678901,-;usb 1-1.3: reset high-speed USB device number 4 using xhci_hcd\n SUBSYSTEM=usb\n DEVICE=c189:3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := kmsgRecord{
+		level:   4,
+		seq:     1234,
+		ts:      5678901 * time.Microsecond,
+		message: "usb 1-1.3: reset high-speed USB device number 4 using xhci_hcd",
+	}
+	if rec != want {
+		t.Errorf("parseKmsgRecord() = %+v, want %+v", rec, want)
+	}
+
+	// facility bits must not influence the level
+	rec, err = parseKmsgRecord([]byte("30,1,0,-;systemd message\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rec.level, 6; got != want {
+		t.Errorf("level = %d, want %d", got, want)
+	}
+
+	if _, err := parseKmsgRecord([]byte("garbage")); err == nil {
+		t.Errorf("parseKmsgRecord(garbage) unexpectedly succeeded")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+// aspectVariant returns the layout variant for a display of w×h pixels:
+// portrait, landscape or ultrawide (e.g. 21:9).
+func aspectVariant(w, h int) string {
+	switch ratio := float64(w) / float64(h); {
+	case ratio < 1:
+		return "portrait"
+	case ratio >= 2.1:
+		return "ultrawide"
+	default:
+		return "landscape"
+	}
+}
+
+// statusLayout divides the display into the areas of the classic status
+// view.
+type statusLayout struct {
+	info   image.Rectangle // host information
+	gopher image.Rectangle // the “gokrazy!” title and the gopher
+	stats  image.Rectangle // resource usage table
+}
+
+func newStatusLayout(w, h int) statusLayout {
+	switch aspectVariant(w, h) {
+	case "portrait":
+		// stacked: the gopher on top of the host information on top of the
+		// resource usage
+		return statusLayout{
+			gopher: image.Rect(0, 0, w, h/5),
+			info:   image.Rect(0, h/5, w, h*3/5),
+			stats:  image.Rect(0, h*3/5, w, h),
+		}
+	case "ultrawide":
+		// the host information in a full-height column on the left, which
+		// leaves enough width for the resource usage table on the right
+		return statusLayout{
+			info:   image.Rect(0, 0, w/3, h),
+			gopher: image.Rect(w/3, 0, w, h/2),
+			stats:  image.Rect(w/3, h/2, w, h),
+		}
+	default:
+		return statusLayout{
+			info:   image.Rect(0, 0, w/2, h/2),
+			gopher: image.Rect(w/2, 0, w, h/2),
+			stats:  image.Rect(0, h/2, w, h),
+		}
+	}
+}
+
+// gridColumns returns into how many columns to divide a w×h display for n
+// panels: the grid whose cells come closest to a 4:3 aspect ratio, which is
+// a 2×2-ish grid on landscape displays, stacked panels on portrait displays
+// and three columns on ultrawide displays.
+func gridColumns(n, w, h int) int {
+	const (
+		target = 4.0 / 3
+		// emptyCellPenalty avoids grids with many unused cells
+		emptyCellPenalty = 0.25
+	)
+	best, bestCost := 1, math.Inf(1)
+	for cols := 1; cols <= n; cols++ {
+		rows := (n + cols - 1) / cols
+		aspect := (float64(w) / float64(cols)) / (float64(h) / float64(rows))
+		cost := math.Abs(math.Log(aspect/target)) + emptyCellPenalty*float64(cols*rows-n)
+		if cost < bestCost {
+			best, bestCost = cols, cost
+		}
+	}
+	return best
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func TestGridColumns(t *testing.T) {
+	for _, tt := range []struct {
+		n, w, h int
+		want    int
+	}{
+		// landscape: the same grids as ceil(sqrt(n)) columns
+		{1, 1920, 1080, 1},
+		{2, 1920, 1080, 2},
+		{3, 1920, 1080, 2},
+		{4, 1920, 1080, 2},
+		{5, 1920, 1080, 3},
+		{9, 1920, 1080, 3},
+		// portrait: stacked
+		{2, 1080, 1920, 1},
+		{3, 1080, 1920, 1},
+		// ultrawide: three columns
+		{3, 2560, 1080, 3},
+		{6, 2560, 1080, 3},
+	} {
+		if got := gridColumns(tt.n, tt.w, tt.h); got != tt.want {
+			t.Errorf("gridColumns(%d, %d, %d) = %d, want %d", tt.n, tt.w, tt.h, got, tt.want)
+		}
+	}
+}
+
+func TestStatusLayout(t *testing.T) {
+	for _, sz := range []image.Point{{1920, 1080}, {1080, 1920}, {2560, 1080}, {800, 480}} {
+		l := newStatusLayout(sz.X, sz.Y)
+		screen := image.Rect(0, 0, sz.X, sz.Y)
+		areas := []image.Rectangle{l.info, l.gopher, l.stats}
+		for i, a := range areas {
+			if a.Empty() || !a.In(screen) {
+				t.Errorf("%v: area %d = %v, not within the screen", sz, i, a)
+			}
+			for _, b := range areas[i+1:] {
+				if a.Overlaps(b) {
+					t.Errorf("%v: areas %v and %v overlap", sz, a, b)
+				}
+			}
+		}
+	}
+	if got, want := newStatusLayout(1920, 1080).stats, image.Rect(0, 540, 1920, 1080); got != want {
+		t.Errorf("landscape stats area = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "strings"
+
+// statUnit returns the unit of the values of metric in the resource usage
+// table, before the suffix (k, M, …) which scales them.
+func statUnit(metric string) string {
+	switch {
+	case strings.HasPrefix(metric, "cpu."):
+		return "%"
+	case strings.HasPrefix(metric, "sys."):
+		return "/s"
+	case strings.HasPrefix(metric, "mem."):
+		return "B"
+	}
+	if f := statFormats[metric]; f != nil && f.bits {
+		return "b/s"
+	}
+	return "B/s"
+}
+
+// legendLines returns the lines (in $color$text markup) of the legend, which
+// explains the resource usage table to people who do not know dstat.
+func legendLines() []string {
+	return []string{
+		"$cyan$usr sys idl wai stl$$: % of CPU time in programs, the kernel, idle, waiting for I/O, stolen by the hypervisor",
+		"$cyan$read writ$$: bytes per second read from and written to disks",
+		"$cyan$int csw$$: interrupts and context switches per second",
+		"$cyan$recv send$$: bytes (or bits, see -stat-format) per second received and sent over the network",
+		"$cyan$used free buff cach$$: memory used by programs, free, used for I/O buffers and for the page cache",
+		"",
+		"$cyan$k M G T$$: multiples of 1024 (of 1000 for counts); the color of a value indicates its magnitude",
+		"$cyan$avg 1m$$, $cyan$peak$$: average over the last minute and peak since fbstatus started",
+	}
+}
+
+// drawLegendOverlay draws the legend of the resource usage table, which is
+// toggled by pressing l, over the center of the display.
+func (d *statusDrawer) drawLegendOverlay() {
+	d.drawTextOverlay("resource usage legend", legendLines(), "press l to close")
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gokrazy/fbstatus/internal/ethtool"
+)
+
+type ifaceLink struct {
+	iface string
+	link  ethtool.Link
+}
+
+// wiredInterfaces returns the names of the network interfaces in root
+// (/sys/class/net) which are backed by a device and are not wireless, i.e.
+// those for which link speed and duplex are meaningful.
+func wiredInterfaces(root string) []string {
+	dirs, err := filepath.Glob(filepath.Join(root, "*"))
+	if err != nil {
+		return nil
+	}
+	var ifaces []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "device")); err != nil {
+			continue // virtual, e.g. lo, bridges or tunnels
+		}
+		if _, err := os.Stat(filepath.Join(dir, "wireless")); err == nil {
+			continue
+		}
+		ifaces = append(ifaces, filepath.Base(dir))
+	}
+	return ifaces
+}
+
+// newLinkStatePoller polls the link state of the wired network interfaces
+// via ethtool netlink.
+func newLinkStatePoller() *poller[[]ifaceLink] {
+	return newPoller(5*time.Second, func(context.Context) ([]ifaceLink, error) {
+		var links []ifaceLink
+		var lastErr error
+		for _, iface := range wiredInterfaces("/sys/class/net") {
+			link, err := ethtool.Get(iface)
+			if err != nil {
+				lastErr = err // e.g. a driver without link settings
+				continue
+			}
+			links = append(links, ifaceLink{iface: iface, link: link})
+		}
+		if len(links) == 0 && lastErr != nil {
+			return nil, lastErr
+		}
+		return links, nil
+	})
+}
+
+// degraded returns whether the link negotiated a lower speed than the
+// interface advertises, or half duplex: usually a bad cable, e.g. a gigabit
+// port which only got two of its four pairs connected ends up at 100 Mb/s.
+func (l ifaceLink) degraded() bool {
+	if !l.link.Carrier {
+		return false
+	}
+	return l.link.Duplex == "half" ||
+		(l.link.Speed > 0 && l.link.Speed < l.link.MaxSpeed)
+}
+
+// linkLine returns a host information line (in $color$text markup) with the
+// carrier state, speed and duplex of each link, or the empty string if there
+// are no links.
+func linkLine(links []ifaceLink) string {
+	if len(links) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(links))
+	for _, l := range links {
+		if !l.link.Carrier {
+			parts = append(parts, "$$"+l.iface+" $darkgray$no carrier")
+			continue
+		}
+		state := "up"
+		if l.link.Speed > 0 {
+			state = fmt.Sprintf("%d Mb/s", l.link.Speed)
+		}
+		if l.link.Duplex != "" {
+			state += " " + l.link.Duplex + " duplex"
+		}
+		if l.degraded() {
+			state = "$red$" + state
+			if l.link.Speed < l.link.MaxSpeed {
+				state += fmt.Sprintf("$$ (of %d Mb/s)", l.link.MaxSpeed)
+			}
+		} else {
+			state = "$green$" + state
+		}
+		parts = append(parts, "$$"+l.iface+" "+state)
+	}
+	return "$$link: " + strings.Join(parts, "$$, ")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gokrazy/fbstatus/internal/ethtool"
+)
+
+func TestWiredInterfaces(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{
+		"lo",
+		"eth0/device",
+		"eth1/device",
+		"wlan0/device",
+		"wlan0/wireless",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := wiredInterfaces(root)
+	if want := []string{"eth0", "eth1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wiredInterfaces() = %q, want %q", got, want)
+	}
+}
+
+func TestLinkLine(t *testing.T) {
+	for _, tt := range []struct {
+		links []ifaceLink
+		want  string
+	}{
+		{
+			links: nil,
+			want:  "",
+		},
+		{
+			links: []ifaceLink{
+				{"eth0", ethtool.Link{Carrier: true, Speed: 1000, Duplex: "full", MaxSpeed: 1000}},
+			},
+			want: "$$link: $$eth0 $green$1000 Mb/s full duplex",
+		},
+		{
+			links: []ifaceLink{
+				{"eth0", ethtool.Link{Carrier: true, Speed: 100, Duplex: "half", MaxSpeed: 1000}},
+				{"eth1", ethtool.Link{MaxSpeed: 1000}},
+			},
+			want: "$$link: $$eth0 $red$100 Mb/s half duplex$$ (of 1000 Mb/s)$$, $$eth1 $darkgray$no carrier",
+		},
+		{
+			// the interface only advertises 100 Mb/s, e.g. a Raspberry Pi 3
+			links: []ifaceLink{
+				{"eth0", ethtool.Link{Carrier: true, Speed: 100, Duplex: "full", MaxSpeed: 100}},
+			},
+			want: "$$link: $$eth0 $green$100 Mb/s full duplex",
+		},
+		{
+			links: []ifaceLink{
+				{"usb0", ethtool.Link{Carrier: true}},
+			},
+			want: "$$link: $$usb0 $green$up",
+		},
+	} {
+		if got := linkLine(tt.links); got != tt.want {
+			t.Errorf("linkLine(%+v) = %q, want %q", tt.links, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+type loadAverage struct {
+	load1, load5, load15 float64
+	runnable, total      int
+}
+
+// parseLoadavg parses the contents of /proc/loadavg as per proc(5).
+func parseLoadavg(b []byte) (loadAverage, error) {
+	fields := strings.Fields(string(b))
+	if len(fields) < 4 {
+		return loadAverage{}, fmt.Errorf("malformed loadavg: %q", b)
+	}
+	var la loadAverage
+	for idx, dst := range []*float64{&la.load1, &la.load5, &la.load15} {
+		v, err := strconv.ParseFloat(fields[idx], 64)
+		if err != nil {
+			return loadAverage{}, err
+		}
+		*dst = v
+	}
+	runnable, total, ok := strings.Cut(fields[3], "/")
+	if !ok {
+		return loadAverage{}, fmt.Errorf("malformed loadavg: %q", b)
+	}
+	var err error
+	if la.runnable, err = strconv.Atoi(runnable); err != nil {
+		return loadAverage{}, err
+	}
+	if la.total, err = strconv.Atoi(total); err != nil {
+		return loadAverage{}, err
+	}
+	return la, nil
+}
+
+// loadColor returns the color for displaying load relative to the number of
+// cores: by default, the system is busy when approaching one runnable task
+// per core, and overloaded beyond that.
+func loadColor(load float64, cores int) string {
+	return metricColor("load", load/float64(cores), "green")
+}
+
+// loadavgLine returns a host information line (in the $color$text markup
+// understood by drawMarkup) with the load averages and run queue.
+func loadavgLine() (string, error) {
+	b, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return "", err
+	}
+	la, err := parseLoadavg(b)
+	if err != nil {
+		return "", err
+	}
+	cores := runtime.NumCPU()
+	line := "$$load:"
+	for _, load := range []float64{la.load1, la.load5, la.load15} {
+		line += fmt.Sprintf(" $%s$%.2f", loadColor(load, cores), load)
+	}
+	line += fmt.Sprintf("$$ (%d CPUs), runnable: %d/%d", cores, la.runnable, la.total)
+	return line, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestParseLoadavg(t *testing.T) {
+	la, err := parseLoadavg([]byte("0.37 0.28 0.14 2/72 23436\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := loadAverage{
+		load1:    0.37,
+		load5:    0.28,
+		load15:   0.14,
+		runnable: 2,
+		total:    72,
+	}
+	if la != want {
+		t.Errorf("parseLoadavg() = %+v, want %+v", la, want)
+	}
+}
+
+func TestLoadColor(t *testing.T) {
+	for _, tt := range []struct {
+		load  float64
+		cores int
+		want  string
+	}{
+		{load: 0.5, cores: 4, want: "green"},
+		{load: 3, cores: 4, want: "yellow"},
+		{load: 4, cores: 4, want: "red"},
+		{load: 1.5, cores: 1, want: "red"},
+	} {
+		if got := loadColor(tt.load, tt.cores); got != tt.want {
+			t.Errorf("loadColor(%v, %v) = %q, want %q", tt.load, tt.cores, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+var (
+	verbosity = flag.Int("v",
+		0,
+		"log verbosity: 0 logs only warnings and errors, 1 also what fbstatus does (e.g. which input devices it uses), 2 also details for debugging (e.g. the framebuffer screeninfo)")
+
+	logFormat = flag.String("log-format",
+		"text",
+		"log format: text (key=value pairs) or json (one object per line, e.g. for log collectors)")
+)
+
+// newLogHandler returns the slog handler for -v and -log-format, which writes
+// to w.
+func newLogHandler(w io.Writer, verbosity int, format string) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: slog.LevelWarn}
+	switch {
+	case verbosity >= 2:
+		opts.Level = slog.LevelDebug
+	case verbosity == 1:
+		opts.Level = slog.LevelInfo
+	}
+	switch format {
+	case "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown -log-format=%q, expected text or json", format)
+	}
+}
+
+// setupLogging configures the default logger as per -v and -log-format.
+func setupLogging(w io.Writer) error {
+	h, err := newLogHandler(w, *verbosity, *logFormat)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// fatal logs err and exits, like log.Fatal. Unlike log.Fatal, the message is
+// logged at error level, which is never suppressed by -v.
+func fatal(err error) {
+	slog.Error(err.Error())
+	os.Exit(1)
+}
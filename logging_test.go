@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLogHandler(t *testing.T) {
+	for _, tt := range []struct {
+		verbosity int
+		want      []string // messages which are logged
+	}{
+		{verbosity: 0, want: []string{"warn", "error"}},
+		{verbosity: 1, want: []string{"info", "warn", "error"}},
+		{verbosity: 2, want: []string{"debug", "info", "warn", "error"}},
+	} {
+		var buf bytes.Buffer
+		h, err := newLogHandler(&buf, tt.verbosity, "json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger := slog.New(h)
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn", "err", "details")
+		logger.Error("error")
+
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var record struct {
+				Msg string `json:"msg"`
+			}
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Fatalf("-v=%d: invalid JSON log line %q: %v", tt.verbosity, line, err)
+			}
+			got = append(got, record.Msg)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("-v=%d: logged %q, want %q", tt.verbosity, got, tt.want)
+		}
+	}
+
+	if _, err := newLogHandler(&bytes.Buffer{}, 0, "xml"); err == nil {
+		t.Errorf("newLogHandler(-log-format=xml) succeeded unexpectedly")
+	}
+}
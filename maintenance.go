@@ -0,0 +1,367 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	maintenanceFlag = flag.String("maintenance",
+		"",
+		"semicolon-separated cron expressions (minute hour day-of-month month day-of-week, in local time, e.g. 0 3 * * sun) of maintenance windows, ahead of which a countdown banner is shown, see -maintenance-warning. The job performing the maintenance can query and honor postponements via the /maintenance HTTP endpoint")
+
+	maintenanceMessage = flag.String("maintenance-message",
+		"reboot",
+		"with -maintenance, what the countdown banner announces, e.g. reboot shows “reboot in 12m”")
+
+	maintenanceWarning = flag.Duration("maintenance-warning",
+		time.Hour,
+		"with -maintenance, how long ahead of a maintenance window the countdown banner is shown")
+)
+
+// cronField is the set of values a field of a cron expression matches, as a
+// bit mask.
+type cronField uint64
+
+func (f cronField) has(v int) bool { return f&(1<<uint(v)) != 0 }
+
+// cronSchedule is a parsed cron expression, see crontab(5).
+type cronSchedule struct {
+	minute, hour, dom, month, dow cronField
+	// domStar and dowStar are true if the day of month or the day of week
+	// field is *: if neither is, a day matching either field matches.
+	domStar, dowStar bool
+}
+
+var (
+	cronMonths = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronDays   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// parseCronField parses one field of a cron expression with values in
+// [min, max]: *, numbers, names (the first of which corresponds to min),
+// ranges (a-b), steps (*/n, a-b/n) and comma-separated lists thereof.
+func parseCronField(field string, min, max int, names []string) (cronField, error) {
+	value := func(s string) (int, error) {
+		for idx, name := range names {
+			if strings.EqualFold(s, name) {
+				return min + idx, nil
+			}
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", s)
+		}
+		if v < min || v > max {
+			return 0, fmt.Errorf("value %d out of range [%d, %d]", v, min, max)
+		}
+		return v, nil
+	}
+	var f cronField
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if r, s, ok := strings.Cut(part, "/"); ok {
+			var err error
+			step, err = strconv.Atoi(s)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", s)
+			}
+			rng = r
+		}
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			v, err := value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = max // e.g. 5/15 means 5-max/15
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+	return f, nil
+}
+
+// parseCron parses a cron expression with the five fields minute, hour, day
+// of month, month and day of week.
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q: expected 5 fields (minute hour day-of-month month day-of-week), got %d", expr, len(fields))
+	}
+	var c cronSchedule
+	for idx, f := range []struct {
+		dst      *cronField
+		min, max int
+		names    []string
+	}{
+		{&c.minute, 0, 59, nil},
+		{&c.hour, 0, 23, nil},
+		{&c.dom, 1, 31, nil},
+		{&c.month, 1, 12, cronMonths},
+		{&c.dow, 0, 7, cronDays},
+	} {
+		var err error
+		if *f.dst, err = parseCronField(fields[idx], f.min, f.max, f.names); err != nil {
+			return nil, fmt.Errorf("cron expression %q: %v", expr, err)
+		}
+	}
+	if c.dow.has(7) {
+		c.dow |= 1 << 0 // both 0 and 7 are Sunday
+	}
+	c.domStar = fields[2] == "*"
+	c.dowStar = fields[4] == "*"
+	return &c, nil
+}
+
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom, dow := c.dom.has(t.Day()), c.dow.has(int(t.Weekday()))
+	switch {
+	case c.domStar && c.dowStar:
+		return true
+	case c.domStar:
+		return dow
+	case c.dowStar:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// next returns the first time after t which c matches, or the zero time if
+// there is none within 5 years (e.g. for 0 0 30 feb *).
+func (c *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		y, m, d := t.Date()
+		switch {
+		case !c.month.has(int(m)):
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		case !c.hour.has(t.Hour()):
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, loc)
+		case !c.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// maintenanceSchedule computes the next maintenance window of -maintenance,
+// which can be postponed via HTTP (or MQTT).
+type maintenanceSchedule struct {
+	windows []*cronSchedule
+
+	mu sync.Mutex
+	// postponedFrom and postponedTo are the original and the postponed
+	// start of a postponed maintenance window, or zero.
+	postponedFrom time.Time
+	postponedTo   time.Time
+}
+
+func parseMaintenanceSchedule(spec string) (*maintenanceSchedule, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	var m maintenanceSchedule
+	for _, expr := range strings.Split(spec, ";") {
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		c, err := parseCron(expr)
+		if err != nil {
+			return nil, fmt.Errorf("-maintenance: %v", err)
+		}
+		m.windows = append(m.windows, c)
+	}
+	return &m, nil
+}
+
+// earliest returns the start of the first maintenance window after t.
+func (m *maintenanceSchedule) earliest(t time.Time) time.Time {
+	var first time.Time
+	for _, c := range m.windows {
+		if n := c.next(t); !n.IsZero() && (first.IsZero() || n.Before(first)) {
+			first = n
+		}
+	}
+	return first
+}
+
+// upcoming returns the start of the next maintenance window after now, and
+// whether it was postponed.
+func (m *maintenanceSchedule) upcoming(now time.Time) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.upcomingLocked(now)
+}
+
+func (m *maintenanceSchedule) upcomingLocked(now time.Time) (time.Time, bool) {
+	if m.postponedTo.IsZero() || !now.Before(m.postponedTo) {
+		return m.earliest(now), false
+	}
+	next := m.earliest(now)
+	if next.Equal(m.postponedFrom) {
+		next = m.earliest(m.postponedFrom)
+	}
+	if !next.IsZero() && next.Before(m.postponedTo) {
+		return next, false
+	}
+	return m.postponedTo, true
+}
+
+// postpone implements the /maintenance endpoint: a duration (e.g. 1h)
+// postpones the next maintenance window by that long, skip postpones it to
+// the window after, and off reverts postponing.
+func (m *maintenanceSchedule) postpone(value string, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if value == "off" {
+		m.postponedFrom, m.postponedTo = time.Time{}, time.Time{}
+		return nil
+	}
+	next, postponed := m.upcomingLocked(now)
+	if next.IsZero() {
+		return fmt.Errorf("no upcoming maintenance window")
+	}
+	if !postponed {
+		m.postponedFrom = next
+	}
+	if value == "skip" {
+		after := m.earliest(next)
+		if after.IsZero() {
+			return fmt.Errorf("no maintenance window after %v", next)
+		}
+		m.postponedTo = after
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid postponement %q: expected a duration (e.g. 1h), skip or off", value)
+	}
+	m.postponedTo = next.Add(d)
+	return nil
+}
+
+// maintenanceStatus is the response of GET /maintenance.
+type maintenanceStatus struct {
+	Next      time.Time `json:"next"`
+	Postponed bool      `json:"postponed"`
+}
+
+func (d *statusDrawer) serveMaintenance(w http.ResponseWriter, r *http.Request) {
+	m := d.maintenance
+	if m == nil {
+		http.Error(w, "no -maintenance windows configured", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := d.postponeMaintenance(r.FormValue("postpone")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	next, postponed := m.upcoming(time.Now())
+	b, err := json.MarshalIndent(maintenanceStatus{Next: next, Postponed: postponed}, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+// postponeMaintenance postpones the next maintenance window, see postpone.
+func (d *statusDrawer) postponeMaintenance(value string) error {
+	if d.maintenance == nil {
+		return fmt.Errorf("no -maintenance windows configured")
+	}
+	if err := d.maintenance.postpone(value, time.Now()); err != nil {
+		return err
+	}
+	d.control.notify()
+	return nil
+}
+
+// formatCountdown formats the time left until a maintenance window: in
+// minutes, or in seconds during the last minute.
+func formatCountdown(left time.Duration) string {
+	if left < time.Minute {
+		if left < 0 {
+			left = 0
+		}
+		return left.Round(time.Second).String()
+	}
+	s := strings.TrimSuffix(left.Round(time.Minute).String(), "0s")
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// maintenanceBanner returns the text of the countdown banner at now, or the
+// empty string if no maintenance window is within -maintenance-warning.
+func (d *statusDrawer) maintenanceBanner(now time.Time) string {
+	if d.maintenance == nil {
+		return ""
+	}
+	next, postponed := d.maintenance.upcoming(now)
+	if next.IsZero() || next.Sub(now) > *maintenanceWarning {
+		return ""
+	}
+	text := *maintenanceMessage + " in " + formatCountdown(next.Sub(now)) + " (at " + next.Format("15:04") + ")"
+	if postponed {
+		text += ", postponed"
+	}
+	return text
+}
+
+// drawMaintenanceBanner draws the countdown banner across the top of the
+// display.
+func (d *statusDrawer) drawMaintenanceBanner(text string) {
+	em := 16 * d.scaleFactor
+	r := image.Rect(0, 0, d.w, int(2.5*em))
+	dc := d.overlayContext(r)
+	setColor(dc, "yellow")
+	dc.DrawRectangle(0, 0, float64(r.Dx()), float64(r.Dy()))
+	dc.Fill()
+	dc.SetFontFace(d.faces.medium)
+	dc.SetRGB(0, 0, 0) // white is illegible on yellow
+	dc.DrawStringAnchored(fitString(dc, text, float64(r.Dx())-2*em), float64(r.Dx())/2, float64(r.Dy())/2, 0.5, 0.35)
+	d.drawOverlay(dc, r, draw.Src)
+}
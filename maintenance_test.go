@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	// Saturday
+	now := time.Date(2022, 8, 13, 17, 35, 54, 0, time.UTC)
+	for _, tt := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2022, 8, 13, 17, 36, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2022, 8, 14, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * sun", time.Date(2022, 8, 14, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 7", time.Date(2022, 8, 14, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * mon-fri", time.Date(2022, 8, 15, 3, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2022, 8, 13, 17, 45, 0, 0, time.UTC)},
+		{"5/20 18 * * *", time.Date(2022, 8, 13, 18, 5, 0, 0, time.UTC)},
+		{"30 2 1 * *", time.Date(2022, 9, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week, as per crontab(5)
+		{"0 4 20 * mon", time.Date(2022, 8, 15, 4, 0, 0, 0, time.UTC)},
+		{"0 0 30 feb *", time.Time{}},
+	} {
+		c, err := parseCron(tt.expr)
+		if err != nil {
+			t.Errorf("parseCron(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := c.next(now); !got.Equal(tt.want) {
+			t.Errorf("%q: next(%v) = %v, want %v", tt.expr, now, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{
+		"",
+		"0 3 * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * someday",
+		"*/0 * * * *",
+		"5-1 * * * *",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) succeeded unexpectedly", expr)
+		}
+	}
+}
+
+func TestMaintenancePostpone(t *testing.T) {
+	now := time.Date(2022, 8, 13, 2, 30, 0, 0, time.UTC)
+	at3 := time.Date(2022, 8, 13, 3, 0, 0, 0, time.UTC)
+	m, err := parseMaintenanceSchedule("0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upcoming := func(want time.Time, wantPostponed bool) {
+		t.Helper()
+		got, postponed := m.upcoming(now)
+		if !got.Equal(want) || postponed != wantPostponed {
+			t.Errorf("upcoming(%v) = %v, %v, want %v, %v", now, got, postponed, want, wantPostponed)
+		}
+	}
+	upcoming(at3, false)
+
+	if err := m.postpone("1h", now); err != nil {
+		t.Fatal(err)
+	}
+	upcoming(at3.Add(time.Hour), true)
+	// postponing again adds to the postponement
+	if err := m.postpone("30m", now); err != nil {
+		t.Fatal(err)
+	}
+	upcoming(at3.Add(90*time.Minute), true)
+
+	if err := m.postpone("skip", now); err != nil {
+		t.Fatal(err)
+	}
+	upcoming(at3.AddDate(0, 0, 1), true)
+
+	if err := m.postpone("off", now); err != nil {
+		t.Fatal(err)
+	}
+	upcoming(at3, false)
+
+	if err := m.postpone("soon", now); err == nil {
+		t.Errorf("postpone(soon) succeeded unexpectedly")
+	}
+
+	// once the postponed window passed, the schedule continues as usual
+	if err := m.postpone("2h", now); err != nil {
+		t.Fatal(err)
+	}
+	now = at3.Add(2*time.Hour + time.Minute)
+	upcoming(at3.AddDate(0, 0, 1), false)
+}
+
+func TestFormatCountdown(t *testing.T) {
+	for _, tt := range []struct {
+		left time.Duration
+		want string
+	}{
+		{12*time.Minute + 10*time.Second, "12m"},
+		{time.Hour + 5*time.Minute, "1h5m"},
+		{2 * time.Hour, "2h"},
+		{45 * time.Second, "45s"},
+		{-time.Second, "0s"},
+	} {
+		if got := formatCountdown(tt.left); got != tt.want {
+			t.Errorf("formatCountdown(%v) = %q, want %q", tt.left, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"image"
+	"image/draw"
+	"time"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+var marqueeEnabled = flag.Bool("marquee",
+	true,
+	"scroll table cells which are too long to fit (e.g. long service names) horizontally instead of clipping them. Scrolling redraws only the affected cells, but at a higher frame rate; disable to save CPU on slow devices")
+
+const (
+	// marqueeInterval is how often marquees scroll, which is much more
+	// often than frameInterval, but only redraws the marquee cells.
+	marqueeInterval = 50 * time.Millisecond
+
+	// marqueePause is how long a marquee stays at the start of its text
+	// before scrolling, so that the beginning can be read.
+	marqueePause = 2 * time.Second
+
+	// marqueeSpeed is how fast marquees scroll, in ems per second.
+	marqueeSpeed = 4
+
+	// marqueeGap is the space between the end of the text and its
+	// repetition, in ems.
+	marqueeGap = 4
+)
+
+// marqueeOffset returns how far (in pixels) a marquee of the given text width
+// (including the gap to its repetition) has scrolled after elapsed, at speed
+// pixels per second. Marquees pause at the start of their text, then scroll
+// until the repetition is at the start.
+func marqueeOffset(elapsed time.Duration, width, speed float64) float64 {
+	scroll := time.Duration(width / speed * float64(time.Second))
+	t := elapsed % (marqueePause + scroll)
+	if t < marqueePause {
+		return 0
+	}
+	return (t - marqueePause).Seconds() * speed
+}
+
+// A marquee is a table cell whose text scrolls horizontally within the cell.
+type marquee struct {
+	rect     image.Rectangle // in d.buffer coordinates
+	text     string
+	color    string
+	face     font.Face
+	baseline float64 // within rect
+	width    float64 // of the text
+	em       float64
+	dc       *gg.Context // of rect size, re-used between frames
+}
+
+// render draws the marquee at its position after elapsed into m.dc.
+func (m *marquee) render(d *statusDrawer, elapsed time.Duration) {
+	if m.dc == nil {
+		m.dc = gg.NewContext(m.rect.Dx(), m.rect.Dy())
+		m.dc.SetFontFace(m.face)
+	}
+	d.clear(m.dc, m.rect.Min)
+	setColor(m.dc, m.color)
+	period := m.width + marqueeGap*m.em
+	x := -marqueeOffset(elapsed, period, marqueeSpeed*m.em)
+	m.dc.DrawString(m.text, x, m.baseline)
+	m.dc.DrawString(m.text, x+period, m.baseline)
+}
+
+// drawMarquee draws text in face (which must be selected in dc), which is
+// wider than width, as a marquee into dc at x, y (the baseline, as for
+// DrawString) and registers the marquee so that scrollMarquees keeps it
+// moving until the next frame.
+func (d *statusDrawer) drawMarquee(dc *gg.Context, face font.Face, text, color string, x, y, width float64) {
+	metrics := face.Metrics()
+	ascent := float64(metrics.Ascent.Ceil())
+	descent := float64(metrics.Descent.Ceil())
+	local := image.Rect(int(x), int(y-ascent), int(x+width), int(y+descent))
+	local = local.Intersect(image.Rect(0, 0, dc.Width(), dc.Height()))
+	if local.Empty() {
+		return
+	}
+	em, _ := dc.MeasureString("m")
+	w, _ := dc.MeasureString(text)
+	m := &marquee{
+		rect:     local.Add(d.panelOrigin),
+		text:     text,
+		color:    color,
+		face:     face,
+		baseline: y - float64(local.Min.Y),
+		width:    w,
+		em:       em,
+	}
+	m.render(d, time.Since(d.started))
+	dc.DrawImage(m.dc.Image(), local.Min.X, local.Min.Y)
+	d.marquees = append(d.marquees, m)
+}
+
+// scrollMarquees redraws the marquees of the current frame at their current
+// position, copying only their cells to the framebuffer.
+func (d *statusDrawer) scrollMarquees() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.marquees) == 0 || d.blanked || d.splash != nil || d.transition != nil || d.overlayShown {
+		// overlays and transitions cover the cells
+		return nil
+	}
+	elapsed := time.Since(d.started)
+	for _, m := range d.marquees {
+		m.render(d, elapsed)
+		draw.Draw(d.buffer, m.rect, m.dc.Image(), image.Point{}, draw.Src)
+		if d.shown != nil {
+			draw.Draw(d.shown, m.rect, m.dc.Image(), image.Point{}, draw.Src)
+		}
+		d.copyRect(d.buffer, m.rect)
+	}
+	return nil
+}
+
+// marqueeTick returns a channel which fires when the marquees need to scroll,
+// or nil if there are none.
+func (d *statusDrawer) marqueeTick() <-chan time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.marquees) == 0 {
+		return nil
+	}
+	return time.After(marqueeInterval)
+}
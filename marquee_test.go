@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/gokrazy/fbstatus/internal/fbimage"
+)
+
+func TestMarqueeOffset(t *testing.T) {
+	const (
+		width = 100 // pixels, i.e. scrolling takes 10s
+		speed = 10  // pixels per second
+	)
+	for _, tt := range []struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		{0, 0},
+		{marqueePause - time.Millisecond, 0},
+		{marqueePause + time.Second, 10},
+		{marqueePause + 5*time.Second, 50},
+		{marqueePause + 10*time.Second, 0}, // the next cycle starts
+		{2*marqueePause + 11*time.Second, 10},
+	} {
+		if got := marqueeOffset(tt.elapsed, width, speed); got != tt.want {
+			t.Errorf("marqueeOffset(%v) = %v, want %v", tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestShrinkColumns(t *testing.T) {
+	header := []string{"service", "state", "summary"}
+	for _, tt := range []struct {
+		name      string
+		widths    []int
+		scrolling []bool
+		maxWidth  int
+		want      []int
+	}{
+		{
+			name:      "fits",
+			widths:    []int{20, 10, 30},
+			scrolling: []bool{true, false, true},
+			maxWidth:  64,
+			want:      []int{20, 10, 30},
+		},
+		{
+			name:      "first column",
+			widths:    []int{20, 10, 30},
+			scrolling: []bool{true, false, false},
+			maxWidth:  54,
+			want:      []int{10, 10, 30},
+		},
+		{
+			name:      "down to the header",
+			widths:    []int{20, 10, 30},
+			scrolling: []bool{true, false, true},
+			maxWidth:  40,
+			want:      []int{7, 10, 19},
+		},
+		{
+			name:      "not scrolling",
+			widths:    []int{20, 10, 30},
+			scrolling: []bool{false, false, false},
+			maxWidth:  40,
+			want:      []int{20, 10, 30},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			shrinkColumns(tt.widths, header, tt.scrolling, tt.maxWidth)
+			if !reflect.DeepEqual(tt.widths, tt.want) {
+				t.Errorf("shrinkColumns() = %v, want %v", tt.widths, tt.want)
+			}
+		})
+	}
+}
+
+func TestCopyRectBGRA(t *testing.T) {
+	r := image.Rect(0, 0, 10, 10)
+	src := uniformRGBA(r, color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff})
+	dst := &fbimage.BGRA{Pix: make([]byte, 4*r.Dx()*r.Dy()), Rect: r, Stride: 4 * r.Dx()}
+	copyRGBAtoBGRA(dst, src, image.Rect(2, 3, 5, 4), nil)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			want := []byte{0, 0, 0, 0}
+			if (image.Point{x, y}).In(image.Rect(2, 3, 5, 4)) {
+				want = []byte{0x33, 0x22, 0x11, 0xff}
+			}
+			off := dst.PixOffset(x, y)
+			if got := dst.Pix[off : off+4]; !reflect.DeepEqual(got, want) {
+				t.Errorf("pixel %d,%d = %x, want %x", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestScrollMarquees(t *testing.T) {
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	*pagesFlag = "clock"
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.draw1(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d.overlayShown {
+		t.Skip("overlay shown, marquees do not scroll")
+	}
+
+	dc := gg.NewContext(300, 200)
+	d.panelOrigin = image.Pt(100, 50)
+	d.clear(dc, d.panelOrigin)
+	rows := [][]cell{
+		{{text: "short", scroll: true}, {text: "running"}},
+		{{text: "a-service-with-a-very-long-name-indeed", scroll: true}, {text: "running"}},
+	}
+	d.drawTable(dc, 20, []string{"service", "state"}, rows)
+	if got, want := len(d.marquees), 1; got != want {
+		t.Fatalf("len(marquees) = %d, want %d", got, want)
+	}
+	m := d.marquees[0]
+	if !m.rect.In(image.Rect(100, 50, 400, 250)) {
+		t.Errorf("marquee rect %v not within the panel", m.rect)
+	}
+
+	before := image.NewRGBA(img.Bounds())
+	copy(before.Pix, img.Pix)
+	// scroll past the pause
+	d.started = time.Now().Add(-marqueePause - time.Second)
+	if err := d.scrollMarquees(); err != nil {
+		t.Fatal(err)
+	}
+	dirty, _ := dirtyRect(before, img)
+	if dirty.Empty() {
+		t.Fatalf("scrollMarquees did not change the display")
+	}
+	if !dirty.In(m.rect) {
+		t.Errorf("scrollMarquees changed %v, outside of the marquee %v", dirty, m.rect)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/gokrazy/fbstatus/internal/mdns"
+)
+
+var mdnsFlag = flag.Bool("mdns",
+	true,
+	"if -http-listen is set, advertise the HTTP endpoints via multicast DNS as a _fbstatus._tcp service (with the screenshot and status URLs in its TXT record) and answer queries for <hostname>.local")
+
+// mdnsServiceType is the DNS-SD service type under which fbstatus advertises
+// its HTTP endpoints.
+const mdnsServiceType = "_fbstatus._tcp"
+
+// mdnsAdvertiser advertises the HTTP endpoints of fbstatus via mDNS, so that
+// other fbstatus instances and users can discover the device.
+type mdnsAdvertiser struct {
+	svc mdns.Service
+
+	mu  sync.Mutex
+	err error
+}
+
+// newMDNSAdvertiser returns an advertiser for the HTTP endpoints listening
+// on listen (as per -http-listen).
+func newMDNSAdvertiser(hostname, listen string) (*mdnsAdvertiser, error) {
+	_, portStr, err := net.SplitHostPort(listen)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("-http-listen=%s: invalid port: %v", listen, err)
+	}
+	base := fmt.Sprintf("http://%s.local:%d", hostname, port)
+	return &mdnsAdvertiser{
+		svc: mdns.Service{
+			Instance: hostname,
+			Service:  mdnsServiceType,
+			Host:     hostname,
+			Port:     uint16(port),
+			TXT: []string{
+				"path=/",
+				"screenshot=" + base + "/screenshot.png",
+				"status=" + base + "/status.json",
+			},
+			Addrs: mdnsAddrs,
+		},
+	}, nil
+}
+
+// mdnsAddrs returns the addresses of all network interfaces which are
+// reachable from other hosts on the local network.
+func mdnsAddrs() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		ips = append(ips, ipnet.IP)
+	}
+	return ips
+}
+
+func (a *mdnsAdvertiser) run(ctx context.Context) {
+	err := mdns.Advertise(ctx, a.svc)
+	if err != nil && err != ctx.Err() {
+		slog.Warn("mDNS advertisement failed", "err", err)
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.err = err
+	}
+}
+
+// line returns a host information line (in $color$text markup) with the
+// .local name under which the device can be reached.
+func (a *mdnsAdvertiser) line() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return "$$mdns: $red$" + a.err.Error()
+	}
+	return fmt.Sprintf("$$mdns: $green$%s.local$$, advertised as %s on port %d", a.svc.Host, mdnsServiceType, a.svc.Port)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// frameStats are cumulative statistics about drawing frames.
+type frameStats struct {
+	frames  uint64
+	dropped uint64        // frames skipped because drawing took too long
+	render  time.Duration // time spent rendering into the buffer
+	copy    time.Duration // time spent copying the buffer to the frame buffer
+}
+
+// writeMetric writes one metric (without labels) in the Prometheus text
+// exposition format.
+func writeMetric(buf *bytes.Buffer, name, typ, help string, value float64) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, typ)
+	fmt.Fprintf(buf, "%s %g\n", name, value)
+}
+
+// serveMetrics exports metrics about fbstatus itself in the Prometheus text
+// exposition format, e.g. to monitor how much CPU time rendering costs. The
+// frame rate is rate(fbstatus_frames_total[5m]).
+func (d *statusDrawer) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	stats := d.stats
+	lastRender, lastCopy := d.lastRender, d.lastCopy
+	d.mu.Unlock()
+
+	var buf bytes.Buffer
+	writeMetric(&buf, "fbstatus_frames_total", "counter",
+		"Number of frames drawn.",
+		float64(stats.frames))
+	writeMetric(&buf, "fbstatus_frames_dropped_total", "counter",
+		"Number of frames skipped because drawing the previous frame took longer than the frame interval.",
+		float64(stats.dropped))
+	writeMetric(&buf, "fbstatus_render_seconds_total", "counter",
+		"Time spent rendering frames into the buffer.",
+		stats.render.Seconds())
+	writeMetric(&buf, "fbstatus_copy_seconds_total", "counter",
+		"Time spent copying frames from the buffer to the frame buffer.",
+		stats.copy.Seconds())
+	writeMetric(&buf, "fbstatus_last_render_seconds", "gauge",
+		"Time spent rendering the most recent frame.",
+		lastRender.Seconds())
+	writeMetric(&buf, "fbstatus_last_copy_seconds", "gauge",
+		"Time spent copying the most recent frame.",
+		lastCopy.Seconds())
+	writeMetric(&buf, "fbstatus_frame_interval_seconds", "gauge",
+		"Configured interval between frames.",
+		frameInterval.Seconds())
+
+	if b, err := os.ReadFile("/proc/self/stat"); err == nil {
+		if sample, err := parseProcStat(b); err == nil {
+			writeMetric(&buf, "process_cpu_seconds_total", "counter",
+				"Total user and system CPU time spent in seconds.",
+				float64(sample.ticks)/clockTicks)
+			writeMetric(&buf, "process_resident_memory_bytes", "gauge",
+				"Resident memory size in bytes.",
+				float64(sample.rss))
+		}
+	}
+
+	fetchErrors.Lock()
+	sources := make([]string, 0, len(fetchErrors.bySource))
+	for source := range fetchErrors.bySource {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	buf.WriteString("# HELP fbstatus_fetch_errors_total Number of failed fetches of data sources (e.g. HTTP APIs) displayed by fbstatus.\n")
+	buf.WriteString("# TYPE fbstatus_fetch_errors_total counter\n")
+	for _, source := range sources {
+		fmt.Fprintf(&buf, "fbstatus_fetch_errors_total{source=%q} %d\n", source, fetchErrors.bySource[source])
+	}
+	fetchErrors.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if _, err := buf.WriteTo(w); err != nil {
+		slog.Debug("writing HTTP response failed", "err", err)
+	}
+}
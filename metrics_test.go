@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newFailingPoller() *poller[int] {
+	return newPoller(time.Minute, func(context.Context) (int, error) {
+		return 0, errors.New("unavailable")
+	})
+}
+
+func TestFetchSource(t *testing.T) {
+	if got, want := newFailingPoller().source, "newFailingPoller"; got != want {
+		t.Errorf("poller source = %q, want %q", got, want)
+	}
+	if got, want := fetchSource(sntpQuery), "sntpQuery"; got != want {
+		t.Errorf("fetchSource(sntpQuery) = %q, want %q", got, want)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := d.draw1(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := newFailingPoller()
+	p.get()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if _, _, err := p.get(); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("poller did not fail")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	d.httpHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"\nfbstatus_frames_total 2\n",
+		"# TYPE fbstatus_render_seconds_total counter\n",
+		"\nfbstatus_frames_dropped_total ",
+		`fbstatus_fetch_errors_total{source="newFailingPoller"} `,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, body)
+		}
+	}
+}
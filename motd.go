@@ -0,0 +1,443 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/opentype"
+)
+
+var motdFile = flag.String("motd",
+	"/perm/motd.md",
+	"markdown (.md) or plain text file to show in the motd panel, which is re-read when it changes, so that other programs can put messages on the display")
+
+// motdMaxSize bounds how much of the motd file is read; more would not fit
+// on the display anyway.
+const motdMaxSize = 64 * 1024
+
+// motdStyle is the text style of a span, as per the markdown emphasis.
+type motdStyle int
+
+const (
+	motdRegular motdStyle = iota
+	motdBold
+	motdItalic
+	motdCode
+)
+
+type motdSpan struct {
+	text  string
+	style motdStyle
+}
+
+type motdBlockKind int
+
+const (
+	motdParagraph motdBlockKind = iota
+	motdHeading
+	motdListItem
+	motdRule
+)
+
+// motdBlock is a paragraph, heading, list item or horizontal rule.
+type motdBlock struct {
+	kind   motdBlockKind
+	level  int    // heading level (1–3), or list nesting depth
+	marker string // list item marker, e.g. • or 1.
+	spans  []motdSpan
+}
+
+// parseInline splits markdown text into spans of **bold**, *italic* (or
+// _italic_) and `code` text. Links are replaced by their text.
+func parseInline(s string) []motdSpan {
+	var spans []motdSpan
+	var b strings.Builder
+	style := motdRegular
+	flush := func() {
+		if b.Len() > 0 {
+			spans = append(spans, motdSpan{text: b.String(), style: style})
+			b.Reset()
+		}
+	}
+	toggle := func(s motdStyle) {
+		flush()
+		if style == s {
+			style = motdRegular
+		} else {
+			style = s
+		}
+	}
+	for len(s) > 0 {
+		switch {
+		case style != motdCode && (strings.HasPrefix(s, "**") || strings.HasPrefix(s, "__")):
+			if style == motdRegular || style == motdBold {
+				toggle(motdBold)
+			}
+			s = s[2:]
+		case style != motdCode && (s[0] == '*' || s[0] == '_'):
+			// an underscore within a word (e.g. snake_case) is literal
+			if s[0] == '_' && b.Len() > 0 && !strings.HasSuffix(b.String(), " ") && style != motdItalic {
+				b.WriteByte(s[0])
+			} else if style == motdRegular || style == motdItalic {
+				toggle(motdItalic)
+			}
+			s = s[1:]
+		case s[0] == '`':
+			if style == motdRegular || style == motdCode {
+				toggle(motdCode)
+			}
+			s = s[1:]
+		case style != motdCode && s[0] == '[':
+			// [text](url)
+			end := strings.Index(s, "](")
+			closing := -1
+			if end > -1 {
+				closing = strings.IndexByte(s[end:], ')')
+			}
+			if end == -1 || closing == -1 {
+				b.WriteByte(s[0])
+				s = s[1:]
+				continue
+			}
+			b.WriteString(s[1:end])
+			s = s[end+closing+1:]
+		case s[0] == '\\' && len(s) > 1 && style != motdCode:
+			b.WriteByte(s[1])
+			s = s[2:]
+		default:
+			b.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+	flush()
+	return spans
+}
+
+// listItem returns the marker and text of a markdown list item line, e.g.
+// “- text” or “1. text”.
+func listItem(line string) (marker, text string, ok bool) {
+	for _, bullet := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, bullet) {
+			return "•", line[len(bullet):], true
+		}
+	}
+	digits := 0
+	for digits < len(line) && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 && (strings.HasPrefix(line[digits:], ". ") || strings.HasPrefix(line[digits:], ") ")) {
+		return line[:digits+1], line[digits+2:], true
+	}
+	return "", "", false
+}
+
+// parseMOTD parses the motd file contents into blocks. Markdown is parsed
+// only if markdown is true, otherwise each line is a paragraph of its own.
+func parseMOTD(text string, markdown bool) []motdBlock {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var blocks []motdBlock
+	if !markdown {
+		for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+			blocks = append(blocks, motdBlock{
+				kind:  motdParagraph,
+				spans: []motdSpan{{text: strings.ReplaceAll(line, "\t", "    ")}},
+			})
+		}
+		return blocks
+	}
+	var paragraph []string
+	endParagraph := func() {
+		if len(paragraph) > 0 {
+			blocks = append(blocks, motdBlock{
+				kind:  motdParagraph,
+				spans: parseInline(strings.Join(paragraph, " ")),
+			})
+			paragraph = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		indent := len(strings.ReplaceAll(line, "\t", "    ")) - len(strings.TrimLeft(strings.ReplaceAll(line, "\t", "    "), " "))
+		switch {
+		case trimmed == "":
+			endParagraph()
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			if level > 6 || !strings.HasPrefix(trimmed[level:], " ") {
+				paragraph = append(paragraph, trimmed)
+				continue
+			}
+			endParagraph()
+			if level > 3 {
+				level = 3
+			}
+			blocks = append(blocks, motdBlock{
+				kind:  motdHeading,
+				level: level,
+				spans: parseInline(strings.TrimSpace(strings.TrimRight(trimmed[level:], "#"))),
+			})
+		case strings.Trim(trimmed, "-") == "" && len(trimmed) >= 3,
+			strings.Trim(trimmed, "*") == "" && len(trimmed) >= 3:
+			endParagraph()
+			blocks = append(blocks, motdBlock{kind: motdRule})
+		default:
+			if marker, item, ok := listItem(trimmed); ok {
+				endParagraph()
+				blocks = append(blocks, motdBlock{
+					kind:   motdListItem,
+					level:  indent / 2,
+					marker: marker,
+					spans:  parseInline(item),
+				})
+				continue
+			}
+			if n := len(blocks); n > 0 && len(paragraph) == 0 && blocks[n-1].kind == motdListItem && indent > 0 {
+				// continuation of the list item
+				blocks[n-1].spans = append(blocks[n-1].spans, parseInline(" "+trimmed)...)
+				continue
+			}
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	endParagraph()
+	return blocks
+}
+
+// wrapSpans breaks spans into lines which are at most width wide, as per
+// measure, breaking at spaces.
+func wrapSpans(spans []motdSpan, width float64, measure func(motdSpan) float64) [][]motdSpan {
+	var lines [][]motdSpan
+	var line []motdSpan
+	var x float64
+	for _, span := range spans {
+		words := strings.SplitAfter(span.text, " ")
+		for _, word := range words {
+			if word == "" {
+				continue
+			}
+			w := motdSpan{text: word, style: span.style}
+			ww := measure(motdSpan{text: strings.TrimRight(word, " "), style: span.style})
+			if x > 0 && x+ww > width {
+				lines = append(lines, line)
+				line, x = nil, 0
+				if strings.TrimSpace(word) == "" {
+					continue
+				}
+			}
+			if n := len(line); n > 0 && line[n-1].style == w.style {
+				line[n-1].text += w.text
+			} else {
+				line = append(line, w)
+			}
+			x += measure(w)
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// motdDocument is the parsed motd file.
+type motdDocument struct {
+	title  string // the leading heading, if any
+	blocks []motdBlock
+}
+
+// motdPanel shows a markdown or plain text file, e.g. a message of the day
+// which other programs write to /perm.
+type motdPanel struct {
+	doc *poller[motdDocument]
+
+	// created on first draw, when the font size is known
+	faces map[motdStyle]font.Face
+	h1    font.Face
+}
+
+func readMOTD(path string) (motdDocument, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return motdDocument{}, err
+	}
+	defer f.Close()
+	b, err := io.ReadAll(io.LimitReader(f, motdMaxSize))
+	if err != nil {
+		return motdDocument{}, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	doc := motdDocument{blocks: parseMOTD(string(b), ext == ".md" || ext == ".markdown")}
+	if len(doc.blocks) > 0 && doc.blocks[0].kind == motdHeading && doc.blocks[0].level == 1 {
+		for _, span := range doc.blocks[0].spans {
+			doc.title += span.text
+		}
+		doc.blocks = doc.blocks[1:]
+	}
+	return doc, nil
+}
+
+func newMOTDPanel() (panel, error) {
+	path := *motdFile
+	var (
+		modTime time.Time
+		size    int64
+		doc     motdDocument
+	)
+	return &motdPanel{
+		doc: newPoller(2*time.Second, func(context.Context) (motdDocument, error) {
+			fi, err := os.Stat(path)
+			if err != nil {
+				return motdDocument{}, err
+			}
+			if fi.ModTime().Equal(modTime) && fi.Size() == size {
+				return doc, nil // unchanged
+			}
+			d, err := readMOTD(path)
+			if err != nil {
+				return motdDocument{}, err
+			}
+			doc, modTime, size = d, fi.ModTime(), fi.Size()
+			return doc, nil
+		}),
+	}, nil
+}
+
+func (p *motdPanel) initFaces(d *statusDrawer) error {
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return err
+	}
+	italic, err := opentype.Parse(goitalic.TTF)
+	if err != nil {
+		return err
+	}
+	size := 16 * d.scaleFactor // as per newStatusDrawer
+	p.faces = map[motdStyle]font.Face{
+		motdRegular: d.face,
+		motdBold:    d.newFace(bold, size),
+		motdItalic:  d.newFace(italic, size),
+		motdCode:    d.monoface,
+	}
+	p.h1 = d.newFace(bold, 1.5*size)
+	return nil
+}
+
+func (p *motdPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	doc, updated, err := p.doc.get()
+	title := doc.title
+	if title == "" {
+		title = "Message of the day"
+	}
+	y := d.drawTitle(dc, title)
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	if p.faces == nil {
+		if err := p.initFaces(d); err != nil {
+			return err
+		}
+	}
+	em, _ := dc.MeasureString("m")
+	lineHeight := dc.FontHeight() * lineSpacing
+	measure := func(s motdSpan) float64 {
+		dc.SetFontFace(p.faces[s.style])
+		w, _ := dc.MeasureString(s.text)
+		return w
+	}
+	defer dc.SetFontFace(d.face)
+	drawLine := func(line []motdSpan, x float64) {
+		var text string
+		for _, span := range line {
+			dc.SetFontFace(p.faces[span.style])
+			if span.style == motdCode {
+				setColor(dc, "cyan")
+			}
+			dc.DrawString(span.text, x, y)
+			dc.SetRGB(1, 1, 1)
+			w, _ := dc.MeasureString(span.text)
+			x += w
+			text += span.text
+		}
+		if d.recording != nil {
+			d.recording.Messages = append(d.recording.Messages, strings.TrimSpace(text))
+		}
+	}
+	width := float64(dc.Width()) - 6*em
+	bottom := float64(dc.Height()) - em
+	for idx, block := range doc.blocks {
+		if y > bottom {
+			break
+		}
+		x := 3 * em
+		switch block.kind {
+		case motdRule:
+			setColor(dc, "darkgray")
+			dc.SetLineWidth(1)
+			dc.DrawLine(x, y-lineHeight/3, x+width, y-lineHeight/3)
+			dc.Stroke()
+			dc.SetRGB(1, 1, 1)
+			y += lineHeight
+			continue
+		case motdHeading:
+			if idx > 0 {
+				y += lineHeight / 2
+			}
+			spans := make([]motdSpan, 0, len(block.spans))
+			for _, span := range block.spans {
+				spans = append(spans, motdSpan{text: span.text, style: motdBold})
+			}
+			if block.level == 1 {
+				dc.SetFontFace(p.h1)
+				text := fitString(dc, spanText(spans), width)
+				y += dc.FontHeight() / 2
+				dc.DrawString(text, x, y)
+				if d.recording != nil {
+					d.recording.Messages = append(d.recording.Messages, text)
+				}
+				y += lineHeight
+				continue
+			}
+			block.spans = spans
+		case motdListItem:
+			x += float64(block.level) * 2 * em
+			dc.SetFontFace(d.face)
+			setColor(dc, "darkgray")
+			dc.DrawString(block.marker, x, y)
+			dc.SetRGB(1, 1, 1)
+			x += 2 * em
+		}
+		for _, line := range wrapSpans(block.spans, width-(x-3*em), measure) {
+			if y > bottom {
+				break
+			}
+			drawLine(line, x)
+			y += lineHeight
+		}
+		if block.kind == motdParagraph || block.kind == motdHeading {
+			y += lineHeight / 2
+		}
+	}
+	return nil
+}
+
+// spanText returns the text of spans, without styles.
+func spanText(spans []motdSpan) string {
+	var s strings.Builder
+	for _, span := range spans {
+		s.WriteString(span.text)
+	}
+	return s.String()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseInline(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want []motdSpan
+	}{
+		{"plain text", []motdSpan{{"plain text", motdRegular}}},
+		{
+			"a **bold** and *italic* word",
+			[]motdSpan{
+				{"a ", motdRegular},
+				{"bold", motdBold},
+				{" and ", motdRegular},
+				{"italic", motdItalic},
+				{" word", motdRegular},
+			},
+		},
+		{"run `go **test**`", []motdSpan{{"run ", motdRegular}, {"go **test**", motdCode}}},
+		{"see [the docs](https://gokrazy.org/)!", []motdSpan{{"see the docs!", motdRegular}}},
+		{"snake_case_name", []motdSpan{{"snake_case_name", motdRegular}}},
+		{`\*not italic\*`, []motdSpan{{"*not italic*", motdRegular}}},
+	} {
+		if got := parseInline(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseInline(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMOTD(t *testing.T) {
+	const md = `# Maintenance
+
+The **NAS** is down
+for maintenance.
+
+## Affected
+- backups
+  (nightly)
+  - offsite
+1. wait
+---
+#hashtag
+`
+	want := []motdBlock{
+		{kind: motdHeading, level: 1, spans: []motdSpan{{"Maintenance", motdRegular}}},
+		{kind: motdParagraph, spans: []motdSpan{
+			{"The ", motdRegular},
+			{"NAS", motdBold},
+			{" is down for maintenance.", motdRegular},
+		}},
+		{kind: motdHeading, level: 2, spans: []motdSpan{{"Affected", motdRegular}}},
+		{kind: motdListItem, marker: "•", spans: []motdSpan{{"backups", motdRegular}, {" (nightly)", motdRegular}}},
+		{kind: motdListItem, level: 1, marker: "•", spans: []motdSpan{{"offsite", motdRegular}}},
+		{kind: motdListItem, marker: "1.", spans: []motdSpan{{"wait", motdRegular}}},
+		{kind: motdRule},
+		{kind: motdParagraph, spans: []motdSpan{{"#hashtag", motdRegular}}},
+	}
+	if got := parseMOTD(md, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMOTD() =\n%v\nwant\n%v", got, want)
+	}
+
+	wantPlain := []motdBlock{
+		{kind: motdParagraph, spans: []motdSpan{{"# not a heading", motdRegular}}},
+		{kind: motdParagraph, spans: []motdSpan{{"**literal**", motdRegular}}},
+	}
+	if got := parseMOTD("# not a heading\n**literal**\n", false); !reflect.DeepEqual(got, wantPlain) {
+		t.Errorf("parseMOTD(plain) = %v, want %v", got, wantPlain)
+	}
+}
+
+func TestWrapSpans(t *testing.T) {
+	// every character is 1 wide
+	measure := func(s motdSpan) float64 { return float64(len(s.text)) }
+	spans := []motdSpan{
+		{"the quick ", motdRegular},
+		{"brown", motdBold},
+		{" fox jumps", motdRegular},
+	}
+	got := wrapSpans(spans, 10, measure)
+	want := [][]motdSpan{
+		{{"the quick ", motdRegular}},
+		{{"brown", motdBold}, {" fox ", motdRegular}},
+		{{"jumps", motdRegular}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrapSpans() = %v, want %v", got, want)
+	}
+}
+
+func TestReadMOTD(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "motd.md")
+	if err := os.WriteFile(path, []byte("# Hello\n\nworld\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := readMOTD(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.title, "Hello"; got != want {
+		t.Errorf("title = %q, want %q", got, want)
+	}
+	if got, want := len(doc.blocks), 1; got != want {
+		t.Errorf("len(blocks) = %d, want %d", got, want)
+	}
+}
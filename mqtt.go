@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/gokrazy/fbstatus/internal/mqtt"
+)
+
+var (
+	mqttBroker = flag.String("mqtt-broker",
+		"",
+		"MQTT broker to connect to, specified as mqtt://[user:password@]host[:port]")
+
+	mqttTopics = flag.String("mqtt-topics",
+		"",
+		"comma-separated list of MQTT topics whose latest value to display in the mqtt panel, each specified as label[:unit]=topic, e.g. living room:°C=home/living/temperature,heating=home/heating/state")
+)
+
+// dialMQTT connects to the broker specified in -mqtt-broker.
+func dialMQTT(ctx context.Context) (*mqtt.Client, error) {
+	if *mqttBroker == "" {
+		return nil, errors.New("-mqtt-broker not set")
+	}
+	u, err := url.Parse(*mqttBroker)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "mqtt" {
+		return nil, fmt.Errorf("-mqtt-broker: unsupported scheme %q, expected mqtt://", u.Scheme)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "1883")
+	}
+	hostname, _ := os.Hostname()
+	opts := mqtt.Options{
+		// Client IDs must be unique per broker.
+		ClientID:  "fbstatus-" + hostname + "-" + strconv.Itoa(os.Getpid()),
+		KeepAlive: time.Minute,
+	}
+	if u.User != nil {
+		opts.Username = u.User.Username()
+		opts.Password, _ = u.User.Password()
+	}
+	return mqtt.Dial(ctx, addr, opts)
+}
+
+type mqttTopic struct {
+	label string
+	unit  string
+	topic string
+}
+
+func parseMQTTTopics(spec string) ([]mqttTopic, error) {
+	var topics []mqttTopic
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		label, topic, ok := strings.Cut(s, "=")
+		if !ok || label == "" || topic == "" {
+			return nil, fmt.Errorf("malformed MQTT topic %q: expected label[:unit]=topic", s)
+		}
+		label, unit, _ := strings.Cut(label, ":")
+		topics = append(topics, mqttTopic{
+			label: label,
+			unit:  unit,
+			topic: topic,
+		})
+	}
+	return topics, nil
+}
+
+// formatMQTTValue renders a payload for display: numbers are shown with
+// their unit, on/off states are colored.
+func formatMQTTValue(payload, unit string) cell {
+	payload = strings.TrimSpace(payload)
+	switch strings.ToLower(payload) {
+	case "on", "true", "open":
+		return cell{text: payload, color: "green"}
+	case "off", "false", "closed":
+		return cell{text: payload, color: "darkgray"}
+	}
+	if _, err := strconv.ParseFloat(payload, 64); err == nil && unit != "" {
+		return cell{text: payload + " " + unit}
+	}
+	return cell{text: payload}
+}
+
+type mqttValue struct {
+	payload  string
+	received time.Time
+}
+
+// mqttPanel shows the latest value published to each of the topics
+// specified in -mqtt-topics.
+type mqttPanel struct {
+	topics []mqttTopic
+
+	mu     sync.Mutex
+	values map[string]mqttValue // by topic
+	err    error
+}
+
+func newMQTTPanel() (panel, error) {
+	topics, err := parseMQTTTopics(*mqttTopics)
+	if err != nil {
+		return nil, err
+	}
+	p := &mqttPanel{
+		topics: topics,
+		values: make(map[string]mqttValue),
+	}
+	if *mqttBroker == "" {
+		p.err = errors.New("-mqtt-broker not set")
+		return p, nil
+	}
+	go func() {
+		for {
+			err := p.subscribe()
+			p.mu.Lock()
+			p.err = err
+			p.mu.Unlock()
+			time.Sleep(10 * time.Second)
+		}
+	}()
+	return p, nil
+}
+
+// subscribe connects to the broker and records the values of all topics
+// until the connection fails.
+func (p *mqttPanel) subscribe() error {
+	ctx, canc := context.WithTimeout(context.Background(), 10*time.Second)
+	defer canc()
+	c, err := dialMQTT(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	topics := make([]string, 0, len(p.topics))
+	for _, t := range p.topics {
+		topics = append(topics, t.topic)
+	}
+	if err := c.Subscribe(topics...); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.err = nil
+	p.mu.Unlock()
+	for {
+		msg, err := c.ReadMessage()
+		if err != nil {
+			return err
+		}
+		p.mu.Lock()
+		p.values[msg.Topic] = mqttValue{
+			payload:  string(msg.Payload),
+			received: time.Now(),
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (p *mqttPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "MQTT")
+	p.mu.Lock()
+	err := p.err
+	rows := make([][]cell, 0, len(p.topics))
+	for _, t := range p.topics {
+		val, ok := p.values[t.topic]
+		if !ok {
+			rows = append(rows, []cell{
+				{text: t.label},
+				{text: "—", color: "darkgray"},
+				{text: ""},
+			})
+			continue
+		}
+		rows = append(rows, []cell{
+			{text: t.label},
+			formatMQTTValue(val.payload, t.unit),
+			{text: time.Since(val.received).Round(time.Second).String() + " ago", color: "darkgray"},
+		})
+	}
+	p.mu.Unlock()
+	if err != nil {
+		d.drawMessage(dc, y, "unavailable: "+err.Error())
+		y += dc.FontHeight() * lineSpacing
+	}
+	if len(rows) == 0 {
+		d.drawMessage(dc, y, "no topics configured (-mqtt-topics)")
+		return nil
+	}
+	d.drawTable(dc, y, []string{"topic", "value", "updated"}, rows)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMQTTTopics(t *testing.T) {
+	got, err := parseMQTTTopics("living room:°C=home/living/temperature,heating=home/heating/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []mqttTopic{
+		{label: "living room", unit: "°C", topic: "home/living/temperature"},
+		{label: "heating", topic: "home/heating/state"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMQTTTopics() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFormatMQTTValue(t *testing.T) {
+	for _, tt := range []struct {
+		payload, unit string
+		want          cell
+	}{
+		{"21.5", "°C", cell{text: "21.5 °C"}},
+		{"ON", "", cell{text: "ON", color: "green"}},
+		{"off\n", "", cell{text: "off", color: "darkgray"}},
+		{"heating", "°C", cell{text: "heating"}},
+	} {
+		if got := formatMQTTValue(tt.payload, tt.unit); got != tt.want {
+			t.Errorf("formatMQTTValue(%q, %q) = %+v, want %+v", tt.payload, tt.unit, got, tt.want)
+		}
+	}
+}
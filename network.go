@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type defaultRoute struct {
+	iface   string
+	gateway net.IP
+	metric  int
+}
+
+// parseDefaultRoutes returns the IPv4 default routes from /proc/net/route,
+// preferred (lowest metric) route first.
+func parseDefaultRoutes(routes []byte) ([]defaultRoute, error) {
+	var defaults []defaultRoute
+	scanner := bufio.NewScanner(strings.NewReader(string(routes)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 7 || fields[1] != "00000000" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != 4 {
+			continue
+		}
+		metric, err := strconv.Atoi(fields[6])
+		if err != nil {
+			continue
+		}
+		defaults = append(defaults, defaultRoute{
+			iface: fields[0],
+			// /proc/net/route contains addresses in host byte order, which
+			// is little endian on all architectures gokrazy supports.
+			gateway: net.IPv4(b[3], b[2], b[1], b[0]),
+			metric:  metric,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(defaults, func(i, j int) bool {
+		return defaults[i].metric < defaults[j].metric
+	})
+	return defaults, nil
+}
+
+// parseNameservers returns the nameservers from resolv.conf(5) contents.
+func parseNameservers(resolvConf []byte) []string {
+	var servers []string
+	scanner := bufio.NewScanner(strings.NewReader(string(resolvConf)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// hardwareAddr is the MAC address of a network interface.
+type hardwareAddr struct {
+	iface string
+	addr  string // e.g. dc:a6:32:01:23:45
+}
+
+// hardwareAddrs returns the MAC addresses of the network interfaces in root
+// (/sys/class/net) which are backed by a device (wired or wireless), e.g. to
+// register them in a DHCP server.
+func hardwareAddrs(root string) []hardwareAddr {
+	dirs, err := filepath.Glob(filepath.Join(root, "*"))
+	if err != nil {
+		return nil
+	}
+	var addrs []hardwareAddr
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(dir, "device")); err != nil {
+			continue // virtual, e.g. lo, bridges or tunnels
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "address"))
+		if err != nil {
+			continue
+		}
+		addr := strings.TrimSpace(string(b))
+		if addr == "" || addr == "00:00:00:00:00:00" {
+			continue
+		}
+		addrs = append(addrs, hardwareAddr{iface: filepath.Base(dir), addr: addr})
+	}
+	return addrs
+}
+
+// macLine returns a host information line (in $color$text markup) with the
+// MAC address of each interface, or the empty string if there are none.
+func macLine(addrs []hardwareAddr) string {
+	if len(addrs) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		parts = append(parts, a.iface+" "+a.addr)
+	}
+	return "$$MAC: " + strings.Join(parts, ", ")
+}
+
+// dhcpLease is what the gokrazy DHCP client logs for each DHCPACK. The
+// client does not persist its lease (nor log the lease time), so the time of
+// the most recent DHCPACK is the best indication of lease health there is.
+type dhcpLease struct {
+	acked   time.Time
+	details string // e.g. IP 10.0.0.5/24, router 10.0.0.1, DNS [10.0.0.1]
+}
+
+// parseDHCPLog returns the most recent DHCPACK from the gokrazy DHCP client
+// log output, whose lines look like this:
+//
+//	2022/08/13 17:35:54 dhcp.go:159: DHCPACK: IP 10.0.0.5/24, router 10.0.0.1
+func parseDHCPLog(log []byte) (dhcpLease, error) {
+	var lease dhcpLease
+	scanner := bufio.NewScanner(strings.NewReader(string(log)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		_, details, ok := strings.Cut(line, " DHCPACK: ")
+		if !ok || len(line) < len("2006/01/02 15:04:05") {
+			continue
+		}
+		acked, err := time.ParseInLocation("2006/01/02 15:04:05", line[:len("2006/01/02 15:04:05")], time.Local)
+		if err != nil {
+			continue
+		}
+		lease = dhcpLease{
+			acked:   acked,
+			details: details,
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return dhcpLease{}, err
+	}
+	if lease.acked.IsZero() {
+		return dhcpLease{}, errors.New("no DHCPACK logged")
+	}
+	return lease, nil
+}
+
+// readDHCPLog returns the log output of the gokrazy DHCP client which gokrazy
+// retains in memory. The log endpoint streams new lines indefinitely after
+// sending the retained ones, so stop reading after a short while.
+func readDHCPLog(ctx context.Context) ([]byte, error) {
+	ctx, canc := context.WithTimeout(ctx, 2*time.Second)
+	defer canc()
+	resp, err := gokrazyRequest(ctx, "/log?"+url.Values{
+		"path":   []string{"/gokrazy/dhcp"},
+		"stream": []string{"stderr"},
+	}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var log []byte
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		log = append(log, scanner.Bytes()...)
+		log = append(log, '\n')
+	}
+	if ctx.Err() != nil {
+		return log, nil // expected
+	}
+	return log, scanner.Err()
+}
+
+// networkConfig shows the default route, DNS servers, MAC addresses and DHCP
+// state, so that misconfigured networks are obvious.
+type networkConfig struct {
+	dhcp *poller[dhcpLease]
+}
+
+func newNetworkConfig() *networkConfig {
+	return &networkConfig{
+		dhcp: newPoller(time.Minute, func(ctx context.Context) (dhcpLease, error) {
+			log, err := readDHCPLog(ctx)
+			if err != nil {
+				return dhcpLease{}, err
+			}
+			return parseDHCPLog(log)
+		}),
+	}
+}
+
+// lines returns host information lines (in $color$text markup).
+func (n *networkConfig) lines() []string {
+	route := "$$network: "
+	b, err := os.ReadFile("/proc/net/route")
+	if err == nil {
+		var defaults []defaultRoute
+		defaults, err = parseDefaultRoutes(b)
+		if err == nil && len(defaults) == 0 {
+			err = errors.New("no default route")
+		}
+		if err == nil {
+			descs := make([]string, 0, len(defaults))
+			for _, r := range defaults {
+				descs = append(descs, fmt.Sprintf("%v via %s", r.gateway, r.iface))
+			}
+			route += "$$default " + strings.Join(descs, ", ")
+		}
+	}
+	if err != nil {
+		route += "$red$" + err.Error()
+	}
+
+	if b, err := os.ReadFile("/etc/resolv.conf"); err != nil {
+		route += "$$, DNS $red$" + err.Error()
+	} else if servers := parseNameservers(b); len(servers) == 0 {
+		route += "$$, DNS $red$no nameservers"
+	} else {
+		route += "$$, DNS " + strings.Join(servers, " ")
+	}
+
+	dhcp := "$$dhcp: "
+	lease, updated, err := n.dhcp.get()
+	switch {
+	case updated.IsZero() && err != nil:
+		dhcp += "$darkgray$unknown (" + err.Error() + ")"
+	case updated.IsZero():
+		dhcp += "$darkgray$loading…"
+	default:
+		dhcp += "$$last DHCPACK " + time.Since(lease.acked).Round(time.Second).String() + " ago: " + lease.details
+	}
+	lines := []string{route}
+	if line := macLine(hardwareAddrs("/sys/class/net")); line != "" {
+		lines = append(lines, line)
+	}
+	return append(lines, dhcp)
+}
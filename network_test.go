@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDefaultRoutes(t *testing.T) {
+	const routes = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+wlan0	00000000	0101A8C0	0003	0	0	2	00000000	0	0	0
+eth0	000200C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+eth0	00000000	010200C0	0003	0	0	1	00000000	0	0	0
+`
+	got, err := parseDefaultRoutes([]byte(routes))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []defaultRoute{
+		{iface: "eth0", gateway: net.IPv4(192, 0, 2, 1), metric: 1},
+		{iface: "wlan0", gateway: net.IPv4(192, 168, 1, 1), metric: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDefaultRoutes() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseNameservers(t *testing.T) {
+	const resolvConf = `domain lan
+search lan
+nameserver 192.0.2.1
+nameserver 2001:db8::1
+`
+	got := parseNameservers([]byte(resolvConf))
+	want := []string{"192.0.2.1", "2001:db8::1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseNameservers() = %v, want %v", got, want)
+	}
+}
+
+func TestParseDHCPLog(t *testing.T) {
+	const log = `2022/08/13 17:35:54 dhcp.go:159: DHCPACK: IP 10.0.0.5/24, router 10.0.0.1, DNS [10.0.0.1]
+2022/08/13 17:35:54 dhcp.go:240: adjusting route [dst=10.0.0.0/24 src=10.0.0.5 gw=<nil>] priority to 1
+2022/08/14 05:35:54 dhcp.go:159: DHCPACK: IP 10.0.0.5/24, router 10.0.0.1, DNS [10.0.0.2]
+`
+	got, err := parseDHCPLog([]byte(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := dhcpLease{
+		acked:   time.Date(2022, 8, 14, 5, 35, 54, 0, time.Local),
+		details: "IP 10.0.0.5/24, router 10.0.0.1, DNS [10.0.0.2]",
+	}
+	if !got.acked.Equal(want.acked) || got.details != want.details {
+		t.Errorf("parseDHCPLog() = %+v, want %+v", got, want)
+	}
+
+	if _, err := parseDHCPLog([]byte("dhcp.go:398: no DHCPOFFER received\n")); err == nil {
+		t.Errorf("parseDHCPLog(no DHCPACK) unexpectedly succeeded")
+	}
+}
+
+func TestHardwareAddrs(t *testing.T) {
+	root := t.TempDir()
+	for iface, addr := range map[string]string{
+		"lo":    "00:00:00:00:00:00",
+		"br0":   "02:42:ac:11:00:01",
+		"eth0":  "dc:a6:32:01:23:45",
+		"wlan0": "dc:a6:32:01:23:46",
+		"can0":  "",
+	} {
+		dir := filepath.Join(root, iface)
+		if iface != "lo" && iface != "br0" {
+			dir = filepath.Join(dir, "device")
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, iface, "address"), []byte(addr+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := hardwareAddrs(root)
+	want := []hardwareAddr{
+		{iface: "eth0", addr: "dc:a6:32:01:23:45"},
+		{iface: "wlan0", addr: "dc:a6:32:01:23:46"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hardwareAddrs() = %+v, want %+v", got, want)
+	}
+	if got, want := macLine(got), "$$MAC: eth0 dc:a6:32:01:23:45, wlan0 dc:a6:32:01:23:46"; got != want {
+		t.Errorf("macLine() = %q, want %q", got, want)
+	}
+	if got := macLine(nil); got != "" {
+		t.Errorf("macLine(nil) = %q, want empty", got)
+	}
+}
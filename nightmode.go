@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	nightTemperature = flag.Int("night-temperature",
+		0,
+		"if non-zero, color temperature in Kelvin (e.g. 3400) to which the display shifts at night (see -night-schedule and -night-lux), like redshift. The shift is applied when copying to the framebuffer")
+
+	nightSchedule = flag.String("night-schedule",
+		"20:00-07:00",
+		"local time range in which -night-temperature applies, fading in and out over 30 minutes")
+
+	nightLux = flag.Float64("night-lux",
+		0,
+		"if non-zero, apply -night-temperature whenever an ambient light sensor (IIO, in /sys/bus/iio/devices) measures less than this many lux, instead of as per -night-schedule")
+)
+
+const (
+	// nightFade is how long the shift fades in and out as per
+	// -night-schedule.
+	nightFade = 30 * time.Minute
+
+	// nightSensorFade is how long the shift fades in and out as per
+	// -night-lux, which smoothes over brief changes of the light.
+	nightSensorFade = time.Minute
+
+	// nightSteps is the number of steps in which the shift fades, each of
+	// which requires computing a new colorCurve.
+	nightSteps = 32
+)
+
+// whitepoints are the red, green and blue factors which approximate the
+// color of black body radiation at 2000 K, 2500 K, …, 6500 K (which is
+// neutral).
+var whitepoints = [][3]float64{
+	{1.00, 0.54, 0.09},
+	{1.00, 0.64, 0.29},
+	{1.00, 0.72, 0.43},
+	{1.00, 0.78, 0.55},
+	{1.00, 0.83, 0.65},
+	{1.00, 0.87, 0.74},
+	{1.00, 0.90, 0.81},
+	{1.00, 0.94, 0.88},
+	{1.00, 0.97, 0.94},
+	{1.00, 1.00, 1.00},
+}
+
+// whitepoint returns the tint for the color temperature kelvin, as per
+// whitepoints.
+func whitepoint(kelvin int) [3]float64 {
+	pos := (float64(kelvin) - 2000) / 500
+	if pos <= 0 {
+		return whitepoints[0]
+	}
+	if pos >= float64(len(whitepoints)-1) {
+		return whitepoints[len(whitepoints)-1]
+	}
+	idx := int(pos)
+	frac := pos - float64(idx)
+	var tint [3]float64
+	for c := range tint {
+		tint[c] = whitepoints[idx][c] + frac*(whitepoints[idx+1][c]-whitepoints[idx][c])
+	}
+	return tint
+}
+
+// parseTimeRange parses a range of local times like 20:00-07:00 into the
+// durations since midnight.
+func parseTimeRange(s string) (start, end time.Duration, _ error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("malformed time range %q: expected e.g. 20:00-07:00", s)
+	}
+	parse := func(s string) (time.Duration, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("malformed time range: %v", err)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+	var err error
+	if start, err = parse(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parse(to); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// scheduledStrength returns how strongly (0 to 1) the night shift applies
+// at now for the time range from start to end (since midnight, which can
+// span midnight), fading in from start and fading out until end.
+func scheduledStrength(now time.Time, start, end time.Duration) float64 {
+	const day = 24 * time.Hour
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	since := now.Sub(midnight)
+	length := (end - start + day) % day
+	// how long ago the night started
+	into := (since - start + day) % day
+	if into >= length {
+		return 0
+	}
+	left := length - into
+	strength := 1.0
+	if into < nightFade {
+		strength = float64(into) / float64(nightFade)
+	}
+	if left < nightFade {
+		strength = math.Min(strength, float64(left)/float64(nightFade))
+	}
+	return strength
+}
+
+// readIlluminance returns the illuminance in lux measured by the IIO light
+// sensor in dir (e.g. /sys/bus/iio/devices/iio:device0).
+func readIlluminance(dir string) (float64, error) {
+	read := func(name string) (float64, error) {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return 0, err
+		}
+		return strconv.ParseFloat(strings.TrimSpace(string(b)), 64)
+	}
+	if lux, err := read("in_illuminance_input"); err == nil {
+		return lux, nil
+	}
+	raw, err := read("in_illuminance_raw")
+	if err != nil {
+		return 0, err
+	}
+	offset, _ := read("in_illuminance_offset")
+	scale, err := read("in_illuminance_scale")
+	if err != nil {
+		scale = 1
+	}
+	return (raw + offset) * scale, nil
+}
+
+// findLightSensor returns the directory of the first IIO device in root
+// (i.e. /sys/bus/iio/devices) which measures illuminance.
+func findLightSensor(root string) (string, error) {
+	for _, pattern := range []string{"*/in_illuminance_input", "*/in_illuminance_raw"} {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return "", err
+		}
+		if len(matches) > 0 {
+			return filepath.Dir(matches[0]), nil
+		}
+	}
+	return "", errors.New("no IIO ambient light sensor found")
+}
+
+func newLightPoller() *poller[float64] {
+	return newPoller(5*time.Second, func(context.Context) (float64, error) {
+		dir, err := findLightSensor("/sys/bus/iio/devices")
+		if err != nil {
+			return 0, err
+		}
+		return readIlluminance(dir)
+	})
+}
+
+// nightShift computes the color curve (see gamma.go) for the night shift at
+// a given time.
+type nightShift struct {
+	gamma, brightness float64 // as per -gamma and -brightness
+	tint              [3]float64
+	start, end        time.Duration    // as per -night-schedule
+	light             *poller[float64] // nil unless -night-lux is set
+	lux               float64
+
+	level   float64 // with -night-lux, faded towards the target
+	updated time.Time
+	step    int         // of curve
+	adjust  lightAdjust // of curve, see -auto-brightness
+	curve   *colorCurve
+}
+
+// newNightShift returns the night shift as per the flags, or nil if
+// -night-temperature is not set.
+func newNightShift() (*nightShift, error) {
+	if *nightTemperature == 0 {
+		return nil, nil
+	}
+	if *nightTemperature < 1000 || *nightTemperature > 6500 {
+		return nil, fmt.Errorf("-night-temperature must be between 1000 and 6500 K, got %d", *nightTemperature)
+	}
+	n := &nightShift{
+		gamma:      *gammaFlag,
+		brightness: *brightnessFlag,
+		tint:       whitepoint(*nightTemperature),
+		lux:        *nightLux,
+		step:       -1,
+	}
+	if n.lux > 0 {
+		n.light = newLightPoller()
+	} else {
+		var err error
+		if n.start, n.end, err = parseTimeRange(*nightSchedule); err != nil {
+			return nil, fmt.Errorf("-night-schedule: %v", err)
+		}
+	}
+	return n, nil
+}
+
+// strength returns how strongly (0 to 1) the night shift applies at now.
+func (n *nightShift) strength(now time.Time) float64 {
+	if n.light == nil {
+		return scheduledStrength(now, n.start, n.end)
+	}
+	lux, updated, err := n.light.get()
+	if updated.IsZero() || err != nil {
+		return n.level // keep the current level until the sensor works
+	}
+	target := 0.0
+	if lux < n.lux {
+		target = 1
+	}
+	if !n.updated.IsZero() {
+		delta := float64(now.Sub(n.updated)) / float64(nightSensorFade)
+		if target > n.level {
+			n.level = math.Min(target, n.level+delta)
+		} else {
+			n.level = math.Max(target, n.level-delta)
+		}
+	}
+	n.updated = now
+	return n.level
+}
+
+// colorCurve returns the color curve at now, adjusted to the room lighting
+// by adjust. It is only recomputed when the strength of the shift or the
+// adjustment changed noticeably.
+func (n *nightShift) colorCurve(now time.Time, adjust lightAdjust) *colorCurve {
+	step := int(math.Round(n.strength(now) * nightSteps))
+	if step == n.step && adjust == n.adjust {
+		return n.curve
+	}
+	var tint [3]float64
+	frac := float64(step) / nightSteps
+	for c := range tint {
+		tint[c] = 1 - frac*(1-n.tint[c])
+	}
+	curve := adjust.colorCurve(n.gamma, n.brightness, tint)
+	n.step, n.adjust, n.curve = step, adjust, curve
+	return curve
+}
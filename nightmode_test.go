@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWhitepoint(t *testing.T) {
+	if got, want := whitepoint(6500), [3]float64{1, 1, 1}; got != want {
+		t.Errorf("whitepoint(6500) = %v, want %v", got, want)
+	}
+	if got, want := whitepoint(1000), whitepoints[0]; got != want {
+		t.Errorf("whitepoint(1000) = %v, want %v (clamped)", got, want)
+	}
+	// halfway between 3000 K and 3500 K
+	got := whitepoint(3250)
+	if got[0] != 1 || got[1] <= whitepoints[2][1] || got[1] >= whitepoints[3][1] {
+		t.Errorf("whitepoint(3250) = %v, want between %v and %v", got, whitepoints[2], whitepoints[3])
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	start, end, err := parseTimeRange("20:00-07:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if start != 20*time.Hour || end != 7*time.Hour+30*time.Minute {
+		t.Errorf("parseTimeRange() = %v, %v, want 20h, 7h30m", start, end)
+	}
+	for _, s := range []string{"20:00", "20:00-25:00", "evening-morning"} {
+		if _, _, err := parseTimeRange(s); err == nil {
+			t.Errorf("parseTimeRange(%q) did not return an error", s)
+		}
+	}
+}
+
+func TestScheduledStrength(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2022, 8, 20, hour, min, 0, 0, time.UTC)
+	}
+	for _, tt := range []struct {
+		name       string
+		now        time.Time
+		start, end time.Duration
+		want       float64
+	}{
+		{"day", at(12, 0), 20 * time.Hour, 7 * time.Hour, 0},
+		{"fading in", at(20, 15), 20 * time.Hour, 7 * time.Hour, 0.5},
+		{"evening", at(23, 0), 20 * time.Hour, 7 * time.Hour, 1},
+		{"after midnight", at(3, 0), 20 * time.Hour, 7 * time.Hour, 1},
+		{"fading out", at(6, 45), 20 * time.Hour, 7 * time.Hour, 0.5},
+		{"morning", at(7, 0), 20 * time.Hour, 7 * time.Hour, 0},
+		{"not spanning midnight", at(2, 0), 1 * time.Hour, 5 * time.Hour, 1},
+		{"before not spanning midnight", at(23, 0), 1 * time.Hour, 5 * time.Hour, 0},
+	} {
+		if got := scheduledStrength(tt.now, tt.start, tt.end); got != tt.want {
+			t.Errorf("%s: scheduledStrength(%v) = %v, want %v", tt.name, tt.now.Format("15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestReadIlluminance(t *testing.T) {
+	root := t.TempDir()
+	if _, err := findLightSensor(root); err == nil {
+		t.Errorf("findLightSensor(empty) did not return an error")
+	}
+	accel := filepath.Join(root, "iio:device0")
+	light := filepath.Join(root, "iio:device1")
+	for name, content := range map[string]string{
+		filepath.Join(accel, "in_accel_x_raw"):        "12\n",
+		filepath.Join(light, "in_illuminance_raw"):    "200\n",
+		filepath.Join(light, "in_illuminance_scale"):  "0.25\n",
+		filepath.Join(light, "in_illuminance_offset"): "4\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir, err := findLightSensor(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != light {
+		t.Errorf("findLightSensor() = %q, want %q", dir, light)
+	}
+	lux, err := readIlluminance(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := lux, 51.0; got != want {
+		t.Errorf("readIlluminance() = %v, want %v", got, want)
+	}
+
+	// processed values take precedence
+	if err := os.WriteFile(filepath.Join(light, "in_illuminance_input"), []byte("7.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if lux, err := readIlluminance(dir); err != nil || lux != 7.5 {
+		t.Errorf("readIlluminance() = %v, %v, want 7.5", lux, err)
+	}
+}
+
+func TestNightShiftColorCurve(t *testing.T) {
+	n := &nightShift{
+		gamma:      1,
+		brightness: 1,
+		tint:       whitepoint(3000),
+		start:      20 * time.Hour,
+		end:        7 * time.Hour,
+		step:       -1,
+	}
+	day := time.Date(2022, 8, 20, 12, 0, 0, 0, time.UTC)
+	if c := n.colorCurve(day, lightAdjust{}); c != nil {
+		t.Errorf("colorCurve(day) = %v, want nil", c)
+	}
+	night := time.Date(2022, 8, 20, 23, 0, 0, 0, time.UTC)
+	c := n.colorCurve(night, lightAdjust{})
+	if c == nil {
+		t.Fatalf("colorCurve(night) = nil")
+	}
+	if got, want := c.r[200], uint8(200); got != want {
+		t.Errorf("red at night = %d, want %d", got, want)
+	}
+	if got := c.b[200]; got >= 100 {
+		t.Errorf("blue at night = %d, want less than 100", got)
+	}
+	if c2 := n.colorCurve(night.Add(time.Minute), lightAdjust{}); c2 != c {
+		t.Errorf("colorCurve recomputed the curve although the strength did not change")
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// notificationTimeout is how long notifications are shown by default.
+	notificationTimeout = 10 * time.Second
+
+	// maxNotificationTimeout keeps forgotten notifications from cluttering
+	// the screen forever.
+	maxNotificationTimeout = 24 * time.Hour
+
+	// maxNotifications is the number of notifications shown at once; older
+	// ones are dropped.
+	maxNotifications = 5
+)
+
+type notification struct {
+	text     string
+	severity string // info, warning or critical
+	expires  time.Time
+}
+
+// parseNotification validates the parameters of a notification. timeout is
+// a Go duration (e.g. 30s) or a number of seconds, and defaults to
+// notificationTimeout.
+func parseNotification(text, severity, timeout string, now time.Time) (notification, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return notification{}, errors.New("text must not be empty")
+	}
+	switch severity {
+	case "":
+		severity = "info"
+	case "info", "warning", "critical":
+	default:
+		return notification{}, errors.New("severity must be one of info, warning or critical")
+	}
+	d := notificationTimeout
+	if timeout != "" {
+		var err error
+		if d, err = time.ParseDuration(timeout); err != nil {
+			secs, err := strconv.ParseFloat(timeout, 64)
+			if err != nil {
+				return notification{}, errors.New("timeout must be a duration (e.g. 30s) or a number of seconds")
+			}
+			d = time.Duration(secs * float64(time.Second))
+		}
+		if d <= 0 || d > maxNotificationTimeout {
+			return notification{}, errors.New("timeout out of range")
+		}
+	}
+	return notification{
+		text:     text,
+		severity: severity,
+		expires:  now.Add(d),
+	}, nil
+}
+
+// notifier retains notifications pushed via HTTP (or MQTT) until they
+// expire.
+type notifier struct {
+	mu            sync.Mutex
+	notifications []notification // oldest first
+}
+
+func (n *notifier) add(notif notification) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notifications = append(n.notifications, notif)
+	if len(n.notifications) > maxNotifications {
+		n.notifications = n.notifications[len(n.notifications)-maxNotifications:]
+	}
+}
+
+// active returns the notifications which did not expire yet, oldest first.
+func (n *notifier) active(now time.Time) []notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	active := n.notifications[:0]
+	for _, notif := range n.notifications {
+		if now.Before(notif.expires) {
+			active = append(active, notif)
+		}
+	}
+	n.notifications = active
+	return append([]notification(nil), active...)
+}
+
+// notificationRequest is the JSON representation of a notification.
+type notificationRequest struct {
+	Text     string      `json:"text"`
+	Severity string      `json:"severity"`
+	Timeout  interface{} `json:"timeout"` // "30s" or 30
+}
+
+func (req *notificationRequest) parse(now time.Time) (notification, error) {
+	var timeout string
+	if req.Timeout != nil {
+		timeout = fmt.Sprint(req.Timeout)
+	}
+	return parseNotification(req.Text, req.Severity, timeout, now)
+}
+
+// parseNotificationPayload parses an MQTT payload, which is either a JSON
+// object (like the /notify HTTP endpoint accepts) or the plain text of an
+// info notification.
+func parseNotificationPayload(payload []byte, now time.Time) (notification, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(payload), []byte("{")) {
+		return parseNotification(string(payload), "", "", now)
+	}
+	var req notificationRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return notification{}, err
+	}
+	return req.parse(now)
+}
+
+// ServeHTTP accepts notifications as a JSON object ({"text": "backup
+// finished", "severity": "info", "timeout": "30s"}) or as form values, e.g.
+// curl -d text='backup finished' http://gokrazy:8318/notify
+func (n *notifier) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req notificationRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		req.Text = r.FormValue("text")
+		req.Severity = r.FormValue("severity")
+		if timeout := r.FormValue("timeout"); timeout != "" {
+			req.Timeout = timeout
+		}
+	}
+	notif, err := req.parse(time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n.add(notif)
+}
+
+// drawNotifications overlays notifications as toasts at the bottom of the
+// buffer, most recent at the bottom.
+func (d *statusDrawer) drawNotifications(notifications []notification) {
+	em := 16 * d.scaleFactor // font size of the host information
+	toastH := int(3 * em)
+	toastW := d.w * 3 / 5
+	y := d.h - int(2*em)
+	for idx := len(notifications) - 1; idx >= 0; idx-- {
+		notif := notifications[idx]
+		y -= toastH
+		r := image.Rect((d.w-toastW)/2, y, (d.w+toastW)/2, y+toastH)
+		y -= int(em / 2)
+
+		dc := d.overlayContext(r)
+		dc.SetFontFace(d.faces.medium)
+		bg := "blue"
+		switch notif.severity {
+		case "warning":
+			bg = "yellow"
+		case "critical":
+			bg = "red"
+		}
+		setColor(dc, bg)
+		dc.DrawRoundedRectangle(0, 0, float64(r.Dx()), float64(r.Dy()), em/2)
+		dc.Fill()
+		if bg == "yellow" {
+			dc.SetRGB(0, 0, 0) // white is illegible on yellow
+		} else {
+			dc.SetRGB(1, 1, 1)
+		}
+		text := fitString(dc, notif.text, float64(r.Dx())-2*em)
+		dc.DrawStringAnchored(text, float64(r.Dx())/2, float64(r.Dy())/2, 0.5, 0.35)
+		d.drawOverlay(dc, r, draw.Over)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNotification(t *testing.T) {
+	now := time.Date(2022, 10, 12, 10, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		text, severity, timeout string
+		wantSeverity            string
+		wantTimeout             time.Duration
+		wantErr                 bool
+	}{
+		{"backup finished", "", "", "info", notificationTimeout, false},
+		{"disk full", "critical", "30s", "critical", 30 * time.Second, false},
+		{"disk full", "warning", "90", "warning", 90 * time.Second, false},
+		{"disk full", "warning", "1.5", "warning", 1500 * time.Millisecond, false},
+		{"  ", "", "", "", 0, true},
+		{"disk full", "fatal", "", "", 0, true},
+		{"disk full", "", "soon", "", 0, true},
+		{"disk full", "", "-5s", "", 0, true},
+		{"disk full", "", "48h", "", 0, true},
+	} {
+		got, err := parseNotification(tt.text, tt.severity, tt.timeout, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseNotification(%q, %q, %q) = %v, want error %v", tt.text, tt.severity, tt.timeout, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.severity != tt.wantSeverity {
+			t.Errorf("parseNotification(%q, %q, %q).severity = %q, want %q", tt.text, tt.severity, tt.timeout, got.severity, tt.wantSeverity)
+		}
+		if got, want := got.expires, now.Add(tt.wantTimeout); !got.Equal(want) {
+			t.Errorf("parseNotification(%q, %q, %q).expires = %v, want %v", tt.text, tt.severity, tt.timeout, got, want)
+		}
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	n := &notifier{}
+	post := func(req *http.Request) int {
+		rec := httptest.NewRecorder()
+		n.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	form := url.Values{"text": {"backup finished"}, "timeout": {"1m"}}
+	req := httptest.NewRequest("POST", "/notify", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if got, want := post(req), http.StatusOK; got != want {
+		t.Fatalf("form POST: unexpected HTTP status: got %d, want %d", got, want)
+	}
+
+	req = httptest.NewRequest("POST", "/notify", strings.NewReader(`{"text": "disk full", "severity": "critical", "timeout": 3600}`))
+	req.Header.Set("Content-Type", "application/json")
+	if got, want := post(req), http.StatusOK; got != want {
+		t.Fatalf("JSON POST: unexpected HTTP status: got %d, want %d", got, want)
+	}
+
+	req = httptest.NewRequest("POST", "/notify", strings.NewReader(`{"severity": "critical"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if got, want := post(req), http.StatusBadRequest; got != want {
+		t.Errorf("POST without text: unexpected HTTP status: got %d, want %d", got, want)
+	}
+
+	if got, want := post(httptest.NewRequest("GET", "/notify", nil)), http.StatusMethodNotAllowed; got != want {
+		t.Errorf("GET: unexpected HTTP status: got %d, want %d", got, want)
+	}
+
+	active := n.active(time.Now())
+	if got, want := len(active), 2; got != want {
+		t.Fatalf("active notifications = %+v, want %d", active, want)
+	}
+	if got, want := active[1].severity, "critical"; got != want {
+		t.Errorf("severity = %q, want %q", got, want)
+	}
+
+	// The form notification expires after one minute.
+	active = n.active(time.Now().Add(2 * time.Minute))
+	if len(active) != 1 || active[0].text != "disk full" {
+		t.Errorf("active notifications after 2m = %+v, want only disk full", active)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+var ntpServer = flag.String("ntp-server",
+	"0.gokrazy.pool.ntp.org",
+	"NTP server to measure the clock offset against (the same server the gokrazy NTP client uses by default). Empty disables the measurement")
+
+// Kernel time status bits (see adjtimex(2)), which are not (yet) defined in
+// golang.org/x/sys/unix.
+const (
+	staUnsync = 0x0040 // clock not synchronized
+	staNano   = 0x2000 // offset is in nanoseconds (as opposed to microseconds)
+)
+
+// ntpEpochOffset is the number of seconds between the NTP epoch (1900) and
+// the UNIX epoch (1970).
+const ntpEpochOffset = 2208988800
+
+func toNTPTime(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return secs<<32 | frac
+}
+
+func fromNTPTime(ts uint64) time.Time {
+	secs := int64(ts>>32) - ntpEpochOffset
+	nsec := int64((ts & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(secs, nsec)
+}
+
+type sntpResult struct {
+	offset  time.Duration // positive if the local clock is behind
+	stratum int
+	source  string
+}
+
+// sntpQuery measures the offset of the local clock by sending a single SNTP
+// (RFC 4330) request to addr (host:port).
+func sntpQuery(ctx context.Context, addr string) (sntpResult, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return sntpResult{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := make([]byte, 48)
+	req[0] = 0<<6 | 4<<3 | 3 // leap indicator 0, version 4, mode 3 (client)
+	t1 := time.Now()
+	transmit := toNTPTime(t1)
+	binary.BigEndian.PutUint64(req[40:], transmit)
+	if _, err := conn.Write(req); err != nil {
+		return sntpResult{}, err
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return sntpResult{}, err
+	}
+	t4 := time.Now()
+	if n < 48 {
+		return sntpResult{}, fmt.Errorf("short NTP response (%d bytes)", n)
+	}
+	if mode := resp[0] & 7; mode != 4 {
+		return sntpResult{}, fmt.Errorf("unexpected NTP mode %d in response", mode)
+	}
+	if originate := binary.BigEndian.Uint64(resp[24:]); originate != transmit {
+		return sntpResult{}, errors.New("NTP response does not match request")
+	}
+	stratum := int(resp[1])
+	if stratum == 0 {
+		return sntpResult{}, errors.New("NTP server sent kiss-of-death packet")
+	}
+	t2 := fromNTPTime(binary.BigEndian.Uint64(resp[32:]))
+	t3 := fromNTPTime(binary.BigEndian.Uint64(resp[40:]))
+	return sntpResult{
+		offset:  (t2.Sub(t1) + t3.Sub(t4)) / 2,
+		stratum: stratum,
+		source:  conn.RemoteAddr().(*net.UDPAddr).IP.String(),
+	}, nil
+}
+
+func offsetColor(offset time.Duration) string {
+	if offset < 0 {
+		offset = -offset
+	}
+	switch {
+	case offset >= time.Second:
+		return "red"
+	case offset >= 100*time.Millisecond:
+		return "yellow"
+	default:
+		return "green"
+	}
+}
+
+// clockStatus determines whether the system clock is synchronized, either by
+// asking the kernel (when a kernel-disciplining NTP daemon like chrony is in
+// use), or by measuring the offset against an NTP server (the gokrazy NTP
+// client merely sets the time and leaves the kernel status unsynchronized).
+type clockStatus struct {
+	sntp *poller[sntpResult]
+}
+
+func newClockStatus() *clockStatus {
+	cs := &clockStatus{}
+	if *ntpServer != "" {
+		addr := net.JoinHostPort(*ntpServer, "123")
+		cs.sntp = newPoller(10*time.Minute, func(ctx context.Context) (sntpResult, error) {
+			return sntpQuery(ctx, addr)
+		})
+	}
+	return cs
+}
+
+// line returns a host information line (in $color$text markup) describing
+// the clock synchronization status.
+func (cs *clockStatus) line() string {
+	const prefix = "$$clock: "
+	if time.Now().Before(time.Unix(60*60*24*365, 0)) {
+		// same heuristic as gokrazy.WaitForClock()
+		return prefix + "$red$not set"
+	}
+
+	var tx unix.Timex
+	if _, err := unix.Adjtimex(&tx); err == nil && tx.Status&staUnsync == 0 {
+		offset := time.Duration(tx.Offset) * time.Microsecond
+		if tx.Status&staNano != 0 {
+			offset = time.Duration(tx.Offset)
+		}
+		maxError := time.Duration(tx.Maxerror) * time.Microsecond
+		return fmt.Sprintf("%s$green$synchronized$$ (kernel), offset $%s$%v$$, max error %v",
+			prefix,
+			offsetColor(offset),
+			offset.Round(time.Microsecond),
+			maxError.Round(time.Microsecond))
+	}
+
+	if cs.sntp == nil {
+		return prefix + "$yellow$unsynchronized"
+	}
+	res, updated, err := cs.sntp.get()
+	if updated.IsZero() {
+		if err != nil {
+			return prefix + "$darkgray$unknown (" + err.Error() + ")"
+		}
+		return prefix + "$darkgray$measuring…"
+	}
+	state := "synchronized"
+	if offsetColor(res.offset) == "red" {
+		state = "unsynchronized"
+	}
+	return fmt.Sprintf("%s$%s$%s$$, offset $%s$%v$$ (stratum %d via %s)",
+		prefix,
+		offsetColor(res.offset),
+		state,
+		offsetColor(res.offset),
+		res.offset.Round(time.Millisecond),
+		res.stratum,
+		res.source)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNTPTime(t *testing.T) {
+	want := time.Date(2026, 10, 16, 12, 34, 56, 789000000, time.UTC)
+	got := fromNTPTime(toNTPTime(want))
+	if diff := got.Sub(want); diff < -time.Microsecond || diff > time.Microsecond {
+		t.Errorf("fromNTPTime(toNTPTime(%v)) = %v", want, got)
+	}
+}
+
+func TestSNTPQuery(t *testing.T) {
+	const skew = 2 * time.Second
+
+	// Run a fake NTP server whose clock is skew ahead of ours.
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 48)
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil || n < 48 {
+			return
+		}
+		resp := make([]byte, 48)
+		resp[0] = 0<<6 | 4<<3 | 4 // mode 4 (server)
+		resp[1] = 2               // stratum
+		copy(resp[24:32], buf[40:48])
+		now := toNTPTime(time.Now().Add(skew))
+		binary.BigEndian.PutUint64(resp[32:], now)
+		binary.BigEndian.PutUint64(resp[40:], now)
+		pc.WriteTo(resp, addr)
+	}()
+
+	ctx, canc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer canc()
+	res, err := sntpQuery(ctx, pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := res.offset - skew; diff < -100*time.Millisecond || diff > 100*time.Millisecond {
+		t.Errorf("offset = %v, want approximately %v", res.offset, skew)
+	}
+	if got, want := res.stratum, 2; got != want {
+		t.Errorf("stratum = %d, want %d", got, want)
+	}
+	if got, want := res.source, "127.0.0.1"; got != want {
+		t.Errorf("source = %q, want %q", got, want)
+	}
+}
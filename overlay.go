@@ -0,0 +1,39 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/fogleman/gg"
+	"golang.org/x/image/font"
+)
+
+// overlayFaces are the faces of the regular font in which the overlays (e.g.
+// notifications or the reboot screen) are drawn, relative to the font size of
+// the host information (em). They are created once in newStatusDrawer, so
+// that their glyph caches persist across frames.
+type overlayFaces struct {
+	huge   font.Face // 3 em
+	large  font.Face // 2 em
+	medium font.Face // 1.5 em
+	text   font.Face // 1.25 em
+}
+
+// overlayContext returns the context for drawing an overlay the size of r,
+// which is shared by all overlays. The overlay is drawn at the origin of the
+// context, which is cleared to transparent, and then copied to r of the
+// buffer with drawOverlay.
+func (d *statusDrawer) overlayContext(r image.Rectangle) *gg.Context {
+	dc := d.overlay
+	dc.Identity()
+	dc.ResetClip()
+	dc.ClearPath()
+	draw.Draw(dc.Image().(*image.RGBA), image.Rect(0, 0, r.Dx(), r.Dy()), image.Transparent, image.Point{}, draw.Src)
+	return dc
+}
+
+// drawOverlay copies the overlay for r from dc (see overlayContext) to the
+// buffer.
+func (d *statusDrawer) drawOverlay(dc *gg.Context, r image.Rectangle, op draw.Op) {
+	draw.Draw(d.buffer, r, dc.Image(), image.Point{}, op)
+}
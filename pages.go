@@ -0,0 +1,282 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+var (
+	pagesFlag = flag.String("pages",
+		"status",
+		"comma-separated list of pages to display. Each page is either status (the classic fbstatus view) or a +-separated list of panels, e.g. status,services")
+
+	rotateInterval = flag.Duration("rotate",
+		60*time.Second,
+		"how long to display each page before switching to the next one")
+)
+
+// A panel renders one rectangular area of a page.
+type panel interface {
+	// draw renders the panel into dc, which covers exactly the area of the
+	// panel and has been cleared to the background color.
+	draw(d *statusDrawer, dc *gg.Context) error
+}
+
+// panels maps panel names (as used in the -pages flag) to their constructors.
+var panels = map[string]func() (panel, error){
+	"agenda":       newAgendaPanel,
+	"alerts":       newAlertsPanel,
+	"bluetooth":    newBluetoothPanel,
+	"camera":       newCameraPanel,
+	"clock":        newClockPanel,
+	"containers":   newContainersPanel,
+	"dhcp-clients": newDHCPClientsPanel,
+	"disks":        newDisksPanel,
+	"fleet":        newFleetPanel,
+	"grafana":      newGrafanaPanel,
+	"ip-cameras":   newIPCamerasPanel,
+	"json":         newJSONPanel,
+	"kmsg":         newKmsgPanel,
+	"motd":         newMOTDPanel,
+	"mqtt":         newMQTTPanel,
+	"pools":        newPoolsPanel,
+	"power":        newPowerPanel,
+	"pressure":     newPressurePanel,
+	"raid":         newRAIDPanel,
+	"services":     newServicesPanel,
+	"snmp":         newSNMPPanel,
+	"sockets":      newSocketsPanel,
+	"ticker":       newTickerPanel,
+	"top":          newTopPanel,
+	"version":      newVersionPanel,
+	"watch":        newWatchPanel,
+}
+
+// A page is either the classic status view (panels is nil) or a grid of
+// panels.
+type page struct {
+	name   string
+	panels []panel
+
+	// contexts and rects are initialized on first draw
+	contexts []*gg.Context
+	rects    []image.Rectangle
+
+	// status is what the panels drew in the most recent frame
+	status []panelStatus
+
+	// health tracks failing panels, which are retried with backoff
+	health []backoff
+
+	// renderTimes are how long the panels took to draw in the most recent
+	// frame, for the debug HUD
+	renderTimes []time.Duration
+}
+
+func panelNames() []string {
+	names := make([]string, 0, len(panels))
+	for name := range panels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parsePages(spec string) ([]*page, error) {
+	var pages []*page
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "status" {
+			pages = append(pages, &page{name: name})
+			continue
+		}
+		pg := &page{name: name}
+		for _, panelName := range strings.Split(name, "+") {
+			newPanel, ok := panels[panelName]
+			if !ok {
+				return nil, fmt.Errorf("unknown panel %q (known panels: %s)", panelName, strings.Join(panelNames(), ", "))
+			}
+			p, err := newPanel()
+			if err != nil {
+				return nil, fmt.Errorf("panel %q: %v", panelName, err)
+			}
+			pg.panels = append(pg.panels, p)
+		}
+		pages = append(pages, pg)
+	}
+	return pages, nil
+}
+
+// currentPage returns the page which should be displayed right now.
+func (d *statusDrawer) currentPage() *page {
+	if idx, ok := d.control.pinnedPage(); ok {
+		return d.pages[idx]
+	}
+	if len(d.pages) == 1 || *rotateInterval <= 0 {
+		return d.pages[0]
+	}
+	idx := int(time.Since(d.control.rotationStarted()) / *rotateInterval) % len(d.pages)
+	return d.pages[idx]
+}
+
+// layout divides the screen into a grid with one cell per panel.
+func (d *statusDrawer) layout(pg *page) {
+	n := len(pg.panels)
+	cols := gridColumns(n, d.w, d.h)
+	rows := (n + cols - 1) / cols
+	cellW := d.w / cols
+	cellH := d.h / rows
+	for idx := range pg.panels {
+		col, row := idx%cols, idx/cols
+		r := image.Rect(col*cellW, row*cellH, (col+1)*cellW, (row+1)*cellH)
+		dc := gg.NewContext(r.Dx(), r.Dy())
+		dc.SetFontFace(d.face)
+		pg.contexts = append(pg.contexts, dc)
+		pg.rects = append(pg.rects, r)
+	}
+}
+
+// drawPanels renders all panels of pg into the buffer. Panels which fail are
+// retried with backoff, showing the error in the meantime.
+func (d *statusDrawer) drawPanels(pg *page) {
+	if pg.contexts == nil {
+		d.layout(pg)
+	}
+	if pg.health == nil {
+		pg.health = make([]backoff, len(pg.panels))
+	}
+	prev := pg.status
+	pg.status = make([]panelStatus, len(pg.panels))
+	pg.renderTimes = make([]time.Duration, len(pg.panels))
+	defer func() { d.recording = nil }()
+	names := strings.Split(pg.name, "+")
+	for idx, p := range pg.panels {
+		start := time.Now()
+		dc := pg.contexts[idx]
+		h := &pg.health[idx]
+		d.recording = &pg.status[idx]
+		d.panelOrigin = pg.rects[idx].Min
+		if h.due(start) {
+			d.clear(dc, d.panelOrigin)
+			if err := drawPanel(d, p, dc); err != nil {
+				if h.failures == 0 {
+					slog.Warn("drawing panel failed", "panel", names[idx], "err", err)
+				}
+				h.fail(err, start)
+			} else if h.failures > 0 {
+				slog.Info("panel recovered", "panel", names[idx], "failures", h.failures)
+				h.succeed()
+			}
+		} else if prev != nil {
+			// keep showing what the panel drew before it failed
+			pg.status[idx] = prev[idx]
+		}
+		if h.failures > 0 {
+			d.drawPanelError(dc, h, start)
+			pg.status[idx].Error = h.err.Error()
+		}
+		if idx == 0 {
+			d.drawBadges(dc, true)
+		}
+		draw.Draw(d.buffer, pg.rects[idx], dc.Image(), image.Point{}, draw.Src)
+		pg.renderTimes[idx] = time.Since(start)
+	}
+}
+
+// clear fills dc, which is drawn at origin on the display, with the
+// background and selects white for drawing.
+func (d *statusDrawer) clear(dc *gg.Context, origin image.Point) {
+	clearBackground(dc, d.bgcolor, d.backdrop, origin)
+}
+
+// clearBackground fills dc with the part of backdrop at origin, or with
+// bgcolor if backdrop is nil, and selects white for drawing.
+func clearBackground(dc *gg.Context, bgcolor color.RGBA, backdrop *image.RGBA, origin image.Point) {
+	if backdrop != nil {
+		img := dc.Image().(*image.RGBA)
+		draw.Draw(img, img.Bounds(), backdrop, origin, draw.Src)
+	} else {
+		r, gg, b, a := bgcolor.RGBA()
+		dc.SetRGBA(
+			float64(r)/0xffff,
+			float64(gg)/0xffff,
+			float64(b)/0xffff,
+			float64(a)/0xffff)
+		dc.Clear()
+	}
+	dc.SetRGB(1, 1, 1)
+}
+
+// drawTitle draws the panel title and returns the vertical position at which
+// the panel contents start.
+func (d *statusDrawer) drawTitle(dc *gg.Context, title string) float64 {
+	if d.recording != nil {
+		d.recording.Title = title
+	}
+	em, _ := dc.MeasureString("m")
+	dc.Push()
+	dc.SetFontFace(d.italicface)
+	dc.DrawString(title, 3*em, 3*em+dc.FontHeight())
+	y := 3*em + dc.FontHeight()*2
+	dc.Pop()
+	return y
+}
+
+// drawMessage draws a single line of informational text (e.g. while data is
+// loading) in dark gray.
+func (d *statusDrawer) drawMessage(dc *gg.Context, y float64, msg string) {
+	if d.recording != nil {
+		d.recording.Messages = append(d.recording.Messages, msg)
+	}
+	em, _ := dc.MeasureString("m")
+	setColor(dc, "darkgray")
+	dc.DrawString(msg, 3*em, y)
+	dc.SetRGB(1, 1, 1)
+}
+
+// fitString shortens s (indicated by an ellipsis) so that it is at most
+// maxWidth wide when drawn into dc.
+func fitString(dc *gg.Context, s string, maxWidth float64) string {
+	if w, _ := dc.MeasureString(s); w <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		shortened := string(runes) + "…"
+		if w, _ := dc.MeasureString(shortened); w <= maxWidth {
+			return shortened
+		}
+	}
+	return ""
+}
+
+// drawMarkup draws s, which can optionally contain colored segments in the
+// same $color$text markup used for the stats table (e.g. "$red$alert"), where
+// an empty color name selects white. Strings which do not start with $ are
+// drawn verbatim.
+func drawMarkup(dc *gg.Context, s string, x, y float64) {
+	if !strings.HasPrefix(s, "$") {
+		dc.DrawString(s, x, y)
+		return
+	}
+	defer dc.SetRGB(1, 1, 1)
+	for idx, field := range strings.Split(strings.TrimPrefix(s, "$"), "$") {
+		if idx%2 == 0 {
+			setColor(dc, field)
+			continue
+		}
+		dc.DrawString(field, x, y)
+		w, _ := dc.MeasureString(field)
+		x += w
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// mountOptions returns the mount options of the file system mounted at
+// mountpoint, or false if nothing is mounted there.
+func mountOptions(mounts []byte, mountpoint string) ([]string, bool) {
+	var opts []string
+	found := false
+	scanner := bufio.NewScanner(strings.NewReader(string(mounts)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[1] != mountpoint {
+			continue
+		}
+		// later entries shadow earlier ones
+		opts = strings.Split(fields[3], ",")
+		found = true
+	}
+	return opts, found
+}
+
+// usageColor returns the color for displaying a usage percentage of metric
+// (e.g. perm).
+func usageColor(metric string, percent float64) string {
+	return metricColor(metric, percent, "green")
+}
+
+// permUsage returns the percentage of used blocks of the /perm partition.
+func permUsage() (float64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs("/perm", &st); err != nil {
+		return 0, err
+	}
+	if st.Blocks == 0 {
+		return 0, fmt.Errorf("/perm: no blocks")
+	}
+	return 100 * float64(st.Blocks-st.Bfree) / float64(st.Blocks), nil
+}
+
+// permLine returns a host information line (in $color$text markup) about
+// the health of the /perm partition, on which gokrazy stores all persistent
+// data. When /perm is missing or read-only, many programs fail in subtle
+// ways (e.g. gokrazy logs “cannot create $HOME without writeable /perm”).
+func permLine() string {
+	const prefix = "$$/perm: "
+	mounts, err := os.ReadFile("/proc/self/mounts")
+	if err != nil {
+		return prefix + "$red$" + err.Error()
+	}
+	opts, ok := mountOptions(mounts, "/perm")
+	if !ok {
+		return prefix + "$red$not mounted"
+	}
+	mode := "$green$read-write"
+	for _, opt := range opts {
+		if opt == "ro" {
+			mode = "$red$read-only"
+		}
+	}
+	var st unix.Statfs_t
+	if err := unix.Statfs("/perm", &st); err != nil {
+		return prefix + mode + "$$, $red$" + err.Error()
+	}
+	total := st.Blocks * uint64(st.Bsize)
+	free := st.Bavail * uint64(st.Bsize)
+	line := prefix + mode
+	if total > 0 {
+		used := 100 * float64(st.Blocks-st.Bfree) / float64(st.Blocks)
+		line += fmt.Sprintf("$$, %s free of %s ($%s$%.f%% used$$)",
+			formatBytes(free), formatBytes(total), usageColor("perm", used), used)
+	}
+	if st.Files > 0 {
+		used := 100 * float64(st.Files-st.Ffree) / float64(st.Files)
+		line += fmt.Sprintf(", inodes $%s$%.f%% used", usageColor("perm", used), used)
+	}
+	return line
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMountOptions(t *testing.T) {
+	const mounts = `/dev/root / squashfs ro,relatime 0 0
+/dev/mmcblk0p4 /perm ext4 rw,relatime 0 0
+/dev/mmcblk0p4 /perm ext4 ro,relatime 0 0
+tmpfs /tmp tmpfs rw,relatime 0 0
+`
+	opts, ok := mountOptions([]byte(mounts), "/perm")
+	if !ok {
+		t.Fatalf("/perm not found")
+	}
+	if want := []string{"ro", "relatime"}; !reflect.DeepEqual(opts, want) {
+		t.Errorf("mountOptions(/perm) = %v, want %v", opts, want)
+	}
+	if _, ok := mountOptions([]byte(mounts), "/mnt"); ok {
+		t.Errorf("mountOptions(/mnt) unexpectedly found")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fetchErrors counts failed fetches by source, for /metrics.
+var fetchErrors = struct {
+	sync.Mutex
+	bySource map[string]uint64
+}{bySource: make(map[string]uint64)}
+
+// fetchSource names the data source of a poller after the function which
+// created the fetch function, e.g. newTopPanel.
+func fetchSource(fetch interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(fetch).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	// e.g. main.newTopPanel.func1, or github.com/gokrazy/fbstatus.… in tests
+	name := fn.Name()
+	if idx := strings.LastIndexByte(name, '/'); idx > -1 {
+		name = name[idx+1:]
+	}
+	if _, after, ok := strings.Cut(name, "."); ok {
+		name = after
+	}
+	if idx := strings.Index(name, ".func"); idx > -1 {
+		name = name[:idx]
+	}
+	return name
+}
+
+// poller periodically calls fetch in the background and retains the most
+// recent result, so that slow data sources (e.g. HTTP APIs) never block
+// drawing.
+type poller[T any] struct {
+	interval time.Duration
+	timeout  time.Duration
+	fetch    func(context.Context) (T, error)
+	source   string
+
+	mu       sync.Mutex
+	val      T
+	err      error
+	failures int       // consecutive failed fetches
+	updated  time.Time // zero until the first fetch completed
+	running  bool
+	started  time.Time
+}
+
+func newPoller[T any](interval time.Duration, fetch func(context.Context) (T, error)) *poller[T] {
+	return &poller[T]{
+		interval: interval,
+		timeout:  interval,
+		fetch:    fetch,
+		source:   fetchSource(fetch),
+	}
+}
+
+// get returns the most recent result and when it was obtained. If the result
+// is older than the poll interval, a background refresh is started. After a
+// failed fetch, the next one is started sooner (with backoff, but never later
+// than the poll interval), so that panels recover quickly.
+func (p *poller[T]) get() (T, time.Time, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	wait := p.interval
+	if p.failures > 0 {
+		wait = retryDelay(p.failures, p.interval)
+	}
+	if !p.running && time.Since(p.started) >= wait {
+		p.running = true
+		p.started = time.Now()
+		go p.refresh()
+	}
+	return p.val, p.updated, p.err
+}
+
+func (p *poller[T]) refresh() {
+	ctx, canc := context.WithTimeout(context.Background(), p.timeout)
+	defer canc()
+	val, err := p.fetch(ctx)
+	if err != nil {
+		fetchErrors.Lock()
+		fetchErrors.bySource[p.source]++
+		fetchErrors.Unlock()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = false
+	p.err = err
+	if err != nil {
+		p.failures++
+	} else {
+		p.failures = 0
+		p.val = val
+		p.updated = time.Now()
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/gokrazy/fbstatus/internal/btrfs"
+	"golang.org/x/sys/unix"
+)
+
+// poolHealth is the health of one btrfs file system or ZFS pool.
+type poolHealth struct {
+	name   string // mountpoint (btrfs) or pool name (ZFS)
+	kind   string // btrfs or zfs
+	state  string // e.g. ok, degraded
+	errors uint64
+	scrub  string // e.g. running 42%, or the last scrub result
+	size   uint64
+	free   uint64
+	err    error
+}
+
+// btrfsMountpoints returns the mountpoints of all btrfs file systems, one
+// per file system (subvolumes of the same file system are skipped).
+func btrfsMountpoints(mounts []byte) []string {
+	var mountpoints []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(string(mounts)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[2] != "btrfs" || seen[fields[0]] {
+			continue
+		}
+		seen[fields[0]] = true
+		mountpoints = append(mountpoints, fields[1])
+	}
+	return mountpoints
+}
+
+func btrfsHealth(mountpoint string) poolHealth {
+	h := poolHealth{
+		name:  mountpoint,
+		kind:  "btrfs",
+		state: "ok",
+	}
+	var st unix.Statfs_t
+	if err := unix.Statfs(mountpoint, &st); err == nil {
+		h.size = st.Blocks * uint64(st.Bsize)
+		h.free = st.Bavail * uint64(st.Bsize)
+	}
+	devices, err := btrfs.Devices(mountpoint)
+	if err != nil {
+		h.err = err
+		return h
+	}
+	var scrubbed, total uint64
+	scrubbing := false
+	for _, dev := range devices {
+		h.errors += dev.Errors()
+		total += dev.TotalBytes
+		if dev.Scrub != nil {
+			scrubbing = true
+			scrubbed += dev.Scrub.LastPhysical
+			h.errors += dev.Scrub.UncorrectableErrors
+		}
+	}
+	if h.errors > 0 {
+		h.state = "errors"
+	}
+	h.scrub = "not running"
+	if scrubbing && total > 0 {
+		h.scrub = fmt.Sprintf("running %.f%%", 100*float64(scrubbed)/float64(total))
+	}
+	return h
+}
+
+// parseZpoolList parses the output of zpool list -H -p -o
+// name,health,size,free.
+func parseZpoolList(out []byte) ([]poolHealth, error) {
+	var pools []poolHealth
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("malformed zpool list line %q", scanner.Text())
+		}
+		size, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		free, err := strconv.ParseUint(fields[3], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		pools = append(pools, poolHealth{
+			name:  fields[0],
+			kind:  "zfs",
+			state: strings.ToLower(fields[1]),
+			size:  size,
+			free:  free,
+		})
+	}
+	return pools, scanner.Err()
+}
+
+// parseZpoolStatus extracts the scrub status and whether errors are known
+// from the output of zpool status <pool>.
+func parseZpoolStatus(out []byte) (scrub string, errors bool) {
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "scan:"):
+			scrub = strings.TrimSpace(strings.TrimPrefix(line, "scan:"))
+		case strings.HasPrefix(line, "errors:"):
+			errors = !strings.Contains(line, "No known data errors")
+		}
+	}
+	return scrub, errors
+}
+
+func zfsHealth(ctx context.Context) ([]poolHealth, error) {
+	zpool, err := exec.LookPath("zpool")
+	if err != nil {
+		return nil, nil // ZFS not present
+	}
+	out, err := exec.CommandContext(ctx, zpool, "list", "-H", "-p", "-o", "name,health,size,free").Output()
+	if err != nil {
+		return nil, fmt.Errorf("zpool list: %v", err)
+	}
+	pools, err := parseZpoolList(out)
+	if err != nil {
+		return nil, err
+	}
+	for idx, p := range pools {
+		out, err := exec.CommandContext(ctx, zpool, "status", p.name).Output()
+		if err != nil {
+			pools[idx].err = fmt.Errorf("zpool status: %v", err)
+			continue
+		}
+		scrub, errors := parseZpoolStatus(out)
+		pools[idx].scrub = scrub
+		if errors {
+			pools[idx].errors = 1
+		}
+	}
+	return pools, nil
+}
+
+// poolsPanel shows the health of btrfs file systems and ZFS pools.
+type poolsPanel struct {
+	pools *poller[[]poolHealth]
+}
+
+func newPoolsPanel() (panel, error) {
+	return &poolsPanel{
+		pools: newPoller(time.Minute, func(ctx context.Context) ([]poolHealth, error) {
+			mounts, err := os.ReadFile("/proc/self/mounts")
+			if err != nil {
+				return nil, err
+			}
+			var pools []poolHealth
+			for _, mp := range btrfsMountpoints(mounts) {
+				pools = append(pools, btrfsHealth(mp))
+			}
+			zfs, err := zfsHealth(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return append(pools, zfs...), nil
+		}),
+	}, nil
+}
+
+func (p *poolsPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Storage pools")
+	pools, updated, err := p.pools.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	if len(pools) == 0 {
+		d.drawMessage(dc, y, "no btrfs file systems or ZFS pools")
+		return nil
+	}
+	rows := make([][]cell, 0, len(pools))
+	for _, pool := range pools {
+		if pool.err != nil {
+			rows = append(rows, []cell{
+				{text: pool.name},
+				{text: pool.kind, color: "darkgray"},
+				{text: pool.err.Error(), color: "red"},
+			})
+			continue
+		}
+		stateColor := "green"
+		if pool.state != "ok" && pool.state != "online" {
+			stateColor = "red"
+		}
+		errorsColor := "darkgray"
+		if pool.errors > 0 {
+			errorsColor = "red"
+		}
+		free := "-"
+		if pool.size > 0 {
+			free = fmt.Sprintf("%s (%.f%%)", formatBytes(pool.free), 100*float64(pool.free)/float64(pool.size))
+		}
+		rows = append(rows, []cell{
+			{text: pool.name},
+			{text: pool.kind, color: "darkgray"},
+			{text: pool.state, color: stateColor},
+			{text: strconv.FormatUint(pool.errors, 10), color: errorsColor},
+			{text: free},
+			{text: pool.scrub},
+		})
+	}
+	d.drawTable(dc, y, []string{"pool", "type", "state", "errors", "free", "scrub"}, rows)
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBtrfsMountpoints(t *testing.T) {
+	const mounts = `/dev/mmcblk0p4 /perm ext4 rw,relatime 0 0
+/dev/sda1 /mnt/data btrfs rw,relatime,subvol=/data 0 0
+/dev/sda1 /mnt/backup btrfs rw,relatime,subvol=/backup 0 0
+/dev/sdb1 /mnt/media btrfs rw,relatime 0 0
+`
+	got := btrfsMountpoints([]byte(mounts))
+	if want := []string{"/mnt/data", "/mnt/media"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("btrfsMountpoints() = %v, want %v", got, want)
+	}
+}
+
+func TestParseZpoolList(t *testing.T) {
+	got, err := parseZpoolList([]byte("tank\tDEGRADED\t3985729650688\t1992864825344\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []poolHealth{{
+		name:  "tank",
+		kind:  "zfs",
+		state: "degraded",
+		size:  3985729650688,
+		free:  1992864825344,
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseZpoolList() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseZpoolStatus(t *testing.T) {
+	const status = `  pool: tank
+ state: ONLINE
+  scan: scrub repaired 0B in 01:23:45 with 0 errors on Sun Aug 14 01:47:46 2022
+config:
+
+	NAME        STATE     READ WRITE CKSUM
+	tank        ONLINE       0     0     0
+	  mirror-0  ONLINE       0     0     0
+	    sda     ONLINE       0     0     0
+	    sdb     ONLINE       0     0     0
+
+errors: No known data errors
+`
+	scrub, errors := parseZpoolStatus([]byte(status))
+	if want := "scrub repaired 0B in 01:23:45 with 0 errors on Sun Aug 14 01:47:46 2022"; scrub != want {
+		t.Errorf("scrub = %q, want %q", scrub, want)
+	}
+	if errors {
+		t.Errorf("errors = true, want false")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// powerInterval is how often the power panel samples the power monitors.
+const powerInterval = 2 * time.Second
+
+// powerWindow is how much history the power graphs cover.
+const powerWindow = 10 * time.Minute
+
+// powerChannel is one channel of an I²C power monitor (e.g. INA219 or
+// INA3221) as reported by the Linux hwmon subsystem (ina2xx and ina3221
+// drivers), see https://www.kernel.org/doc/Documentation/hwmon/sysfs-interface
+type powerChannel struct {
+	name  string  // label, or hwmon chip name and channel, e.g. ina3221 ch2
+	volts float64 // bus voltage
+	amps  float64 // negative when e.g. a battery is charging
+	watts float64
+}
+
+// readPowerMonitors returns the channels of all INA power monitors found in
+// the hwmon directory root (usually /sys/class/hwmon).
+func readPowerMonitors(root string) ([]powerChannel, error) {
+	chips, err := filepath.Glob(filepath.Join(root, "hwmon*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(chips)
+	var channels []powerChannel
+	names := make(map[string]int)
+	for _, chip := range chips {
+		b, err := os.ReadFile(filepath.Join(chip, "name"))
+		if err != nil {
+			continue
+		}
+		name := strings.TrimSpace(string(b))
+		if !strings.HasPrefix(name, "ina") {
+			continue
+		}
+		// The ina2xx driver reports the bus voltage as in1, the ina3221
+		// driver reports 3 channels as in1-in3 (and their shunt voltages as
+		// in4-in6). Both number the currents like the bus voltages.
+		n := 1
+		if name == "ina3221" {
+			n = 3
+		}
+		var chipChannels []powerChannel
+		for i := 1; i <= n; i++ {
+			// Reading the inputs of a disabled ina3221 channel fails.
+			if enabled, err := readHwmonInt(filepath.Join(chip, fmt.Sprintf("in%d_enable", i))); err == nil && enabled == 0 {
+				continue
+			}
+			mV, err := readHwmonInt(filepath.Join(chip, fmt.Sprintf("in%d_input", i)))
+			if err != nil {
+				continue
+			}
+			mA, err := readHwmonInt(filepath.Join(chip, fmt.Sprintf("curr%d_input", i)))
+			if err != nil {
+				continue
+			}
+			ch := powerChannel{
+				name:  fmt.Sprintf("%s ch%d", name, i),
+				volts: float64(mV) / 1000,
+				amps:  float64(mA) / 1000,
+			}
+			ch.watts = ch.volts * ch.amps
+			if uW, err := readHwmonInt(filepath.Join(chip, fmt.Sprintf("power%d_input", i))); err == nil {
+				ch.watts = float64(uW) / 1e6
+			}
+			if b, err := os.ReadFile(filepath.Join(chip, fmt.Sprintf("in%d_label", i))); err == nil {
+				ch.name = strings.TrimSpace(string(b))
+			}
+			chipChannels = append(chipChannels, ch)
+		}
+		if len(chipChannels) == 1 && chipChannels[0].name == name+" ch1" {
+			chipChannels[0].name = name
+		}
+		for _, ch := range chipChannels {
+			// e.g. two ina219 on different I²C addresses
+			names[ch.name]++
+			if n := names[ch.name]; n > 1 {
+				ch.name = fmt.Sprintf("%s #%d", ch.name, n)
+			}
+			channels = append(channels, ch)
+		}
+	}
+	return channels, nil
+}
+
+// formatSI formats v (in unit) with two decimals, or with the milli prefix
+// below 1, e.g. 850 mA or 4.35 W.
+func formatSI(v float64, unit string) string {
+	if a := math.Abs(v); a != 0 && a < 1 {
+		return fmt.Sprintf("%.0f m%s", v*1000, unit)
+	}
+	return fmt.Sprintf("%.2f %s", v, unit)
+}
+
+type powerReading struct {
+	channel powerChannel
+	hist    *history // of watts, a copy owned by the reading
+}
+
+// powerPanel shows the voltage, current and power draw measured by I²C
+// power monitors, with a graph of the power of each channel, e.g. for solar
+// or battery powered installations.
+type powerPanel struct {
+	power *poller[[]powerReading]
+}
+
+func newPowerPanel() (panel, error) {
+	size := int(powerWindow / powerInterval)
+	hists := make(map[string]*history)
+	return &powerPanel{
+		power: newPoller(powerInterval, func(context.Context) ([]powerReading, error) {
+			channels, err := readPowerMonitors("/sys/class/hwmon")
+			if err != nil {
+				return nil, err
+			}
+			if len(channels) == 0 {
+				return nil, errors.New("no INA219/INA3221 power monitor found (hwmon ina2xx/ina3221 drivers)")
+			}
+			readings := make([]powerReading, 0, len(channels))
+			for _, ch := range channels {
+				hist, ok := hists[ch.name]
+				if !ok {
+					hist = newHistory(size)
+					hists[ch.name] = hist
+				}
+				hist.add(ch.watts)
+				readings = append(readings, powerReading{channel: ch, hist: hist.clone()})
+			}
+			return readings, nil
+		}),
+	}, nil
+}
+
+func (p *powerPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Power")
+	readings, updated, err := p.power.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	header := []string{"channel", "voltage", "current", "power"}
+	rows := make([][]cell, 0, len(readings))
+	for _, r := range readings {
+		ch := r.channel
+		rows = append(rows, []cell{
+			{text: ch.name},
+			{text: formatSI(ch.volts, "V")},
+			{text: formatSI(ch.amps, "A")},
+			{text: formatSI(ch.watts, "W")},
+		})
+	}
+	y = d.drawTable(dc, y, header, rows)
+
+	// graph the power of each channel, if there is enough space left
+	em, _ := dc.MeasureString("m")
+	lineHeight := dc.FontHeight() * lineSpacing
+	for _, r := range readings {
+		h := 3 * dc.FontHeight()
+		if y+2*lineHeight+h > float64(dc.Height()) {
+			break
+		}
+		y += lineHeight
+		setColor(dc, "darkgray")
+		dc.DrawString(r.channel.name, 3*em, y)
+		y += lineHeight / 2
+		drawGraph(dc, r.hist, 3*em, y, float64(dc.Width())-6*em, h, "%.2f W")
+		y += h
+	}
+	dc.SetRGB(1, 1, 1)
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadPowerMonitors(t *testing.T) {
+	root := t.TempDir()
+	writeFiles := func(chip string, files map[string]string) {
+		dir := filepath.Join(root, chip)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, contents := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(contents+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	writeFiles("hwmon0", map[string]string{
+		"name":        "cpu_thermal",
+		"temp1_input": "45000",
+	})
+	writeFiles("hwmon1", map[string]string{
+		"name":         "ina219",
+		"in0_input":    "12", // shunt voltage
+		"in1_input":    "5120",
+		"curr1_input":  "850",
+		"power1_input": "4360000",
+	})
+	writeFiles("hwmon2", map[string]string{
+		"name":        "ina3221",
+		"in1_enable":  "0", // reading in1_input and curr1_input fails
+		"in2_input":   "18000",
+		"curr2_input": "1500",
+		"in2_label":   "solar",
+		"in3_input":   "12600",
+		"curr3_input": "-400", // battery charging
+		"in4_input":   "30",   // shunt voltages
+		"in5_input":   "0",
+		"in6_input":   "8",
+	})
+	writeFiles("hwmon3", map[string]string{
+		"name":        "ina219",
+		"in1_input":   "3300",
+		"curr1_input": "200",
+	})
+
+	got, err := readPowerMonitors(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []powerChannel{
+		{name: "ina219", volts: 5.12, amps: 0.85, watts: 4.36},
+		{name: "solar", volts: 18, amps: 1.5, watts: 27},
+		{name: "ina3221 ch3", volts: 12.6, amps: -0.4, watts: 12.6 * -0.4},
+		{name: "ina219 #2", volts: 3.3, amps: 0.2, watts: 3.3 * 0.2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("readPowerMonitors() = %+v, want %+v", got, want)
+	}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Errorf("channel %d = %+v, want %+v", idx, got[idx], want[idx])
+		}
+	}
+}
+
+func TestFormatSI(t *testing.T) {
+	for _, tt := range []struct {
+		v    float64
+		unit string
+		want string
+	}{
+		{5.12, "V", "5.12 V"},
+		{0.85, "A", "850 mA"},
+		{-0.4, "A", "-400 mA"},
+		{0, "W", "0.00 W"},
+		{27, "W", "27.00 W"},
+	} {
+		if got := formatSI(tt.v, tt.unit); got != tt.want {
+			t.Errorf("formatSI(%v, %s) = %q, want %q", tt.v, tt.unit, got, tt.want)
+		}
+	}
+}
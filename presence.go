@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+var (
+	presenceFlag = flag.String("presence",
+		"",
+		"comma-separated list of devices (MAC or IP addresses, e.g. of phones) whose presence on the local network keeps the display on: when none of them was seen for -presence-timeout, the display is blanked until one of them returns (or input wakes it up). Devices are probed via ARP (IPv4) and NDP (IPv6)")
+
+	presenceTimeout = flag.Duration("presence-timeout",
+		10*time.Minute,
+		"with -presence, how long none of the devices must have been seen before the display is blanked. Sleeping phones often do not respond for a few minutes, so this should not be too short")
+)
+
+const (
+	// presenceInterval is how often the devices of -presence are probed.
+	presenceInterval = 30 * time.Second
+
+	// presenceResolveTimeout is how long to wait at most for ARP/NDP
+	// resolution after sending a packet to a device. For a stale entry, the
+	// kernel waits 5s (delay_first_probe_time) before it sends 3 probes, 1s
+	// apart (retrans_time), so resolution can take 8s.
+	presenceResolveTimeout = 10 * time.Second
+)
+
+// neighbor is an entry of the kernel neighbor table (ARP and NDP cache).
+type neighbor struct {
+	ip    net.IP
+	mac   net.HardwareAddr
+	state uint16 // NUD_* state
+}
+
+// reachable reports whether the neighbor recently confirmed its address.
+func (n neighbor) reachable() bool {
+	return n.state&unix.NUD_REACHABLE != 0
+}
+
+// resolving reports whether the kernel is still verifying the neighbor's
+// address (i.e. neither confirmed it nor gave up).
+func (n neighbor) resolving() bool {
+	return n.state&(unix.NUD_INCOMPLETE|unix.NUD_DELAY|unix.NUD_PROBE) != 0
+}
+
+// resolving reports whether any of ips is still being resolved as per
+// neighbors.
+func resolving(neighbors []neighbor, ips []net.IP) bool {
+	for _, n := range neighbors {
+		if !n.resolving() {
+			continue
+		}
+		for _, ip := range ips {
+			if ip.Equal(n.ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseNeighbors parses an RTM_GETNEIGH dump.
+func parseNeighbors(b []byte) ([]neighbor, error) {
+	msgs, err := syscall.ParseNetlinkMessage(b)
+	if err != nil {
+		return nil, err
+	}
+	var neighbors []neighbor
+	for _, m := range msgs {
+		if m.Header.Type != unix.RTM_NEWNEIGH {
+			continue
+		}
+		if len(m.Data) < unix.SizeofNdMsg {
+			return nil, fmt.Errorf("neighbor message too short: %d bytes", len(m.Data))
+		}
+		n := neighbor{state: binary.LittleEndian.Uint16(m.Data[8:])}
+		for attrs := m.Data[unix.SizeofNdMsg:]; len(attrs) >= unix.SizeofRtAttr; {
+			l := int(binary.LittleEndian.Uint16(attrs[0:]))
+			if l < unix.SizeofRtAttr || l > len(attrs) {
+				return nil, fmt.Errorf("malformed neighbor attribute")
+			}
+			switch binary.LittleEndian.Uint16(attrs[2:]) {
+			case unix.NDA_DST:
+				n.ip = net.IP(attrs[unix.SizeofRtAttr:l])
+			case unix.NDA_LLADDR:
+				n.mac = net.HardwareAddr(attrs[unix.SizeofRtAttr:l])
+			}
+			l = (l + unix.RTA_ALIGNTO - 1) &^ (unix.RTA_ALIGNTO - 1)
+			if l > len(attrs) {
+				break
+			}
+			attrs = attrs[l:]
+		}
+		if n.ip != nil {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors, nil
+}
+
+func readNeighbors() ([]neighbor, error) {
+	b, err := syscall.NetlinkRIB(unix.RTM_GETNEIGH, unix.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("RTM_GETNEIGH: %v", err)
+	}
+	return parseNeighbors(b)
+}
+
+// presenceDevice is one device of -presence, identified either by MAC or by
+// IP address.
+type presenceDevice struct {
+	spec string
+	mac  net.HardwareAddr
+	ip   net.IP
+}
+
+func (dev *presenceDevice) matches(n neighbor) bool {
+	if dev.mac != nil {
+		return bytes.Equal(dev.mac, n.mac)
+	}
+	return dev.ip.Equal(n.ip)
+}
+
+// presenceMonitor probes the devices of -presence in the background and
+// records when any of them was last seen.
+type presenceMonitor struct {
+	devices []*presenceDevice
+
+	mu       sync.Mutex
+	lastSeen time.Time
+	present  map[*presenceDevice]bool
+}
+
+func parsePresence(spec string, now time.Time) (*presenceMonitor, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	// Until the first probe, consider the devices present, so that the
+	// display is not blanked right after starting up.
+	p := &presenceMonitor{
+		lastSeen: now,
+		present:  make(map[*presenceDevice]bool),
+	}
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		dev := &presenceDevice{spec: s}
+		if ip := net.ParseIP(s); ip != nil {
+			dev.ip = ip
+		} else if mac, err := net.ParseMAC(s); err == nil {
+			dev.mac = mac
+		} else {
+			return nil, fmt.Errorf("-presence: %q is neither an IP nor a MAC address", s)
+		}
+		p.devices = append(p.devices, dev)
+	}
+	return p, nil
+}
+
+// targets returns the IP addresses to send a packet to, so that the kernel
+// verifies via ARP/NDP whether the devices are reachable. For devices
+// specified by MAC address, these are the addresses under which they
+// appear in the neighbor table.
+func (p *presenceMonitor) targets(neighbors []neighbor) []net.IP {
+	var ips []net.IP
+	for _, dev := range p.devices {
+		if dev.ip != nil {
+			ips = append(ips, dev.ip)
+			continue
+		}
+		for _, n := range neighbors {
+			if dev.matches(n) {
+				ips = append(ips, n.ip)
+			}
+		}
+	}
+	return ips
+}
+
+// update records which devices are reachable as per neighbors at now.
+func (p *presenceMonitor) update(neighbors []neighbor, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, dev := range p.devices {
+		present := false
+		for _, n := range neighbors {
+			if n.reachable() && dev.matches(n) {
+				present = true
+				break
+			}
+		}
+		if present != p.present[dev] {
+			slog.Info("presence changed", "device", dev.spec, "present", present)
+		}
+		p.present[dev] = present
+		if present {
+			p.lastSeen = now
+		}
+	}
+}
+
+// absent reports whether none of the devices was seen (and there was no
+// input since lastInput) for -presence-timeout at now.
+func (p *presenceMonitor) absent(now, lastInput time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	last := p.lastSeen
+	if lastInput.After(last) {
+		last = lastInput
+	}
+	return now.Sub(last) >= *presenceTimeout
+}
+
+// poke sends a UDP packet to the discard port of ip. Whether or not
+// anything listens there, the kernel needs to resolve the link-layer
+// address first.
+func poke(ip net.IP) error {
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: ip, Port: 9})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte{0})
+	return err
+}
+
+func (p *presenceMonitor) probe() error {
+	neighbors, err := readNeighbors()
+	if err != nil {
+		return err
+	}
+	targets := p.targets(neighbors)
+	for _, ip := range targets {
+		if err := poke(ip); err != nil {
+			slog.Debug("presence probe failed", "ip", ip, "err", err)
+		}
+	}
+	// Only reachable devices count as present, so wait until the kernel
+	// confirmed or gave up on all of them.
+	deadline := time.Now().Add(presenceResolveTimeout)
+	for {
+		time.Sleep(time.Second)
+		if neighbors, err = readNeighbors(); err != nil {
+			return err
+		}
+		if !resolving(neighbors, targets) || time.Now().After(deadline) {
+			break
+		}
+	}
+	p.update(neighbors, time.Now())
+	return nil
+}
+
+func (p *presenceMonitor) run() {
+	for {
+		start := time.Now()
+		if err := p.probe(); err != nil {
+			slog.Warn("presence probe failed", "err", err)
+		}
+		time.Sleep(presenceInterval - time.Since(start))
+	}
+}
+
+// startPresence probes the devices of -presence in the background. It
+// returns nil if -presence is empty.
+func startPresence(spec string) (*presenceMonitor, error) {
+	p, err := parsePresence(spec, time.Now())
+	if err != nil || p == nil {
+		return nil, err
+	}
+	go p.run()
+	return p, nil
+}
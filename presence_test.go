@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// neighborMessage returns an RTM_NEWNEIGH netlink message.
+func neighborMessage(ip net.IP, mac net.HardwareAddr, state uint16) []byte {
+	attr := func(typ uint16, data []byte) []byte {
+		b := make([]byte, unix.SizeofRtAttr, unix.SizeofRtAttr+len(data)+unix.RTA_ALIGNTO)
+		binary.LittleEndian.PutUint16(b[0:], uint16(unix.SizeofRtAttr+len(data)))
+		binary.LittleEndian.PutUint16(b[2:], typ)
+		b = append(b, data...)
+		for len(b)%unix.RTA_ALIGNTO != 0 {
+			b = append(b, 0)
+		}
+		return b
+	}
+	body := make([]byte, unix.SizeofNdMsg)
+	body[0] = unix.AF_INET
+	binary.LittleEndian.PutUint16(body[8:], state)
+	body = append(body, attr(unix.NDA_DST, ip.To4())...)
+	if mac != nil {
+		body = append(body, attr(unix.NDA_LLADDR, mac)...)
+	}
+	msg := make([]byte, unix.NLMSG_HDRLEN, unix.NLMSG_HDRLEN+len(body))
+	binary.LittleEndian.PutUint32(msg[0:], uint32(unix.NLMSG_HDRLEN+len(body)))
+	binary.LittleEndian.PutUint16(msg[4:], unix.RTM_NEWNEIGH)
+	return append(msg, body...)
+}
+
+func TestParseNeighbors(t *testing.T) {
+	phone, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	var dump []byte
+	dump = append(dump, neighborMessage(net.ParseIP("10.0.0.23"), phone, unix.NUD_REACHABLE)...)
+	dump = append(dump, neighborMessage(net.ParseIP("10.0.0.42"), nil, unix.NUD_FAILED)...)
+	neighbors, err := parseNeighbors(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(neighbors) != 2 {
+		t.Fatalf("parseNeighbors() = %v, want 2 neighbors", neighbors)
+	}
+	if n := neighbors[0]; !n.ip.Equal(net.ParseIP("10.0.0.23")) || n.mac.String() != phone.String() || !n.reachable() {
+		t.Errorf("neighbors[0] = %+v, want 10.0.0.23 (%s), reachable", n, phone)
+	}
+	if n := neighbors[1]; !n.ip.Equal(net.ParseIP("10.0.0.42")) || n.mac != nil || n.reachable() {
+		t.Errorf("neighbors[1] = %+v, want 10.0.0.42, unreachable", n)
+	}
+}
+
+func TestPresence(t *testing.T) {
+	if _, err := parsePresence("phone", time.Now()); err == nil {
+		t.Errorf("parsePresence(phone) succeeded unexpectedly")
+	}
+
+	start := time.Date(2022, 1, 1, 18, 0, 0, 0, time.UTC)
+	p, err := parsePresence("aa:bb:cc:dd:ee:01, 10.0.0.42", start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, _ := net.ParseMAC("aa:bb:cc:dd:ee:01")
+	other, _ := net.ParseMAC("aa:bb:cc:dd:ee:02")
+	neighbors := []neighbor{
+		{ip: net.ParseIP("10.0.0.23"), mac: phone, state: unix.NUD_STALE},
+		{ip: net.ParseIP("fe80::1"), mac: phone, state: unix.NUD_STALE},
+		{ip: net.ParseIP("10.0.0.24"), mac: other, state: unix.NUD_REACHABLE},
+	}
+	var targets []string
+	for _, ip := range p.targets(neighbors) {
+		targets = append(targets, ip.String())
+	}
+	if got, want := targets, []string{"10.0.0.23", "fe80::1", "10.0.0.42"}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("targets() = %v, want %v", got, want)
+	}
+
+	ips := p.targets(neighbors)
+	if resolving(neighbors, ips) {
+		t.Errorf("resolving() = true for stale entries")
+	}
+	neighbors[0].state = unix.NUD_DELAY // after sending a packet to a stale entry
+	if !resolving(neighbors, ips) {
+		t.Errorf("resolving() = false during the probe delay")
+	}
+	neighbors[0].state = unix.NUD_STALE
+
+	if p.absent(start.Add(*presenceTimeout-time.Second), time.Time{}) {
+		t.Errorf("absent() before the first probe")
+	}
+	// stale entries do not count as present
+	p.update(neighbors, start.Add(time.Minute))
+	now := start.Add(*presenceTimeout)
+	if !p.absent(now, time.Time{}) {
+		t.Errorf("absent() = false, want true without reachable devices")
+	}
+	if p.absent(now, now.Add(-time.Minute)) {
+		t.Errorf("absent() = true despite recent input")
+	}
+	neighbors[0].state = unix.NUD_REACHABLE
+	p.update(neighbors, now)
+	if p.absent(now.Add(time.Minute), time.Time{}) {
+		t.Errorf("absent() = true, want false after the phone returned")
+	}
+}
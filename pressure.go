@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// pressureInterval is how often the pressure panel samples /proc/pressure.
+const pressureInterval = 2 * time.Second
+
+// pressureWindow is how much history the pressure sparklines cover.
+const pressureWindow = 5 * time.Minute
+
+// pressureResources are the resources for which Linux reports Pressure Stall
+// Information (PSI) in /proc/pressure.
+var pressureResources = []string{"cpu", "io", "memory"}
+
+// pressureLine is a line of a /proc/pressure file: the percentage of time in
+// which some (or all) tasks were stalled waiting for the resource, averaged
+// over 10, 60 and 300 seconds, and the total stall time in microseconds.
+type pressureLine struct {
+	avg10, avg60, avg300 float64
+	total                uint64
+}
+
+type pressureStats struct {
+	some, full pressureLine
+	hasFull    bool // cpu pressure only has a full line since Linux 5.13
+}
+
+// parsePressure parses the contents of a /proc/pressure file as per
+// Documentation/accounting/psi.rst.
+func parsePressure(b []byte) (pressureStats, error) {
+	var st pressureStats
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 5 {
+			return pressureStats{}, fmt.Errorf("malformed pressure line: %q", line)
+		}
+		var pl pressureLine
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			var err error
+			switch key {
+			case "avg10":
+				pl.avg10, err = strconv.ParseFloat(value, 64)
+			case "avg60":
+				pl.avg60, err = strconv.ParseFloat(value, 64)
+			case "avg300":
+				pl.avg300, err = strconv.ParseFloat(value, 64)
+			case "total":
+				pl.total, err = strconv.ParseUint(value, 10, 64)
+			}
+			if err != nil {
+				return pressureStats{}, fmt.Errorf("malformed pressure line: %q: %v", line, err)
+			}
+		}
+		switch fields[0] {
+		case "some":
+			st.some = pl
+		case "full":
+			st.full = pl
+			st.hasFull = true
+		}
+	}
+	return st, nil
+}
+
+// stalledPercent returns the percentage of time between two samples of the
+// total stall time (in microseconds), taken elapsed apart.
+func stalledPercent(prev, cur uint64, elapsed time.Duration) float64 {
+	if cur < prev || elapsed <= 0 {
+		return 0
+	}
+	return 100 * float64(cur-prev) / float64(elapsed.Microseconds())
+}
+
+type pressureResource struct {
+	name  string
+	stats pressureStats
+	hist  []float64 // percentage of time some tasks stalled, per interval
+}
+
+// pressurePanel shows the Pressure Stall Information of the CPU, I/O and
+// memory, which reveals contention (“the Pi feels slow”) that utilization
+// percentages hide.
+type pressurePanel struct {
+	pressure *poller[[]pressureResource]
+}
+
+func newPressurePanel() (panel, error) {
+	size := int(pressureWindow / pressureInterval)
+	hists := make(map[string]*history)
+	prev := make(map[string]uint64)
+	var prevTime time.Time
+	return &pressurePanel{
+		pressure: newPoller(pressureInterval, func(context.Context) ([]pressureResource, error) {
+			now := time.Now()
+			var resources []pressureResource
+			for _, name := range pressureResources {
+				b, err := os.ReadFile("/proc/pressure/" + name)
+				if err != nil {
+					if os.IsNotExist(err) {
+						return nil, fmt.Errorf("%v (kernel without CONFIG_PSI?)", err)
+					}
+					return nil, err
+				}
+				st, err := parsePressure(b)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", name, err)
+				}
+				hist, ok := hists[name]
+				if !ok {
+					hist = newHistory(size)
+					hists[name] = hist
+				}
+				if !prevTime.IsZero() {
+					hist.add(stalledPercent(prev[name], st.some.total, now.Sub(prevTime)))
+				}
+				prev[name] = st.some.total
+				resources = append(resources, pressureResource{
+					name:  name,
+					stats: st,
+					hist:  hist.values(),
+				})
+			}
+			prevTime = now
+			return resources, nil
+		}),
+	}, nil
+}
+
+// pressureCell formats a pressure percentage, colored as per the pressure
+// threshold.
+func pressureCell(v float64) cell {
+	if v == 0 {
+		return cell{text: "0", color: "darkgray"}
+	}
+	return cell{
+		text:  strconv.FormatFloat(v, 'f', 2, 64) + "%",
+		color: metricColor("pressure", v, ""),
+	}
+}
+
+func (p *pressurePanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Pressure")
+	resources, updated, err := p.pressure.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	header := []string{"resource", "some 10s", "1m", "5m", "full 10s"}
+	rows := make([][]cell, 0, len(resources))
+	for _, r := range resources {
+		full := cell{text: "–", color: "darkgray"}
+		if r.stats.hasFull {
+			full = pressureCell(r.stats.full.avg10)
+		}
+		rows = append(rows, []cell{
+			{text: r.name},
+			pressureCell(r.stats.some.avg10),
+			pressureCell(r.stats.some.avg60),
+			pressureCell(r.stats.some.avg300),
+			full,
+		})
+	}
+	y = d.drawTable(dc, y, header, rows)
+
+	// sparklines of the time in which some tasks stalled, one per resource,
+	// labeled with their peak
+	em, _ := dc.MeasureString("m")
+	lineHeight := dc.FontHeight() * lineSpacing
+	labelW := 6 * em
+	peakW := 6 * em
+	for _, r := range resources {
+		h := 2 * dc.FontHeight()
+		if y+lineHeight+h > float64(dc.Height()) {
+			break
+		}
+		y += lineHeight
+		setColor(dc, "darkgray")
+		dc.DrawString(r.name, 3*em, y+h/2)
+		var max float64
+		for _, v := range r.hist {
+			if v > max {
+				max = v
+			}
+		}
+		color := metricColor("pressure", max, "green")
+		drawSparkline(dc, r.hist, 3*em+labelW, y, float64(dc.Width())-6*em-labelW-peakW, h, color)
+		setColor(dc, "darkgray")
+		dc.DrawStringAnchored(strconv.FormatFloat(max, 'f', 2, 64)+"%", float64(dc.Width())-3*em, y, 1, 1)
+		y += h
+	}
+	dc.SetRGB(1, 1, 1)
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePressure(t *testing.T) {
+	const memory = `some avg10=1.50 avg60=0.75 avg300=0.10 total=123456
+full avg10=0.50 avg60=0.25 avg300=0.00 total=45678
+`
+	got, err := parsePressure([]byte(memory))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := pressureStats{
+		some:    pressureLine{avg10: 1.5, avg60: 0.75, avg300: 0.1, total: 123456},
+		full:    pressureLine{avg10: 0.5, avg60: 0.25, total: 45678},
+		hasFull: true,
+	}
+	if got != want {
+		t.Errorf("parsePressure() = %+v, want %+v", got, want)
+	}
+
+	// cpu pressure before Linux 5.13
+	got, err = parsePressure([]byte("some avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.hasFull {
+		t.Errorf("parsePressure(some only): hasFull = true")
+	}
+
+	for _, malformed := range []string{
+		"some avg10=0.00\n",
+		"some avg10=x avg60=0.00 avg300=0.00 total=0\n",
+	} {
+		if _, err := parsePressure([]byte(malformed)); err == nil {
+			t.Errorf("parsePressure(%q) unexpectedly succeeded", malformed)
+		}
+	}
+}
+
+func TestStalledPercent(t *testing.T) {
+	if got, want := stalledPercent(1000000, 1500000, 2*time.Second), 25.0; got != want {
+		t.Errorf("stalledPercent() = %v, want %v", got, want)
+	}
+	if got := stalledPercent(1500000, 1000000, 2*time.Second); got != 0 {
+		t.Errorf("stalledPercent(counter reset) = %v, want 0", got)
+	}
+}
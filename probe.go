@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var probesFlag = flag.String("probes",
+	"",
+	"comma-separated list of latency probes, each specified as [label=]target, where target is a host (ICMP echo), tcp://host:port (TCP connect) or gateway (ICMP echo to the default gateway), e.g. gateway,cloudflare=1.1.1.1,vpn=tcp://vpn.example.net:443")
+
+const (
+	// probeInterval is how often each probe target is probed.
+	probeInterval = 2 * time.Second
+
+	// probeWindow is the number of most recent probe results from which the
+	// packet loss is calculated.
+	probeWindow = 30
+)
+
+// icmpChecksum is the internet checksum (RFC 1071).
+func icmpChecksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// icmpEcho sends one ICMP echo request to host and waits for the reply. It
+// uses a raw socket, so it requires root privileges (or CAP_NET_RAW), which
+// gokrazy services have.
+func icmpEcho(ctx context.Context, host string, id, seq uint16) (time.Duration, error) {
+	addr, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return 0, err
+	}
+	if len(addr) == 0 {
+		return 0, fmt.Errorf("%s: no addresses", host)
+	}
+	ip := addr[0].IP
+	network, echoRequest, echoReply := "ip4:icmp", byte(8), byte(0)
+	if ip.To4() == nil {
+		// The kernel computes the ICMPv6 checksum for raw sockets.
+		network, echoRequest, echoReply = "ip6:ipv6-icmp", 128, 129
+	}
+	conn, err := net.ListenPacket(network, "")
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := make([]byte, 8)
+	req[0] = echoRequest
+	binary.BigEndian.PutUint16(req[4:], id)
+	binary.BigEndian.PutUint16(req[6:], seq)
+	if echoRequest == 8 {
+		binary.BigEndian.PutUint16(req[2:], icmpChecksum(req))
+	}
+	start := time.Now()
+	if _, err := conn.WriteTo(req, &net.IPAddr{IP: ip}); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		// The raw socket receives all ICMP messages, so skip those which are
+		// not the reply to our request.
+		if n < 8 ||
+			buf[0] != echoReply ||
+			!from.(*net.IPAddr).IP.Equal(ip) ||
+			binary.BigEndian.Uint16(buf[4:]) != id ||
+			binary.BigEndian.Uint16(buf[6:]) != seq {
+			continue
+		}
+		return time.Since(start), nil
+	}
+}
+
+func tcpConnect(ctx context.Context, addr string) (time.Duration, error) {
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	conn.Close()
+	return rtt, nil
+}
+
+type probeResult struct {
+	rtt time.Duration
+	err error
+}
+
+// probe periodically measures the round-trip time to one target.
+type probe struct {
+	label  string
+	target string // as specified in -probes
+
+	mu      sync.Mutex
+	results []probeResult // most recent last, at most probeWindow
+}
+
+func parseProbes(spec string) ([]*probe, error) {
+	var probes []*probe
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		label, target, ok := strings.Cut(s, "=")
+		if !ok {
+			label, target = s, s
+		}
+		if strings.HasPrefix(target, "tcp://") {
+			if _, _, err := net.SplitHostPort(strings.TrimPrefix(target, "tcp://")); err != nil {
+				return nil, fmt.Errorf("malformed probe %q: %v", s, err)
+			}
+			if !ok {
+				label = strings.TrimPrefix(target, "tcp://")
+			}
+		}
+		if label == "" || target == "" {
+			return nil, fmt.Errorf("malformed probe %q: expected [label=]target", s)
+		}
+		probes = append(probes, &probe{
+			label:  label,
+			target: target,
+		})
+	}
+	return probes, nil
+}
+
+func (p *probe) once(ctx context.Context, id, seq uint16) (time.Duration, error) {
+	switch {
+	case strings.HasPrefix(p.target, "tcp://"):
+		return tcpConnect(ctx, strings.TrimPrefix(p.target, "tcp://"))
+	case p.target == "gateway":
+		routes, err := os.ReadFile("/proc/net/route")
+		if err != nil {
+			return 0, err
+		}
+		defaults, err := parseDefaultRoutes(routes)
+		if err != nil {
+			return 0, err
+		}
+		if len(defaults) == 0 {
+			return 0, errors.New("no default route")
+		}
+		return icmpEcho(ctx, defaults[0].gateway.String(), id, seq)
+	default:
+		return icmpEcho(ctx, p.target, id, seq)
+	}
+}
+
+func (p *probe) run(id uint16) {
+	for seq := uint16(0); ; seq++ {
+		ctx, canc := context.WithTimeout(context.Background(), probeInterval)
+		start := time.Now()
+		rtt, err := p.once(ctx, id, seq)
+		canc()
+		p.mu.Lock()
+		p.results = append(p.results, probeResult{rtt: rtt, err: err})
+		if len(p.results) > probeWindow {
+			p.results = p.results[len(p.results)-probeWindow:]
+		}
+		p.mu.Unlock()
+		time.Sleep(probeInterval - time.Since(start))
+	}
+}
+
+// stats returns the most recent result and the packet loss in percent.
+func (p *probe) stats() (last probeResult, loss float64, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.results) == 0 {
+		return probeResult{}, 0, false
+	}
+	var lost int
+	for _, r := range p.results {
+		if r.err != nil {
+			lost++
+		}
+	}
+	return p.results[len(p.results)-1], 100 * float64(lost) / float64(len(p.results)), true
+}
+
+func (p *probe) desc() string {
+	last, loss, ok := p.stats()
+	if !ok {
+		return "$$" + p.label + " $darkgray$…"
+	}
+	if last.err != nil {
+		return "$$" + p.label + " $red$unreachable"
+	}
+	rtt := float64(last.rtt) / float64(time.Millisecond)
+	desc := fmt.Sprintf("$$%s $%s$%.1f ms", p.label, metricColor("ping", rtt, "green"), rtt)
+	if loss > 0 {
+		desc += fmt.Sprintf(" $%s$(%.0f%% loss)", metricColor("ping-loss", loss, "green"), loss)
+	}
+	return desc
+}
+
+// probes runs all latency probes specified in -probes in the background.
+type probes []*probe
+
+func startProbes(spec string) (probes, error) {
+	ps, err := parseProbes(spec)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range ps {
+		// Distinguish our echo requests from those of other processes (and
+		// from each other) by ICMP identifier.
+		go p.run(uint16(os.Getpid()) + uint16(i))
+	}
+	return ps, nil
+}
+
+// line returns a host information line (in $color$text markup) with the
+// round-trip time and packet loss of each probe.
+func (ps probes) line() string {
+	descs := make([]string, 0, len(ps))
+	for _, p := range ps {
+		descs = append(descs, p.desc())
+	}
+	return "$$ping: " + strings.Join(descs, "$$, ")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestICMPChecksum(t *testing.T) {
+	// echo request, id 1, seq 1
+	req := []byte{8, 0, 0, 0, 0, 1, 0, 1}
+	if got, want := icmpChecksum(req), uint16(0xf7fd); got != want {
+		t.Errorf("icmpChecksum() = %#x, want %#x", got, want)
+	}
+}
+
+func TestParseProbes(t *testing.T) {
+	ps, err := parseProbes("gateway,cloudflare=1.1.1.1,tcp://vpn.example.net:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []struct{ label, target string }{
+		{"gateway", "gateway"},
+		{"cloudflare", "1.1.1.1"},
+		{"vpn.example.net:443", "tcp://vpn.example.net:443"},
+	} {
+		if ps[i].label != want.label || ps[i].target != want.target {
+			t.Errorf("probe %d = %q=%q, want %q=%q", i, ps[i].label, ps[i].target, want.label, want.target)
+		}
+	}
+	if _, err := parseProbes("tcp://missing-port"); err == nil {
+		t.Errorf("parseProbes(tcp://missing-port) unexpectedly succeeded")
+	}
+}
+
+func TestTCPProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	p := &probe{label: "local", target: "tcp://" + ln.Addr().String()}
+	ctx, canc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer canc()
+	if _, err := p.once(ctx, 1, 0); err != nil {
+		t.Fatal(err)
+	}
+}
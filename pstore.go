@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var pstoreArchive = flag.String("pstore-archive",
+	"/perm/pstore",
+	"directory to which the kernel crash records in /sys/fs/pstore are moved once the “previous boot crashed” badge is acknowledged (press a, or POST /pstore). If empty, the records are deleted")
+
+// pstoreCrashLines is the number of lines of the crash shown in the badge.
+const pstoreCrashLines = 3
+
+// pstoreRecord is a kernel log dump (dmesg-* file) in /sys/fs/pstore, which
+// the kernel wrote when it panicked or oopsed.
+type pstoreRecord struct {
+	name   string
+	reason string // e.g. Panic or Oops
+	part   int    // part 1 contains the end of the kernel log
+	time   time.Time
+	lines  []string // kernel log lines, without level and timestamp
+}
+
+// pstoreHeaderRe matches the first line of dmesg records, e.g. “Panic#1
+// Part1” (see kmsg_dump_reason_str() and pstore_dump() in the kernel).
+var pstoreHeaderRe = regexp.MustCompile(`^(\w+)#\d+ Part(\d+)$`)
+
+// kmsgPrefixRe matches the log level and timestamp which prefix the kernel
+// log lines in pstore records, e.g. “<0>[  123.456789] ”.
+var kmsgPrefixRe = regexp.MustCompile(`^(<\d+>)?\[\s*\d+\.\d+\] ?`)
+
+// parsePstoreRecord parses the contents of a dmesg record.
+func parsePstoreRecord(name string, b []byte, modTime time.Time) (pstoreRecord, error) {
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	m := pstoreHeaderRe.FindStringSubmatch(lines[0])
+	if m == nil {
+		return pstoreRecord{}, fmt.Errorf("%s: malformed header %q", name, lines[0])
+	}
+	r := pstoreRecord{
+		name:   name,
+		reason: m[1],
+		time:   modTime,
+	}
+	fmt.Sscan(m[2], &r.part)
+	for _, line := range lines[1:] {
+		r.lines = append(r.lines, kmsgPrefixRe.ReplaceAllString(line, ""))
+	}
+	return r, nil
+}
+
+// crashMarkers identify the first line of a kernel crash report.
+var crashMarkers = []string{
+	"Kernel panic",
+	"Unable to handle kernel",
+	"Internal error:",
+	"BUG:",
+	"Oops",
+	"general protection fault",
+}
+
+// crashLines returns the first n lines of the crash report in the record, or
+// its last n lines if it contains no recognizable crash report.
+func (r pstoreRecord) crashLines(n int) []string {
+	for idx, line := range r.lines {
+		for _, marker := range crashMarkers {
+			if strings.Contains(line, marker) {
+				lines := r.lines[idx:]
+				if len(lines) > n {
+					lines = lines[:n]
+				}
+				return lines
+			}
+		}
+	}
+	if len(r.lines) > n {
+		return r.lines[len(r.lines)-n:]
+	}
+	return r.lines
+}
+
+// readPstore returns the panic and oops records in dir, most recent first.
+func readPstore(dir string) ([]pstoreRecord, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "dmesg-*"))
+	if err != nil {
+		return nil, err
+	}
+	var records []pstoreRecord
+	for _, m := range matches {
+		if strings.HasSuffix(m, ".enc.z") {
+			continue // the kernel could not decompress the record
+		}
+		fi, err := os.Stat(m)
+		if err != nil {
+			return nil, err
+		}
+		b, err := os.ReadFile(m)
+		if err != nil {
+			return nil, err
+		}
+		r, err := parsePstoreRecord(filepath.Base(m), b, fi.ModTime())
+		if err != nil {
+			slog.Warn("skipping pstore record", "err", err)
+			continue
+		}
+		if r.reason != "Panic" && r.reason != "Oops" {
+			continue // e.g. a dump on regular reboot (printk.always_kmsg_dump)
+		}
+		records = append(records, r)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].time.Equal(records[j].time) {
+			return records[i].time.After(records[j].time)
+		}
+		return records[i].part < records[j].part
+	})
+	return records, nil
+}
+
+// pstoreCrash shows kernel crashes of previous boots, which pstore retains
+// (in RAM across warm reboots with ramoops, or in EFI variables) until the
+// records are removed, i.e. until the crash was acknowledged.
+type pstoreCrash struct {
+	dir     string // /sys/fs/pstore, except in tests
+	archive string // as per -pstore-archive
+
+	mu      sync.Mutex
+	records []pstoreRecord
+}
+
+func newPstoreCrash() *pstoreCrash {
+	c := &pstoreCrash{
+		dir:     "/sys/fs/pstore",
+		archive: *pstoreArchive,
+	}
+	// The records cannot change while running, as the kernel only writes
+	// them when crashing.
+	records, err := readPstore(c.dir)
+	if err != nil {
+		slog.Warn("reading pstore failed", "err", err)
+	}
+	c.records = records
+	return c
+}
+
+// badgeLines returns the lines of the “previous boot crashed” badge, or nil
+// if there is no crash to show.
+func (c *pstoreCrash) badgeLines() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.records) == 0 {
+		return nil
+	}
+	r := c.records[0]
+	lines := []string{fmt.Sprintf("previous boot crashed (%s, %s)", strings.ToLower(r.reason), r.time.Format("2006-01-02 15:04:05"))}
+	lines = append(lines, r.crashLines(pstoreCrashLines)...)
+	return append(lines, "press a to acknowledge")
+}
+
+// archiveRecord copies the record name from dir into archive.
+func archiveRecord(dir, archive, name string) error {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(archive, name), b, 0644)
+}
+
+// acknowledge hides the badge and moves the records to the archive (or
+// deletes them), so that the crash is not shown again after the next boot.
+func (c *pstoreCrash) acknowledge() error {
+	c.mu.Lock()
+	records := c.records
+	c.records = nil
+	c.mu.Unlock()
+	var errs []string
+	for _, r := range records {
+		if c.archive != "" {
+			// the record names are only unique within one boot, so keep
+			// the records of each crash in a separate directory
+			archive := filepath.Join(c.archive, r.time.Format("20060102-150405"))
+			if err := archiveRecord(c.dir, archive, r.name); err != nil {
+				errs = append(errs, err.Error())
+				continue // keep the record rather than lose it
+			}
+		}
+		if err := os.Remove(filepath.Join(c.dir, r.name)); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ServeHTTP shows the crash records on GET and acknowledges them on POST.
+func (c *pstoreCrash) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		c.mu.Lock()
+		records := c.records
+		c.mu.Unlock()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if len(records) == 0 {
+			fmt.Fprintln(w, "no unacknowledged kernel crashes")
+			return
+		}
+		for _, rec := range records {
+			fmt.Fprintf(w, "==> %s (%s, %s)\n", rec.name, rec.reason, rec.time.Format(time.RFC3339))
+			fmt.Fprintln(w, strings.Join(rec.lines, "\n"))
+		}
+	case http.MethodPost:
+		if err := c.acknowledge(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const oopsRecord = `Oops#1 Part1
+<6>[   12.345678] usb 1-1.2: new high-speed USB device number 4 using dwc_otg
+<1>[   98.765432] Unable to handle kernel NULL pointer dereference at virtual address 00000000
+<1>[   98.765440] pgd = 8a1c4000
+<1>[   98.765447] [00000000] *pgd=00000000
+<0>[   98.765455] Internal error: Oops: 5 [#1] SMP ARM
+`
+
+func TestParsePstoreRecord(t *testing.T) {
+	mtime := time.Date(2022, 8, 20, 10, 5, 12, 0, time.UTC)
+	r, err := parsePstoreRecord("dmesg-ramoops-0", []byte(oopsRecord), mtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.reason != "Oops" || r.part != 1 || !r.time.Equal(mtime) {
+		t.Errorf("parsePstoreRecord() = %+v, want reason Oops, part 1, time %v", r, mtime)
+	}
+	want := []string{
+		"Unable to handle kernel NULL pointer dereference at virtual address 00000000",
+		"pgd = 8a1c4000",
+		"[00000000] *pgd=00000000",
+	}
+	if got := r.crashLines(3); !reflect.DeepEqual(got, want) {
+		t.Errorf("crashLines(3) = %q, want %q", got, want)
+	}
+
+	if _, err := parsePstoreRecord("dmesg-ramoops-1", []byte("garbage\n"), mtime); err == nil {
+		t.Errorf("parsePstoreRecord(garbage) succeeded unexpectedly")
+	}
+}
+
+func TestPstoreAcknowledge(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"dmesg-ramoops-0":   oopsRecord,
+		"dmesg-ramoops-1":   "Reboot#1 Part1\n<6>[  100.000000] reboot: Restarting system\n",
+		"console-ramoops-0": "[    0.000000] Booting Linux\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := time.Date(2022, 8, 20, 10, 5, 12, 0, time.Local)
+	if err := os.Chtimes(filepath.Join(dir, "dmesg-ramoops-0"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	records, err := readPstore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "pstore")
+	c := &pstoreCrash{dir: dir, archive: archive, records: records}
+	want := []string{
+		"previous boot crashed (oops, 2022-08-20 10:05:12)",
+		"Unable to handle kernel NULL pointer dereference at virtual address 00000000",
+		"pgd = 8a1c4000",
+		"[00000000] *pgd=00000000",
+		"press a to acknowledge",
+	}
+	if got := c.badgeLines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("badgeLines() = %q, want %q", got, want)
+	}
+
+	if err := c.acknowledge(); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.badgeLines(); got != nil {
+		t.Errorf("badgeLines() after acknowledge = %q, want nil", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "dmesg-ramoops-0")); !os.IsNotExist(err) {
+		t.Errorf("record still in pstore after acknowledge: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(archive, "20220820-100512", "dmesg-ramoops-0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != oopsRecord {
+		t.Errorf("archived record = %q, want %q", b, oopsRecord)
+	}
+	// records which are not crashes are left alone
+	if _, err := os.Stat(filepath.Join(dir, "dmesg-ramoops-1")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,254 @@
+//go:build qemu
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The QEMU integration test boots a kernel (from $FBSTATUS_QEMU_KERNEL, e.g.
+// the vmlinuz of github.com/gokrazy/kernel, which needs virtio-gpu and its
+// fbdev emulation built in) in QEMU with an initramfs containing fbstatus and
+// internal/qemuinit, which captures the framebuffer after fbstatus drew into
+// it. Run it with:
+//
+//	FBSTATUS_QEMU_KERNEL=/path/to/vmlinuz go test -tags qemu -run TestQEMU .
+
+const (
+	qemuWidth  = 1024
+	qemuHeight = 768
+)
+
+// qemuCommand returns the QEMU binary and machine arguments for GOARCH, and
+// the serial console device of the guest.
+func qemuCommand(t *testing.T) (qemu string, args []string, console string) {
+	kvm := func() []string {
+		if f, err := os.OpenFile("/dev/kvm", os.O_RDWR, 0); err == nil {
+			f.Close()
+			return []string{"-enable-kvm", "-cpu", "host"}
+		}
+		return nil
+	}
+	switch runtime.GOARCH {
+	case "amd64":
+		return "qemu-system-x86_64", kvm(), "ttyS0"
+	case "arm64":
+		args := []string{"-machine", "virt"}
+		if kvm := kvm(); kvm != nil {
+			args = append(args, kvm...)
+		} else {
+			args = append(args, "-cpu", "max")
+		}
+		return "qemu-system-aarch64", args, "ttyAMA0"
+	default:
+		t.Skipf("QEMU integration test not supported on %s", runtime.GOARCH)
+		return "", nil, ""
+	}
+}
+
+// cpioEntry is a file of an initramfs.
+type cpioEntry struct {
+	name    string
+	mode    uint32 // including the file type, e.g. 0100755
+	rdev    [2]uint32
+	content []byte
+}
+
+// writeCPIO writes entries as a cpio archive in the “newc” format, which the
+// kernel unpacks as initramfs.
+func writeCPIO(w io.Writer, entries []cpioEntry) error {
+	pad := func(n int) []byte { return make([]byte, (4-n%4)%4) }
+	write := func(ino int, e cpioEntry) error {
+		nlink := 1
+		if e.mode&0170000 == 0040000 {
+			nlink = 2
+		}
+		hdr := fmt.Sprintf("070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			ino, e.mode, 0, 0, nlink, 0, len(e.content), 0, 0, e.rdev[0], e.rdev[1], len(e.name)+1, 0)
+		var buf bytes.Buffer
+		buf.WriteString(hdr)
+		buf.WriteString(e.name)
+		buf.WriteByte(0)
+		buf.Write(pad(len(hdr) + len(e.name) + 1))
+		buf.Write(e.content)
+		buf.Write(pad(len(e.content)))
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	for idx, e := range entries {
+		if err := write(idx+1, e); err != nil {
+			return err
+		}
+	}
+	return write(0, cpioEntry{name: "TRAILER!!!"})
+}
+
+// buildStatic builds pkg into dir and returns the contents of the binary.
+func buildStatic(t *testing.T, dir, pkg string) []byte {
+	out := filepath.Join(dir, filepath.Base(pkg))
+	cmd := exec.Command("go", "build", "-o", out, pkg)
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0")
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("go build %s: %v", pkg, err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// readCapture returns the framebuffer contents which internal/qemuinit
+// prints to the console.
+func readCapture(r io.Reader, log io.Writer) (image.Image, error) {
+	var (
+		encoded strings.Builder
+		inside  bool
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, "fbstatus-qemu: error: "):
+			return nil, fmt.Errorf("guest: %s", strings.TrimPrefix(line, "fbstatus-qemu: error: "))
+		case line == "fbstatus-qemu: begin png":
+			inside = true
+		case line == "fbstatus-qemu: end png":
+			b, err := base64.StdEncoding.DecodeString(encoded.String())
+			if err != nil {
+				return nil, err
+			}
+			return png.Decode(bytes.NewReader(b))
+		case inside:
+			encoded.WriteString(line)
+		default:
+			fmt.Fprintln(log, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("VM exited without capturing the framebuffer")
+}
+
+func TestQEMU(t *testing.T) {
+	kernel := os.Getenv("FBSTATUS_QEMU_KERNEL")
+	if kernel == "" {
+		t.Skip("FBSTATUS_QEMU_KERNEL not set")
+	}
+	qemu, args, console := qemuCommand(t)
+	if _, err := exec.LookPath(qemu); err != nil {
+		t.Skip(err)
+	}
+
+	dir := t.TempDir()
+	var initramfs bytes.Buffer
+	err := writeCPIO(&initramfs, []cpioEntry{
+		{name: "dev", mode: 0040755},
+		// the kernel opens /dev/console for init before devtmpfs is mounted
+		{name: "dev/console", mode: 0020600, rdev: [2]uint32{5, 1}},
+		{name: "proc", mode: 0040755},
+		{name: "sys", mode: 0040755},
+		{name: "tmp", mode: 0041777},
+		{name: "init", mode: 0100755, content: buildStatic(t, dir, "./internal/qemuinit")},
+		{name: "fbstatus", mode: 0100755, content: buildStatic(t, dir, ".")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	initrd := filepath.Join(dir, "initramfs.cpio")
+	if err := os.WriteFile(initrd, initramfs.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, canc := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer canc()
+	args = append(args,
+		"-m", "512",
+		"-nographic",
+		"-no-reboot",
+		"-vga", "none",
+		"-device", fmt.Sprintf("virtio-gpu-pci,xres=%d,yres=%d", qemuWidth, qemuHeight),
+		"-kernel", kernel,
+		"-initrd", initrd,
+		"-append", "console="+console+" panic=-1 -- -wait=15s -- -pages=status")
+	cmd := exec.CommandContext(ctx, qemu, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	var guestLog bytes.Buffer
+	img, err := readCapture(stdout, &guestLog)
+	io.Copy(&guestLog, stdout)
+	if werr := cmd.Wait(); err == nil && werr != nil {
+		err = fmt.Errorf("%s: %v", qemu, werr)
+	}
+	if err != nil {
+		t.Fatalf("%v, console output:\n%s", err, guestLog.String())
+	}
+
+	fn := filepath.Join(os.TempDir(), "fbstatus-qemu.png")
+	if f, err := os.Create(fn); err == nil {
+		png.Encode(f, img)
+		f.Close()
+		t.Logf("framebuffer contents written to %s", fn)
+	}
+	checkRendering(t, img)
+}
+
+// checkRendering asserts that img looks like the status view: mostly the
+// dark gray background, with white text and the (light blue) gopher.
+func checkRendering(t *testing.T, img image.Image) {
+	t.Helper()
+	if got, want := img.Bounds().Size(), image.Pt(qemuWidth, qemuHeight); got != want {
+		t.Errorf("framebuffer size = %v, want %v", got, want)
+	}
+	var background, text, gopher int
+	near := func(v, want uint32) bool { return v+8 >= want && v <= want+8 }
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			r, g, b := uint32(c.R), uint32(c.G), uint32(c.B)
+			switch {
+			// 16 bpp framebuffers store 50 as 48
+			case near(r, 50) && near(g, 50) && near(b, 50):
+				background++
+			case r >= 200 && g >= 200 && b >= 200:
+				text++
+			case b > r+40 && b > 150:
+				gopher++
+			}
+		}
+	}
+	total := img.Bounds().Dx() * img.Bounds().Dy()
+	t.Logf("background: %d, text: %d, gopher: %d of %d pixels", background, text, gopher, total)
+	if background < total*4/10 {
+		t.Errorf("only %d of %d pixels are background, want at least 40%%", background, total)
+	}
+	if text < 1000 {
+		t.Errorf("only %d pixels of text, want at least 1000", text)
+	}
+	if gopher < 1000 {
+		t.Errorf("only %d pixels of the gopher, want at least 1000", gopher)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// mdArray is a Linux software RAID (md) array as listed in /proc/mdstat.
+type mdArray struct {
+	name    string // e.g. md0
+	active  bool
+	level   string   // e.g. raid1
+	devices []string // e.g. sda1[0], sdb1[1](F)
+	want    int      // number of devices the array should have
+	have    int      // number of working devices
+	status  string   // per device, U (up) or _ (down), e.g. UU_
+
+	action   string  // resync, recovery, reshape, check or repair, if any
+	progress float64 // percent
+	finish   string  // estimated remaining time, e.g. 78.1min
+
+	mismatches int64 // from /sys/block/<name>/md/mismatch_cnt, -1 if unknown
+}
+
+func (a mdArray) degraded() bool {
+	return a.have < a.want
+}
+
+// parseMdstat parses the contents of /proc/mdstat, see
+// https://raid.wiki.kernel.org/index.php/Mdstat
+func parseMdstat(b []byte) ([]mdArray, error) {
+	var (
+		arrays []mdArray
+		cur    *mdArray
+	)
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "md") {
+			// md0 : active raid1 sdb1[1] sda1[0]
+			fields := strings.Fields(line)
+			if len(fields) < 3 || fields[1] != ":" {
+				return nil, fmt.Errorf("malformed mdstat line %q", line)
+			}
+			arrays = append(arrays, mdArray{
+				name:       fields[0],
+				active:     fields[2] == "active",
+				mismatches: -1,
+			})
+			cur = &arrays[len(arrays)-1]
+			rest := fields[3:]
+			// skip (read-only) or (auto-read-only)
+			for len(rest) > 0 && strings.HasPrefix(rest[0], "(") {
+				rest = rest[1:]
+			}
+			if cur.active && len(rest) > 0 {
+				cur.level, rest = rest[0], rest[1:]
+			}
+			cur.devices = rest
+			continue
+		}
+		if cur == nil || !strings.HasPrefix(line, " ") {
+			cur = nil
+			continue
+		}
+		fields := strings.Fields(line)
+		for idx, f := range fields {
+			// [2/1] [U_]
+			if strings.HasPrefix(f, "[") && strings.HasSuffix(f, "]") && strings.Contains(f, "/") {
+				if _, err := fmt.Sscanf(f, "[%d/%d]", &cur.want, &cur.have); err != nil {
+					return nil, fmt.Errorf("malformed mdstat device counts %q: %v", f, err)
+				}
+				if idx+1 < len(fields) {
+					cur.status = strings.Trim(fields[idx+1], "[]")
+				}
+			}
+			// recovery = 12.6% (123456/976630272) finish=100.0min
+			if f == "=" && idx > 0 && idx+1 < len(fields) {
+				cur.action = fields[idx-1]
+				pct, err := strconv.ParseFloat(strings.TrimSuffix(fields[idx+1], "%"), 64)
+				if err != nil {
+					return nil, fmt.Errorf("malformed mdstat progress %q: %v", line, err)
+				}
+				cur.progress = pct
+			}
+			if strings.HasPrefix(f, "finish=") {
+				cur.finish = strings.TrimPrefix(f, "finish=")
+			}
+		}
+	}
+	return arrays, scanner.Err()
+}
+
+func readMdArrays() ([]mdArray, error) {
+	b, err := os.ReadFile("/proc/mdstat")
+	if err != nil {
+		return nil, err
+	}
+	arrays, err := parseMdstat(b)
+	if err != nil {
+		return nil, err
+	}
+	for idx, a := range arrays {
+		b, err := os.ReadFile("/sys/block/" + a.name + "/md/mismatch_cnt")
+		if err != nil {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64); err == nil {
+			arrays[idx].mismatches = n
+		}
+	}
+	return arrays, nil
+}
+
+// raidPanel shows the state of all Linux software RAID arrays, e.g. of a
+// gokrazy NAS.
+type raidPanel struct {
+	arrays *poller[[]mdArray]
+}
+
+func newRAIDPanel() (panel, error) {
+	return &raidPanel{
+		arrays: newPoller(5*time.Second, func(context.Context) ([]mdArray, error) {
+			return readMdArrays()
+		}),
+	}, nil
+}
+
+func (p *raidPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "RAID arrays")
+	arrays, updated, err := p.arrays.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	if len(arrays) == 0 {
+		d.drawMessage(dc, y, "no arrays")
+		return nil
+	}
+	em, _ := dc.MeasureString("m")
+	lineHeight := dc.FontHeight() * lineSpacing
+	for _, a := range arrays {
+		state := "$green$clean"
+		switch {
+		case !a.active:
+			state = "$red$inactive"
+		case a.degraded():
+			state = "$red$degraded"
+		}
+		line := fmt.Sprintf("$$%s (%s): %s$$ [%d/%d] %s", a.name, a.level, state, a.want, a.have, a.status)
+		if a.mismatches > 0 {
+			line += fmt.Sprintf(", $yellow$%d mismatches", a.mismatches)
+		}
+		drawMarkup(dc, line, 3*em, y)
+		y += lineHeight
+		d.drawMessage(dc, y, strings.Join(a.devices, " "))
+		y += lineHeight
+		if a.action != "" {
+			color := "yellow"
+			if a.action == "check" || a.action == "resync" {
+				color = "green"
+			}
+			barW := float64(dc.Width()) / 2
+			drawBar(dc, 3*em, y-dc.FontHeight(), barW, dc.FontHeight(), a.progress/100, color)
+			desc := fmt.Sprintf("%s %.1f%%", a.action, a.progress)
+			if a.finish != "" {
+				desc += ", " + a.finish + " left"
+			}
+			dc.DrawString(desc, 4*em+barW, y)
+			y += lineHeight
+		}
+		y += lineHeight / 2
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMdstat(t *testing.T) {
+	const mdstat = `Personalities : [raid1] [raid6] [raid5] [raid4]
+md1 : active raid5 sdc1[3] sdb1[1] sda1[0]
+      1953260544 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+      [==>..................]  recovery = 12.6% (123456/976630272) finish=100.0min speed=100000K/sec
+
+md0 : active (auto-read-only) raid1 sdb2[1] sda2[0]
+      976630464 blocks super 1.2 [2/2] [UU]
+      bitmap: 8/8 pages [32KB], 65536KB chunk
+
+md2 : inactive sdd1[0](S)
+      976630464 blocks super 1.2
+
+unused devices: <none>
+`
+	got, err := parseMdstat([]byte(mdstat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []mdArray{
+		{
+			name:       "md1",
+			active:     true,
+			level:      "raid5",
+			devices:    []string{"sdc1[3]", "sdb1[1]", "sda1[0]"},
+			want:       3,
+			have:       2,
+			status:     "UU_",
+			action:     "recovery",
+			progress:   12.6,
+			finish:     "100.0min",
+			mismatches: -1,
+		},
+		{
+			name:       "md0",
+			active:     true,
+			level:      "raid1",
+			devices:    []string{"sdb2[1]", "sda2[0]"},
+			want:       2,
+			have:       2,
+			status:     "UU",
+			mismatches: -1,
+		},
+		{
+			name:       "md2",
+			devices:    []string{"sdd1[0](S)"},
+			mismatches: -1,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMdstat() =\n%+v\nwant\n%+v", got, want)
+	}
+	if !got[0].degraded() || got[1].degraded() {
+		t.Errorf("degraded() = %v, %v, want true, false", got[0].degraded(), got[1].degraded())
+	}
+}
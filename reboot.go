@@ -0,0 +1,100 @@
+package main
+
+import (
+	"image/draw"
+	"sync"
+	"time"
+)
+
+const (
+	// rebootAnnounceTimeout is how long the reboot screen is shown after a
+	// reboot was announced via /rebooting. If fbstatus is still running by
+	// then, the reboot was most likely aborted.
+	rebootAnnounceTimeout = 5 * time.Minute
+
+	defaultRebootMessage = "Rebooting…"
+)
+
+// rebootNotice retains a reboot announced via HTTP (or MQTT) until it
+// expires.
+type rebootNotice struct {
+	mu      sync.Mutex
+	message string
+	expires time.Time
+}
+
+// announce shows message until now+rebootAnnounceTimeout. An empty message
+// cancels the announcement.
+func (n *rebootNotice) announce(message string, now time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.message = message
+	n.expires = now.Add(rebootAnnounceTimeout)
+}
+
+// active returns the message of the announced reboot, or the empty string if
+// none was announced or the announcement expired.
+func (n *rebootNotice) active(now time.Time) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if now.After(n.expires) {
+		return ""
+	}
+	return n.message
+}
+
+// announceReboot implements the /rebooting endpoint: "off" cancels the
+// announcement, any other value is shown as the message of the reboot
+// screen (the default message if empty).
+func (d *statusDrawer) announceReboot(message string) error {
+	switch message {
+	case "off":
+		message = ""
+	case "":
+		message = defaultRebootMessage
+	}
+	d.reboot.announce(message, time.Now())
+	d.control.notify()
+	return nil
+}
+
+// rebootMessage returns the message of the reboot screen if a reboot is
+// imminent: it was announced, or gokrazy switched to a newly installed
+// update, in which case it reboots right after stopping all services.
+func (d *statusDrawer) rebootMessage() string {
+	if msg := d.reboot.active(time.Now()); msg != "" {
+		return msg
+	}
+	if status, ok := d.updateInProgress(); ok && status.phase == updateSwitched {
+		return "Rebooting for update…"
+	}
+	return ""
+}
+
+// drawRebootScreen draws the reboot screen into the buffer, so that the
+// display going dark during the reboot does not alarm anyone watching it.
+func (d *statusDrawer) drawRebootScreen(message string) {
+	em := 16 * d.scaleFactor
+	dc := d.overlayContext(d.bounds)
+	dc.SetColor(d.bgcolor)
+	dc.Clear()
+
+	dc.SetFontFace(d.faces.huge)
+	dc.SetRGB(1, 1, 1)
+	width := float64(d.w) - 4*em
+	dc.DrawStringAnchored(fitString(dc, message, width), float64(d.w)/2, float64(d.h)/2, 0.5, 0)
+
+	dc.SetFontFace(d.faces.medium)
+	dc.SetRGB(0.7, 0.7, 0.7)
+	dc.DrawStringAnchored(fitString(dc, d.hostname+" will be back shortly", width), float64(d.w)/2, float64(d.h)/2+3*em, 0.5, 0)
+	d.drawOverlay(dc, d.bounds, draw.Src)
+}
+
+// showRebootScreen draws the reboot screen to the framebuffer, e.g. when
+// fbstatus is stopped for a reboot.
+func (d *statusDrawer) showRebootScreen(message string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drawRebootScreen(message)
+	return d.copyBuffer()
+}
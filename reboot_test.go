@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"image"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebootNotice(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	var n rebootNotice
+	if got := n.active(start); got != "" {
+		t.Errorf("active() before announcing = %q, want empty", got)
+	}
+	n.announce("Rebooting…", start)
+	if got, want := n.active(start.Add(time.Minute)), "Rebooting…"; got != want {
+		t.Errorf("active() = %q, want %q", got, want)
+	}
+	if got := n.active(start.Add(rebootAnnounceTimeout + time.Second)); got != "" {
+		t.Errorf("active() after the timeout = %q, want empty", got)
+	}
+	n.announce("", start)
+	if got := n.active(start.Add(time.Minute)); got != "" {
+		t.Errorf("active() after canceling = %q, want empty", got)
+	}
+}
+
+func TestRebootScreen(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.draw1(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	status := image.NewRGBA(img.Rect)
+	copy(status.Pix, img.Pix)
+
+	defer func(password string) { httpPassword = password }(httpPassword)
+	httpPassword = "secret"
+	handler := d.httpHandler()
+	post := func(message string) {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/rebooting", strings.NewReader(url.Values{"message": {message}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("gokrazy", "secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != 200 {
+			t.Fatalf("POST /rebooting: status %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	post("")
+	if got, want := d.rebootMessage(), defaultRebootMessage; got != want {
+		t.Errorf("rebootMessage() = %q, want %q", got, want)
+	}
+	if err := d.draw1(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if dirty, _ := dirtyRect(status, img); dirty.Dx() < img.Rect.Dx()/2 {
+		t.Errorf("reboot screen only changed %v of the display", dirty)
+	}
+
+	post("off")
+	if got := d.rebootMessage(); got != "" {
+		t.Errorf("rebootMessage() after canceling = %q, want empty", got)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+const (
+	// retryMin is the delay before retrying a data source (or panel) after
+	// its first failure. The delay doubles with every consecutive failure.
+	retryMin = time.Second
+
+	// retryMax bounds the delay between retries.
+	retryMax = time.Minute
+)
+
+// retryDelay returns how long to wait after the given number of consecutive
+// failures (at least 1) before retrying, at most max.
+func retryDelay(failures int, max time.Duration) time.Duration {
+	delay := retryMin
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// backoff tracks the consecutive failures of a data source or panel, so that
+// it is retried with exponentially increasing delays instead of failing (or
+// hammering the source) on every frame.
+type backoff struct {
+	failures int
+	err      error // most recent error
+	retry    time.Time
+}
+
+// fail records that err occurred at now.
+func (b *backoff) fail(err error, now time.Time) {
+	b.failures++
+	b.err = err
+	b.retry = now.Add(retryDelay(b.failures, retryMax))
+}
+
+// succeed records a success, resetting the backoff.
+func (b *backoff) succeed() {
+	*b = backoff{}
+}
+
+// due reports whether the source should be tried (again) at now.
+func (b *backoff) due(now time.Time) bool {
+	return b.failures == 0 || !now.Before(b.retry)
+}
+
+// drawPanel calls the draw method of p, turning panics (e.g. on unexpected
+// data) into errors, so that one broken panel does not take down fbstatus.
+func drawPanel(d *statusDrawer, p panel, dc *gg.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("panic drawing panel", "panel", fmt.Sprintf("%T", p), "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return p.draw(d, dc)
+}
+
+// drawPanelError draws a banner with the error of the failing panel at the
+// bottom of dc, over what the panel drew before it failed.
+func (d *statusDrawer) drawPanelError(dc *gg.Context, b *backoff, now time.Time) {
+	em, _ := dc.MeasureString("m")
+	lineHeight := dc.FontHeight() * lineSpacing
+	w, h := float64(dc.Width()), float64(dc.Height())
+	y := h - 2*lineHeight
+	setColor(dc, "red")
+	dc.DrawRectangle(0, y, w, 2*lineHeight)
+	dc.Fill()
+	msg := "error: " + b.err.Error()
+	if left := b.retry.Sub(now); left > 0 {
+		msg += fmt.Sprintf(" (retrying in %v)", left.Round(time.Second))
+	}
+	dc.SetRGB(1, 1, 1)
+	dc.DrawString(fitString(dc, msg, w-6*em), 3*em, y+lineHeight+dc.FontHeight()/3)
+}
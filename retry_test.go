@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+func TestRetryDelay(t *testing.T) {
+	for _, tt := range []struct {
+		failures int
+		max      time.Duration
+		want     time.Duration
+	}{
+		{1, time.Minute, time.Second},
+		{2, time.Minute, 2 * time.Second},
+		{4, time.Minute, 8 * time.Second},
+		{7, time.Minute, time.Minute},
+		{100, time.Minute, time.Minute},
+		{3, 3 * time.Second, 3 * time.Second},
+	} {
+		if got := retryDelay(tt.failures, tt.max); got != tt.want {
+			t.Errorf("retryDelay(%d, %v) = %v, want %v", tt.failures, tt.max, got, tt.want)
+		}
+	}
+}
+
+type failingPanel struct {
+	calls int
+	fail  func() error
+}
+
+func (p *failingPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	p.calls++
+	d.drawTitle(dc, "Failing")
+	return p.fail()
+}
+
+func TestDrawPanelsIsolation(t *testing.T) {
+	d, err := newStatusDrawer(image.NewRGBA(image.Rect(0, 0, 800, 600)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := errors.New("connection refused")
+	failing := &failingPanel{fail: func() error { return broken }}
+	panicking := &failingPanel{fail: func() error { panic("index out of range") }}
+	working := &failingPanel{fail: func() error { return nil }}
+	pg := &page{
+		name:   "failing+panicking+working",
+		panels: []panel{failing, panicking, working},
+	}
+	d.drawPanels(pg)
+	if got, want := pg.status[0].Error, broken.Error(); got != want {
+		t.Errorf("error of the failing panel = %q, want %q", got, want)
+	}
+	if got, want := pg.status[1].Error, "panic: index out of range"; got != want {
+		t.Errorf("error of the panicking panel = %q, want %q", got, want)
+	}
+	if got := pg.status[2].Error; got != "" {
+		t.Errorf("error of the working panel = %q, want none", got)
+	}
+
+	// Within the backoff, failing panels are not drawn again, but keep
+	// showing their content and error.
+	d.drawPanels(pg)
+	if failing.calls != 1 || working.calls != 2 {
+		t.Errorf("panels drawn %d and %d times, want 1 and 2", failing.calls, working.calls)
+	}
+	if got, want := pg.status[0].Title, "Failing"; got != want {
+		t.Errorf("title of the failing panel = %q, want %q", got, want)
+	}
+
+	// Once the retry is due and succeeds, the error is gone.
+	pg.health[0].retry = time.Now().Add(-time.Second)
+	failing.fail = func() error { return nil }
+	d.drawPanels(pg)
+	if got := pg.status[0].Error; got != "" || pg.health[0].failures != 0 {
+		t.Errorf("error after recovery = %q (%d failures), want none", got, pg.health[0].failures)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"math"
+)
+
+var scaleFlag = flag.Float64("scale",
+	0,
+	"if non-zero, scale factor for text and layout (e.g. 1.5 for text 1.5 times as large as on a 1024 pixel wide display of typical pixel density), overriding the automatic choice based on the physical size of the display")
+
+const (
+	// referenceDPI is the pixel density for which fbstatus' layout was
+	// designed (scale factor 1), that of a typical desktop monitor.
+	referenceDPI = 96
+
+	// minLogicalWidth is the width (in pixels at scale factor 1) which
+	// fbstatus' layout needs at least (e.g. for the resource usage table),
+	// which limits the scale factor for small high-resolution displays.
+	minLogicalWidth = 1024
+
+	// Displays often report bogus physical sizes (e.g. the aspect ratio in
+	// centimeters), so pixel densities outside of this range are ignored.
+	minPlausibleDPI = 40
+	maxPlausibleDPI = 600
+)
+
+// displayScale returns the scale factor for text and layout on a display w
+// pixels wide, which is physically widthMM millimeters wide (0 if unknown),
+// so that text has about the same physical size on all displays.
+func displayScale(w int, widthMM uint32) float64 {
+	if *scaleFlag > 0 {
+		return *scaleFlag
+	}
+	fallback := math.Max(1, math.Floor(float64(w)/1024))
+	if widthMM == 0 {
+		return fallback
+	}
+	dpi := float64(w) / (float64(widthMM) / 25.4)
+	if dpi < minPlausibleDPI || dpi > maxPlausibleDPI {
+		return fallback
+	}
+	scale := math.Min(dpi/referenceDPI, float64(w)/minLogicalWidth)
+	return math.Max(1, scale)
+}
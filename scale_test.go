@@ -0,0 +1,38 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDisplayScale(t *testing.T) {
+	for _, tt := range []struct {
+		desc    string
+		w       int
+		widthMM uint32
+		want    float64
+	}{
+		{"unknown size", 1920, 0, 1},
+		{"unknown size, 4K", 3840, 0, 3},
+		{"24 inch full HD monitor", 1920, 531, 1},
+		{"27 inch 4K monitor", 3840, 597, 1.70},
+		{"55 inch 4K TV", 3840, 1210, 1},
+		{"5.5 inch 1080p panel", 1080, 68, 1.05}, // limited by the layout
+		{"7 inch Raspberry Pi display", 800, 155, 1},
+		{"13 inch 2560x1600 laptop", 2560, 286, 2.37},
+		{"bogus size (aspect ratio)", 3840, 16, 3},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := displayScale(tt.w, tt.widthMM)
+			if math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("displayScale(%d, %d) = %.2f, want %.2f", tt.w, tt.widthMM, got, tt.want)
+			}
+		})
+	}
+
+	defer func(scale float64) { *scaleFlag = scale }(*scaleFlag)
+	*scaleFlag = 2.5
+	if got := displayScale(1920, 531); got != 2.5 {
+		t.Errorf("displayScale with -scale=2.5 = %v, want 2.5", got)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var displayScheduleFlag = flag.String("display-schedule",
+	"",
+	"if non-empty, semicolon-separated weekly schedule of when the display is on, e.g. mon-fri 08:00-18:00; sat,sun 10:00-14:00 (local time, days as mon, tue, …, ranges like mon-fri or daily; a time range may span midnight). Outside of it, the display is blanked and nothing is rendered, until input wakes it up or /blank turns it on")
+
+// scheduleRule is one entry of -display-schedule.
+type scheduleRule struct {
+	days       [7]bool       // by time.Weekday
+	start, end time.Duration // since midnight, see parseTimeRange
+}
+
+// displaySchedule is a weekly schedule of when the display is on.
+type displaySchedule struct {
+	rules []scheduleRule
+}
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+func parseWeekday(s string) (int, error) {
+	for idx, day := range weekdays {
+		if strings.EqualFold(s, day) {
+			return idx, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid day %q, expected one of %s", s, strings.Join(weekdays, ", "))
+}
+
+// parseDays parses a comma-separated list of days and day ranges (e.g.
+// mon-fri,sun), or daily.
+func parseDays(spec string) ([7]bool, error) {
+	var days [7]bool
+	if spec == "daily" {
+		for idx := range days {
+			days[idx] = true
+		}
+		return days, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := parseWeekday(from)
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if isRange {
+			if last, err = parseWeekday(to); err != nil {
+				return days, err
+			}
+		}
+		// ranges may wrap around, e.g. fri-mon
+		for day := first; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseDisplaySchedule(spec string) (*displaySchedule, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	var s displaySchedule
+	for _, entry := range strings.Split(spec, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("-display-schedule: malformed entry %q: expected e.g. mon-fri 08:00-18:00", strings.TrimSpace(entry))
+		}
+		days, err := parseDays(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("-display-schedule: %v", err)
+		}
+		for _, rng := range strings.Split(fields[1], ",") {
+			start, end, err := parseTimeRange(rng)
+			if err != nil {
+				return nil, fmt.Errorf("-display-schedule: %v", err)
+			}
+			s.rules = append(s.rules, scheduleRule{days: days, start: start, end: end})
+		}
+	}
+	if len(s.rules) == 0 {
+		return nil, fmt.Errorf("-display-schedule: no entries in %q", spec)
+	}
+	return &s, nil
+}
+
+// on reports whether the display is scheduled to be on at t. A time range
+// which spans midnight belongs to the day on which it starts.
+func (s *displaySchedule) on(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	since := t.Sub(midnight)
+	today := int(t.Weekday())
+	yesterday := (today + 6) % 7
+	for _, r := range s.rules {
+		if r.start < r.end {
+			if r.days[today] && since >= r.start && since < r.end {
+				return true
+			}
+			continue
+		}
+		if (r.days[today] && since >= r.start) || (r.days[yesterday] && since < r.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextOn returns when the display is next scheduled to be on after t, or the
+// zero time if never.
+func (s *displaySchedule) nextOn(t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	for limit := t.AddDate(0, 0, 8); t.Before(limit); t = t.Add(time.Minute) {
+		if s.on(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
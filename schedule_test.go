@@ -0,0 +1,118 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDisplaySchedule(t *testing.T) {
+	for _, spec := range []string{
+		"mon-fri",
+		"mon-fri 08:00",
+		"someday 08:00-18:00",
+		"mon-someday 08:00-18:00",
+		"mon-fri 08:00-18:00 extra",
+		";",
+	} {
+		if _, err := parseDisplaySchedule(spec); err == nil {
+			t.Errorf("parseDisplaySchedule(%q) succeeded unexpectedly", spec)
+		}
+	}
+	if s, err := parseDisplaySchedule(""); err != nil || s != nil {
+		t.Errorf("parseDisplaySchedule(\"\") = %v, %v, want nil, nil", s, err)
+	}
+}
+
+func TestDisplayScheduleOn(t *testing.T) {
+	s, err := parseDisplaySchedule("mon-fri 08:00-12:00,13:00-18:00; sat,sun 10:00-14:00; fri-sat 22:00-02:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2022-01-03 is a Monday
+	at := func(day int, hhmm string) time.Time {
+		tm, err := time.Parse("15:04", hhmm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2022, 1, 2+day, tm.Hour(), tm.Minute(), 0, 0, time.UTC)
+	}
+	for _, tt := range []struct {
+		day  int // 0 is Sunday
+		time string
+		want bool
+	}{
+		{1, "07:59", false},
+		{1, "08:00", true},
+		{1, "12:30", false},
+		{3, "17:59", true},
+		{3, "18:00", false},
+		{5, "23:00", true},  // Friday night
+		{6, "01:00", true},  // after midnight, still Friday night
+		{6, "09:00", false}, // Saturday
+		{6, "11:00", true},
+		{7, "01:00", true}, // after midnight, Saturday night
+		{7, "02:00", false},
+		{0, "11:00", true},  // Sunday
+		{1, "01:00", false}, // Sunday night is not in the schedule
+	} {
+		tm := at(tt.day, tt.time)
+		if got := s.on(tm); got != tt.want {
+			t.Errorf("on(%s) = %v, want %v", tm.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+
+	if got, want := s.nextOn(at(1, "12:30")), at(1, "13:00"); !got.Equal(want) {
+		t.Errorf("nextOn(Mon 12:30) = %v, want %v", got, want)
+	}
+	if got, want := s.nextOn(at(7, "03:00")), at(7, "10:00"); !got.Equal(want) {
+		t.Errorf("nextOn(Sun 03:00) = %v, want %v", got, want)
+	}
+}
+
+func TestDisplayScheduleBlanking(t *testing.T) {
+	defer func(d time.Duration) { *blankAfter = d }(*blankAfter)
+	*blankAfter = 0
+	c := newDisplayControl()
+	c.schedule = &displaySchedule{} // never on
+	c.lastInput = time.Now().Add(-defaultWakeDuration)
+	if !c.isBlanked() {
+		t.Errorf("display not blanked outside of -display-schedule")
+	}
+
+	// input wakes up the display for defaultWakeDuration
+	if !c.wake() || c.isBlanked() {
+		t.Errorf("input did not wake up the display")
+	}
+	c.lastInput = time.Now().Add(-defaultWakeDuration)
+	if !c.isBlanked() {
+		t.Errorf("display not blanked again after the wake duration")
+	}
+
+	// /blank off overrides the schedule
+	c.mu.Lock()
+	c.setBlankedLocked(false)
+	c.mu.Unlock()
+	c.lastInput = time.Now().Add(-defaultWakeDuration)
+	if c.isBlanked() {
+		t.Errorf("display blanked despite /blank off")
+	}
+	c.mu.Lock()
+	c.setBlankedLocked(true)
+	c.mu.Unlock()
+	if !c.isBlanked() {
+		t.Errorf("display not blanked after /blank on")
+	}
+	c.mu.Lock()
+	c.setBlankedLocked(false)
+	c.mu.Unlock()
+	if c.isBlanked() {
+		t.Errorf("display blanked after /blank off")
+	}
+
+	// the override ends when the display is scheduled to be on
+	c.overridden = time.Now().Add(-time.Second)
+	c.lastInput = time.Now().Add(-defaultWakeDuration)
+	if !c.isBlanked() {
+		t.Errorf("display not blanked after the override ended")
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+
+	"github.com/gokrazy/fbstatus/internal/fb"
+)
+
+// writeScreenshot encodes the contents of img (e.g. the framebuffer) as PNG.
+// img is copied first, so that the screenshot is consistent even if another
+// program draws into img meanwhile.
+func writeScreenshot(w io.Writer, img image.Image) error {
+	rgba := image.NewRGBA(img.Bounds().Sub(img.Bounds().Min))
+	draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+	return png.Encode(w, rgba)
+}
+
+// screenshot implements the screenshot subcommand, which saves what is
+// currently displayed on the framebuffer (by fbstatus or any other program)
+// as PNG, without writing to the framebuffer.
+func screenshot(args []string) error {
+	fset := flag.NewFlagSet("screenshot", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "usage: fbstatus screenshot [screenshot flags] [file.png]\n\n")
+		fmt.Fprintf(fset.Output(), "Saves the framebuffer contents as PNG to file.png, or to stdout if omitted.\n\n")
+		fset.PrintDefaults()
+	}
+	device := fset.String("device",
+		"/dev/fb0",
+		"framebuffer device to capture")
+	fset.Parse(args)
+	if fset.NArg() > 1 {
+		fset.Usage()
+		os.Exit(2)
+	}
+
+	dev, err := fb.OpenReadOnly(*device)
+	if err != nil {
+		return err
+	}
+	defer dev.Close()
+	img, err := dev.Image()
+	if err != nil {
+		return err
+	}
+
+	if fset.NArg() == 0 || fset.Arg(0) == "-" {
+		return writeScreenshot(os.Stdout, img)
+	}
+	f, err := os.Create(fset.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := writeScreenshot(f, img); err != nil {
+		return err
+	}
+	return f.Close()
+}
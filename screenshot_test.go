@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/gokrazy/fbstatus/internal/fbimage"
+)
+
+func TestWriteScreenshot(t *testing.T) {
+	// a framebuffer whose visible part starts at an offset, with padding at
+	// the end of each line
+	r := image.Rect(0, 2, 4, 5)
+	fbimg := &fbimage.BGR565{Pix: make([]byte, 12*5), Rect: r, Stride: 12}
+	fbimg.Set(0, 2, color.RGBA{R: 0xf8, A: 0xff})
+	fbimg.Set(3, 4, color.RGBA{B: 0xf8, A: 0xff})
+
+	var buf bytes.Buffer
+	if err := writeScreenshot(&buf, fbimg); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 4, 3); got != want {
+		t.Errorf("bounds = %v, want %v", got, want)
+	}
+	for _, tt := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{0, 0, color.RGBA{R: 0xf8, A: 0xff}},
+		{3, 2, color.RGBA{B: 0xf8, A: 0xff}},
+		{1, 1, color.RGBA{A: 0xff}},
+	} {
+		if got := color.RGBAModel.Convert(img.At(tt.x, tt.y)); got != tt.want {
+			t.Errorf("pixel %d,%d = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gokrazy/internal/fat"
+	"golang.org/x/sys/unix"
+)
+
+var updateOverlay = flag.Bool("update-overlay",
+	true,
+	"while gokrazy installs an update (e.g. via gok update), show its progress and that the device must not be powered off, waking up the display if blanked")
+
+const (
+	// selfUpdateIdle is how long gokrazy may not write to the partitions it
+	// updates before fbstatus considers an update finished or aborted.
+	selfUpdateIdle = 30 * time.Second
+
+	// rebootDelay is roughly how long gokrazy takes to reboot after
+	// switching to the new root partition: it waits up to 15 seconds for
+	// all services to stop.
+	rebootDelay = 16 * time.Second
+)
+
+type updatePhase int
+
+const (
+	updateIdle updatePhase = iota
+	updateWritingRoot
+	updateWritingBoot
+	updateSwitched
+)
+
+// updateSample is what fbstatus observes of an update at one point in time.
+type updateSample struct {
+	rootWritten uint64 // bytes written to the inactive root partition
+	bootWritten uint64 // bytes written to the boot partition
+
+	// switched is true if the boot partition selects the inactive root
+	// partition for the next boot.
+	switched bool
+}
+
+// updateStatus describes the update in progress, if any.
+type updateStatus struct {
+	phase    updatePhase
+	written  uint64    // bytes of the new root file system written so far
+	estimate uint64    // approximate size of the new root file system, or 0
+	switched time.Time // when gokrazy switched to the new root partition
+}
+
+// updateTracker derives the update status from consecutive samples. gokrazy
+// has no API to query the progress of an update, but an update writes the
+// inactive root partition, then the boot partition and finally switches the
+// root partition in the boot partition's cmdline.txt before rebooting.
+type updateTracker struct {
+	estimate  uint64
+	primed    bool // whether last is valid
+	last      updateSample
+	base      updateSample // when the update started
+	lastWrite time.Time
+	status    updateStatus
+}
+
+func (t *updateTracker) update(s updateSample, now time.Time) updateStatus {
+	if !t.primed {
+		t.last, t.primed = s, true
+	}
+	if t.status.phase == updateIdle {
+		t.base = t.last
+	}
+	phase := updateIdle
+	switch {
+	case s.switched && !t.last.switched:
+		phase = updateSwitched
+	case s.bootWritten != t.last.bootWritten:
+		phase = updateWritingBoot
+	case s.rootWritten != t.last.rootWritten:
+		phase = updateWritingRoot
+	}
+	t.last = s
+	if phase != updateIdle {
+		t.lastWrite = now
+	}
+	switch {
+	case phase > t.status.phase:
+		// Phases only advance: the kernel might write back data of the
+		// root partition while the boot partition is already written.
+		t.status.phase = phase
+		if phase == updateSwitched {
+			t.status.switched = now
+		}
+	case phase == updateIdle && t.status.phase != updateIdle && now.Sub(t.lastWrite) >= selfUpdateIdle:
+		t.status = updateStatus{}
+	}
+	if t.status.phase != updateIdle {
+		t.status.written = s.rootWritten - t.base.rootWritten
+		t.status.estimate = t.estimate
+	}
+	return t.status
+}
+
+// rootPartitions returns the block device names (e.g. mmcblk0p2) of the
+// active and inactive root partition and of the boot partition, following
+// gokrazy's partition layout.
+func rootPartitions() (active, inactive, boot string, _ error) {
+	var st unix.Stat_t
+	if err := unix.Stat("/", &st); err != nil {
+		return "", "", "", err
+	}
+	dev, err := filepath.EvalSymlinks(fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(st.Dev), unix.Minor(st.Dev)))
+	if err != nil {
+		return "", "", "", err
+	}
+	byNumber := make(map[int]string)
+	siblings, _ := filepath.Glob(filepath.Join(filepath.Dir(dev), "*", "partition"))
+	for _, fn := range siblings {
+		b, err := os.ReadFile(fn)
+		if err != nil {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(string(b)))
+		if err != nil {
+			continue
+		}
+		byNumber[n] = filepath.Base(filepath.Dir(fn))
+	}
+	active = filepath.Base(dev)
+	switch active {
+	case byNumber[2]:
+		inactive = byNumber[3]
+	case byNumber[3]:
+		inactive = byNumber[2]
+	default:
+		return "", "", "", fmt.Errorf("root file system %s is not on partition 2 or 3", active)
+	}
+	if inactive == "" || byNumber[1] == "" {
+		return "", "", "", fmt.Errorf("partitions of %s not found", filepath.Dir(dev))
+	}
+	return active, inactive, byNumber[1], nil
+}
+
+// bytesWritten returns how many bytes were written to the block device name
+// (e.g. mmcblk0p3) since boot, see Documentation/block/stat.rst.
+func bytesWritten(name string) (uint64, error) {
+	b, err := os.ReadFile("/sys/class/block/" + name + "/stat")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) < 7 {
+		return 0, fmt.Errorf("/sys/class/block/%s/stat: unexpected format", name)
+	}
+	sectors, err := strconv.ParseUint(fields[6], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return sectors * 512, nil
+}
+
+// cmdlineRoot returns the root= parameter of a kernel command line.
+func cmdlineRoot(cmdline string) string {
+	for _, param := range strings.Fields(cmdline) {
+		if strings.HasPrefix(param, "root=") {
+			return strings.TrimPrefix(param, "root=")
+		}
+	}
+	return ""
+}
+
+// bootCmdlineRoot returns the root= parameter of the cmdline.txt on the boot
+// partition dev, i.e. the root partition of the next boot.
+func bootCmdlineRoot(dev string) (string, error) {
+	f, err := os.Open(dev)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	rd, err := fat.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	offset, length, err := rd.Extents("/cmdline.txt")
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, length)
+	if _, err := f.ReadAt(b, offset); err != nil {
+		return "", err
+	}
+	return cmdlineRoot(string(b)), nil
+}
+
+// squashfsSize returns the size of the SquashFS file system in r, which is
+// what gokrazy uses for its root file system.
+func squashfsSize(r io.ReaderAt) (uint64, error) {
+	sb := make([]byte, 48)
+	if _, err := r.ReadAt(sb, 0); err != nil {
+		return 0, err
+	}
+	if magic := binary.LittleEndian.Uint32(sb); magic != 0x73717368 {
+		return 0, fmt.Errorf("not a SquashFS file system (magic %#x)", magic)
+	}
+	return binary.LittleEndian.Uint64(sb[40:]), nil
+}
+
+// newUpdatePoller returns a poller for the status of a gokrazy update, which
+// is only possible on gokrazy's partition layout.
+func newUpdatePoller() (*poller[updateStatus], error) {
+	active, inactive, boot, err := rootPartitions()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile("/proc/cmdline")
+	if err != nil {
+		return nil, err
+	}
+	running := cmdlineRoot(string(b))
+	tracker := &updateTracker{}
+	// The new root file system is most likely about as large as the
+	// running one.
+	if f, err := os.Open("/dev/" + active); err == nil {
+		tracker.estimate, _ = squashfsSize(f)
+		f.Close()
+	}
+	var (
+		lastBoot uint64
+		switched bool
+	)
+	return newPoller(time.Second, func(ctx context.Context) (updateStatus, error) {
+		var s updateSample
+		var err error
+		if s.rootWritten, err = bytesWritten(inactive); err != nil {
+			return updateStatus{}, err
+		}
+		if s.bootWritten, err = bytesWritten(boot); err != nil {
+			return updateStatus{}, err
+		}
+		if s.bootWritten != lastBoot {
+			// Only read cmdline.txt when it might have changed.
+			lastBoot = s.bootWritten
+			if next, err := bootCmdlineRoot("/dev/" + boot); err == nil {
+				switched = next != "" && next != running
+			}
+		}
+		s.switched = switched
+		return tracker.update(s, time.Now()), nil
+	}), nil
+}
+
+// updateInProgress returns the status of the update in progress, if any.
+func (d *statusDrawer) updateInProgress() (updateStatus, bool) {
+	if d.update == nil {
+		return updateStatus{}, false
+	}
+	status, _, _ := d.update.get()
+	return status, status.phase != updateIdle
+}
+
+// drawUpdateOverlay draws the progress of an update over the center of the
+// display, so that users do not power off the device in the middle of it.
+func (d *statusDrawer) drawUpdateOverlay(status updateStatus) {
+	em := 16 * d.scaleFactor
+	h := int(11 * em)
+	r := image.Rect(d.w/10, (d.h-h)/2, d.w*9/10, (d.h+h)/2)
+	dc := d.overlayContext(r)
+	dc.SetRGBA(0, 0, 0, 0.9)
+	dc.DrawRoundedRectangle(0, 0, float64(r.Dx()), float64(r.Dy()), em)
+	dc.Fill()
+	width := float64(r.Dx()) - 4*em
+
+	title := "Updating, do not power off!"
+	progress := 1.0
+	var msg string
+	switch status.phase {
+	case updateWritingRoot:
+		msg = "writing the new root file system: " + formatBytes(status.written)
+		progress = 0
+		if status.estimate > 0 {
+			msg += " of about " + formatBytes(status.estimate)
+			progress = float64(status.written) / float64(status.estimate)
+			if progress > 0.99 {
+				progress = 0.99
+			}
+		}
+	case updateWritingBoot:
+		msg = "writing the boot partition"
+	case updateSwitched:
+		title = "Update installed, do not power off!"
+		msg = "rebooting into the new image…"
+		if left := rebootDelay - time.Since(status.switched); left > 0 {
+			msg = fmt.Sprintf("rebooting into the new image in %v", left.Round(time.Second))
+		}
+	}
+
+	dc.SetFontFace(d.faces.large)
+	setColor(dc, "yellow")
+	y := 3.5 * em
+	dc.DrawStringAnchored(fitString(dc, title, width), float64(r.Dx())/2, y, 0.5, 0)
+
+	dc.SetFontFace(d.faces.text)
+	dc.SetRGB(1, 1, 1)
+	y += 3 * em
+	dc.DrawStringAnchored(fitString(dc, msg, width), float64(r.Dx())/2, y, 0.5, 0)
+
+	y += 1.5 * em
+	dc.SetRGBA(1, 1, 1, 0.2)
+	dc.DrawRoundedRectangle(2*em, y, width, em, em/2)
+	dc.Fill()
+	if progress > 0 {
+		setColor(dc, "green")
+		dc.DrawRoundedRectangle(2*em, y, width*progress, em, em/2)
+		dc.Fill()
+	}
+	d.drawOverlay(dc, r, draw.Over)
+}
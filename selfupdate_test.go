@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestUpdateTracker(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	const mib = 1024 * 1024
+	tr := &updateTracker{estimate: 50 * mib}
+	for _, step := range []struct {
+		desc   string
+		at     time.Duration
+		sample updateSample
+		want   updateStatus
+	}{
+		{
+			desc:   "initial",
+			sample: updateSample{rootWritten: 3 * mib, bootWritten: 1 * mib},
+			want:   updateStatus{},
+		},
+		{
+			desc:   "unchanged",
+			at:     time.Second,
+			sample: updateSample{rootWritten: 3 * mib, bootWritten: 1 * mib},
+			want:   updateStatus{},
+		},
+		{
+			desc:   "writing root",
+			at:     2 * time.Second,
+			sample: updateSample{rootWritten: 13 * mib, bootWritten: 1 * mib},
+			want:   updateStatus{phase: updateWritingRoot, written: 10 * mib, estimate: 50 * mib},
+		},
+		{
+			desc:   "still writing root",
+			at:     5 * time.Second,
+			sample: updateSample{rootWritten: 43 * mib, bootWritten: 1 * mib},
+			want:   updateStatus{phase: updateWritingRoot, written: 40 * mib, estimate: 50 * mib},
+		},
+		{
+			desc:   "writing boot",
+			at:     6 * time.Second,
+			sample: updateSample{rootWritten: 45 * mib, bootWritten: 30 * mib},
+			want:   updateStatus{phase: updateWritingBoot, written: 42 * mib, estimate: 50 * mib},
+		},
+		{
+			desc:   "writeback of root",
+			at:     7 * time.Second,
+			sample: updateSample{rootWritten: 46 * mib, bootWritten: 30 * mib},
+			want:   updateStatus{phase: updateWritingBoot, written: 43 * mib, estimate: 50 * mib},
+		},
+		{
+			desc:   "switched",
+			at:     8 * time.Second,
+			sample: updateSample{rootWritten: 46 * mib, bootWritten: 31 * mib, switched: true},
+			want:   updateStatus{phase: updateSwitched, written: 43 * mib, estimate: 50 * mib, switched: start.Add(8 * time.Second)},
+		},
+		{
+			desc:   "no reboot",
+			at:     38 * time.Second,
+			sample: updateSample{rootWritten: 46 * mib, bootWritten: 31 * mib, switched: true},
+			want:   updateStatus{},
+		},
+		{
+			desc:   "next update",
+			at:     40 * time.Second,
+			sample: updateSample{rootWritten: 47 * mib, bootWritten: 31 * mib, switched: true},
+			want:   updateStatus{phase: updateWritingRoot, written: 1 * mib, estimate: 50 * mib},
+		},
+	} {
+		got := tr.update(step.sample, start.Add(step.at))
+		if got != step.want {
+			t.Errorf("%s: update() = %+v, want %+v", step.desc, got, step.want)
+		}
+	}
+}
+
+func TestCmdlineRoot(t *testing.T) {
+	const cmdline = "console=tty1 root=PARTUUID=2eec4b72-03 rootfstype=squashfs rootwait init=/gokrazy/init\n"
+	if got, want := cmdlineRoot(cmdline), "PARTUUID=2eec4b72-03"; got != want {
+		t.Errorf("cmdlineRoot() = %q, want %q", got, want)
+	}
+	if got := cmdlineRoot("console=tty1"); got != "" {
+		t.Errorf("cmdlineRoot() = %q, want empty", got)
+	}
+}
+
+func TestSquashfsSize(t *testing.T) {
+	sb := make([]byte, 96)
+	copy(sb, "hsqs")
+	binary.LittleEndian.PutUint64(sb[40:], 52428800)
+	got, err := squashfsSize(bytes.NewReader(sb))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uint64(52428800); got != want {
+		t.Errorf("squashfsSize() = %d, want %d", got, want)
+	}
+	if _, err := squashfsSize(bytes.NewReader(make([]byte, 96))); err == nil {
+		t.Errorf("squashfsSize(zeros) = nil error, want error")
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gokrazy/gokrazy"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	serialDevice = flag.String("serial",
+		"",
+		"serial port (e.g. /dev/ttyAMA0 or /dev/ttyS0) on which to show a compact status for a VT100-compatible terminal, alongside the framebuffer or, on devices without one, instead of it")
+
+	serialBaud = flag.Int("serial-baud",
+		115200,
+		"baud rate of the -serial port")
+
+	serialSize = flag.String("serial-size",
+		"80x24",
+		"columns and rows of the terminal connected to the -serial port")
+)
+
+// serialFullRedraw is how often the entire serial terminal is redrawn, e.g.
+// for a terminal which was connected after fbstatus started. In between,
+// only changed lines are sent, which is quick even at low baud rates.
+const serialFullRedraw = time.Minute
+
+var serialSpeeds = map[int]uint32{
+	9600:   unix.B9600,
+	19200:  unix.B19200,
+	38400:  unix.B38400,
+	57600:  unix.B57600,
+	115200: unix.B115200,
+	230400: unix.B230400,
+	460800: unix.B460800,
+	921600: unix.B921600,
+}
+
+// openSerial opens the serial port dev for writing with baud 8N1.
+func openSerial(dev string, baud int) (*os.File, error) {
+	speed, ok := serialSpeeds[baud]
+	if !ok {
+		return nil, fmt.Errorf("unsupported -serial-baud=%d", baud)
+	}
+	f, err := os.OpenFile(dev, os.O_WRONLY|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+	fd := int(f.Fd())
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", dev, err)
+	}
+	t.Cflag &^= unix.CBAUD | unix.CSIZE | unix.PARENB | unix.CSTOPB
+	t.Cflag |= speed | unix.CS8 | unix.CLOCAL | unix.CREAD
+	t.Ispeed, t.Ospeed = speed, speed
+	t.Oflag &^= unix.OPOST // line endings are \r\n already
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, t); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", dev, err)
+	}
+	return f, nil
+}
+
+func parseSerialSize(s string) (w, h int, _ error) {
+	if _, err := fmt.Sscanf(s, "%dx%d", &w, &h); err != nil || w < 1 || h < 1 {
+		return 0, 0, fmt.Errorf("invalid -serial-size=%q, expected e.g. 80x24", s)
+	}
+	return w, h, nil
+}
+
+// sgrForeground returns the SGR parameter which selects the named color, the
+// inverse of ansiColors. White is the terminal's default color.
+func sgrForeground(name string) string {
+	if name == "darkgray" {
+		return "90" // bright black: black might be invisible
+	}
+	for idx, c := range ansiColors {
+		if c == name && name != "white" {
+			return strconv.Itoa(30 + idx)
+		}
+	}
+	return "0"
+}
+
+// ansiMarkup converts s from the $color$text markup (see drawMarkup) into
+// text with ANSI color escape sequences, shortened to width characters.
+func ansiMarkup(s string, width int) string {
+	var buf strings.Builder
+	n := 0
+	write := func(text string) {
+		for _, r := range text {
+			if n >= width {
+				return
+			}
+			buf.WriteRune(r)
+			n++
+		}
+	}
+	if !strings.HasPrefix(s, "$") {
+		write(s)
+		return buf.String()
+	}
+	for idx, field := range strings.Split(strings.TrimPrefix(s, "$"), "$") {
+		if idx%2 == 0 {
+			buf.WriteString("\x1b[" + sgrForeground(field) + "m")
+			continue
+		}
+		write(field)
+	}
+	buf.WriteString("\x1b[0m")
+	return buf.String()
+}
+
+// serialScreen tracks the content of a w×h terminal on a serial port, so
+// that only changed lines need to be sent.
+type serialScreen struct {
+	w, h int
+	prev []string  // lines on the terminal (in markup), nil before the first update
+	full time.Time // when the terminal was last redrawn entirely
+}
+
+// update returns the VT100 escape sequences which change the terminal to
+// show lines (in $color$text markup) at now.
+func (s *serialScreen) update(lines []string, now time.Time) []byte {
+	if len(lines) > s.h {
+		lines = lines[:s.h]
+	}
+	var buf bytes.Buffer
+	full := s.prev == nil || now.Sub(s.full) >= serialFullRedraw
+	if full {
+		// reset colors, hide the cursor and clear the screen
+		buf.WriteString("\x1b[0m\x1b[?25l\x1b[2J")
+		s.full = now
+	}
+	for idx := 0; idx < s.h; idx++ {
+		var line, prev string
+		if idx < len(lines) {
+			line = lines[idx]
+		}
+		if idx < len(s.prev) {
+			prev = s.prev[idx]
+		}
+		if full && line == "" || !full && line == prev {
+			continue
+		}
+		// move the cursor to the line, then erase the rest of the line
+		fmt.Fprintf(&buf, "\x1b[%d;1H%s\x1b[K", idx+1, ansiMarkup(line, s.w))
+	}
+	s.prev = append([]string{}, lines...)
+	return buf.Bytes()
+}
+
+// serialLines returns the compact status shown on the serial port, one
+// element per line in $color$text markup.
+func (d *statusDrawer) serialLines() []string {
+	line := "time: " + time.Now().Format(time.RFC3339)
+	if up, err := uptime(); err == nil {
+		line += ", up for " + up
+	}
+	lines := []string{
+		"host “" + d.hostname + "” (" + gokrazy.Model() + ")",
+		line,
+	}
+	for _, cond := range d.criticalConditions() {
+		lines = append(lines, "$red$"+cond.message)
+	}
+	lines = append(lines, d.infoLines()...)
+	private, public := interfaceAddrs()
+	lines = append(lines, "IP: "+strings.Join(append(private, public...), ", "))
+
+	// the most recent row of the resource usage table
+	lines = append(lines, "", d.statLayout.header())
+	return append(lines, d.statLayout.row(d.last[len(d.last)-1]))
+}
+
+// serialStatus shows the status on the serial port every frameInterval until
+// ctx is done. With collect, serialStatus also reads the data sources, which
+// is otherwise done by draw1.
+func (d *statusDrawer) serialStatus(ctx context.Context, port *os.File, screen *serialScreen, collect bool) error {
+	tick := time.NewTicker(frameInterval)
+	defer tick.Stop()
+	for {
+		d.mu.Lock()
+		if collect {
+			d.collect()
+		}
+		lines := d.serialLines()
+		d.mu.Unlock()
+		if _, err := port.Write(screen.update(lines, time.Now())); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+}
+
+// openSerialScreen opens the -serial port.
+func openSerialScreen() (*os.File, *serialScreen, error) {
+	w, h, err := parseSerialSize(*serialSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	port, err := openSerial(*serialDevice, *serialBaud)
+	if err != nil {
+		return nil, nil, err
+	}
+	return port, &serialScreen{w: w, h: h}, nil
+}
+
+// serialOnly shows the status on the -serial port of a device without a
+// framebuffer.
+func serialOnly(ctx context.Context) error {
+	port, screen, err := openSerialScreen()
+	if err != nil {
+		return err
+	}
+	defer port.Close()
+	// The drawer never draws, but holds the data sources.
+	drawer, err := newStatusDrawer(image.NewRGBA(image.Rect(0, 0, 1024, 768)), 0)
+	if err != nil {
+		return err
+	}
+	slog.Info("showing the status on the serial device", "device", *serialDevice)
+	return drawer.serialStatus(ctx, port, screen, true)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnsiMarkup(t *testing.T) {
+	for _, tt := range []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"plain text", 80, "plain text"},
+		{"plain text", 5, "plain"},
+		{"$$load: $red$9.5", 80, "\x1b[0mload: \x1b[31m9.5\x1b[0m"},
+		{"$darkgray$n/a", 80, "\x1b[90mn/a\x1b[0m"},
+		{"$$load: $red$9.5", 7, "\x1b[0mload: \x1b[31m9\x1b[0m"},
+	} {
+		if got := ansiMarkup(tt.s, tt.width); got != tt.want {
+			t.Errorf("ansiMarkup(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestSerialScreen(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &serialScreen{w: 20, h: 3}
+	out := string(s.update([]string{"host", "", "time: 1", "dropped"}, start))
+	if !strings.HasPrefix(out, "\x1b[0m\x1b[?25l\x1b[2J") {
+		t.Errorf("first update = %q, want a full redraw", out)
+	}
+	if want := "\x1b[1;1Hhost\x1b[K\x1b[3;1Htime: 1\x1b[K"; !strings.HasSuffix(out, want) {
+		t.Errorf("first update = %q, want suffix %q", out, want)
+	}
+
+	// only changed lines are sent
+	out = string(s.update([]string{"host", "", "time: 2"}, start.Add(time.Second)))
+	if want := "\x1b[3;1Htime: 2\x1b[K"; out != want {
+		t.Errorf("update = %q, want %q", out, want)
+	}
+	out = string(s.update([]string{"host"}, start.Add(2*time.Second)))
+	if want := "\x1b[3;1H\x1b[K"; out != want {
+		t.Errorf("update = %q, want %q", out, want)
+	}
+
+	out = string(s.update([]string{"host"}, start.Add(serialFullRedraw)))
+	if !strings.Contains(out, "\x1b[2J") {
+		t.Errorf("update after %v = %q, want a full redraw", serialFullRedraw, out)
+	}
+}
+
+func TestParseSerialSize(t *testing.T) {
+	if w, h, err := parseSerialSize("132x43"); err != nil || w != 132 || h != 43 {
+		t.Errorf("parseSerialSize(132x43) = %d, %d, %v, want 132, 43", w, h, err)
+	}
+	for _, s := range []string{"", "80", "0x24", "ax24"} {
+		if _, _, err := parseSerialSize(s); err == nil {
+			t.Errorf("parseSerialSize(%q) unexpectedly succeeded", s)
+		}
+	}
+}
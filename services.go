@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// serviceState is the state of one supervised service, as displayed in the
+// services panel.
+type serviceState struct {
+	name    string
+	state   string // running, exited or stopped
+	pid     int
+	started time.Time
+
+	// restarts counts how often the service was (re)started since fbstatus
+	// started observing it.
+	restarts int
+
+	// crashLooping is true if the service was restarted at least
+	// crashLoopRestarts times within crashLoopWindow.
+	crashLooping bool
+
+	// resource usage, see cgroup.go
+	hasUsage bool
+	cgroup   bool    // whether the usage is of the service's own cgroup
+	cpu      float64 // percent of one CPU
+	memory   uint64  // bytes
+}
+
+const (
+	crashLoopRestarts = 3
+	crashLoopWindow   = 5 * time.Minute
+)
+
+// serviceTracker derives restart counts from consecutive gokrazy service
+// listings, which only contain the most recent start time.
+type serviceTracker struct {
+	lastStart map[string]time.Time
+	restarts  map[string]int
+	recent    map[string][]time.Time // restarts within crashLoopWindow
+}
+
+func newServiceTracker() *serviceTracker {
+	return &serviceTracker{
+		lastStart: make(map[string]time.Time),
+		restarts:  make(map[string]int),
+		recent:    make(map[string][]time.Time),
+	}
+}
+
+func (t *serviceTracker) update(services []gokrazyService, now time.Time) []serviceState {
+	states := make([]serviceState, 0, len(services))
+	for _, svc := range services {
+		if last, ok := t.lastStart[svc.Path]; ok && !svc.StartTime.Equal(last) {
+			t.restarts[svc.Path]++
+			t.recent[svc.Path] = append(t.recent[svc.Path], svc.StartTime)
+		}
+		t.lastStart[svc.Path] = svc.StartTime
+		recent := t.recent[svc.Path][:0]
+		for _, started := range t.recent[svc.Path] {
+			if now.Sub(started) < crashLoopWindow {
+				recent = append(recent, started)
+			}
+		}
+		t.recent[svc.Path] = recent
+
+		state := "running"
+		if svc.Stopped {
+			state = "stopped"
+		} else if svc.Pid == 0 {
+			state = "exited"
+		}
+		states = append(states, serviceState{
+			name:     filepath.Base(svc.Path),
+			state:    state,
+			pid:      svc.Pid,
+			started:  svc.StartTime,
+			restarts: t.restarts[svc.Path],
+
+			crashLooping: len(recent) >= crashLoopRestarts,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].name < states[j].name
+	})
+	return states
+}
+
+// newServicesPoller returns a poller for the state of all services, which
+// is shared by the services panel and the crash-loop badge (and polled more
+// often for the boot splash, see splash.go). With usage, the poller also
+// determines the CPU and memory usage of the running services.
+func newServicesPoller(interval time.Duration, usage bool) *poller[[]serviceState] {
+	tracker := newServiceTracker()
+	usageTracker := &serviceUsageTracker{}
+	return newPoller(interval, func(ctx context.Context) ([]serviceState, error) {
+		var status gokrazyStatus
+		if err := gokrazyAPI(ctx, "/", &status); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		states := tracker.update(status.Services, now)
+		if usage {
+			usageTracker.update(states, now)
+		}
+		return states, nil
+	})
+}
+
+// servicesPanel lists all services supervised by gokrazy.
+type servicesPanel struct{}
+
+func newServicesPanel() (panel, error) {
+	return &servicesPanel{}, nil
+}
+
+var serviceStateColor = map[string]string{
+	"running": "green",
+	"exited":  "red",
+	"stopped": "darkgray",
+}
+
+func (p *servicesPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Services")
+	services, updated, err := d.services.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	rows := make([][]cell, 0, len(services))
+	for _, svc := range services {
+		pid := "-"
+		up := "-"
+		if svc.state == "running" {
+			pid = strconv.Itoa(svc.pid)
+			up = time.Since(svc.started).Round(time.Second).String()
+		}
+		cpu := cell{text: "-", color: "darkgray"}
+		mem := cell{text: "-", color: "darkgray"}
+		if svc.hasUsage {
+			cpu = cell{text: fmt.Sprintf("%5.1f%%", svc.cpu), color: "darkgray"}
+			if svc.cpu >= 1 {
+				cpu.color = ""
+			}
+			mem = cell{text: formatBytes(svc.memory)}
+		}
+		restartsColor := "darkgray"
+		if svc.crashLooping {
+			restartsColor = "red"
+		} else if svc.restarts > 0 {
+			restartsColor = "yellow"
+		}
+		rows = append(rows, []cell{
+			{text: svc.name, scroll: true},
+			{text: svc.state, color: serviceStateColor[svc.state]},
+			{text: pid},
+			{text: up},
+			{text: strconv.Itoa(svc.restarts), color: restartsColor},
+			cpu,
+			mem,
+		})
+	}
+	d.drawTable(dc, y, []string{"service", "state", "pid", "up", "restarts", "cpu", "mem"}, rows)
+	return nil
+}
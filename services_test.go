@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestServiceTrackerCrashLoop(t *testing.T) {
+	tracker := newServiceTracker()
+	now := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	var (
+		states    []serviceState
+		lastStart time.Time
+	)
+	// The service crashes and is restarted between each poll.
+	for i := 0; i < crashLoopRestarts+1; i++ {
+		now = now.Add(5 * time.Second)
+		lastStart = now.Add(-time.Second)
+		states = tracker.update([]gokrazyService{
+			{Path: "/user/flaky", StartTime: lastStart, Pid: 100 + i},
+			{Path: "/user/stable", StartTime: time.Date(2022, 8, 1, 11, 0, 0, 0, time.UTC), Pid: 42},
+		}, now)
+	}
+	flaky, stable := states[0], states[1]
+	if !flaky.crashLooping || flaky.restarts != crashLoopRestarts {
+		t.Errorf("flaky: crashLooping = %v, restarts = %d, want true, %d", flaky.crashLooping, flaky.restarts, crashLoopRestarts)
+	}
+	if stable.crashLooping || stable.restarts != 0 {
+		t.Errorf("stable: crashLooping = %v, restarts = %d, want false, 0", stable.crashLooping, stable.restarts)
+	}
+
+	// Once the service stays up for crashLoopWindow, it is no longer
+	// considered crash-looping.
+	now = now.Add(crashLoopWindow)
+	states = tracker.update([]gokrazyService{
+		{Path: "/user/flaky", StartTime: lastStart, Pid: 100 + crashLoopRestarts},
+	}, now)
+	if states[0].crashLooping {
+		t.Errorf("flaky: still crash-looping after %v", crashLoopWindow)
+	}
+}
+
+func TestCrashLoopBadge(t *testing.T) {
+	for _, tt := range []struct {
+		services []serviceState
+		want     string
+	}{
+		{[]serviceState{{name: "ok", state: "running"}}, ""},
+		{
+			[]serviceState{
+				{name: "flaky", state: "exited", crashLooping: true},
+				{name: "once", state: "stopped"},
+			},
+			"crash-looping: flaky · stopped: once",
+		},
+	} {
+		if got := crashLoopBadge(tt.services); got != tt.want {
+			t.Errorf("crashLoopBadge() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/gokrazy/fbstatus/internal/snmp"
+)
+
+var (
+	snmpValues = flag.String("snmp",
+		"",
+		"comma-separated list of SNMP values to show in the snmp panel, each specified as label[:unit[:warn:crit]]=snmp://[community@]host[:port]/oid, e.g. uplink in:B=snmp://public@switch/1.3.6.1.2.1.2.2.1.10.1,ups battery:%:30:10=snmp://ups/1.3.6.1.4.1.318.1.1.1.2.2.1.0. Counters are shown as rate per second. The community defaults to public")
+
+	snmpInterval = flag.Duration("snmp-interval",
+		30*time.Second,
+		"how often the snmp panel polls the SNMP agents")
+)
+
+type snmpValue struct {
+	label     string
+	unit      string
+	threshold *threshold // nil if none was specified
+	agent     string     // host:port
+	community string
+	oid       string
+}
+
+func parseSNMPValues(spec string) ([]snmpValue, error) {
+	var values []snmpValue
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		label, rawurl, ok := strings.Cut(s, "=")
+		if !ok || label == "" || rawurl == "" {
+			return nil, fmt.Errorf("malformed SNMP value %q: expected label[:unit[:warn:crit]]=snmp://[community@]host[:port]/oid", s)
+		}
+		label, unit, _ := strings.Cut(label, ":")
+		unit, thresh, _ := strings.Cut(unit, ":")
+		v := snmpValue{
+			label:     label,
+			unit:      unit,
+			community: "public",
+		}
+		if thresh != "" {
+			t, err := parseThreshold(thresh)
+			if err != nil {
+				return nil, fmt.Errorf("SNMP value %q: %v", label, err)
+			}
+			v.threshold = t
+		}
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("SNMP value %q: %v", label, err)
+		}
+		if u.Scheme != "snmp" {
+			return nil, fmt.Errorf("SNMP value %q: unsupported scheme %q, expected snmp://", label, u.Scheme)
+		}
+		if u.User != nil {
+			v.community = u.User.Username()
+		}
+		v.agent = u.Host
+		if u.Port() == "" {
+			v.agent = net.JoinHostPort(u.Hostname(), "161")
+		}
+		v.oid = strings.TrimPrefix(strings.TrimPrefix(u.Path, "/"), ".")
+		if v.oid == "" {
+			return nil, fmt.Errorf("SNMP value %q: missing OID", label)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// snmpReading is the most recent value of an OID and, for counters, its rate
+// of change per second.
+type snmpReading struct {
+	snmp.Variable
+	rate    float64
+	hasRate bool
+}
+
+// counterDelta returns the increase of a counter between two samples,
+// accounting for the wrap-around of 32-bit counters. ok is false if v is
+// not a counter.
+func counterDelta(prev, cur interface{}) (delta float64, ok bool) {
+	switch c := cur.(type) {
+	case snmp.Counter32:
+		p, ok := prev.(snmp.Counter32)
+		if !ok {
+			return 0, false
+		}
+		return float64(uint32(c - p)), true
+	case snmp.Counter64:
+		p, ok := prev.(snmp.Counter64)
+		if !ok || c < p {
+			return 0, false // wrap-around of a 64-bit counter means a reset
+		}
+		return float64(c - p), true
+	}
+	return 0, false
+}
+
+// snmpAgent polls all OIDs configured for one agent in a single request.
+type snmpAgent struct {
+	readings *poller[map[string]snmpReading] // by OID
+}
+
+func newSNMPAgent(addr, community string, oids []string) *snmpAgent {
+	var (
+		prev     map[string]snmp.Variable
+		prevTime time.Time
+	)
+	return &snmpAgent{
+		readings: newPoller(*snmpInterval, func(ctx context.Context) (map[string]snmpReading, error) {
+			vars, err := snmp.Get(ctx, addr, community, oids)
+			if err != nil {
+				return nil, err
+			}
+			now := time.Now()
+			elapsed := now.Sub(prevTime).Seconds()
+			cur := make(map[string]snmp.Variable, len(vars))
+			readings := make(map[string]snmpReading, len(vars))
+			for _, v := range vars {
+				cur[v.OID] = v
+				r := snmpReading{Variable: v}
+				if old, ok := prev[v.OID]; ok && elapsed > 0 {
+					if delta, ok := counterDelta(old.Value, v.Value); ok {
+						r.rate = delta / elapsed
+						r.hasRate = true
+					}
+				}
+				readings[v.OID] = r
+			}
+			prev, prevTime = cur, now
+			return readings, nil
+		}),
+	}
+}
+
+// formatSNMPReading renders a reading for display. Counters are shown as
+// rate per second once two samples are available.
+func formatSNMPReading(r snmpReading, unit string, t *threshold) cell {
+	if r.Err != nil {
+		return cell{text: r.Err.Error(), color: "darkgray"}
+	}
+	switch x := r.Value.(type) {
+	case snmp.Counter32, snmp.Counter64:
+		if !r.hasRate {
+			return cell{text: "measuring…", color: "darkgray"}
+		}
+		if unit == "B" {
+			return cell{text: formatBytes(uint64(r.rate)) + "/s", color: t.color(r.rate)}
+		}
+		text := strconv.FormatFloat(math.Round(r.rate*10)/10, 'f', -1, 64)
+		if unit != "" {
+			text += " " + unit
+		}
+		return cell{text: text + "/s", color: t.color(r.rate)}
+	case snmp.Gauge32:
+		return formatJSONValue(float64(x), unit, t)
+	case int64:
+		return formatJSONValue(float64(x), unit, t)
+	case snmp.TimeTicks:
+		d := time.Duration(x) * 10 * time.Millisecond
+		return cell{text: d.Round(time.Second).String()}
+	case string:
+		return formatJSONValue(x, unit, t)
+	default:
+		return cell{text: fmt.Sprint(x)}
+	}
+}
+
+// snmpPanel shows values polled from SNMP agents, e.g. switch port counters
+// or the UPS status.
+type snmpPanel struct {
+	values []snmpValue
+	agents map[string]*snmpAgent // by community@agent
+}
+
+func snmpAgentKey(v snmpValue) string {
+	return v.community + "@" + v.agent
+}
+
+func newSNMPPanel() (panel, error) {
+	values, err := parseSNMPValues(*snmpValues)
+	if err != nil {
+		return nil, err
+	}
+	oids := make(map[string][]string)
+	for _, v := range values {
+		key := snmpAgentKey(v)
+		oids[key] = append(oids[key], v.oid)
+	}
+	p := &snmpPanel{
+		values: values,
+		agents: make(map[string]*snmpAgent),
+	}
+	for _, v := range values {
+		key := snmpAgentKey(v)
+		if _, ok := p.agents[key]; ok {
+			continue
+		}
+		p.agents[key] = newSNMPAgent(v.agent, v.community, oids[key])
+	}
+	return p, nil
+}
+
+func (p *snmpPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "SNMP")
+	if len(p.values) == 0 {
+		d.drawMessage(dc, y, "-snmp not set")
+		return nil
+	}
+	rows := make([][]cell, 0, len(p.values))
+	for _, v := range p.values {
+		value := cell{text: "loading…", color: "darkgray"}
+		readings, updated, err := p.agents[snmpAgentKey(v)].readings.get()
+		switch {
+		case err != nil:
+			value = cell{text: "unavailable: " + err.Error(), color: "darkgray"}
+		case !updated.IsZero():
+			if r, ok := readings[v.oid]; ok {
+				value = formatSNMPReading(r, v.unit, v.threshold)
+			} else {
+				value = cell{text: "missing in response", color: "red"}
+			}
+		}
+		host, _, _ := net.SplitHostPort(v.agent)
+		rows = append(rows, []cell{
+			{text: v.label},
+			value,
+			{text: host, color: "darkgray"},
+		})
+	}
+	d.drawTable(dc, y, []string{"name", "value", "agent"}, rows)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gokrazy/fbstatus/internal/snmp"
+)
+
+func TestParseSNMPValues(t *testing.T) {
+	got, err := parseSNMPValues("uplink in:B=snmp://secret@switch/1.3.6.1.2.1.2.2.1.10.1,battery:%:30:10=snmp://ups:1161/.1.3.6.1.4.1.318.1.1.1.2.2.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []snmpValue{
+		{label: "uplink in", unit: "B", agent: "switch:161", community: "secret", oid: "1.3.6.1.2.1.2.2.1.10.1"},
+		{label: "battery", unit: "%", threshold: &threshold{warn: 30, crit: 10}, agent: "ups:1161", community: "public", oid: "1.3.6.1.4.1.318.1.1.1.2.2.1.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSNMPValues() = %+v, want %+v", got, want)
+	}
+
+	for _, spec := range []string{
+		"uplink=http://switch/1.3.6.1",
+		"uplink=snmp://switch/",
+		"snmp://switch/1.3.6.1",
+	} {
+		if _, err := parseSNMPValues(spec); err == nil {
+			t.Errorf("parseSNMPValues(%q) did not return an error", spec)
+		}
+	}
+}
+
+func TestCounterDelta(t *testing.T) {
+	for _, tt := range []struct {
+		prev, cur interface{}
+		want      float64
+		ok        bool
+	}{
+		{snmp.Counter32(100), snmp.Counter32(350), 250, true},
+		{snmp.Counter32(4294967200), snmp.Counter32(100), 196, true}, // wrap-around
+		{snmp.Counter64(1 << 40), snmp.Counter64(1<<40 + 5), 5, true},
+		{snmp.Counter64(100), snmp.Counter64(5), 0, false}, // reset
+		{snmp.Gauge32(1), snmp.Gauge32(2), 0, false},
+	} {
+		got, ok := counterDelta(tt.prev, tt.cur)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("counterDelta(%v, %v) = %v, %v, want %v, %v", tt.prev, tt.cur, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFormatSNMPReading(t *testing.T) {
+	down := &threshold{warn: 30, crit: 10}
+	for _, tt := range []struct {
+		r    snmpReading
+		unit string
+		t    *threshold
+		want cell
+	}{
+		{snmpReading{Variable: snmp.Variable{Value: snmp.Gauge32(100)}}, "%", down, cell{text: "100 %"}},
+		{snmpReading{Variable: snmp.Variable{Value: snmp.Gauge32(20)}}, "%", down, cell{text: "20 %", color: "yellow"}},
+		{snmpReading{Variable: snmp.Variable{Value: "onLine"}}, "", nil, cell{text: "onLine"}},
+		{snmpReading{Variable: snmp.Variable{Value: snmp.TimeTicks(9000)}}, "", nil, cell{text: "1m30s"}},
+		{snmpReading{Variable: snmp.Variable{Value: snmp.Counter32(1)}}, "B", nil, cell{text: "measuring…", color: "darkgray"}},
+		{snmpReading{Variable: snmp.Variable{Value: snmp.Counter64(1)}, rate: 2048, hasRate: true}, "B", nil, cell{text: formatBytes(2048) + "/s"}},
+		{snmpReading{Variable: snmp.Variable{Value: snmp.Counter32(1)}, rate: 1.25, hasRate: true}, "pkt", nil, cell{text: "1.3 pkt/s"}},
+		{snmpReading{Variable: snmp.Variable{Err: snmp.ErrNoSuchObject}}, "", nil, cell{text: "no such object", color: "darkgray"}},
+	} {
+		if got := formatSNMPReading(tt.r, tt.unit, tt.t); got != tt.want {
+			t.Errorf("formatSNMPReading(%+v, %q) = %+v, want %+v", tt.r, tt.unit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// Socket states in /proc/net/{tcp,udp}, see include/net/tcp_states.h.
+const (
+	tcpEstablished = 0x01
+	tcpListen      = 0x0A
+	udpClose       = 0x07 // unconnected UDP sockets, i.e. those receiving from anyone
+)
+
+type listeningSocket struct {
+	proto string // tcp, tcp6, udp or udp6
+	addr  net.IP
+	port  int
+	inode uint64
+}
+
+// parseProcNetAddr parses an address in /proc/net/{tcp,udp}[6] notation,
+// e.g. 0100007F:0050 for 127.0.0.1:80. The address consists of 32-bit words
+// in host byte order, which is little endian on all architectures gokrazy
+// supports.
+func parseProcNetAddr(s string) (net.IP, int, error) {
+	addr, port, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("malformed address %q", s)
+	}
+	b, err := hex.DecodeString(addr)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(b) != net.IPv4len && len(b) != net.IPv6len {
+		return nil, 0, fmt.Errorf("malformed address %q", s)
+	}
+	ip := make(net.IP, len(b))
+	for i := 0; i < len(b); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = b[i+3], b[i+2], b[i+1], b[i]
+	}
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ip, int(p), nil
+}
+
+// procNetEntry is one line of /proc/net/{tcp,udp}[6].
+type procNetEntry struct {
+	localAddr  net.IP
+	localPort  int
+	remoteAddr net.IP
+	remotePort int
+	state      uint64
+	inode      uint64
+}
+
+func parseProcNetEntries(b []byte) ([]procNetEntry, error) {
+	var entries []procNetEntry
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	scanner.Scan() // skip header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		state, err := strconv.ParseUint(fields[3], 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		localAddr, localPort, err := parseProcNetAddr(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		remoteAddr, remotePort, err := parseProcNetAddr(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, procNetEntry{
+			localAddr:  localAddr,
+			localPort:  localPort,
+			remoteAddr: remoteAddr,
+			remotePort: remotePort,
+			state:      state,
+			inode:      inode,
+		})
+	}
+	return entries, scanner.Err()
+}
+
+// parseProcNet returns the listening sockets from the contents of
+// /proc/net/<proto>.
+func parseProcNet(proto string, b []byte) ([]listeningSocket, error) {
+	listenState := uint64(tcpListen)
+	if strings.HasPrefix(proto, "udp") {
+		listenState = udpClose
+	}
+	entries, err := parseProcNetEntries(b)
+	if err != nil {
+		return nil, err
+	}
+	var sockets []listeningSocket
+	for _, e := range entries {
+		if e.state != listenState {
+			continue
+		}
+		sockets = append(sockets, listeningSocket{
+			proto: proto,
+			addr:  e.localAddr,
+			port:  e.localPort,
+			inode: e.inode,
+		})
+	}
+	return sockets, nil
+}
+
+// tcpPeers returns the distinct remote addresses of all established
+// connections to the local TCP port. listening is false if nothing listens on
+// the port, i.e. the corresponding server is not running.
+func tcpPeers(port int) (remotes []string, listening bool, err error) {
+	seen := make(map[string]bool)
+	for _, proto := range []string{"tcp", "tcp6"} {
+		b, err := os.ReadFile("/proc/net/" + proto)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // e.g. IPv6 disabled
+			}
+			return nil, false, err
+		}
+		entries, err := parseProcNetEntries(b)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, e := range entries {
+			if e.localPort != port {
+				continue
+			}
+			switch e.state {
+			case tcpListen:
+				listening = true
+			case tcpEstablished:
+				remote := e.remoteAddr.String()
+				if !seen[remote] {
+					seen[remote] = true
+					remotes = append(remotes, remote)
+				}
+			}
+		}
+	}
+	sort.Strings(remotes)
+	return remotes, listening, nil
+}
+
+// socketOwners maps socket inodes to the name of the process owning them.
+func socketOwners() map[uint64]string {
+	owners := make(map[uint64]string)
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		target, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(target, "socket:[") {
+			continue
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]"), 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, ok := owners[inode]; ok {
+			continue
+		}
+		pidDir := filepath.Dir(filepath.Dir(fd))
+		comm, err := os.ReadFile(filepath.Join(pidDir, "comm"))
+		if err != nil {
+			continue
+		}
+		owners[inode] = strings.TrimSpace(string(comm)) + " (" + filepath.Base(pidDir) + ")"
+	}
+	return owners
+}
+
+type socketEntry struct {
+	proto   string
+	addr    string
+	port    int
+	process string
+}
+
+func listeningSockets() ([]socketEntry, error) {
+	var sockets []listeningSocket
+	for _, proto := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		b, err := os.ReadFile("/proc/net/" + proto)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // e.g. IPv6 disabled
+			}
+			return nil, err
+		}
+		s, err := parseProcNet(proto, b)
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, s...)
+	}
+	owners := socketOwners()
+	entries := make([]socketEntry, 0, len(sockets))
+	for _, s := range sockets {
+		addr := s.addr.String()
+		if s.addr.IsUnspecified() {
+			addr = "*"
+		}
+		process, ok := owners[s.inode]
+		if !ok {
+			process = "?"
+		}
+		entries = append(entries, socketEntry{
+			proto:   s.proto,
+			addr:    addr,
+			port:    s.port,
+			process: process,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].port != entries[j].port {
+			return entries[i].port < entries[j].port
+		}
+		return entries[i].proto < entries[j].proto
+	})
+	return entries, nil
+}
+
+// socketsPanel lists the TCP and UDP ports on which the appliance listens,
+// i.e. what it is exposing to the network.
+type socketsPanel struct {
+	sockets *poller[[]socketEntry]
+}
+
+func newSocketsPanel() (panel, error) {
+	return &socketsPanel{
+		sockets: newPoller(10*time.Second, func(context.Context) ([]socketEntry, error) {
+			return listeningSockets()
+		}),
+	}, nil
+}
+
+func (p *socketsPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Listening sockets")
+	sockets, updated, err := p.sockets.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	rows := make([][]cell, 0, len(sockets))
+	for _, s := range sockets {
+		addrColor := ""
+		if ip := net.ParseIP(s.addr); ip != nil && ip.IsLoopback() {
+			// only reachable locally
+			addrColor = "darkgray"
+		}
+		rows = append(rows, []cell{
+			{text: s.proto},
+			{text: s.addr, color: addrColor},
+			{text: strconv.Itoa(s.port)},
+			{text: s.process},
+		})
+	}
+	d.drawTable(dc, y, []string{"proto", "address", "port", "process"}, rows)
+	return nil
+}
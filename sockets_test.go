@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseProcNet(t *testing.T) {
+	const tcp = `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:BC8F 00000000:0000 0A 00000000:00000000 00:00000000 00000000 65534        0 1046 1 0000000059dda744 100 0 0 10 0
+   1: 00000000:0050 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 662 1 0000000084dd3745 100 0 0 10 0
+   2: 0202000A:0016 0302000A:D431 01 00000000:00000000 02:00097B23 00000000     0        0 9511 2 0000000034a13dd6 20 4 29 10 -1
+`
+	got, err := parseProcNet("tcp", []byte(tcp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("parseProcNet() returned %d sockets, want 2 (established connection must be skipped)", len(got))
+	}
+	if want := net.IPv4(127, 0, 0, 1); !got[0].addr.Equal(want) || got[0].port != 48271 || got[0].inode != 1046 {
+		t.Errorf("socket 0 = %+v, want 127.0.0.1:48271 inode 1046", got[0])
+	}
+	if !got[1].addr.IsUnspecified() || got[1].port != 80 {
+		t.Errorf("socket 1 = %+v, want 0.0.0.0:80", got[1])
+	}
+}
+
+func TestParseProcNetAddrIPv6(t *testing.T) {
+	ip, port, err := parseProcNetAddr("00000000000000000000000001000000:1F90")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv6loopback) || port != 8080 {
+		t.Errorf("parseProcNetAddr() = %v, %d, want ::1, 8080", ip, port)
+	}
+}
+
+func TestParseProcNetEntriesEstablished(t *testing.T) {
+	const tcp6 = `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1234 1 0000000000000000 100 0 0 10 0
+   1: 0000000000000000FFFF00000202000A:0016 0000000000000000FFFF00000302000A:D431 01 00000000:00000000 02:00097B23 00000000     0        0 5678 2 0000000000000000 20 4 29 10 -1
+`
+	entries, err := parseProcNetEntries([]byte(tcp6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parseProcNetEntries() returned %d entries, want 2", len(entries))
+	}
+	e := entries[1]
+	if e.state != tcpEstablished || e.localPort != 22 || e.remoteAddr.String() != "10.0.2.3" {
+		t.Errorf("entry 1 = %+v, want established connection to port 22 from 10.0.2.3", e)
+	}
+}
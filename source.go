@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gokrazy/gokrazy"
+)
+
+// statusSource provides the host data which the status view displays. It is
+// hostSource, except in tests, which feed canned data for byte-stable golden
+// images (see golden_test.go).
+type statusSource interface {
+	// now returns the current time.
+	now() time.Time
+
+	// statFiles returns the contents of the files which the resource usage
+	// modules process (see FileContents), keyed by path. Files which could
+	// not be read are missing.
+	statFiles(now time.Time) map[string][]byte
+
+	// hostInfo returns the data for the host information template.
+	hostInfo() hostInfo
+}
+
+// hostSource is the statusSource for the host fbstatus runs on.
+type hostSource struct {
+	d *statusDrawer
+}
+
+func (s hostSource) now() time.Time { return time.Now() }
+
+func (s hostSource) statFiles(now time.Time) map[string][]byte {
+	return s.d.readStatFiles(now)
+}
+
+func (s hostSource) hostInfo() hostInfo {
+	d := s.d
+	info := hostInfo{
+		Hostname: d.hostname,
+		Model:    gokrazy.Model(),
+		Time:     time.Now(),
+		Info:     d.infoLines(),
+		Version:  fbstatusVersion(),
+		Vars:     d.hostVars,
+	}
+	if up, err := uptime(); err == nil {
+		info.Uptime = up
+	}
+	if d.lastRender > 0 || d.lastCopy > 0 {
+		info.Render = fmt.Sprintf("fb: draw %v, cp %v",
+			d.lastRender.Round(time.Millisecond),
+			d.lastCopy.Round(time.Millisecond))
+	}
+	info.PrivateAddrs, info.PublicAddrs = interfaceAddrs()
+	return info
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"log/slog"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+var (
+	splashFlag = flag.Bool("splash",
+		false,
+		"show a boot splash with the gokrazy logo and the startup progress of all supervised services (instead of the kernel's boot messages) until all services are up, then switch to the pages. Add quiet to the kernel command line (cmdline.txt) to hide the boot messages before fbstatus starts, too")
+
+	splashTimeout = flag.Duration("splash-timeout",
+		2*time.Minute,
+		"with -splash, the maximum duration of the boot splash, e.g. when a service never comes up")
+)
+
+const (
+	// splashSettle is how long all services need to stay up before the
+	// boot splash ends, so that quickly crashing services are shown.
+	splashSettle = 3 * time.Second
+
+	// splashPollInterval is how often the service listing is polled while
+	// the boot splash is shown.
+	splashPollInterval = time.Second
+)
+
+// splashScreen is the boot splash shown with -splash.
+type splashScreen struct {
+	services *poller[[]serviceState]
+	logo     *image.RGBA // scaled to the display
+	deadline time.Time
+
+	settled time.Time // when all services were first seen up, or zero
+	done    bool
+}
+
+func newSplashScreen(w, h int) (*splashScreen, error) {
+	gokrazyLogo, _, err := image.Decode(bytes.NewReader(gokrazyLogoPNG))
+	if err != nil {
+		return nil, err
+	}
+	logo := image.NewRGBA(scaleImage(gokrazyLogo.Bounds(), w/2, h/3))
+	xdraw.BiLinear.Scale(logo, logo.Bounds(), gokrazyLogo, gokrazyLogo.Bounds(), draw.Src, nil)
+	return &splashScreen{
+		services: newServicesPoller(splashPollInterval, false),
+		logo:     logo,
+		deadline: time.Now().Add(*splashTimeout),
+	}, nil
+}
+
+// serviceUp reports whether svc finished starting: it is running, or it was
+// stopped (e.g. because it exited with status 125 to not be restarted).
+func serviceUp(svc serviceState) bool {
+	return svc.state == "running" || svc.state == "stopped"
+}
+
+// update records the service listing polled at now and reports whether the
+// boot splash is still shown.
+func (s *splashScreen) update(services []serviceState, now time.Time) bool {
+	if s.done {
+		return false
+	}
+	if now.After(s.deadline) {
+		s.done = true
+		return false
+	}
+	up := len(services) > 0
+	for _, svc := range services {
+		up = up && serviceUp(svc)
+	}
+	switch {
+	case !up:
+		s.settled = time.Time{}
+	case s.settled.IsZero():
+		s.settled = now
+	case now.Sub(s.settled) >= splashSettle:
+		s.done = true
+		return false
+	}
+	return true
+}
+
+// drawSplash draws the boot splash into the buffer and reports whether it is
+// still shown. Once it returns false, the pages are displayed instead.
+func (d *statusDrawer) drawSplash() bool {
+	s := d.splash
+	services, updated, _ := s.services.get()
+	if !s.update(services, time.Now()) {
+		slog.Info("boot splash done")
+		return false
+	}
+
+	dc := d.overlayContext(d.bounds)
+	em := 16 * d.scaleFactor
+	dc.SetColor(d.bgcolor)
+	dc.Clear()
+
+	lr := s.logo.Bounds()
+	y := d.h / 10
+	dc.DrawImage(s.logo, (d.w-lr.Dx())/2, y)
+	y += lr.Dy()
+
+	dc.SetFontFace(d.faces.large)
+	dc.SetRGB(1, 1, 1)
+	fy := float64(y) + 3*em
+	dc.DrawStringAnchored(fitString(dc, d.hostname+" is starting…", float64(d.w)-4*em), float64(d.w)/2, fy, 0.5, 0)
+
+	dc.SetFontFace(d.faces.text)
+	lineHeight := dc.FontHeight() * lineSpacing
+	fy += 2 * em
+	if updated.IsZero() {
+		setColor(dc, "darkgray")
+		dc.DrawStringAnchored("waiting for gokrazy…", float64(d.w)/2, fy+lineHeight, 0.5, 0)
+		d.drawOverlay(dc, d.bounds, draw.Src)
+		return true
+	}
+
+	up := 0
+	for _, svc := range services {
+		if serviceUp(svc) {
+			up++
+		}
+	}
+	barW := float64(d.w) * 0.6
+	barX := (float64(d.w) - barW) / 2
+	dc.SetRGBA(1, 1, 1, 0.2)
+	dc.DrawRoundedRectangle(barX, fy, barW, em, em/2)
+	dc.Fill()
+	if up > 0 {
+		setColor(dc, "green")
+		dc.DrawRoundedRectangle(barX, fy, barW*float64(up)/float64(len(services)), em, em/2)
+		dc.Fill()
+	}
+	fy += em + lineHeight
+	dc.SetRGB(1, 1, 1)
+	dc.DrawStringAnchored(fmt.Sprintf("%d of %d services up", up, len(services)), float64(d.w)/2, fy, 0.5, 0)
+
+	// list the services in as many columns as fit
+	var colW float64
+	for _, svc := range services {
+		if w, _ := dc.MeasureString("● " + svc.name); w > colW {
+			colW = w
+		}
+	}
+	colW += 2 * em
+	cols := int((float64(d.w) - 4*em) / colW)
+	if cols < 1 {
+		cols = 1
+	}
+	if cols > len(services) {
+		cols = len(services)
+	}
+	x0 := (float64(d.w) - float64(cols)*colW) / 2
+	fy += lineHeight
+	rows := int((float64(d.h) - fy - em) / lineHeight)
+	for idx, svc := range services {
+		col, row := idx%cols, idx/cols
+		if row >= rows {
+			break
+		}
+		color := "yellow" // exited, i.e. restarting
+		switch svc.state {
+		case "running":
+			color = "green"
+		case "stopped":
+			color = "darkgray"
+		}
+		x := x0 + float64(col)*colW
+		ly := fy + float64(row+1)*lineHeight
+		setColor(dc, color)
+		dc.DrawString("●", x, ly)
+		dc.SetRGB(1, 1, 1)
+		dc.DrawString(fitString(dc, svc.name, colW-3*em), x+1.5*em, ly)
+	}
+	d.drawOverlay(dc, d.bounds, draw.Src)
+	return true
+}
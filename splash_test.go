@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplashUpdate(t *testing.T) {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	running := serviceState{name: "fbstatus", state: "running"}
+	exited := serviceState{name: "backupd", state: "exited"}
+	stopped := serviceState{name: "oneshot", state: "stopped"}
+
+	for _, tt := range []struct {
+		desc     string
+		services [][]serviceState // polled every second
+		want     bool
+	}{
+		{
+			desc:     "no services yet",
+			services: [][]serviceState{nil, nil, nil, nil, nil},
+			want:     true,
+		},
+		{
+			desc: "settled",
+			services: [][]serviceState{
+				{running, exited},
+				{running, stopped},
+				{running, stopped},
+				{running, stopped},
+				{running, stopped},
+			},
+			want: false,
+		},
+		{
+			desc: "restarting",
+			services: [][]serviceState{
+				{running, running},
+				{running, running},
+				{running, exited},
+				{running, running},
+				{running, running},
+			},
+			want: true,
+		},
+	} {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := &splashScreen{deadline: start.Add(time.Minute)}
+			var got bool
+			for idx, services := range tt.services {
+				got = s.update(services, start.Add(time.Duration(idx)*time.Second))
+			}
+			if got != tt.want {
+				t.Errorf("update() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		s := &splashScreen{deadline: start.Add(time.Minute)}
+		if !s.update(nil, start) {
+			t.Fatalf("update() = false before the deadline")
+		}
+		if s.update(nil, start.Add(2*time.Minute)) {
+			t.Fatalf("update() = true after the deadline")
+		}
+		if s.update([]serviceState{exited}, start) {
+			t.Fatalf("update() = true after the splash ended")
+		}
+	})
+}
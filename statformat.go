@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gokrazy/stat"
+)
+
+var statFormatFlag = flag.String("stat-format",
+	"",
+	"comma-separated list of formatting options for columns of the resource usage table, each specified as metric=options with options separated by colons: bits (net.* only: bits instead of bytes per second), si or iec (prefixes in steps of 1000 or 1024) and a number (the maximum number of decimal places). A module name applies to all its columns, e.g. net=bits:si,mem=iec:1")
+
+// statFormat overrides how a column of the resource usage table is formatted.
+type statFormat struct {
+	bits     bool
+	base     float64 // 1000 (si), 1024 (iec) or 0 to keep the default
+	decimals int     // maximum number of decimal places, or -1 for the default
+}
+
+// statFormats are the -stat-format overrides by metric (e.g. net.recv).
+var statFormats map[string]*statFormat
+
+// statMagnitudeColors are the colors of values by magnitude (B, k, M, G, T),
+// like the stat package uses.
+var statMagnitudeColors = []string{"red", "yellow", "green", "blue", "cyan"}
+
+func parseStatFormat(metric, s string) (*statFormat, error) {
+	f := &statFormat{decimals: -1}
+	for _, opt := range strings.Split(s, ":") {
+		switch opt {
+		case "bits":
+			if !strings.HasPrefix(metric, "net.") {
+				return nil, fmt.Errorf("%s: bits are only supported for net columns", metric)
+			}
+			f.bits = true
+		case "bytes":
+			f.bits = false
+		case "si":
+			f.base = 1000
+		case "iec":
+			f.base = 1024
+		default:
+			n, err := strconv.Atoi(opt)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("%s: unknown option %q, expected bits, bytes, si, iec or a number of decimal places", metric, opt)
+			}
+			f.decimals = n
+		}
+	}
+	return f, nil
+}
+
+func parseStatFormats(spec string) (map[string]*statFormat, error) {
+	result := make(map[string]*statFormat)
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed stat format %q: expected metric=options", s)
+		}
+		var metrics []string
+		for _, cols := range statColumns {
+			for _, col := range cols {
+				if col == name || strings.HasPrefix(col, name+".") {
+					metrics = append(metrics, col)
+				}
+			}
+		}
+		if len(metrics) == 0 {
+			return nil, fmt.Errorf("unknown column %q in -stat-format", name)
+		}
+		if strings.HasPrefix(name, "cpu") {
+			return nil, fmt.Errorf("-stat-format: cpu columns are percentages and cannot be formatted")
+		}
+		for _, metric := range metrics {
+			f, err := parseStatFormat(metric, value)
+			if err != nil {
+				return nil, err
+			}
+			result[metric] = f
+		}
+	}
+	return result, nil
+}
+
+// scaleStatValue shortens v to at most width characters by dividing it by
+// base (and counting the magnitude) as often as needed, with at most decimals
+// decimal places (or as many as fit if decimals is negative).
+func scaleStatValue(v float64, width int, base float64, decimals int) (string, int) {
+	magnitude := 0
+	for len(strconv.FormatFloat(v, 'f', 0, 64)) > width && magnitude < len(statMagnitudeColors)-1 {
+		v /= base
+		magnitude++
+	}
+	if decimals < 0 || decimals > width {
+		decimals = width
+	}
+	for ; decimals > 0; decimals-- {
+		if s := strconv.FormatFloat(v, 'f', decimals, 64); len(s) <= width {
+			return s, magnitude
+		}
+	}
+	return strconv.FormatFloat(v, 'f', 0, 64), magnitude
+}
+
+// render formats col like the stat package does, but with the units, prefixes
+// and decimal places of f. color renders a colored segment.
+func (f *statFormat) render(col stat.Col, color func(col, text string) string) string {
+	width := col.Width
+	v := statColValue(col)
+	if v == 0 {
+		return color("darkgray", fmt.Sprintf("%*d", width, 0))
+	}
+	base := f.base
+	if base == 0 {
+		base = 1024
+		if col.Unit == stat.UnitMetric {
+			base = 1000
+		}
+	}
+	suffixes := []string{"B", "k", "M", "G", "T"}
+	switch {
+	case f.bits:
+		v *= 8
+		suffixes[0] = "b"
+	case col.Unit == stat.UnitMetric:
+		suffixes[0] = " "
+	}
+	decimals := f.decimals
+	if decimals < 0 && col.Unit != stat.UnitBytesFloat {
+		decimals = 0 // like the stat package, which formats integers as such
+	}
+	width-- // for the unit suffix
+	text, magnitude := scaleStatValue(v, width, base, decimals)
+	return color(statMagnitudeColors[magnitude], fmt.Sprintf("%*s", width, text)) +
+		color("darkgray", suffixes[magnitude])
+}
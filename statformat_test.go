@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gokrazy/stat"
+)
+
+func TestParseStatFormats(t *testing.T) {
+	formats, err := parseStatFormats("net=bits:si,mem.used=iec:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, metric := range []string{"net.recv", "net.send"} {
+		if f := formats[metric]; f == nil || !f.bits || f.base != 1000 || f.decimals != -1 {
+			t.Errorf("format of %s = %+v, want bits, si", metric, f)
+		}
+	}
+	if f := formats["mem.used"]; f == nil || f.bits || f.base != 1024 || f.decimals != 1 {
+		t.Errorf("format of mem.used = %+v, want iec, 1 decimal place", f)
+	}
+	if f := formats["mem.free"]; f != nil {
+		t.Errorf("format of mem.free = %+v, want none", f)
+	}
+
+	for _, spec := range []string{
+		"net",
+		"bogus=si",
+		"cpu.usr=1",
+		"mem=bits",
+		"disk=kibibytes",
+		"disk=-1",
+	} {
+		if _, err := parseStatFormats(spec); err == nil {
+			t.Errorf("parseStatFormats(%q) unexpectedly succeeded", spec)
+		}
+	}
+}
+
+func TestStatFormatRender(t *testing.T) {
+	markup := func(color, text string) string { return "$" + color + "$" + text }
+	for _, tt := range []struct {
+		desc string
+		f    statFormat
+		col  stat.Col
+		want string
+	}{
+		{
+			desc: "default",
+			f:    statFormat{decimals: -1},
+			col:  stat.ByteCol(1500000).WithWidth(5),
+			want: "$yellow$1465$darkgray$k",
+		},
+		{
+			desc: "bits, si",
+			f:    statFormat{bits: true, base: 1000, decimals: -1},
+			col:  stat.ByteCol(1500000).WithWidth(5),
+			want: "$green$  12$darkgray$M",
+		},
+		{
+			desc: "iec, 1 decimal place",
+			f:    statFormat{base: 1024, decimals: 1},
+			col:  stat.ByteCol(15872).WithWidth(5),
+			want: "$yellow$15.5$darkgray$k",
+		},
+		{
+			desc: "no decimal places",
+			f:    statFormat{decimals: 0},
+			col:  stat.Col{Type: stat.ColGauge, Unit: stat.UnitBytesFloat, ValFloat64: 15872, Width: 5},
+			want: "$yellow$  16$darkgray$k",
+		},
+		{
+			desc: "as many decimal places as fit",
+			f:    statFormat{decimals: -1},
+			col:  stat.Col{Type: stat.ColGauge, Unit: stat.UnitBytesFloat, ValFloat64: 15872, Width: 5},
+			want: "$yellow$15.5$darkgray$k",
+		},
+		{
+			desc: "per second",
+			f:    statFormat{decimals: -1},
+			col:  stat.MetricCol(250).WithWidth(5),
+			want: "$red$ 250$darkgray$ ",
+		},
+		{
+			desc: "zero",
+			f:    statFormat{bits: true, decimals: -1},
+			col:  stat.ByteCol(0).WithWidth(5),
+			want: "$darkgray$    0",
+		},
+	} {
+		if got := tt.f.render(tt.col, markup); got != tt.want {
+			t.Errorf("%s: render() = %q, want %q", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// statLayout holds the widths (in characters) of the columns of the resource
+// usage table by module, not counting the space which precedes each column.
+type statLayout [][]int
+
+// markupWidth returns the number of characters s (in $color$text markup)
+// displays.
+func markupWidth(s string) int {
+	if !strings.HasPrefix(s, "$") {
+		return utf8.RuneCountInString(s)
+	}
+	width := 0
+	for idx, field := range strings.Split(strings.TrimPrefix(s, "$"), "$") {
+		if idx%2 == 1 {
+			width += utf8.RuneCountInString(field)
+		}
+	}
+	return width
+}
+
+// newStatLayout returns the layout in which the header and all of rows (like
+// statusDrawer.last) fit without overflowing into neighboring columns.
+func newStatLayout(rows [][][]string) statLayout {
+	l := make(statLayout, len(statColumns))
+	for modIdx, metrics := range statColumns {
+		for _, metric := range metrics {
+			_, label, _ := strings.Cut(metric, ".")
+			l[modIdx] = append(l[modIdx], len(label))
+		}
+	}
+	for _, row := range rows {
+		for len(l) < len(row) {
+			l = append(l, nil)
+		}
+		for modIdx, modcols := range row {
+			if l.spans(modIdx, modcols) {
+				continue
+			}
+			for colIdx, colored := range modcols {
+				if colIdx == len(l[modIdx]) {
+					l[modIdx] = append(l[modIdx], 0)
+				}
+				if w := markupWidth(colored); w > l[modIdx][colIdx] {
+					l[modIdx][colIdx] = w
+				}
+			}
+		}
+	}
+	// Placeholders (see statPlaceholderCol) span their module, which is
+	// widened in its last column if they do not fit.
+	for _, row := range rows {
+		for modIdx, modcols := range row {
+			if !l.spans(modIdx, modcols) || len(l[modIdx]) == 0 {
+				continue
+			}
+			if extra := markupWidth(strings.Join(modcols, "")) - l.moduleWidth(modIdx); extra > 0 {
+				l[modIdx][len(l[modIdx])-1] += extra
+			}
+		}
+	}
+	return l
+}
+
+// spans reports whether modcols has fewer columns than module modIdx, i.e.
+// is a placeholder which spans the entire module.
+func (l statLayout) spans(modIdx int, modcols []string) bool {
+	return len(modcols) < len(l[modIdx])
+}
+
+// moduleWidth returns the width of module modIdx, not counting the space
+// which precedes its first column.
+func (l statLayout) moduleWidth(modIdx int) int {
+	width := -1
+	for _, w := range l[modIdx] {
+		width += 1 + w
+	}
+	if width < 0 {
+		return 0
+	}
+	return width
+}
+
+// header returns the header of the resource usage table in layout l.
+func (l statLayout) header() string {
+	return l.labels(func(metric string) string {
+		_, label, _ := strings.Cut(metric, ".")
+		return label
+	})
+}
+
+// units returns the row below the header, which names the unit of each
+// column (see statUnit).
+func (l statLayout) units() string {
+	return l.labels(statUnit)
+}
+
+// labels returns a line with the label of each column in layout l, as
+// returned by label for the column's metric, separating modules like the
+// header.
+func (l statLayout) labels(label func(metric string) string) string {
+	var b strings.Builder
+	for modIdx, widths := range l {
+		if modIdx > 0 {
+			b.WriteString(" | ")
+		}
+		for colIdx, w := range widths {
+			var text string
+			if modIdx < len(statColumns) && colIdx < len(statColumns[modIdx]) {
+				text = label(statColumns[modIdx][colIdx])
+			}
+			fmt.Fprintf(&b, " %-*s", w, text)
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// row returns row (one element per module, in $color$text markup) aligned to
+// layout l: columns are right-aligned, and modules are followed by three
+// spaces (the width of the separators in the header).
+func (l statLayout) row(row [][]string) string {
+	var b strings.Builder
+	for modIdx, modcols := range row {
+		if modIdx >= len(l) {
+			break
+		}
+		if l.spans(modIdx, modcols) {
+			text := strings.Join(modcols, "")
+			b.WriteString("$$" + strings.Repeat(" ", 1+l.moduleWidth(modIdx)-markupWidth(text)) + text)
+		} else {
+			for colIdx, colored := range modcols {
+				if colIdx >= len(l[modIdx]) {
+					break
+				}
+				b.WriteString("$$" + strings.Repeat(" ", 1+l[modIdx][colIdx]-markupWidth(colored)) + colored)
+			}
+		}
+		b.WriteString("$$   ")
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkupWidth(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want int
+	}{
+		{"plain", 5},
+		{"$yellow$1465$darkgray$k", 5},
+		{"$$ °C", 3},
+		{"", 0},
+	} {
+		if got := markupWidth(tt.s); got != tt.want {
+			t.Errorf("markupWidth(%q) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestStatLayout(t *testing.T) {
+	narrow := [][]string{
+		{"$red$ 26", "$red$  4", "$green$ 68", "$darkgray$  0", "$darkgray$  0"},
+		{"$green$ 745$darkgray$M", "$green$1687$darkgray$M"},
+	}
+	wide := [][]string{
+		{"$red$100", "$red$  4", "$green$ 68", "$darkgray$  0", "$darkgray$  0"},
+		{"$green$123456$darkgray$M", "$green$1687$darkgray$M"},
+	}
+
+	l := newStatLayout([][][]string{narrow})
+	if got, want := l.header(), " usr sys idl wai stl |  read  writ"; !strings.HasPrefix(got, want) {
+		t.Errorf("header = %q, want prefix %q", got, want)
+	}
+
+	// the header reflows to fit the widest value
+	l = newStatLayout([][][]string{narrow, wide})
+	if got, want := l[1][0], len("123456M"); got != want {
+		t.Errorf("disk.read width = %d, want %d", got, want)
+	}
+	header := l.header()
+	if got, want := header, " usr sys idl wai stl |  read    writ"; !strings.HasPrefix(got, want) {
+		t.Errorf("header = %q, want prefix %q", got, want)
+	}
+	// columns of all rows end where the header's columns end
+	sep := strings.Index(header, "writ") + l[1][1]
+	for _, row := range [][][]string{narrow, wide} {
+		line := l.row(row)
+		if got := markupWidth(line); got < sep {
+			t.Fatalf("row %q too short", line)
+		}
+		var text string
+		for idx, field := range strings.Split(strings.TrimPrefix(line, "$"), "$") {
+			if idx%2 == 1 {
+				text += field
+			}
+		}
+		if !strings.HasSuffix(text[:sep], "1687M") {
+			t.Errorf("row %q: disk.writ does not end at column %d", text, sep)
+		}
+	}
+}
+
+func TestStatLayoutUnits(t *testing.T) {
+	defer func(formats map[string]*statFormat) { statFormats = formats }(statFormats)
+	statFormats = nil
+	l := newStatLayout(nil)
+	if got, want := l.units(), " %   %   %   %   %   |  B/s  B/s  |  /s  /s  |  B/s  B/s  |  B    B    B    B"; got != want {
+		t.Errorf("units = %q, want %q", got, want)
+	}
+	statFormats = map[string]*statFormat{"net.recv": {bits: true, decimals: -1}}
+	if got, want := l.units(), "|  b/s  B/s  |"; !strings.Contains(got, want) {
+		t.Errorf("units = %q, want %q", got, want)
+	}
+}
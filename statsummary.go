@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/gokrazy/stat"
+)
+
+// statAverageWindow is the period over which the resource usage table
+// averages each column.
+const statAverageWindow = time.Minute
+
+type statSample struct {
+	t time.Time
+	v float64
+}
+
+// statSummary tracks the values of the resource usage columns over
+// statAverageWindow and their peaks since fbstatus started, so that the table
+// shows sustained load, not only the most recent seconds.
+type statSummary struct {
+	samples map[string][]statSample // by metric, oldest first
+	peaks   map[string]float64
+	cols    map[string]stat.Col // most recent column by metric, for formatting
+}
+
+func newStatSummary() *statSummary {
+	return &statSummary{
+		samples: make(map[string][]statSample),
+		peaks:   make(map[string]float64),
+		cols:    make(map[string]stat.Col),
+	}
+}
+
+// add records the value of column col of metric at now.
+func (s *statSummary) add(metric string, col stat.Col, now time.Time) {
+	v := statColValue(col)
+	s.samples[metric] = append(s.samples[metric], statSample{t: now, v: v})
+	if peak, ok := s.peaks[metric]; !ok || v > peak {
+		s.peaks[metric] = v
+	}
+	s.cols[metric] = col
+}
+
+// average returns the average of metric over statAverageWindow before now.
+func (s *statSummary) average(metric string, now time.Time) (float64, bool) {
+	samples := s.samples[metric]
+	for len(samples) > 0 && now.Sub(samples[0].t) > statAverageWindow {
+		samples = samples[1:]
+	}
+	s.samples[metric] = samples
+	if len(samples) == 0 {
+		return 0, false
+	}
+	var sum float64
+	for _, sample := range samples {
+		sum += sample.v
+	}
+	return sum / float64(len(samples)), true
+}
+
+// withStatValue returns col with its value replaced by v, so that averages
+// and peaks are formatted like the column.
+func withStatValue(col stat.Col, v float64) stat.Col {
+	if col.Type == stat.ColPercentage || col.Unit == stat.UnitBytesFloat {
+		col.ValFloat64 = v
+	} else {
+		col.ValU64 = uint64(math.Round(v))
+	}
+	return col
+}
+
+// rows returns the averages and the peaks as rows of the resource usage table
+// (like statusDrawer.last). Modules without samples are left blank.
+func (s *statSummary) rows(now time.Time) (averages, peaks [][]string) {
+	for _, metrics := range statColumns {
+		var avgcols, peakcols []string
+		for _, metric := range metrics {
+			avg, ok := s.average(metric, now)
+			if !ok {
+				avgcols, peakcols = nil, nil
+				break
+			}
+			col := s.cols[metric]
+			avgcols = append(avgcols, renderStatCol(metric, withStatValue(col, avg)))
+			peakcols = append(peakcols, renderStatCol(metric, withStatValue(col, s.peaks[metric])))
+		}
+		if avgcols == nil {
+			blank := statPlaceholderCol("darkgray", "")
+			avgcols, peakcols = []string{blank}, []string{blank}
+		}
+		averages = append(averages, avgcols)
+		peaks = append(peaks, peakcols)
+	}
+	return averages, peaks
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gokrazy/stat"
+)
+
+func TestStatSummary(t *testing.T) {
+	s := newStatSummary()
+	start := time.Now()
+	for idx, v := range []uint64{100, 900, 200, 400} {
+		s.add("net.recv", stat.ByteCol(v).WithWidth(5), start.Add(time.Duration(idx)*20*time.Second))
+	}
+	now := start.Add(60 * time.Second)
+	if got, ok := s.average("net.recv", now); !ok || got != 400 {
+		t.Errorf("average = %v, %v, want 400 (all samples)", got, ok)
+	}
+	// the first sample drops out of the window
+	now = start.Add(70 * time.Second)
+	if got, ok := s.average("net.recv", now); !ok || got != 500 {
+		t.Errorf("average = %v, %v, want 500", got, ok)
+	}
+	if got, want := s.peaks["net.recv"], 900.0; got != want {
+		t.Errorf("peak = %v, want %v", got, want)
+	}
+	if _, ok := s.average("net.recv", now.Add(time.Hour)); ok {
+		t.Errorf("average unexpectedly available after the window")
+	}
+	if got, want := s.peaks["net.recv"], 900.0; got != want {
+		t.Errorf("peak after the window = %v, want %v (session peak)", got, want)
+	}
+}
+
+func TestWithStatValue(t *testing.T) {
+	if got := withStatValue(stat.ByteCol(1), 2.6); got.ValU64 != 3 {
+		t.Errorf("withStatValue(ByteCol) = %d, want 3", got.ValU64)
+	}
+	pct := stat.Col{Type: stat.ColPercentage, ValFloat64: 1}
+	if got := withStatValue(pct, 2.5); got.ValFloat64 != 2.5 {
+		t.Errorf("withStatValue(percentage) = %v, want 2.5", got.ValFloat64)
+	}
+}
+
+func TestStatSummaryRows(t *testing.T) {
+	s := newStatSummary()
+	now := time.Now()
+	s.add("net.recv", stat.ByteCol(0).WithWidth(5), now)
+	s.add("net.send", stat.ByteCol(0).WithWidth(5), now)
+	averages, peaks := s.rows(now)
+	if len(averages) != len(statColumns) || len(peaks) != len(statColumns) {
+		t.Fatalf("rows returned %d/%d modules, want %d", len(averages), len(peaks), len(statColumns))
+	}
+	for modIdx, metrics := range statColumns {
+		want := 1 // blank placeholder
+		if metrics[0] == "net.recv" {
+			want = len(metrics)
+		}
+		if got := len(averages[modIdx]); got != want {
+			t.Errorf("module %d: %d average columns, want %d", modIdx, got, want)
+		}
+		if got := len(peaks[modIdx]); got != want {
+			t.Errorf("module %d: %d peak columns, want %d", modIdx, got, want)
+		}
+	}
+}
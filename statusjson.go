@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gokrazy/gokrazy"
+)
+
+// panelStatus records what a panel drew (via drawTitle, drawMessage and
+// drawTable), for /status.json.
+type panelStatus struct {
+	Title    string        `json:"title,omitempty"`
+	Messages []string      `json:"messages,omitempty"`
+	Tables   []tableStatus `json:"tables,omitempty"`
+	Error    string        `json:"error,omitempty"` // if the panel fails
+}
+
+type tableStatus struct {
+	Header []string       `json:"header"`
+	Rows   [][]cellStatus `json:"rows"`
+}
+
+type cellStatus struct {
+	Text  string `json:"text"`
+	Color string `json:"color,omitempty"`
+}
+
+func (p *panelStatus) addTable(header []string, rows [][]cell) {
+	table := tableStatus{
+		Header: header,
+		Rows:   make([][]cellStatus, 0, len(rows)),
+	}
+	for _, row := range rows {
+		cells := make([]cellStatus, 0, len(row))
+		for _, c := range row {
+			cells = append(cells, cellStatus{Text: c.text, Color: c.color})
+		}
+		table.Rows = append(table.Rows, cells)
+	}
+	p.Tables = append(p.Tables, table)
+}
+
+// infoStatus is a host information line.
+type infoStatus struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+	// Color is the most severe color used in the line (red, yellow, green,
+	// darkgray), or empty if the line is all white.
+	Color string `json:"color,omitempty"`
+}
+
+// colorSeverity orders colors by how alarming they are.
+var colorSeverity = map[string]int{
+	"darkgray": 1,
+	"green":    2,
+	"yellow":   3,
+	"red":      4,
+}
+
+// parseInfoLine converts a host information line in $color$text markup into
+// its label (the text before the first colon), value and color.
+func parseInfoLine(line string) infoStatus {
+	var text strings.Builder
+	var color string
+	for _, span := range markupSpans(line) {
+		text.WriteString(span.Text)
+	}
+	if strings.HasPrefix(line, "$") {
+		for idx, field := range strings.Split(strings.TrimPrefix(line, "$"), "$") {
+			if idx%2 == 0 && colorSeverity[field] > colorSeverity[color] {
+				color = field
+			}
+		}
+	}
+	info := infoStatus{Value: text.String(), Color: color}
+	if label, value, ok := strings.Cut(info.Value, ": "); ok {
+		info.Label = label
+		info.Value = value
+	}
+	return info
+}
+
+type status struct {
+	Hostname         string             `json:"hostname"`
+	Model            string             `json:"model"`
+	Time             time.Time          `json:"time"`
+	Uptime           string             `json:"uptime,omitempty"`
+	Page             string             `json:"page"`
+	Info             []infoStatus       `json:"info"`
+	PrivateAddresses []string           `json:"private_addresses"`
+	PublicAddresses  []string           `json:"public_addresses"`
+	Resources        map[string]float64 `json:"resources"`
+	Panels           []panelStatus      `json:"panels,omitempty"`
+}
+
+// currentStatus returns the data which fbstatus displays, in structured form.
+func (d *statusDrawer) currentStatus() status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	up, _ := uptime()
+	pg := d.currentPage()
+	st := status{
+		Hostname:  d.hostname,
+		Model:     gokrazy.Model(),
+		Time:      time.Now(),
+		Uptime:    up,
+		Page:      pg.name,
+		Resources: d.resources,
+	}
+	for _, line := range d.infoLines() {
+		st.Info = append(st.Info, parseInfoLine(line))
+	}
+	st.PrivateAddresses, st.PublicAddresses = interfaceAddrs()
+	if pg == d.lastPage {
+		st.Panels = pg.status
+	}
+	return st
+}
+
+// serveStatusJSON serves the data which fbstatus displays as JSON, so that
+// scripts can consume the same view.
+func (d *statusDrawer) serveStatusJSON(w http.ResponseWriter, r *http.Request) {
+	b, err := json.MarshalIndent(d.currentStatus(), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseInfoLine(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		want infoStatus
+	}{
+		{
+			line: "$$clock: $green$synchronized$$ (kernel), offset $yellow$150ms",
+			want: infoStatus{Label: "clock", Value: "synchronized (kernel), offset 150ms", Color: "yellow"},
+		},
+		{
+			line: "$$/perm: $red$not mounted",
+			want: infoStatus{Label: "/perm", Value: "not mounted", Color: "red"},
+		},
+		{
+			line: "update: up to date",
+			want: infoStatus{Label: "update", Value: "up to date"},
+		},
+	} {
+		if got := parseInfoLine(tt.line); got != tt.want {
+			t.Errorf("parseInfoLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestStatusJSON(t *testing.T) {
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	*pagesFlag = "services+version"
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.draw1(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	d.httpHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/status.json", nil))
+	var st status
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := st.Hostname, d.hostname; got != want {
+		t.Errorf("hostname = %q, want %q", got, want)
+	}
+	if got, want := st.Page, "services+version"; got != want {
+		t.Errorf("page = %q, want %q", got, want)
+	}
+	if len(st.Info) == 0 || st.Info[0].Label != "clock" {
+		t.Errorf("info = %+v, want clock first", st.Info)
+	}
+	if _, ok := st.Resources["cpu.usr"]; !ok {
+		t.Errorf("resources = %v, want cpu.usr", st.Resources)
+	}
+	if got, want := len(st.Panels), 2; got != want {
+		t.Fatalf("len(panels) = %d, want %d", got, want)
+	}
+	if got, want := st.Panels[1].Title, "Versions"; got != want {
+		t.Errorf("panel title = %q, want %q", got, want)
+	}
+}
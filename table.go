@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fogleman/gg"
+)
+
+// A cell is one field of a table row.
+type cell struct {
+	text  string
+	color string // name as per colorNameToRGBA, or empty for white
+
+	// scroll makes the column of the cell narrower if the table does not fit
+	// and the text scroll (as a marquee) if it does not fit into its column,
+	// instead of being clipped. Use it for text which cannot be shortened
+	// meaningfully, e.g. long names or URLs.
+	scroll bool
+}
+
+// setColor selects the named color (as per colorNameToRGBA) for drawing, or
+// white if name is empty.
+func setColor(dc *gg.Context, name string) {
+	if name == "" {
+		dc.SetRGB(1, 1, 1)
+		return
+	}
+	col := colorNameToRGBA[name]
+	dc.SetRGB255(int(col.R), int(col.G), int(col.B))
+}
+
+// drawTable draws header and rows in the monospace font, starting at vertical
+// position y. Columns are as wide as their widest field. Rows which do not
+// fit into dc are omitted and summarized in a final line. Columns containing
+// cells with scroll set are narrowed so that the table fits (see
+// shrinkColumns). drawTable returns the vertical position following the
+// table.
+func (d *statusDrawer) drawTable(dc *gg.Context, y float64, header []string, rows [][]cell) float64 {
+	if d.recording != nil {
+		d.recording.addTable(header, rows)
+	}
+	dc.Push()
+	defer dc.Pop()
+	dc.SetFontFace(d.monoface)
+	em, _ := dc.MeasureString("m")
+	lineHeight := dc.FontHeight() * lineSpacing
+
+	widths := make([]int, len(header))
+	for idx, hdr := range header {
+		widths[idx] = utf8.RuneCountInString(hdr)
+	}
+	for _, row := range rows {
+		for idx, c := range row {
+			if idx >= len(widths) {
+				break
+			}
+			if w := utf8.RuneCountInString(c.text); w > widths[idx] {
+				widths[idx] = w
+			}
+		}
+	}
+
+	if *marqueeEnabled {
+		scrolling := make([]bool, len(header))
+		for _, row := range rows {
+			for idx, c := range row {
+				if idx < len(scrolling) && c.scroll {
+					scrolling[idx] = true
+				}
+			}
+		}
+		// the table starts at 3 ems, and is followed by as much space
+		shrinkColumns(widths, header, scrolling, int(float64(dc.Width())/em)-6)
+	}
+
+	drawRow := func(row []cell) {
+		x := 3 * em
+		for idx, c := range row {
+			if idx >= len(widths) {
+				break
+			}
+			if c.scroll && *marqueeEnabled && utf8.RuneCountInString(c.text) > widths[idx] {
+				d.drawMarquee(dc, d.monoface, c.text, c.color, x, y, float64(widths[idx])*em)
+			} else {
+				setColor(dc, c.color)
+				dc.DrawString(c.text, x, y)
+			}
+			x += float64(widths[idx]+2) * em
+		}
+		y += lineHeight
+	}
+
+	headerRow := make([]cell, len(header))
+	for idx, hdr := range header {
+		headerRow[idx] = cell{text: hdr, color: "darkgray"}
+	}
+	drawRow(headerRow)
+	for idx, row := range rows {
+		if y+lineHeight > float64(dc.Height()) && idx < len(rows)-1 {
+			drawRow([]cell{{
+				text:  fmt.Sprintf("… and %d more", len(rows)-idx),
+				color: "darkgray",
+			}})
+			break
+		}
+		drawRow(row)
+	}
+	return y
+}
+
+// shrinkColumns narrows the columns for which scrolling is true (from left to
+// right, but not below their header width) so that the table, whose columns
+// are separated by 2 characters, is at most maxWidth characters wide.
+func shrinkColumns(widths []int, header []string, scrolling []bool, maxWidth int) {
+	total := -2
+	for _, w := range widths {
+		total += w + 2
+	}
+	for idx, w := range widths {
+		excess := total - maxWidth
+		if excess <= 0 {
+			return
+		}
+		if !scrolling[idx] {
+			continue
+		}
+		min := utf8.RuneCountInString(header[idx])
+		if w-excess > min {
+			min = w - excess
+		}
+		if min < w {
+			widths[idx] = min
+			total -= w - min
+		}
+	}
+}
+
+// tablePageInterval is how long each page of a paginated table is shown.
+const tablePageInterval = 10 * time.Second
+
+// drawPagedTable is like drawTable, but instead of omitting the rows which
+// do not fit into dc, it cycles through pages of rows.
+func (d *statusDrawer) drawPagedTable(dc *gg.Context, y float64, header []string, rows [][]cell) float64 {
+	dc.Push()
+	dc.SetFontFace(d.monoface)
+	lineHeight := dc.FontHeight() * lineSpacing
+	dc.Pop()
+	// reserve one line for the header and one for the page indicator
+	perPage := int((float64(dc.Height())-y)/lineHeight) - 2
+	if perPage < 1 || len(rows) <= perPage+1 {
+		return d.drawTable(dc, y, header, rows)
+	}
+	if rec := d.recording; rec != nil {
+		// record all rows, not just the current page
+		rec.addTable(header, rows)
+		d.recording = nil
+		defer func() { d.recording = rec }()
+	}
+	pages := (len(rows) + perPage - 1) / perPage
+	idx := int(time.Since(d.started)/tablePageInterval) % pages
+	end := (idx + 1) * perPage
+	if end > len(rows) {
+		end = len(rows)
+	}
+	y = d.drawTable(dc, y, header, rows[idx*perPage:end])
+	d.drawMessage(dc, y, fmt.Sprintf("page %d of %d", idx+1, pages))
+	return y + lineHeight
+}
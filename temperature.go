@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// temperatureInterval is how often a sample is recorded into the
+	// temperature history.
+	temperatureInterval = 10 * time.Second
+
+	// temperatureHistory is how much time the temperature graph spans.
+	temperatureHistory = 1 * time.Hour
+)
+
+// temperatureSensor reads the SoC temperature from the first Linux thermal
+// zone, which is the CPU/SoC on the Raspberry Pi and most PCs.
+type temperatureSensor struct {
+	path string
+
+	// state
+	hist       *history
+	sum        float64
+	n          int
+	lastSample time.Time
+}
+
+func newTemperatureSensor() *temperatureSensor {
+	return &temperatureSensor{
+		path: "/sys/class/thermal/thermal_zone0/temp",
+		hist: newHistory(int(temperatureHistory / temperatureInterval)),
+	}
+}
+
+// read returns the current temperature in degrees Celsius. Readings are
+// averaged over temperatureInterval before being recorded in the history.
+func (t *temperatureSensor) read() (float64, error) {
+	b, err := os.ReadFile(t.path)
+	if err != nil {
+		return 0, err
+	}
+	millidegrees, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	celsius := float64(millidegrees) / 1000
+
+	t.sum += celsius
+	t.n++
+	if time.Since(t.lastSample) >= temperatureInterval {
+		t.hist.add(t.sum / float64(t.n))
+		t.sum = 0
+		t.n = 0
+		t.lastSample = time.Now()
+	}
+
+	return celsius, nil
+}
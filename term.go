@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/sys/unix"
+)
+
+// termWidth is the width in pixels of the display which the term subcommand
+// renders off-screen. The height follows from the terminal's aspect ratio.
+const termWidth = 1280
+
+// termCell is one character cell of a terminal.
+type termCell struct {
+	r      rune
+	fg, bg color.RGBA
+}
+
+// termCanvas is the content of a terminal, which ansi converts into escape
+// sequences with 24-bit colors.
+type termCanvas struct {
+	w, h  int
+	bg    color.RGBA
+	cells []termCell
+}
+
+func newTermCanvas(w, h int, bg color.RGBA) *termCanvas {
+	c := &termCanvas{w: w, h: h, bg: bg, cells: make([]termCell, w*h)}
+	for idx := range c.cells {
+		c.cells[idx] = termCell{r: ' ', fg: bg, bg: bg}
+	}
+	return c
+}
+
+// termColor returns the named color (as per colorNameToRGBA), or white if
+// name is empty, like setColor.
+func termColor(name string) color.RGBA {
+	if name == "" {
+		return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	col := colorNameToRGBA[name]
+	return color.RGBA{R: col.R, G: col.G, B: col.B, A: 255}
+}
+
+// text writes s in color fg at column x of row y, shortened (indicated by an
+// ellipsis) to end before column maxX. text returns the column following s.
+func (c *termCanvas) text(x, y, maxX int, s string, fg color.RGBA) int {
+	if maxX > c.w {
+		maxX = c.w
+	}
+	if y < 0 || y >= c.h {
+		return x
+	}
+	if x+utf8.RuneCountInString(s) > maxX {
+		runes := []rune(s)
+		if n := maxX - x - 1; n >= 0 && n < len(runes) {
+			s = string(runes[:n]) + "…"
+		} else {
+			s = ""
+		}
+	}
+	for _, r := range s {
+		if x >= 0 {
+			cell := &c.cells[y*c.w+x]
+			cell.r, cell.fg = r, fg
+		}
+		x++
+	}
+	return x
+}
+
+// markup writes s, which can contain colored segments in the $color$text
+// markup (see drawMarkup), at column x of row y.
+func (c *termCanvas) markup(x, y, maxX int, s string) int {
+	if !strings.HasPrefix(s, "$") {
+		return c.text(x, y, maxX, s, termColor(""))
+	}
+	fg := termColor("")
+	for idx, field := range strings.Split(strings.TrimPrefix(s, "$"), "$") {
+		if idx%2 == 0 {
+			fg = termColor(field)
+			continue
+		}
+		x = c.text(x, y, maxX, field, fg)
+	}
+	return x
+}
+
+// blocks renders the area sr of img into the cells r as block graphics: each
+// cell shows two pixels, the upper half block in the foreground color and
+// the lower half in the background color.
+func (c *termCanvas) blocks(r image.Rectangle, img image.Image, sr image.Rectangle) {
+	r = r.Intersect(image.Rect(0, 0, c.w, c.h))
+	if r.Empty() || sr.Empty() {
+		return
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, r.Dx(), 2*r.Dy()))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), img, sr, draw.Src, nil)
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			cell := termCell{
+				r:  '▀',
+				fg: scaled.RGBAAt(x, 2*y),
+				bg: scaled.RGBAAt(x, 2*y+1),
+			}
+			if cell.fg == cell.bg {
+				cell.r = ' ' // keeps the output readable without colors
+			}
+			c.cells[(r.Min.Y+y)*c.w+r.Min.X+x] = cell
+		}
+	}
+}
+
+// clear resets the cells r to the background color.
+func (c *termCanvas) clear(r image.Rectangle) {
+	r = r.Intersect(image.Rect(0, 0, c.w, c.h))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c.cells[y*c.w+x] = termCell{r: ' ', fg: c.bg, bg: c.bg}
+		}
+	}
+}
+
+// ansi returns the canvas as text with ANSI escape sequences for the colors,
+// one line per row.
+func (c *termCanvas) ansi() []byte {
+	var buf bytes.Buffer
+	for y := 0; y < c.h; y++ {
+		var fg, bg color.RGBA // alpha 0: not yet set in this row
+		for _, cell := range c.cells[y*c.w : (y+1)*c.w] {
+			if cell.fg != fg {
+				fmt.Fprintf(&buf, "\x1b[38;2;%d;%d;%dm", cell.fg.R, cell.fg.G, cell.fg.B)
+				fg = cell.fg
+			}
+			if cell.bg != bg {
+				fmt.Fprintf(&buf, "\x1b[48;2;%d;%d;%dm", cell.bg.R, cell.bg.G, cell.bg.B)
+				bg = cell.bg
+			}
+			buf.WriteRune(cell.r)
+		}
+		buf.WriteString("\x1b[0m")
+		if y < c.h-1 {
+			buf.WriteString("\r\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+// cellRect returns the cells which correspond to the area r of the display.
+func (d *statusDrawer) cellRect(c *termCanvas, r image.Rectangle) image.Rectangle {
+	return image.Rect(
+		r.Min.X*c.w/d.w, r.Min.Y*c.h/d.h,
+		r.Max.X*c.w/d.w, r.Max.Y*c.h/d.h)
+}
+
+// termFrame renders the most recently drawn frame into a w×h terminal. In
+// mode text, the layout of the display is filled with the same text, and only
+// graphics (e.g. the gopher) are shown as block graphics. In mode blocks, the
+// entire frame is shown as block graphics, which is only legible in large
+// terminals.
+func (d *statusDrawer) termFrame(mode string, w, h int) *termCanvas {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bg := color.RGBAModel.Convert(d.bgcolor).(color.RGBA)
+	c := newTermCanvas(w, h, bg)
+	pg := d.lastPage
+	if mode == "blocks" || pg == nil {
+		c.blocks(image.Rect(0, 0, w, h), d.buffer, d.bounds)
+		return c
+	}
+	if pg.panels == nil {
+		d.termStatus(c)
+		return c
+	}
+	for idx, st := range pg.status {
+		r := d.cellRect(c, pg.rects[idx])
+		d.termPanel(c, r, pg.rects[idx], st)
+	}
+	return c
+}
+
+// termStatus renders the classic status view into c, see drawStatus.
+func (d *statusDrawer) termStatus(c *termCanvas) {
+	info := d.cellRect(c, d.areas.info)
+	y := info.Min.Y + 1
+	for _, line := range d.hostLines() {
+		if y >= info.Max.Y {
+			break
+		}
+		c.markup(info.Min.X+2, y, info.Max.X, line)
+		y++
+	}
+
+	ga := d.cellRect(c, d.areas.gopher)
+	c.blocks(ga, d.buffer, d.areas.gopher)
+	c.clear(image.Rect(ga.Min.X, ga.Min.Y, ga.Max.X, ga.Min.Y+2))
+	title := "gokrazy!"
+	c.text(ga.Min.X+(ga.Dx()-len(title))/2, ga.Min.Y+1, ga.Max.X, title, termColor(""))
+
+	stats := d.cellRect(c, d.areas.stats)
+	c.text(stats.Min.X+3, stats.Min.Y+1, stats.Max.X, d.statLayout.header(), termColor(""))
+	c.text(stats.Min.X+3, stats.Min.Y+2, stats.Max.X, d.statLayout.units(), termColor("darkgray"))
+	row := func(y int, row [][]string, label string) {
+		line := d.statLayout.row(row)
+		if label != "" {
+			line += "$darkgray$" + label
+		}
+		c.markup(stats.Min.X+3, y, stats.Max.X, line)
+	}
+	// the averages and peaks go at the bottom, below the most recent rows
+	y = stats.Min.Y + 3
+	summary := stats.Max.Y - 2
+	last := d.last
+	if n := summary - 1 - y; n < len(last) {
+		if n < 0 {
+			n = 0
+		}
+		last = last[len(last)-n:]
+	}
+	for _, lastrow := range last {
+		row(y, lastrow, "")
+		y++
+	}
+	if d.statAverages != nil && summary > stats.Min.Y+2 {
+		row(summary, d.statAverages, "avg 1m")
+		row(summary+1, d.statPeaks, "peak")
+	}
+}
+
+// termPanel renders what a panel drew (see panelStatus) into the cells r.
+// Panels which drew neither messages nor tables (e.g. the clock or a camera)
+// are shown as block graphics of the display area pr.
+func (d *statusDrawer) termPanel(c *termCanvas, r, pr image.Rectangle, st panelStatus) {
+	if len(st.Messages) == 0 && len(st.Tables) == 0 {
+		c.blocks(r, d.buffer, pr)
+		if st.Title == "" {
+			return
+		}
+		c.clear(image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+3))
+	}
+	c.text(r.Min.X+2, r.Min.Y+1, r.Max.X, st.Title, termColor(""))
+	y := r.Min.Y + 3
+	for _, msg := range st.Messages {
+		if y >= r.Max.Y {
+			return
+		}
+		c.text(r.Min.X+2, y, r.Max.X, msg, termColor("darkgray"))
+		y++
+	}
+	for _, table := range st.Tables {
+		widths := make([]int, len(table.Header))
+		for idx, hdr := range table.Header {
+			widths[idx] = utf8.RuneCountInString(hdr)
+		}
+		for _, row := range table.Rows {
+			for idx, cs := range row {
+				if idx < len(widths) && utf8.RuneCountInString(cs.Text) > widths[idx] {
+					widths[idx] = utf8.RuneCountInString(cs.Text)
+				}
+			}
+		}
+		drawRow := func(row []cellStatus) {
+			x := r.Min.X + 2
+			for idx, cs := range row {
+				if idx >= len(widths) {
+					break
+				}
+				c.text(x, y, r.Max.X, cs.Text, termColor(cs.Color))
+				x += widths[idx] + 2
+			}
+			y++
+		}
+		headerRow := make([]cellStatus, len(table.Header))
+		for idx, hdr := range table.Header {
+			headerRow[idx] = cellStatus{Text: hdr, Color: "darkgray"}
+		}
+		drawRow(headerRow)
+		for idx, row := range table.Rows {
+			if y >= r.Max.Y-1 && idx < len(table.Rows)-1 {
+				drawRow([]cellStatus{{
+					Text:  fmt.Sprintf("… and %d more", len(table.Rows)-idx),
+					Color: "darkgray",
+				}})
+				return
+			}
+			drawRow(row)
+		}
+		y++
+	}
+}
+
+// terminalSize returns the size of the terminal f in character cells,
+// falling back to $COLUMNS and $LINES (e.g. without a pseudo terminal) and
+// finally to 80×24.
+func terminalSize(f *os.File) (w, h int) {
+	if ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ); err == nil && ws.Col > 0 && ws.Row > 0 {
+		return int(ws.Col), int(ws.Row)
+	}
+	w, h = 80, 24
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		w = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LINES")); err == nil && n > 0 {
+		h = n
+	}
+	return w, h
+}
+
+// term implements the term subcommand, which renders the status layout to
+// the terminal (e.g. in a breakglass SSH session) instead of the
+// framebuffer.
+func term(args []string) error {
+	fset := flag.NewFlagSet("term", flag.ExitOnError)
+	fset.Usage = func() {
+		fmt.Fprintf(fset.Output(), "usage: fbstatus [flags] term [term flags]\n\n")
+		fmt.Fprintf(fset.Output(), "Renders the pages to the terminal instead of the framebuffer.\n\n")
+		fset.PrintDefaults()
+	}
+	mode := fset.String("mode",
+		"text",
+		"text renders text (e.g. host information and tables) as text and only graphics as block graphics, blocks renders the entire display as block graphics (legible in large terminals only)")
+	once := fset.Bool("once",
+		false,
+		"render a single frame and exit, e.g. for scripts")
+	fset.Parse(args)
+	if *mode != "text" && *mode != "blocks" {
+		return fmt.Errorf("unknown -mode=%q, expected text or blocks", *mode)
+	}
+
+	// Neither animations nor the boot splash make sense in a terminal.
+	*transitionFlag = "none"
+	*splashFlag = false
+
+	// Log messages would garble the output.
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx, canc := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer canc()
+
+	cols, rows := terminalSize(os.Stdout)
+	img := image.NewRGBA(image.Rect(0, 0, termWidth, termWidth*2*rows/cols))
+	drawer, err := newStatusDrawer(img, 0)
+	if err != nil {
+		return err
+	}
+
+	if !*once {
+		// switch to the alternate screen and hide the cursor
+		os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+		defer os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+	}
+	tick := time.Tick(frameInterval)
+	for {
+		if err := drawer.draw1(ctx); err != nil {
+			return err
+		}
+		cols, rows := terminalSize(os.Stdout)
+		out := drawer.termFrame(*mode, cols, rows).ansi()
+		if *once {
+			os.Stdout.Write(append(out, '\n'))
+			return nil
+		}
+		os.Stdout.Write(append([]byte("\x1b[H"), out...))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick:
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestTermCanvas(t *testing.T) {
+	bg := color.RGBA{R: 50, G: 50, B: 50, A: 255}
+	c := newTermCanvas(12, 2, bg)
+	if got, want := c.markup(1, 0, 12, "$red$ab$$cd"), 5; got != want {
+		t.Errorf("markup() = %d, want %d", got, want)
+	}
+	c.text(0, 1, 6, "truncated", termColor(""))
+	var row0, row1 strings.Builder
+	for x := 0; x < c.w; x++ {
+		row0.WriteRune(c.cells[x].r)
+		row1.WriteRune(c.cells[c.w+x].r)
+	}
+	if got, want := row0.String(), " abcd       "; got != want {
+		t.Errorf("row 0 = %q, want %q", got, want)
+	}
+	if got, want := row1.String(), "trunc…      "; got != want {
+		t.Errorf("row 1 = %q, want %q", got, want)
+	}
+	if got, want := c.cells[1].fg, termColor("red"); got != want {
+		t.Errorf("color of a = %v, want %v", got, want)
+	}
+	if got, want := c.cells[3].fg, termColor(""); got != want {
+		t.Errorf("color of c = %v, want %v", got, want)
+	}
+
+	out := c.ansi()
+	if !bytes.Contains(out, []byte("\x1b[38;2;239;41;41ma")) {
+		t.Errorf("ansi() = %q, want a in red", out)
+	}
+	if got, want := bytes.Count(out, []byte("\r\n")), 1; got != want {
+		t.Errorf("ansi() contains %d line breaks, want %d", got, want)
+	}
+}
+
+func TestTermBlocks(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 4))
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	for x := 0; x < 2; x++ {
+		img.SetRGBA(x, 0, red)
+		img.SetRGBA(x, 1, blue)
+		img.SetRGBA(x, 2, blue)
+		img.SetRGBA(x, 3, blue)
+	}
+	c := newTermCanvas(2, 2, color.RGBA{})
+	c.blocks(image.Rect(0, 0, 2, 2), img, img.Bounds())
+	if got, want := c.cells[0], (termCell{r: '▀', fg: red, bg: blue}); got != want {
+		t.Errorf("upper cell = %+v, want %+v", got, want)
+	}
+	if got, want := c.cells[2], (termCell{r: ' ', fg: blue, bg: blue}); got != want {
+		t.Errorf("lower cell = %+v, want %+v", got, want)
+	}
+}
+
+func TestTermFrame(t *testing.T) {
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	*pagesFlag = "services+version"
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.draw1(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c := d.termFrame("text", 80, 24)
+	var text strings.Builder
+	for _, cell := range c.cells {
+		text.WriteRune(cell.r)
+	}
+	if !strings.Contains(text.String(), "Services") {
+		t.Errorf("termFrame() = %q, want the services panel title", text.String())
+	}
+}
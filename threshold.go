@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gokrazy/stat"
+)
+
+var thresholdsFlag = flag.String("thresholds",
+	"",
+	"comma-separated list of thresholds, each specified as metric=warn:crit, from which values are shown in yellow (warn) or red (crit). If warn > crit, lower values are worse. Metrics: cpu.usr, cpu.sys, cpu.idl, cpu.wai, cpu.stl (percent), disk.read, disk.writ, net.recv, net.send (bytes/s), sys.int, sys.csw (per second), mem.used, mem.free, mem.buff, mem.cach (bytes), temperature (°C, default 70:80), load (per CPU, default 0.7:1), perm (percent used, default 80:90), conntrack (percent used, default 80:90), ping (ms), ping-loss (percent, default 1:100) and pressure (percent of time stalled, default 10:25)")
+
+// threshold colors values yellow from warn and red from crit onwards. If
+// warn is larger than crit, lower values are worse (e.g. battery levels).
+type threshold struct {
+	warn, crit float64
+}
+
+func parseThreshold(s string) (*threshold, error) {
+	warn, crit, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("malformed threshold %q: expected warn:crit", s)
+	}
+	var t threshold
+	var err error
+	if t.warn, err = strconv.ParseFloat(warn, 64); err != nil {
+		return nil, fmt.Errorf("malformed threshold %q: %v", s, err)
+	}
+	if t.crit, err = strconv.ParseFloat(crit, 64); err != nil {
+		return nil, fmt.Errorf("malformed threshold %q: %v", s, err)
+	}
+	return &t, nil
+}
+
+// color returns the color for v (as per colorNameToRGBA), or the empty
+// string if v is fine (or t is nil).
+func (t *threshold) color(v float64) string {
+	if t == nil {
+		return ""
+	}
+	if t.warn > t.crit {
+		// lower is worse
+		switch {
+		case v <= t.crit:
+			return "red"
+		case v <= t.warn:
+			return "yellow"
+		}
+		return ""
+	}
+	switch {
+	case v >= t.crit:
+		return "red"
+	case v >= t.warn:
+		return "yellow"
+	}
+	return ""
+}
+
+// statColumns names the columns of the resource usage table, in the order of
+// statexp.DefaultModules.
+var statColumns = [][]string{
+	{"cpu.usr", "cpu.sys", "cpu.idl", "cpu.wai", "cpu.stl"},
+	{"disk.read", "disk.writ"},
+	{"sys.int", "sys.csw"},
+	{"net.recv", "net.send"},
+	{"mem.used", "mem.free", "mem.buff", "mem.cach"},
+}
+
+// defaultThresholds reproduce the coloring fbstatus has always used.
+var defaultThresholds = map[string]*threshold{
+	"load":        {warn: 0.7, crit: 1},
+	"perm":        {warn: 80, crit: 90},
+	"conntrack":   {warn: 80, crit: 90},
+	"ping-loss":   {warn: 1, crit: 100},
+	"temperature": {warn: 70, crit: 80},
+	"pressure":    {warn: 10, crit: 25},
+}
+
+// thresholds are the thresholds in effect, i.e. defaultThresholds overridden
+// by -thresholds.
+var thresholds = defaultThresholds
+
+func knownMetric(metric string) bool {
+	switch metric {
+	case "temperature", "load", "perm", "conntrack", "ping", "ping-loss", "pressure":
+		return true
+	}
+	for _, cols := range statColumns {
+		for _, col := range cols {
+			if col == metric {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseThresholds(spec string) (map[string]*threshold, error) {
+	result := make(map[string]*threshold)
+	for metric, t := range defaultThresholds {
+		result[metric] = t
+	}
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		metric, value, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed threshold %q: expected metric=warn:crit", s)
+		}
+		if !knownMetric(metric) {
+			var known []string
+			for _, cols := range statColumns {
+				known = append(known, cols...)
+			}
+			known = append(known, "temperature", "load", "perm", "conntrack", "ping", "ping-loss")
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown metric %q, known metrics: %s", metric, strings.Join(known, ", "))
+		}
+		t, err := parseThreshold(value)
+		if err != nil {
+			return nil, err
+		}
+		result[metric] = t
+	}
+	return result, nil
+}
+
+// metricColor returns the color for value v of metric, or okColor if v is
+// fine or no threshold is configured for metric.
+func metricColor(metric string, v float64, okColor string) string {
+	if color := thresholds[metric].color(v); color != "" {
+		return color
+	}
+	return okColor
+}
+
+// statColValue returns the numeric value of a resource usage column.
+func statColValue(col stat.Col) float64 {
+	if col.Type == stat.ColPercentage || col.Unit == stat.UnitBytesFloat {
+		return col.ValFloat64
+	}
+	return float64(col.ValU64)
+}
+
+// renderStatCol renders a column of the resource usage table in $color$text
+// markup, formatted as per -stat-format. If a threshold is configured for
+// metric, it replaces the coloring which statexp chooses by magnitude.
+func renderStatCol(metric string, col stat.Col) string {
+	t := thresholds[metric]
+	colorize := func(color, text string) string {
+		// darkgray is used for zero values and unit suffixes
+		if t != nil && color != "darkgray" {
+			color = t.color(statColValue(col)) // white if fine
+		}
+		return "$" + color + "$" + text
+	}
+	if f := statFormats[metric]; f != nil && col.Type == stat.ColGauge {
+		return f.render(col, colorize)
+	}
+	return col.RenderCustom(colorize)
+}
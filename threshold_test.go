@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gokrazy/stat"
+)
+
+func TestParseThresholds(t *testing.T) {
+	got, err := parseThresholds("cpu.usr=50:80,perm=70:95,mem.free=200e6:50e6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		metric string
+		want   threshold
+	}{
+		{"cpu.usr", threshold{warn: 50, crit: 80}},
+		{"perm", threshold{warn: 70, crit: 95}}, // overrides the default
+		{"mem.free", threshold{warn: 200e6, crit: 50e6}},
+		{"load", threshold{warn: 0.7, crit: 1}}, // default
+	} {
+		if got := got[tt.metric]; got == nil || *got != tt.want {
+			t.Errorf("thresholds[%s] = %v, want %v", tt.metric, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"cpu.nice=50:80", "cpu.usr=50", "cpu.usr"} {
+		if _, err := parseThresholds(spec); err == nil {
+			t.Errorf("parseThresholds(%q) did not return an error", spec)
+		}
+	}
+}
+
+func TestRenderStatCol(t *testing.T) {
+	defer func(orig map[string]*threshold) { thresholds = orig }(thresholds)
+	var err error
+	thresholds, err = parseThresholds("cpu.usr=50:80,mem.free=200e6:50e6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpu := func(v float64) stat.Col {
+		return stat.Col{Type: stat.ColPercentage, ValFloat64: v, Width: 3, Scale: 34}
+	}
+	mem := func(v float64) stat.Col {
+		return stat.Col{Type: stat.ColGauge, Unit: stat.UnitBytesFloat, ValFloat64: v, Width: 5}
+	}
+	for _, tt := range []struct {
+		metric string
+		col    stat.Col
+		want   string
+	}{
+		{"cpu.usr", cpu(10), "$$ 10"},
+		{"cpu.usr", cpu(60), "$yellow$ 60"},
+		{"cpu.usr", cpu(90), "$red$ 90"},
+		{"cpu.usr", cpu(0), "$darkgray$  0"},
+		{"cpu.sys", cpu(60), "$yellow$ 60"}, // statexp coloring
+		{"mem.free", mem(100e6), "$yellow$95.4$darkgray$M"},
+	} {
+		if got := renderStatCol(tt.metric, tt.col); got != tt.want {
+			t.Errorf("renderStatCol(%s, %v) = %q, want %q", tt.metric, statColValue(tt.col), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gokrazy/fbstatus/internal/vcio"
+)
+
+// throttledBits describes the Raspberry Pi firmware throttling bits, see
+// https://www.raspberrypi.com/documentation/computers/os.html#get_throttled
+var throttledBits = []struct {
+	now, occurred uint32
+	desc          string
+}{
+	{now: 1 << 0, occurred: 1 << 16, desc: "under-voltage"},
+	{now: 1 << 1, occurred: 1 << 17, desc: "frequency capped"},
+	{now: 1 << 2, occurred: 1 << 18, desc: "throttled"},
+	{now: 1 << 3, occurred: 1 << 19, desc: "soft temperature limit"},
+}
+
+// throttledLine returns a host information line (in $color$text markup)
+// describing the throttling bits: conditions which are currently active are
+// shown in red, conditions which occurred since boot in yellow.
+func throttledLine(throttled uint32) string {
+	line := "$$throttling: "
+	if throttled == 0 {
+		return line + "$green$none"
+	}
+	var conditions []string
+	for _, bit := range throttledBits {
+		switch {
+		case throttled&bit.now != 0:
+			conditions = append(conditions, "$red$"+bit.desc+" now")
+		case throttled&bit.occurred != 0:
+			conditions = append(conditions, "$yellow$"+bit.desc+" occurred")
+		}
+	}
+	return line + strings.Join(conditions, "$$, ")
+}
+
+// throttledSensor reads the firmware throttling bits, preferably from sysfs
+// (available in Raspberry Pi kernels), falling back to the mailbox interface.
+type throttledSensor struct {
+	vcio *vcio.Device
+}
+
+func newThrottledSensor() *throttledSensor {
+	t := &throttledSensor{}
+	if dev, err := vcio.Open(); err == nil {
+		t.vcio = dev
+	}
+	return t
+}
+
+const throttledSysfs = "/sys/devices/platform/soc/soc:firmware/get_throttled"
+
+// read returns the throttling bits and whether they could be determined,
+// which is only possible on the Raspberry Pi.
+func (t *throttledSensor) read() (uint32, bool) {
+	if b, err := os.ReadFile(throttledSysfs); err == nil {
+		v, err := strconv.ParseUint(strings.TrimSpace(string(b)), 16, 32)
+		if err == nil {
+			return uint32(v), true
+		}
+	}
+	if t.vcio == nil {
+		return 0, false
+	}
+	v, err := t.vcio.GetThrottled()
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
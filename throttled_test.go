@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestThrottledLine(t *testing.T) {
+	for _, tt := range []struct {
+		throttled uint32
+		want      string
+	}{
+		{0, "$$throttling: $green$none"},
+		{0x50000, "$$throttling: $yellow$under-voltage occurred$$, $yellow$throttled occurred"},
+		{0x50005, "$$throttling: $red$under-voltage now$$, $red$throttled now"},
+		{0x80000, "$$throttling: $yellow$soft temperature limit occurred"},
+	} {
+		if got := throttledLine(tt.throttled); got != tt.want {
+			t.Errorf("throttledLine(%#x) = %q, want %q", tt.throttled, got, tt.want)
+		}
+	}
+}
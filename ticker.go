@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+var (
+	tickers = flag.String("tickers",
+		"",
+		"comma-separated list of prices (stocks, crypto, electricity spot prices, …) for the ticker panel, each specified as label[:unit]=url#path. path selects either the current price, in which case fbstatus records the last 24h itself, or an array of prices (e.g. today's hourly spot prices), see -json-values for the path syntax")
+
+	tickerInterval = flag.Duration("ticker-interval",
+		5*time.Minute,
+		"how often the ticker panel fetches prices")
+)
+
+// tickerWindow is how much history the ticker sparklines cover.
+const tickerWindow = 24 * time.Hour
+
+type ticker struct {
+	label  string
+	unit   string
+	url    string
+	path   string
+	prices *poller[[]float64] // oldest first
+}
+
+// tickerPrices converts a value selected by jsonPath into prices: arrays are
+// taken as a series of prices, a single price is added to hist.
+func tickerPrices(v interface{}, hist *history) ([]float64, error) {
+	toFloat := func(v interface{}) (float64, error) {
+		switch x := v.(type) {
+		case float64:
+			return x, nil
+		case string:
+			return strconv.ParseFloat(x, 64)
+		default:
+			return 0, fmt.Errorf("not a price: %v", v)
+		}
+	}
+	if arr, ok := v.([]interface{}); ok {
+		prices := make([]float64, 0, len(arr))
+		for _, elem := range arr {
+			f, err := toFloat(elem)
+			if err != nil {
+				return nil, err
+			}
+			prices = append(prices, f)
+		}
+		return prices, nil
+	}
+	f, err := toFloat(v)
+	if err != nil {
+		return nil, err
+	}
+	hist.add(f)
+	return hist.values(), nil
+}
+
+func formatPrice(v float64, unit string) string {
+	prec := 2
+	if math.Abs(v) < 1 {
+		prec = 4
+	}
+	s := fmt.Sprintf("%.*f", prec, v)
+	if unit != "" {
+		s += " " + unit
+	}
+	return s
+}
+
+// priceChange describes the change from the first to the last price, e.g.
+// ▲ 1.23%, colored green or red.
+func priceChange(prices []float64) cell {
+	if len(prices) < 2 || prices[0] == 0 {
+		return cell{}
+	}
+	first, last := prices[0], prices[len(prices)-1]
+	pct := 100 * (last - first) / math.Abs(first)
+	switch {
+	case last > first:
+		return cell{text: fmt.Sprintf("▲ %.2f%%", pct), color: "green"}
+	case last < first:
+		return cell{text: fmt.Sprintf("▼ %.2f%%", -pct), color: "red"}
+	default:
+		return cell{text: "= 0.00%", color: "darkgray"}
+	}
+}
+
+// tickerPanel shows prices with a sparkline of their last 24 hours.
+type tickerPanel struct {
+	tickers []*ticker
+}
+
+func newTickerPanel() (panel, error) {
+	values, err := parseJSONValues(*tickers)
+	if err != nil {
+		return nil, err
+	}
+	p := &tickerPanel{}
+	for _, v := range values {
+		t := &ticker{
+			label: v.label,
+			unit:  v.unit,
+			url:   v.url,
+			path:  v.path,
+		}
+		size := int(tickerWindow / *tickerInterval)
+		if size < 2 {
+			size = 2
+		}
+		hist := newHistory(size)
+		t.prices = newPoller(*tickerInterval, func(ctx context.Context) ([]float64, error) {
+			doc, err := fetchJSON(ctx, t.url)
+			if err != nil {
+				return nil, err
+			}
+			v, err := jsonPath(doc, t.path)
+			if err != nil {
+				return nil, err
+			}
+			return tickerPrices(v, hist)
+		})
+		p.tickers = append(p.tickers, t)
+	}
+	return p, nil
+}
+
+func (p *tickerPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Ticker")
+	if len(p.tickers) == 0 {
+		d.drawMessage(dc, y, "-tickers not set")
+		return nil
+	}
+	em, _ := dc.MeasureString("m")
+	lineHeight := dc.FontHeight() * lineSpacing
+	// Labels, prices and changes take up the left half, sparklines the
+	// right half.
+	col := (float64(dc.Width()) - 6*em) / 2
+	for _, t := range p.tickers {
+		if y+lineHeight > float64(dc.Height()) {
+			break
+		}
+		prices, updated, err := t.prices.get()
+		dc.DrawString(fitString(dc, t.label, col/2), 3*em, y)
+		switch {
+		case !updated.IsZero() && len(prices) > 0:
+			change := priceChange(prices)
+			dc.DrawString(formatPrice(prices[len(prices)-1], t.unit), 3*em+col/2, y)
+			setColor(dc, change.color)
+			dc.DrawStringAnchored(change.text, 3*em+2*col, y, 1, 0)
+			drawSparkline(dc, prices, 3*em+col, y-dc.FontHeight(), col-8*em, dc.FontHeight(), change.color)
+		case err != nil:
+			setColor(dc, "darkgray")
+			dc.DrawString(fitString(dc, "unavailable: "+err.Error(), 1.5*col), 3*em+col/2, y)
+		default:
+			setColor(dc, "darkgray")
+			dc.DrawString("loading…", 3*em+col/2, y)
+		}
+		dc.SetRGB(1, 1, 1)
+		y += lineHeight * 1.5
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTickerPrices(t *testing.T) {
+	hist := newHistory(3)
+	for _, price := range []interface{}{1.5, "2.5", 3.5, 4.5} {
+		if _, err := tickerPrices(price, hist); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := hist.values(), []float64{2.5, 3.5, 4.5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recorded prices = %v, want %v", got, want)
+	}
+
+	// Series are used as-is and not recorded.
+	got, err := tickerPrices([]interface{}{0.21, "0.19", 0.25}, hist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []float64{0.21, 0.19, 0.25}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tickerPrices(series) = %v, want %v", got, want)
+	}
+
+	if _, err := tickerPrices(map[string]interface{}{}, hist); err == nil {
+		t.Errorf("tickerPrices(object) did not return an error")
+	}
+}
+
+func TestPriceChange(t *testing.T) {
+	for _, tt := range []struct {
+		prices []float64
+		want   cell
+	}{
+		{[]float64{100, 90, 110}, cell{text: "▲ 10.00%", color: "green"}},
+		{[]float64{0.2, 0.15}, cell{text: "▼ 25.00%", color: "red"}},
+		{[]float64{5, 5}, cell{text: "= 0.00%", color: "darkgray"}},
+		{[]float64{5}, cell{}},
+	} {
+		if got := priceChange(tt.prices); got != tt.want {
+			t.Errorf("priceChange(%v) = %+v, want %+v", tt.prices, got, tt.want)
+		}
+	}
+}
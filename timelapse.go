@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+var (
+	timelapseDir = flag.String("timelapse-dir",
+		"",
+		"if non-empty, directory (e.g. /perm/fbstatus-timelapse) in which to save a downscaled frame every -timelapse-interval. With -http-listen, /timelapse.gif assembles the frames into an animated GIF")
+
+	timelapseInterval = flag.Duration("timelapse-interval",
+		5*time.Minute,
+		"how often to save a frame to -timelapse-dir")
+
+	timelapseWidth = flag.Int("timelapse-width",
+		480,
+		"width in pixels to which timelapse frames are downscaled")
+
+	timelapseRetention = flag.Duration("timelapse-retention",
+		24*time.Hour,
+		"how long to keep timelapse frames before deleting them")
+)
+
+const (
+	// timelapseLayout is the file name of a timelapse frame, in UTC so that
+	// file names sort chronologically across DST changes.
+	timelapseLayout = "20060102T150405Z.png"
+
+	// maxTimelapseFrames bounds the memory required to assemble a GIF. Longer
+	// timelapses skip frames.
+	maxTimelapseFrames = 300
+
+	// timelapseDelay is the display time of each GIF frame, in 100ths of a
+	// second.
+	timelapseDelay = 20
+)
+
+// saveTimelapseFrame downscales img to width and saves it in dir, named after
+// t.
+func saveTimelapseFrame(dir string, img image.Image, width int, t time.Time) error {
+	b := img.Bounds()
+	height := b.Dy() * width / b.Dx()
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, b, xdraw.Src, nil)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaled); err != nil {
+		return err
+	}
+	fn := filepath.Join(dir, t.UTC().Format(timelapseLayout))
+	// write to a temporary file first so that /timelapse.gif never reads a
+	// partially written frame
+	if err := os.WriteFile(fn+".tmp", buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(fn+".tmp", fn)
+}
+
+// timelapseFrames returns the paths of the frames in dir which were saved at
+// or after since, in chronological order.
+func timelapseFrames(dir string, since time.Time) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		t, err := time.Parse(timelapseLayout, e.Name())
+		if err != nil {
+			continue // not a frame
+		}
+		if t.Before(since) {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// pruneTimelapse deletes the frames in dir which were saved before before.
+func pruneTimelapse(dir string, before time.Time) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		t, err := time.Parse(timelapseLayout, e.Name())
+		if err != nil || !t.Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quantize converts img to the Plan 9 palette. The status screen consists of
+// relatively few distinct colors, so caching the nearest palette index per
+// color is much faster than draw.Draw, which searches the palette for every
+// pixel.
+func quantize(img image.Image) *image.Paletted {
+	b := img.Bounds()
+	p := image.NewPaletted(b, palette.Plan9)
+	cache := make(map[color.RGBA]uint8)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			idx, ok := cache[c]
+			if !ok {
+				idx = uint8(p.Palette.Index(c))
+				cache[c] = idx
+			}
+			p.SetColorIndex(x, y, idx)
+		}
+	}
+	return p
+}
+
+// encodeTimelapse writes the frames at paths as animated GIF to w, skipping
+// frames if there are more than maxTimelapseFrames.
+func encodeTimelapse(w io.Writer, paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no timelapse frames recorded yet")
+	}
+	step := (len(paths) + maxTimelapseFrames - 1) / maxTimelapseFrames
+	var anim gif.GIF
+	for idx := 0; idx < len(paths); idx += step {
+		f, err := os.Open(paths[idx])
+		if err != nil {
+			return err
+		}
+		img, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", paths[idx], err)
+		}
+		if len(anim.Image) > 0 && img.Bounds() != anim.Image[0].Bounds() {
+			continue // the resolution or -timelapse-width changed
+		}
+		anim.Image = append(anim.Image, quantize(img))
+		anim.Delay = append(anim.Delay, timelapseDelay)
+	}
+	return gif.EncodeAll(w, &anim)
+}
+
+// serveTimelapse serves the frames of the last -timelapse-retention (or the
+// duration in the since parameter, e.g. ?since=2h) as animated GIF.
+func (d *statusDrawer) serveTimelapse(w http.ResponseWriter, r *http.Request) {
+	if *timelapseDir == "" {
+		http.Error(w, "-timelapse-dir not set", http.StatusNotFound)
+		return
+	}
+	since := *timelapseRetention
+	if s := r.FormValue("since"); s != "" {
+		var err error
+		since, err = time.ParseDuration(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	paths, err := timelapseFrames(*timelapseDir, time.Now().Add(-since))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	if err := encodeTimelapse(&buf, paths); err != nil {
+		status := http.StatusInternalServerError
+		if len(paths) == 0 {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "image/gif")
+	if _, err := buf.WriteTo(w); err != nil {
+		slog.Debug("writing HTTP response failed", "err", err)
+	}
+}
+
+// recordTimelapse saves a frame to dir every -timelapse-interval and deletes
+// frames older than -timelapse-retention.
+func (d *statusDrawer) recordTimelapse(dir string) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		slog.Warn("recording timelapse failed", "err", err)
+		return
+	}
+	for {
+		time.Sleep(*timelapseInterval)
+		d.mu.Lock()
+		blanked := d.blanked
+		frame := image.NewRGBA(d.buffer.Rect)
+		copy(frame.Pix, d.buffer.Pix)
+		d.mu.Unlock()
+		if blanked {
+			continue
+		}
+		now := time.Now()
+		if err := saveTimelapseFrame(dir, frame, *timelapseWidth, now); err != nil {
+			slog.Warn("recording timelapse failed", "err", err)
+		}
+		if err := pruneTimelapse(dir, now.Add(-*timelapseRetention)); err != nil {
+			slog.Warn("recording timelapse failed", "err", err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimelapse(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 1024, 768))
+	for idx := range img.Pix {
+		img.Pix[idx] = 0x33
+	}
+	start := time.Date(2021, 8, 30, 12, 0, 0, 0, time.UTC)
+	for idx := 0; idx < 4; idx++ {
+		if err := saveTimelapseFrame(dir, img, 320, start.Add(time.Duration(idx)*5*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := timelapseFrames(dir, start.Add(5*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "20210830T120500Z.png"),
+		filepath.Join(dir, "20210830T121000Z.png"),
+		filepath.Join(dir, "20210830T121500Z.png"),
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("timelapseFrames() = %q, want %q", paths, want)
+	}
+
+	var buf bytes.Buffer
+	if err := encodeTimelapse(&buf, paths); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(anim.Image), 3; got != want {
+		t.Errorf("GIF has %d frames, want %d", got, want)
+	}
+	if got, want := anim.Image[0].Bounds(), image.Rect(0, 0, 320, 240); got != want {
+		t.Errorf("GIF frame bounds = %v, want %v", got, want)
+	}
+
+	if err := pruneTimelapse(dir, start.Add(10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	paths, err = timelapseFrames(dir, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(paths), 2; got != want {
+		t.Errorf("after pruning, %d frames remain, want %d", got, want)
+	}
+
+	if err := encodeTimelapse(&buf, nil); err == nil {
+		t.Errorf("encodeTimelapse(no frames) did not return an error")
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{R: 0xff, A: 0xff})
+	img.Set(1, 0, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+	p := quantize(img)
+	if got, want := color.RGBAModel.Convert(p.At(0, 0)), (color.RGBA{R: 0xff, A: 0xff}); got != want {
+		t.Errorf("quantized red = %v, want %v", got, want)
+	}
+	if got, want := color.RGBAModel.Convert(p.At(1, 0)), (color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}); got != want {
+		t.Errorf("quantized white = %v, want %v", got, want)
+	}
+}
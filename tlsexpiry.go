@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+var tlsEndpoints = flag.String("tls-endpoints",
+	"",
+	"comma-separated list of TLS endpoints whose certificate expiry to display, each specified as [label=]host:port, e.g. gokrazy=localhost:443,proxy=localhost:8443")
+
+// tlsExpiryWarning is how long before expiry a certificate is flagged.
+const tlsExpiryWarning = 14 * 24 * time.Hour
+
+// certExpiry connects to addr and returns when the leaf certificate expires.
+// The certificate is not verified: local endpoints are commonly accessed by
+// a different name than the certificate is issued for (or use self-signed
+// certificates), and expiry is all we are interested in.
+func certExpiry(ctx context.Context, addr string) (time.Time, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: true,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Close()
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return time.Time{}, errors.New("no certificate presented")
+	}
+	return certs[0].NotAfter, nil
+}
+
+type tlsEndpoint struct {
+	label  string
+	addr   string
+	expiry *poller[time.Time]
+}
+
+func parseTLSEndpoints(spec string) ([]*tlsEndpoint, error) {
+	var endpoints []*tlsEndpoint
+	for _, s := range strings.Split(spec, ",") {
+		if s == "" {
+			continue
+		}
+		label, addr, ok := strings.Cut(s, "=")
+		if !ok {
+			label, addr = s, s
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("malformed TLS endpoint %q: %v", s, err)
+		}
+		endpoints = append(endpoints, &tlsEndpoint{
+			label: label,
+			addr:  addr,
+		})
+	}
+	return endpoints, nil
+}
+
+// expiryDesc describes when a certificate expires, in $color$text markup.
+func expiryDesc(notAfter, now time.Time) string {
+	left := notAfter.Sub(now)
+	days := int(left.Hours() / 24)
+	switch {
+	case left <= 0:
+		return "$red$expired"
+	case left < tlsExpiryWarning:
+		return fmt.Sprintf("$yellow$%dd", days)
+	default:
+		return fmt.Sprintf("$green$%dd", days)
+	}
+}
+
+// tlsCertificates displays how many days are left until the certificates of
+// the endpoints specified in -tls-endpoints expire.
+type tlsCertificates []*tlsEndpoint
+
+func newTLSCertificates(spec string) (tlsCertificates, error) {
+	endpoints, err := parseTLSEndpoints(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range endpoints {
+		addr := e.addr
+		e.expiry = newPoller(time.Hour, func(ctx context.Context) (time.Time, error) {
+			return certExpiry(ctx, addr)
+		})
+	}
+	return endpoints, nil
+}
+
+// line returns a host information line (in $color$text markup).
+func (tc tlsCertificates) line() string {
+	descs := make([]string, 0, len(tc))
+	for _, e := range tc {
+		desc := "$darkgray$…"
+		notAfter, updated, err := e.expiry.get()
+		switch {
+		case !updated.IsZero():
+			desc = expiryDesc(notAfter, time.Now())
+		case err != nil:
+			desc = "$red$unreachable"
+		}
+		descs = append(descs, "$$"+e.label+" "+desc)
+	}
+	return "$$certificates: " + strings.Join(descs, "$$, ")
+}
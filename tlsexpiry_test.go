@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCertExpiry(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	ctx, canc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer canc()
+	got, err := certExpiry(ctx, strings.TrimPrefix(srv.URL, "https://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := srv.Certificate().NotAfter; !got.Equal(want) {
+		t.Errorf("certExpiry() = %v, want %v", got, want)
+	}
+}
+
+func TestExpiryDesc(t *testing.T) {
+	now := time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		notAfter time.Time
+		want     string
+	}{
+		{now.Add(-time.Hour), "$red$expired"},
+		{now.Add(3*24*time.Hour + time.Hour), "$yellow$3d"},
+		{now.Add(90 * 24 * time.Hour), "$green$90d"},
+	} {
+		if got := expiryDesc(tt.notAfter, now); got != tt.want {
+			t.Errorf("expiryDesc(%v) = %q, want %q", tt.notAfter, got, tt.want)
+		}
+	}
+}
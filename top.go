@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// clockTicks is the unit of the CPU time fields in /proc/[pid]/stat
+// (sysconf(_SC_CLK_TCK)), which is 100 on all architectures Linux supports.
+const clockTicks = 100
+
+// topN is the number of processes shown in each table of the top panel.
+const topN = 5
+
+type procSample struct {
+	pid   int
+	ppid  int
+	name  string
+	ticks uint64 // utime + stime
+	start uint64 // starttime, in clock ticks after boot
+	rss   uint64 // bytes
+}
+
+// parseProcStat parses the contents of /proc/[pid]/stat as per proc(5).
+func parseProcStat(b []byte) (procSample, error) {
+	s := string(b)
+	// The process name is enclosed in parentheses and can itself contain
+	// spaces and parentheses, so locate the last closing parenthesis.
+	lparen := strings.IndexByte(s, '(')
+	rparen := strings.LastIndexByte(s, ')')
+	if lparen == -1 || rparen < lparen {
+		return procSample{}, fmt.Errorf("malformed stat: %q", s)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(s[:lparen]))
+	if err != nil {
+		return procSample{}, err
+	}
+	// fields starts with field 3 (state)
+	fields := strings.Fields(s[rparen+1:])
+	if len(fields) < 22 {
+		return procSample{}, fmt.Errorf("malformed stat: too few fields in %q", s)
+	}
+	ppid, err := strconv.Atoi(fields[4-3])
+	if err != nil {
+		return procSample{}, err
+	}
+	utime, err := strconv.ParseUint(fields[14-3], 10, 64)
+	if err != nil {
+		return procSample{}, err
+	}
+	stime, err := strconv.ParseUint(fields[15-3], 10, 64)
+	if err != nil {
+		return procSample{}, err
+	}
+	start, err := strconv.ParseUint(fields[22-3], 10, 64)
+	if err != nil {
+		return procSample{}, err
+	}
+	rss, err := strconv.ParseUint(fields[24-3], 10, 64)
+	if err != nil {
+		return procSample{}, err
+	}
+	return procSample{
+		pid:   pid,
+		ppid:  ppid,
+		name:  s[lparen+1 : rparen],
+		ticks: utime + stime,
+		start: start,
+		rss:   rss * uint64(os.Getpagesize()),
+	}, nil
+}
+
+func sampleProcesses() (map[int]procSample, error) {
+	matches, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return nil, err
+	}
+	samples := make(map[int]procSample, len(matches))
+	for _, m := range matches {
+		b, err := os.ReadFile(m)
+		if err != nil {
+			continue // process exited in the meantime
+		}
+		sample, err := parseProcStat(b)
+		if err != nil {
+			continue
+		}
+		samples[sample.pid] = sample
+	}
+	return samples, nil
+}
+
+// cpuPercent returns the CPU usage of a process between two samples, or 0 if
+// the PID was reused by a different process in the meantime.
+func cpuPercent(old, cur procSample, elapsed float64) float64 {
+	if old.start != cur.start || cur.ticks < old.ticks || elapsed <= 0 {
+		return 0
+	}
+	return 100 * float64(cur.ticks-old.ticks) / clockTicks / elapsed
+}
+
+type procUsage struct {
+	pid  int
+	name string
+	cpu  float64 // percent of one CPU
+	rss  uint64
+}
+
+type topProcesses struct {
+	byCPU []procUsage
+	byRSS []procUsage
+}
+
+// topPanel shows the processes using the most CPU and memory.
+type topPanel struct {
+	top *poller[topProcesses]
+}
+
+func newTopPanel() (panel, error) {
+	var (
+		prev     map[int]procSample
+		prevTime time.Time
+	)
+	return &topPanel{
+		top: newPoller(3*time.Second, func(context.Context) (topProcesses, error) {
+			cur, err := sampleProcesses()
+			if err != nil {
+				return topProcesses{}, err
+			}
+			now := time.Now()
+			elapsed := now.Sub(prevTime).Seconds()
+			usage := make([]procUsage, 0, len(cur))
+			for pid, sample := range cur {
+				u := procUsage{
+					pid:  pid,
+					name: sample.name,
+					rss:  sample.rss,
+				}
+				if old, ok := prev[pid]; ok {
+					u.cpu = cpuPercent(old, sample, elapsed)
+				}
+				usage = append(usage, u)
+			}
+			prev, prevTime = cur, now
+
+			n := len(usage)
+			if n > topN {
+				n = topN
+			}
+			var top topProcesses
+			sort.Slice(usage, func(i, j int) bool { return usage[i].cpu > usage[j].cpu })
+			top.byCPU = append(top.byCPU, usage[:n]...)
+			sort.Slice(usage, func(i, j int) bool { return usage[i].rss > usage[j].rss })
+			top.byRSS = append(top.byRSS, usage[:n]...)
+			return top, nil
+		}),
+	}, nil
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func (p *topPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Top processes")
+	top, updated, err := p.top.get()
+	if updated.IsZero() {
+		msg := "loading…"
+		if err != nil {
+			msg = "unavailable: " + err.Error()
+		}
+		d.drawMessage(dc, y, msg)
+		return nil
+	}
+	header := []string{"pid", "process", "cpu", "rss"}
+	row := func(u procUsage) []cell {
+		cpuColor := "darkgray"
+		if u.cpu >= 1 {
+			cpuColor = ""
+		}
+		return []cell{
+			{text: strconv.Itoa(u.pid), color: "darkgray"},
+			{text: u.name},
+			{text: fmt.Sprintf("%5.1f%%", u.cpu), color: cpuColor},
+			{text: formatBytes(u.rss)},
+		}
+	}
+	lineHeight := dc.FontHeight() * lineSpacing
+	for _, table := range []struct {
+		title string
+		usage []procUsage
+	}{
+		{"by CPU usage", top.byCPU},
+		{"by memory usage", top.byRSS},
+	} {
+		d.drawMessage(dc, y, table.title)
+		rows := make([][]cell, 0, len(table.usage))
+		for _, u := range table.usage {
+			rows = append(rows, row(u))
+		}
+		y = d.drawTable(dc, y+lineHeight, header, rows) + lineHeight/2
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseProcStat(t *testing.T) {
+	const stat = "1234 (gokr (x) y) S 1 1234 1234 0 -1 4194560 2961 0 0 0 150 75 0 0 20 0 12 0 1520 1256775680 3000 18446744073709551615 1 1 0 0 0 0 0 0 2143420159 0 0 0 17 3 0 0 0 0 0 0 0 0 0 0 0 0 0\n"
+	sample, err := parseProcStat([]byte(stat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := procSample{
+		pid:   1234,
+		ppid:  1,
+		name:  "gokr (x) y",
+		ticks: 225,
+		start: 1520,
+		rss:   3000 * uint64(os.Getpagesize()),
+	}
+	if sample != want {
+		t.Errorf("parseProcStat() = %+v, want %+v", sample, want)
+	}
+}
+
+func TestCPUPercent(t *testing.T) {
+	old := procSample{pid: 42, ticks: 1000, start: 500}
+	for _, tt := range []struct {
+		desc string
+		cur  procSample
+		want float64
+	}{
+		{"same process", procSample{pid: 42, ticks: 1000 + clockTicks, start: 500}, 50},
+		{"reused pid", procSample{pid: 42, ticks: 10, start: 900}, 0},
+		{"reused pid, more ticks", procSample{pid: 42, ticks: 5000, start: 900}, 0},
+	} {
+		if got := cpuPercent(old, tt.cur, 2); got != tt.want {
+			t.Errorf("%s: cpuPercent() = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/gokrazy/fbstatus/internal/evdev"
+)
+
+var touchInput = flag.Bool("touch",
+	true,
+	"handle touchscreens in /dev/input while the display is visible: tap for the next page, swipe left or right to switch pages, touch and hold for a second to show the configuration overlay. A tap wakes the display when blanked")
+
+const (
+	// longPressDuration is how long a touch must last to count as long press.
+	longPressDuration = time.Second
+
+	// swipeDistance is the minimum horizontal movement of a swipe, as a
+	// fraction of the screen width.
+	swipeDistance = 0.15
+
+	// tapDistance is the maximum movement of a tap, as a fraction of the
+	// screen size, so that a slightly moving finger still counts.
+	tapDistance = 0.05
+)
+
+type gesture int
+
+const (
+	gestureNone gesture = iota
+	gestureTap
+	gestureSwipeLeft
+	gestureSwipeRight
+	gestureLongPress
+)
+
+// touchTracker recognizes gestures from the events of a touchscreen. It
+// relies on BTN_TOUCH and ABS_X/ABS_Y, which multi-touch screens (e.g. the
+// official Raspberry Pi display) emit as single-touch emulation, too.
+type touchTracker struct {
+	x, y evdev.AbsInfo // axis ranges
+
+	cx, cy     int32 // current position
+	x0, y0     int32 // position when the touch started
+	start, end time.Time
+	starting   bool // touch started, position follows until the next SYN
+	ending     bool // touch ended, classify at the next SYN
+}
+
+func (t *touchTracker) feed(ev evdev.Event) gesture {
+	switch ev.Type {
+	case evdev.EvAbs:
+		switch ev.Code {
+		case evdev.AbsX:
+			t.cx = ev.Value
+		case evdev.AbsY:
+			t.cy = ev.Value
+		}
+	case evdev.EvKey:
+		if ev.Code != evdev.BtnTouch {
+			break
+		}
+		if ev.Value == evdev.KeyPressed {
+			t.starting = true
+			t.start = ev.Time
+		} else if ev.Value == evdev.KeyReleased {
+			t.ending = true
+			t.end = ev.Time
+		}
+	case evdev.EvSyn:
+		if t.starting {
+			t.x0, t.y0 = t.cx, t.cy
+			t.starting = false
+		}
+		if t.ending {
+			t.ending = false
+			return t.classify()
+		}
+	}
+	return gestureNone
+}
+
+func (t *touchTracker) classify() gesture {
+	dx := float64(t.cx-t.x0) / float64(t.x.Maximum-t.x.Minimum)
+	dy := float64(t.cy-t.y0) / float64(t.y.Maximum-t.y.Minimum)
+	switch {
+	case math.Abs(dx) >= swipeDistance && math.Abs(dx) > math.Abs(dy):
+		if dx < 0 {
+			return gestureSwipeLeft
+		}
+		return gestureSwipeRight
+	case math.Abs(dx) > tapDistance || math.Abs(dy) > tapDistance:
+		return gestureNone // e.g. a vertical swipe
+	case t.end.Sub(t.start) >= longPressDuration:
+		return gestureLongPress
+	default:
+		return gestureTap
+	}
+}
+
+// newTouchTracker returns a touchTracker for dev, or nil if dev is not a
+// touchscreen.
+func newTouchTracker(dev *evdev.Device) *touchTracker {
+	if ok, err := dev.HasKeys(evdev.BtnTouch); err != nil || !ok {
+		return nil
+	}
+	x, err := dev.AbsInfo(evdev.AbsX)
+	if err != nil || x.Maximum <= x.Minimum {
+		return nil
+	}
+	y, err := dev.AbsInfo(evdev.AbsY)
+	if err != nil || y.Maximum <= y.Minimum {
+		return nil
+	}
+	return &touchTracker{x: x, y: y}
+}
+
+// handleGesture applies a gesture on the touchscreen.
+func (d *statusDrawer) handleGesture(g gesture) {
+	if g == gestureNone {
+		return
+	}
+	if d.control.wake() {
+		return // the touch only woke up the display
+	}
+	if d.control.configShown() {
+		d.control.showConfig(false)
+		if g == gestureTap || g == gestureLongPress {
+			return // the touch only closed the overlay
+		}
+	}
+	var err error
+	switch g {
+	case gestureTap, gestureSwipeLeft:
+		err = d.setPage("next")
+	case gestureSwipeRight:
+		err = d.setPage("prev")
+	case gestureLongPress:
+		d.control.showConfig(true)
+	}
+	if err != nil {
+		slog.Warn("touch input failed", "err", err)
+	}
+}
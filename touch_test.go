@@ -0,0 +1,107 @@
+package main
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/gokrazy/fbstatus/internal/evdev"
+)
+
+// touchEvents returns the events of a touch from (x0, y0) to (x1, y1) which
+// lasts for dur, as emitted by a touchscreen.
+func touchEvents(x0, y0, x1, y1 int32, dur time.Duration) []evdev.Event {
+	start := time.Unix(1630324800, 0)
+	return []evdev.Event{
+		{Time: start, Type: evdev.EvAbs, Code: evdev.AbsX, Value: x0},
+		{Time: start, Type: evdev.EvAbs, Code: evdev.AbsY, Value: y0},
+		{Time: start, Type: evdev.EvKey, Code: evdev.BtnTouch, Value: evdev.KeyPressed},
+		{Time: start, Type: evdev.EvSyn},
+		{Time: start.Add(dur / 2), Type: evdev.EvAbs, Code: evdev.AbsX, Value: x1},
+		{Time: start.Add(dur / 2), Type: evdev.EvAbs, Code: evdev.AbsY, Value: y1},
+		{Time: start.Add(dur / 2), Type: evdev.EvSyn},
+		{Time: start.Add(dur), Type: evdev.EvKey, Code: evdev.BtnTouch, Value: evdev.KeyReleased},
+		{Time: start.Add(dur), Type: evdev.EvSyn},
+	}
+}
+
+func TestTouchTracker(t *testing.T) {
+	for _, tt := range []struct {
+		desc           string
+		x0, y0, x1, y1 int32
+		dur            time.Duration
+		want           gesture
+	}{
+		{"tap", 400, 240, 400, 240, 100 * time.Millisecond, gestureTap},
+		{"wobbly tap", 400, 240, 410, 245, 100 * time.Millisecond, gestureTap},
+		{"long press", 400, 240, 402, 240, 1500 * time.Millisecond, gestureLongPress},
+		{"swipe left", 600, 240, 200, 260, 300 * time.Millisecond, gestureSwipeLeft},
+		{"swipe right", 200, 240, 600, 200, 300 * time.Millisecond, gestureSwipeRight},
+		{"vertical swipe", 400, 50, 420, 400, 300 * time.Millisecond, gestureNone},
+	} {
+		tracker := &touchTracker{
+			x: evdev.AbsInfo{Maximum: 799},
+			y: evdev.AbsInfo{Maximum: 479},
+		}
+		got := gestureNone
+		for _, ev := range touchEvents(tt.x0, tt.y0, tt.x1, tt.y1, tt.dur) {
+			if g := tracker.feed(ev); g != gestureNone {
+				got = g
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: gesture = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestHandleGesture(t *testing.T) {
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	*pagesFlag = "status,clock,version"
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned := func() string {
+		idx, ok := d.control.pinnedPage()
+		if !ok {
+			return ""
+		}
+		return d.pages[idx].name
+	}
+
+	d.handleGesture(gestureTap)
+	if got, want := pinned(), "clock"; got != want {
+		t.Errorf("after tap: page = %q, want %q", got, want)
+	}
+	d.handleGesture(gestureSwipeLeft)
+	if got, want := pinned(), "version"; got != want {
+		t.Errorf("after swipe left: page = %q, want %q", got, want)
+	}
+	d.handleGesture(gestureSwipeRight)
+	if got, want := pinned(), "clock"; got != want {
+		t.Errorf("after swipe right: page = %q, want %q", got, want)
+	}
+
+	d.handleGesture(gestureLongPress)
+	if !d.control.configShown() {
+		t.Errorf("configuration overlay not shown after long press")
+	}
+	d.handleGesture(gestureTap)
+	if d.control.configShown() {
+		t.Errorf("configuration overlay still shown after tap")
+	}
+	if got, want := pinned(), "clock"; got != want {
+		t.Errorf("tap closing the overlay switched the page to %q", got)
+	}
+
+	d.setBlank("on")
+	d.handleGesture(gestureSwipeLeft)
+	if d.control.isBlanked() {
+		t.Errorf("display still blanked after touch")
+	}
+	if got, want := pinned(), ""; got != want {
+		t.Errorf("after waking the display: page = %q, want the first page of the rotation", got)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"log/slog"
+	"time"
+)
+
+var (
+	transitionFlag = flag.String("transition",
+		"none",
+		"how to animate switching pages: slide, fade or none. Unless none, content which changes drastically (e.g. when an overlay is shown) fades in. Transitions are disabled automatically on hardware too slow to animate them smoothly")
+
+	transitionDuration = flag.Duration("transition-duration",
+		300*time.Millisecond,
+		"duration of the -transition animation")
+)
+
+// transitionFrameInterval is how often the display is redrawn while a
+// transition is animated. If a frame takes longer to draw, transitions are
+// disabled.
+const transitionFrameInterval = time.Second / 30
+
+func checkTransition(kind string) error {
+	switch kind {
+	case "slide", "fade", "none":
+		return nil
+	}
+	return fmt.Errorf("unknown -transition=%q, expected slide, fade or none", kind)
+}
+
+// transition is the animation from the frame shown before (the shown
+// buffer) to the current frame (the buffer).
+type transition struct {
+	kind  string // slide or fade
+	dir   int    // for slide: 1 if the new page comes in from the right, -1 from the left
+	start time.Time
+}
+
+// changedDrastically reports whether more than half of the display differs
+// between a and b, which is determined by sampling every 8th pixel in both
+// dimensions.
+func changedDrastically(a, b *image.RGBA) bool {
+	const step = 8
+	bounds := a.Bounds()
+	var total, changed int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			total++
+			off := a.PixOffset(x, y)
+			for i := off; i < off+3; i++ {
+				diff := int(a.Pix[i]) - int(b.Pix[i])
+				if diff > 32 || diff < -32 {
+					changed++
+					break
+				}
+			}
+		}
+	}
+	return changed*2 > total
+}
+
+// compose renders the frame of transition t at progress p (from 0 to 1) from
+// the frames from and to into dst.
+func (t *transition) compose(dst, from, to *image.RGBA, p float64) {
+	bounds := dst.Bounds()
+	switch t.kind {
+	case "slide":
+		offset := int(float64(bounds.Dx())*p) * t.dir
+		draw.Draw(dst, bounds, from, image.Point{offset, 0}, draw.Src)
+		draw.Draw(dst, bounds.Add(image.Point{bounds.Dx()*t.dir - offset, 0}), to, image.Point{}, draw.Src)
+	case "fade":
+		alpha := int(256 * p)
+		for i := range dst.Pix {
+			dst.Pix[i] = uint8((int(from.Pix[i])*(256-alpha) + int(to.Pix[i])*alpha) >> 8)
+		}
+	}
+}
+
+// easeOut makes animations start fast and slow down towards the end.
+func easeOut(p float64) float64 {
+	return 1 - (1-p)*(1-p)*(1-p)
+}
+
+// present shows the buffer, which contains the current frame of page pg, on
+// the display. When the page changed or the content changed drastically, a
+// transition is started, which animate shows.
+func (d *statusDrawer) present(pg *page) error {
+	if d.shown == nil {
+		return d.copyBuffer() // -transition=none
+	}
+	var tr *transition
+	switch {
+	case d.slowTransitions:
+	case d.shownPage != nil && pg != nil && pg != d.shownPage:
+		tr = &transition{kind: *transitionFlag, dir: 1}
+		if d.pageIndex(pg) == (d.pageIndex(d.shownPage)+len(d.pages)-1)%len(d.pages) && len(d.pages) > 2 {
+			tr.dir = -1
+		}
+	case d.shownPage != nil && pg == d.shownPage && changedDrastically(d.shown, d.buffer):
+		tr = &transition{kind: "fade"}
+	}
+	d.shownPage = pg
+	if tr == nil {
+		copy(d.shown.Pix, d.buffer.Pix)
+		return d.copyBuffer()
+	}
+	tr.start = time.Now()
+	d.transition = tr
+	return nil
+}
+
+func (d *statusDrawer) pageIndex(pg *page) int {
+	for idx, p := range d.pages {
+		if p == pg {
+			return idx
+		}
+	}
+	return -1
+}
+
+// animationFrame draws the next frame of the transition in progress, if any,
+// and returns when the next frame is due.
+func (d *statusDrawer) animationFrame() (next time.Time, more bool, _ error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	tr := d.transition
+	if tr == nil {
+		return time.Time{}, false, nil
+	}
+	start := time.Now()
+	p := float64(start.Sub(tr.start)) / float64(*transitionDuration)
+	if p >= 1 || d.slowTransitions {
+		d.transition = nil
+		copy(d.shown.Pix, d.buffer.Pix)
+		return time.Time{}, false, d.copyBuffer()
+	}
+	tr.compose(d.frame, d.shown, d.buffer, easeOut(p))
+	if err := d.copyFrame(d.frame); err != nil {
+		return time.Time{}, false, err
+	}
+	if elapsed := time.Since(start); elapsed > transitionFrameInterval {
+		slog.Info("transitions too slow, disabling them", "frame", elapsed, "max", transitionFrameInterval)
+		d.slowTransitions = true
+	}
+	return start.Add(transitionFrameInterval), true, nil
+}
+
+// animate shows the transition started by draw1, if any, at a higher frame
+// rate than the regular frameInterval.
+func (d *statusDrawer) animate() error {
+	for {
+		next, more, err := d.animationFrame()
+		if err != nil || !more {
+			return err
+		}
+		time.Sleep(time.Until(next))
+	}
+}
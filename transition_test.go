@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func uniformRGBA(r image.Rectangle, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(r)
+	draw.Draw(img, r, &image.Uniform{c}, image.Point{}, draw.Src)
+	return img
+}
+
+func TestChangedDrastically(t *testing.T) {
+	r := image.Rect(0, 0, 160, 100)
+	black := uniformRGBA(r, color.RGBA{A: 255})
+	white := uniformRGBA(r, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	if changedDrastically(black, black) {
+		t.Errorf("changedDrastically(black, black) = true")
+	}
+	if !changedDrastically(black, white) {
+		t.Errorf("changedDrastically(black, white) = false")
+	}
+	// a third of the display changes
+	partly := uniformRGBA(r, color.RGBA{A: 255})
+	draw.Draw(partly, image.Rect(0, 0, 160, 33), white, image.Point{}, draw.Src)
+	if changedDrastically(black, partly) {
+		t.Errorf("changedDrastically(black, partly white) = true")
+	}
+}
+
+func TestTransitionCompose(t *testing.T) {
+	r := image.Rect(0, 0, 100, 10)
+	from := uniformRGBA(r, color.RGBA{R: 255, A: 255})
+	to := uniformRGBA(r, color.RGBA{B: 255, A: 255})
+	dst := image.NewRGBA(r)
+
+	(&transition{kind: "slide", dir: 1}).compose(dst, from, to, 0.25)
+	if got := dst.RGBAAt(74, 0); got != from.RGBAAt(0, 0) {
+		t.Errorf("slide left: pixel 74 = %v, want the previous page", got)
+	}
+	if got := dst.RGBAAt(75, 0); got != to.RGBAAt(0, 0) {
+		t.Errorf("slide left: pixel 75 = %v, want the new page", got)
+	}
+
+	(&transition{kind: "slide", dir: -1}).compose(dst, from, to, 0.25)
+	if got := dst.RGBAAt(24, 0); got != to.RGBAAt(0, 0) {
+		t.Errorf("slide right: pixel 24 = %v, want the new page", got)
+	}
+	if got := dst.RGBAAt(25, 0); got != from.RGBAAt(0, 0) {
+		t.Errorf("slide right: pixel 25 = %v, want the previous page", got)
+	}
+
+	(&transition{kind: "fade"}).compose(dst, from, to, 0.5)
+	if got, want := dst.RGBAAt(50, 5), (color.RGBA{R: 127, B: 127, A: 255}); got != want {
+		t.Errorf("fade: pixel = %v, want %v", got, want)
+	}
+}
+
+func TestPresentTransition(t *testing.T) {
+	defer func(pages string) { *pagesFlag = pages }(*pagesFlag)
+	*pagesFlag = "status,clock+version"
+	defer func(kind string) { *transitionFlag = kind }(*transitionFlag)
+	*transitionFlag = "slide"
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := d.draw1(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if d.transition != nil {
+		t.Fatalf("transition started for the first frame")
+	}
+	if err := d.setPage("next"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.draw1(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if d.transition == nil || d.transition.kind != "slide" {
+		t.Fatalf("no slide transition started when switching pages: %+v", d.transition)
+	}
+	if err := d.animate(); err != nil {
+		t.Fatal(err)
+	}
+	if d.transition != nil {
+		t.Fatalf("transition still in progress after animate")
+	}
+	if got, want := img.Pix, d.buffer.Pix; string(got) != string(want) {
+		t.Errorf("display does not show the new page after the transition")
+	}
+}
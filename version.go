@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/gokrazy/gokrazy"
+	"golang.org/x/sys/unix"
+)
+
+// fbstatusVersion returns the module version of fbstatus, including VCS
+// information if available.
+func fbstatusVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := bi.Main.Version
+	settings := make(map[string]string)
+	for _, s := range bi.Settings {
+		settings[s.Key] = s.Value
+	}
+	if rev := settings["vcs.revision"]; rev != "" {
+		if len(rev) > 12 {
+			rev = rev[:12]
+		}
+		version += " (" + rev
+		if settings["vcs.modified"] == "true" {
+			version += ", modified"
+		}
+		version += ")"
+	}
+	return version
+}
+
+func kernelVersion() string {
+	var uname unix.Utsname
+	if err := unix.Uname(&uname); err != nil {
+		return "unknown: " + err.Error()
+	}
+	return unix.ByteSliceToString(uname.Release[:]) + " " + unix.ByteSliceToString(uname.Version[:])
+}
+
+// versionPanel shows the versions of all software involved, which is the
+// information typically needed when filing issues.
+type versionPanel struct {
+	fbstatus string
+	kernel   string
+	status   *poller[gokrazyStatus]
+}
+
+func newVersionPanel() (panel, error) {
+	return &versionPanel{
+		fbstatus: fbstatusVersion(),
+		kernel:   kernelVersion(),
+		status: newPoller(time.Minute, func(ctx context.Context) (gokrazyStatus, error) {
+			var status gokrazyStatus
+			err := gokrazyAPI(ctx, "/", &status)
+			return status, err
+		}),
+	}, nil
+}
+
+func (p *versionPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	y := d.drawTitle(dc, "Versions")
+	build := cell{text: "loading…", color: "darkgray"}
+	status, updated, err := p.status.get()
+	switch {
+	case !updated.IsZero():
+		build = cell{text: status.BuildTimestamp}
+	case err != nil:
+		build = cell{text: "unavailable: " + err.Error(), color: "darkgray"}
+	}
+	model := gokrazy.Model()
+	if model == "" {
+		model = "unknown"
+	}
+	em, _ := dc.MeasureString("m")
+	maxWidth := float64(dc.Width()) - 6*em
+	for _, line := range []struct {
+		key   string
+		value cell
+	}{
+		{"fbstatus", cell{text: p.fbstatus}},
+		{"Go", cell{text: runtime.Version() + " " + runtime.GOOS + "/" + runtime.GOARCH}},
+		{"kernel", cell{text: p.kernel}},
+		{"gokrazy build", build},
+		{"model", cell{text: model}},
+	} {
+		setColor(dc, line.value.color)
+		dc.DrawString(fitString(dc, line.key+": "+line.value.text, maxWidth), 3*em, y)
+		y += dc.FontHeight() * lineSpacing
+	}
+	dc.SetRGB(1, 1, 1)
+	return nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var wanInterface = flag.String("wan-interface",
+	"",
+	"if non-empty, network interface (e.g. ppp0 or uplink0) whose WAN state to display. Use auto for the interface of the default route")
+
+// wanMonitor derives the WAN session state from the kernel's view of the
+// interface. Neither the gokrazy nor the router7 network clients persist
+// their session state, so the session uptime and the last reconnect reason
+// are as observed by fbstatus.
+type wanMonitor struct {
+	iface string // may be auto
+
+	// state
+	observed   bool
+	up         bool
+	addrs      string // sorted, comma-separated
+	upSince    time.Time
+	sinceStart bool // upSince is when fbstatus started, not when the link came up
+	reason     string
+	reasonTime time.Time
+}
+
+func newWANMonitor(iface string) *wanMonitor {
+	return &wanMonitor{iface: iface}
+}
+
+// observe updates the session state based on the current operational state
+// and global addresses of the interface.
+func (w *wanMonitor) observe(up bool, addrs []string, now time.Time) {
+	sort.Strings(addrs)
+	joined := strings.Join(addrs, ", ")
+	if !w.observed {
+		w.observed = true
+		w.up, w.addrs = up, joined
+		if up {
+			w.upSince, w.sinceStart = now, true
+		}
+		return
+	}
+	switch {
+	case w.up && !up:
+		w.reason, w.reasonTime = "link down", now
+	case !w.up && up:
+		w.upSince, w.sinceStart = now, false
+	case up && joined != w.addrs:
+		// e.g. a PPPoE session was re-established with a new address
+		w.upSince, w.sinceStart = now, false
+		w.reason, w.reasonTime = "address changed", now
+	}
+	w.up, w.addrs = up, joined
+}
+
+func (w *wanMonitor) resolveInterface() (string, error) {
+	if w.iface != "auto" {
+		return w.iface, nil
+	}
+	b, err := os.ReadFile("/proc/net/route")
+	if err != nil {
+		return "", err
+	}
+	defaults, err := parseDefaultRoutes(b)
+	if err != nil {
+		return "", err
+	}
+	if len(defaults) == 0 {
+		return "", fmt.Errorf("no default route")
+	}
+	return defaults[0].iface, nil
+}
+
+// line returns a host information line (in $color$text markup).
+func (w *wanMonitor) line() string {
+	iface, err := w.resolveInterface()
+	if err != nil {
+		return "$$wan: $red$" + err.Error()
+	}
+	prefix := "$$wan " + iface + ": "
+	operstate, err := os.ReadFile("/sys/class/net/" + iface + "/operstate")
+	if err != nil {
+		if os.IsNotExist(err) {
+			// PPP interfaces only exist while a session is established
+			w.observe(false, nil, time.Now())
+			return prefix + "$red$absent" + w.reasonSuffix()
+		}
+		return prefix + "$red$" + err.Error()
+	}
+	// PPP interfaces report operstate unknown while working
+	state := strings.TrimSpace(string(operstate))
+	up := state == "up" || state == "unknown"
+	var addrs []string
+	if intf, err := net.InterfaceByName(iface); err == nil {
+		if ifaddrs, err := intf.Addrs(); err == nil {
+			for _, a := range ifaddrs {
+				if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.IsGlobalUnicast() {
+					addrs = append(addrs, ipnet.IP.String())
+				}
+			}
+		}
+	}
+	w.observe(up, addrs, time.Now())
+	if !up {
+		return prefix + "$red$" + state + w.reasonSuffix()
+	}
+	line := prefix + "$green$up"
+	uptime := time.Since(w.upSince).Round(time.Second).String()
+	if w.sinceStart {
+		uptime = "≥ " + uptime
+	}
+	line += "$$ for " + uptime
+	if w.addrs != "" {
+		line += ", " + w.addrs
+	} else {
+		line += ", $yellow$no address$$"
+	}
+	return line + w.reasonSuffix()
+}
+
+func (w *wanMonitor) reasonSuffix() string {
+	if w.reason == "" {
+		return ""
+	}
+	return fmt.Sprintf("$$, last reconnect: %s %v ago", w.reason, time.Since(w.reasonTime).Round(time.Second))
+}
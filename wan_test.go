@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWANMonitor(t *testing.T) {
+	w := newWANMonitor("ppp0")
+	now := time.Date(2022, 8, 1, 12, 0, 0, 0, time.UTC)
+	w.observe(true, []string{"203.0.113.5"}, now)
+	if !w.sinceStart || !w.upSince.Equal(now) || w.reason != "" {
+		t.Fatalf("after start: %+v", w)
+	}
+
+	now = now.Add(time.Hour)
+	w.observe(false, nil, now)
+	if w.reason != "link down" {
+		t.Errorf("after link down: reason = %q, want %q", w.reason, "link down")
+	}
+
+	now = now.Add(time.Minute)
+	w.observe(true, []string{"203.0.113.6"}, now)
+	if w.sinceStart || !w.upSince.Equal(now) || w.addrs != "203.0.113.6" {
+		t.Errorf("after reconnect: %+v", w)
+	}
+
+	now = now.Add(time.Hour)
+	w.observe(true, []string{"203.0.113.7"}, now)
+	if w.reason != "address changed" || !w.upSince.Equal(now) {
+		t.Errorf("after address change: %+v", w)
+	}
+}
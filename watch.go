@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fogleman/gg"
+)
+
+var (
+	watchCommand = flag.String("watch-command",
+		"",
+		"command (split at whitespace, no shell quoting) to run periodically, showing its output in the watch panel, like watch(1). ANSI colors are supported, e.g. -watch-command='ls --color=always /perm'")
+
+	watchInterval = flag.Duration("watch-interval",
+		10*time.Second,
+		"how often the watch panel runs -watch-command")
+)
+
+// watchMaxOutput bounds how much output of -watch-command is retained; more
+// would not fit on the display anyway.
+const watchMaxOutput = 64 * 1024
+
+// ansiColors maps the 8 ANSI colors (SGR 30–37 and 90–97) to color names as
+// per colorNameToRGBA. Black would be invisible on the black background, so
+// it is shown in dark gray.
+var ansiColors = [8]string{"darkgray", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// applySGR updates color according to the parameters of an SGR (Select
+// Graphic Rendition) escape sequence. Attributes other than the foreground
+// color are ignored.
+func applySGR(color string, params string) string {
+	args := strings.Split(params, ";")
+	for idx := 0; idx < len(args); idx++ {
+		n, err := strconv.Atoi(args[idx])
+		if err != nil {
+			n = 0 // empty parameters mean 0
+		}
+		switch {
+		case n == 0, n == 39:
+			color = ""
+		case n >= 30 && n <= 37:
+			color = ansiColors[n-30]
+		case n >= 90 && n <= 97:
+			color = ansiColors[n-90]
+		case n == 38 || n == 48:
+			// extended colors: 38;5;n (256 colors) or 38;2;r;g;b (true color)
+			if idx+1 >= len(args) {
+				break
+			}
+			switch args[idx+1] {
+			case "5":
+				if idx+2 < len(args) && n == 38 {
+					if c, err := strconv.Atoi(args[idx+2]); err == nil && c < 16 {
+						color = ansiColors[c%8]
+					}
+				}
+				idx += 2
+			case "2":
+				idx += 4
+			}
+		}
+	}
+	return color
+}
+
+// parseANSI splits terminal output into lines of colored cells. SGR color
+// sequences are interpreted, all other escape sequences are dropped. Tabs are
+// expanded and carriage returns (e.g. of progress bars) overwrite the line.
+func parseANSI(out string) [][]cell {
+	var lines [][]cell
+	var color string
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if idx := strings.LastIndexByte(strings.TrimSuffix(line, "\r"), '\r'); idx > -1 {
+			line = line[idx+1:]
+		}
+		var cells []cell
+		var b strings.Builder
+		col := 0
+		flush := func() {
+			if b.Len() > 0 {
+				cells = append(cells, cell{text: b.String(), color: color})
+				b.Reset()
+			}
+		}
+		for len(line) > 0 {
+			r, size := utf8.DecodeRuneInString(line)
+			line = line[size:]
+			switch {
+			case r == '\x1b' && strings.HasPrefix(line, "["):
+				// CSI: parameters and intermediate bytes, then a final byte
+				end := strings.IndexFunc(line[1:], func(r rune) bool { return r >= 0x40 && r <= 0x7e })
+				if end == -1 {
+					line = ""
+					continue
+				}
+				params, final := line[1:1+end], line[1+end]
+				line = line[2+end:]
+				if final == 'm' {
+					flush()
+					color = applySGR(color, params)
+				}
+			case r == '\x1b' && strings.HasPrefix(line, "]"):
+				// OSC (e.g. hyperlinks), terminated by BEL or ST
+				end := strings.IndexAny(line, "\a\x1b")
+				if end == -1 {
+					line = ""
+					continue
+				}
+				line = strings.TrimPrefix(line[end+1:], "\\")
+			case r == '\x1b':
+				if len(line) > 0 {
+					line = line[1:] // two-character escape sequence
+				}
+			case r == '\t':
+				n := 8 - col%8
+				b.WriteString(strings.Repeat(" ", n))
+				col += n
+			case r < ' ' || r == 0x7f:
+				// drop other control characters
+			default:
+				b.WriteRune(r)
+				col++
+			}
+		}
+		flush()
+		lines = append(lines, cells)
+	}
+	return lines
+}
+
+// watchResult is the output of one run of -watch-command.
+type watchResult struct {
+	lines   [][]cell
+	exitErr error // non-nil if the command exited unsuccessfully
+}
+
+func runWatchCommand(ctx context.Context, args []string) (watchResult, error) {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// Programs which check $TERM before emitting colors (e.g. via
+	// --color=auto) still need to be told to colorize, as stdout is not a
+	// terminal.
+	cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	out, err := cmd.Output()
+	var ee *exec.ExitError
+	if err != nil && !errors.As(err, &ee) {
+		return watchResult{}, err
+	}
+	if len(out) > watchMaxOutput {
+		out = out[:watchMaxOutput]
+	}
+	result := watchResult{lines: parseANSI(string(out))}
+	if ee != nil {
+		result.exitErr = ee
+		if stderr := strings.TrimSpace(string(ee.Stderr)); stderr != "" && len(out) == 0 {
+			result.lines = parseANSI(stderr)
+		}
+	}
+	return result, nil
+}
+
+// watchPanel shows the output of -watch-command, as an escape hatch for data
+// which fbstatus does not support natively.
+type watchPanel struct {
+	args   []string
+	output *poller[watchResult]
+}
+
+func newWatchPanel() (panel, error) {
+	p := &watchPanel{args: strings.Fields(*watchCommand)}
+	if len(p.args) > 0 {
+		p.output = newPoller(*watchInterval, func(ctx context.Context) (watchResult, error) {
+			return runWatchCommand(ctx, p.args)
+		})
+	}
+	return p, nil
+}
+
+func (p *watchPanel) draw(d *statusDrawer, dc *gg.Context) error {
+	if len(p.args) == 0 {
+		y := d.drawTitle(dc, "Watch")
+		d.drawMessage(dc, y, "-watch-command not set")
+		return nil
+	}
+	y := d.drawTitle(dc, fmt.Sprintf("Every %v: %s", *watchInterval, strings.Join(p.args, " ")))
+	result, updated, err := p.output.get()
+	switch {
+	case err != nil:
+		d.drawMessage(dc, y, "unavailable: "+err.Error())
+		return nil
+	case updated.IsZero():
+		d.drawMessage(dc, y, "loading…")
+		return nil
+	}
+
+	dc.Push()
+	defer dc.Pop()
+	dc.SetFontFace(d.monoface)
+	em, _ := dc.MeasureString("m")
+	lineHeight := dc.FontHeight() * lineSpacing
+	if result.exitErr != nil {
+		setColor(dc, "red")
+		dc.DrawString(result.exitErr.Error(), 3*em, y)
+		y += lineHeight
+	}
+	maxCols := int((float64(dc.Width()) - 6*em) / em)
+	for _, line := range result.lines {
+		if y > float64(dc.Height())-em {
+			break
+		}
+		x := 3 * em
+		cols := maxCols
+		for _, c := range line {
+			if cols <= 0 {
+				break
+			}
+			text := c.text
+			if utf8.RuneCountInString(text) > cols {
+				text = string([]rune(text)[:cols])
+			}
+			setColor(dc, c.color)
+			dc.DrawString(text, x, y)
+			w, _ := dc.MeasureString(text)
+			x += w
+			cols -= utf8.RuneCountInString(text)
+		}
+		y += lineHeight
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseANSI(t *testing.T) {
+	out := "\x1b[1;32mok\x1b[0m\tdisk\n" +
+		"\x1b]8;;http://example.com/\x1b\\link\x1b]8;;\x1b\\ \x1b[38;5;1mred\x1b[39m\n" +
+		"progress 10%\rprogress 100%\n" +
+		"\x1b[2Kcarried \x1b[33myellow\n" +
+		"still yellow\x1b[m\n"
+	want := [][]cell{
+		{{text: "ok", color: "green"}, {text: "      disk"}},
+		{{text: "link "}, {text: "red", color: "red"}},
+		{{text: "progress 100%"}},
+		{{text: "carried "}, {text: "yellow", color: "yellow"}},
+		{{text: "still yellow", color: "yellow"}},
+	}
+	if got := parseANSI(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseANSI() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestApplySGR(t *testing.T) {
+	for _, tt := range []struct {
+		color, params string
+		want          string
+	}{
+		{"", "31", "red"},
+		{"red", "", ""},
+		{"", "1;94", "blue"},
+		{"", "30", "darkgray"},
+		{"green", "38;2;255;0;0", "green"}, // true color is not supported
+		{"", "38;2;255;0;0;35", "magenta"},
+		{"", "48;5;3;36", "cyan"}, // background colors are ignored
+	} {
+		if got := applySGR(tt.color, tt.params); got != tt.want {
+			t.Errorf("applySGR(%q, %q) = %q, want %q", tt.color, tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestRunWatchCommand(t *testing.T) {
+	ctx := context.Background()
+	result, err := runWatchCommand(ctx, []string{"sh", "-c", "printf '\\033[31mfail\\033[0m\\n'; exit 3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.exitErr == nil {
+		t.Errorf("exitErr unexpectedly nil")
+	}
+	want := [][]cell{{{text: "fail", color: "red"}}}
+	if !reflect.DeepEqual(result.lines, want) {
+		t.Errorf("lines = %v, want %v", result.lines, want)
+	}
+
+	if _, err := runWatchCommand(ctx, []string{"/nonexistent/command"}); err == nil {
+		t.Errorf("runWatchCommand(/nonexistent/command) did not return an error")
+	}
+}
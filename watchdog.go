@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// watchdogStatus is the state of a hardware watchdog as per
+// /sys/class/watchdog/watchdogN (which requires CONFIG_WATCHDOG_SYSFS).
+type watchdogStatus struct {
+	name     string // e.g. watchdog0
+	state    string // active or inactive, empty if unknown
+	timeout  time.Duration
+	timeleft time.Duration // -1 if the driver cannot tell
+	owners   []string      // processes which opened the device, e.g. init (pid 1)
+}
+
+// sinceKick returns how long ago the watchdog was last kicked, which the
+// kernel does not expose directly, but follows from the time left until the
+// watchdog fires.
+func (w watchdogStatus) sinceKick() (time.Duration, bool) {
+	if w.timeleft < 0 || w.timeout == 0 {
+		return 0, false
+	}
+	return w.timeout - w.timeleft, true
+}
+
+func readWatchdog(dir string) (watchdogStatus, error) {
+	read := func(name string) (string, error) {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		return strings.TrimSpace(string(b)), err
+	}
+	readSeconds := func(name string) (time.Duration, error) {
+		s, err := read(name)
+		if err != nil {
+			return 0, err
+		}
+		secs, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(secs) * time.Second, nil
+	}
+	w := watchdogStatus{name: filepath.Base(dir)}
+	w.state, _ = read("state")
+	var err error
+	if w.timeout, err = readSeconds("timeout"); err != nil {
+		return watchdogStatus{}, err
+	}
+	if w.timeleft, err = readSeconds("timeleft"); err != nil {
+		w.timeleft = -1 // not supported by the driver
+	}
+	return w, nil
+}
+
+// watchdogOwners returns the processes (in procRoot, i.e. /proc) which have
+// one of the specified device files open, by device file.
+func watchdogOwners(procRoot string, devices []string) map[string][]string {
+	wanted := make(map[string]bool)
+	for _, dev := range devices {
+		wanted[dev] = true
+	}
+	owners := make(map[string][]string)
+	fds, err := filepath.Glob(filepath.Join(procRoot, "[0-9]*", "fd", "*"))
+	if err != nil {
+		return owners
+	}
+	seen := make(map[string]bool)
+	for _, fd := range fds {
+		target, err := os.Readlink(fd)
+		if err != nil || !wanted[target] {
+			continue
+		}
+		pidDir := filepath.Dir(filepath.Dir(fd))
+		pid := filepath.Base(pidDir)
+		if seen[target+" "+pid] {
+			continue
+		}
+		seen[target+" "+pid] = true
+		comm, err := os.ReadFile(filepath.Join(pidDir, "comm"))
+		if err != nil {
+			continue // exited in the meantime
+		}
+		owners[target] = append(owners[target], fmt.Sprintf("%s (pid %s)", strings.TrimSpace(string(comm)), pid))
+	}
+	return owners
+}
+
+// readWatchdogs returns the status of all watchdogs in sysRoot (i.e.
+// /sys/class/watchdog).
+func readWatchdogs(sysRoot string) ([]watchdogStatus, error) {
+	dirs, err := filepath.Glob(filepath.Join(sysRoot, "watchdog*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+	var watchdogs []watchdogStatus
+	for _, dir := range dirs {
+		w, err := readWatchdog(dir)
+		if err != nil {
+			return nil, err
+		}
+		watchdogs = append(watchdogs, w)
+	}
+	return watchdogs, nil
+}
+
+// newWatchdogOwnersPoller polls which processes opened the watchdogs, which
+// requires looking at the file descriptors of all processes and hence is too
+// expensive to do for every frame.
+func newWatchdogOwnersPoller() *poller[map[string][]string] {
+	return newPoller(5*time.Second, func(context.Context) (map[string][]string, error) {
+		dirs, err := filepath.Glob("/sys/class/watchdog/watchdog*")
+		if err != nil {
+			return nil, err
+		}
+		// /dev/watchdog is the legacy device file of the first watchdog
+		devices := []string{"/dev/watchdog"}
+		for _, dir := range dirs {
+			devices = append(devices, "/dev/"+filepath.Base(dir))
+		}
+		return watchdogOwners("/proc", devices), nil
+	})
+}
+
+// setOwners sets the owners of w from owners (as per watchdogOwners).
+func (w *watchdogStatus) setOwners(owners map[string][]string) {
+	w.owners = owners["/dev/"+w.name]
+	if w.name == "watchdog0" {
+		w.owners = append(w.owners, owners["/dev/watchdog"]...)
+	}
+}
+
+// watchdogLine returns a host information line (in $color$text markup) for
+// the watchdog w: whether it is armed, by whom, its timeout and when it was
+// last kicked.
+func watchdogLine(w watchdogStatus) string {
+	line := "$$" + w.name + ": "
+	switch {
+	case w.state == "inactive":
+		line += "$red$not armed"
+	case w.state == "active":
+		line += "$green$armed"
+	case len(w.owners) > 0:
+		// opening the device arms the watchdog
+		line += "$green$open"
+	default:
+		line += "$darkgray$state unknown"
+	}
+	if len(w.owners) > 0 {
+		line += "$$ by " + strings.Join(w.owners, ", ")
+	}
+	line += fmt.Sprintf("$$, timeout %v", w.timeout)
+	if since, ok := w.sinceKick(); ok && w.state != "inactive" {
+		color := ""
+		if since > w.timeout/2 {
+			color = "yellow" // a kicker should kick well before the timeout
+		}
+		line += fmt.Sprintf(", kicked $%s$%v$$ ago", color, since)
+	}
+	return line
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadWatchdogs(t *testing.T) {
+	sys := t.TempDir()
+	for name, files := range map[string]map[string]string{
+		"watchdog0": {"state": "active\n", "timeout": "15\n", "timeleft": "12\n"},
+		"watchdog1": {"state": "inactive\n", "timeout": "60\n"},
+	} {
+		dir := filepath.Join(sys, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for file, contents := range files {
+			if err := os.WriteFile(filepath.Join(dir, file), []byte(contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	got, err := readWatchdogs(sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []watchdogStatus{
+		{name: "watchdog0", state: "active", timeout: 15 * time.Second, timeleft: 12 * time.Second},
+		{name: "watchdog1", state: "inactive", timeout: time.Minute, timeleft: -1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readWatchdogs() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWatchdogOwners(t *testing.T) {
+	proc := t.TempDir()
+	for pid, fds := range map[string]map[string]string{
+		"1":   {"0": "/dev/null", "3": "/dev/watchdog"},
+		"123": {"3": "/dev/watchdog1", "4": "/dev/watchdog1"},
+		"456": {"3": "/perm/watchdog"},
+	} {
+		if err := os.MkdirAll(filepath.Join(proc, pid, "fd"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		comm := map[string]string{"1": "init", "123": "kicker", "456": "other"}[pid]
+		if err := os.WriteFile(filepath.Join(proc, pid, "comm"), []byte(comm+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for fd, target := range fds {
+			if err := os.Symlink(target, filepath.Join(proc, pid, "fd", fd)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	owners := watchdogOwners(proc, []string{"/dev/watchdog", "/dev/watchdog0", "/dev/watchdog1"})
+	w0 := watchdogStatus{name: "watchdog0"}
+	w0.setOwners(owners)
+	if want := []string{"init (pid 1)"}; !reflect.DeepEqual(w0.owners, want) {
+		t.Errorf("watchdog0 owners = %q, want %q", w0.owners, want)
+	}
+	w1 := watchdogStatus{name: "watchdog1"}
+	w1.setOwners(owners)
+	if want := []string{"kicker (pid 123)"}; !reflect.DeepEqual(w1.owners, want) {
+		t.Errorf("watchdog1 owners = %q, want %q", w1.owners, want)
+	}
+}
+
+func TestWatchdogLine(t *testing.T) {
+	for _, tt := range []struct {
+		w    watchdogStatus
+		want string
+	}{
+		{
+			w:    watchdogStatus{name: "watchdog0", state: "active", timeout: 15 * time.Second, timeleft: 12 * time.Second, owners: []string{"init (pid 1)"}},
+			want: "$$watchdog0: $green$armed$$ by init (pid 1)$$, timeout 15s, kicked $$3s$$ ago",
+		},
+		{
+			w:    watchdogStatus{name: "watchdog0", state: "active", timeout: 15 * time.Second, timeleft: 2 * time.Second},
+			want: "$$watchdog0: $green$armed$$, timeout 15s, kicked $yellow$13s$$ ago",
+		},
+		{
+			w:    watchdogStatus{name: "watchdog1", state: "inactive", timeout: time.Minute, timeleft: -1},
+			want: "$$watchdog1: $red$not armed$$, timeout 1m0s",
+		},
+		{
+			w:    watchdogStatus{name: "watchdog0", timeout: 15 * time.Second, timeleft: -1, owners: []string{"init (pid 1)"}},
+			want: "$$watchdog0: $green$open$$ by init (pid 1)$$, timeout 15s",
+		},
+	} {
+		if got := watchdogLine(tt.w); got != tt.want {
+			t.Errorf("watchdogLine(%+v) = %q, want %q", tt.w, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"image"
+	"image/png"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// markupSpan is one consecutively colored part of a line in $color$text
+// markup.
+type markupSpan struct {
+	Color string // CSS color
+	Text  string
+}
+
+// markupSpans splits a line in $color$text markup (see drawMarkup) into
+// spans for rendering as HTML.
+func markupSpans(s string) []markupSpan {
+	white := cssColor("white")
+	if s == "" {
+		return nil
+	}
+	if !strings.HasPrefix(s, "$") {
+		return []markupSpan{{Color: white, Text: s}}
+	}
+	var spans []markupSpan
+	color := white
+	for idx, field := range strings.Split(strings.TrimPrefix(s, "$"), "$") {
+		if idx%2 == 0 {
+			color = cssColor(field)
+			continue
+		}
+		if field == "" {
+			continue
+		}
+		spans = append(spans, markupSpan{Color: color, Text: field})
+	}
+	return spans
+}
+
+// cssColor returns the CSS color for a color name as used with setColor.
+func cssColor(name string) string {
+	if name == "" {
+		name = "white"
+	}
+	col := colorNameToRGBA[name]
+	return fmt.Sprintf("#%02x%02x%02x", col.R, col.G, col.B)
+}
+
+var statusTmpl = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>fbstatus on {{ .Hostname }}</title>
+<style>
+body { background: #323232; color: #eeeeec; font-family: sans-serif; margin: 1em 2em; }
+a { color: #729fcf; }
+img { max-width: 100%; border: 1px solid #555753; }
+.lines { font-size: 1.1em; line-height: 1.4; }
+</style>
+</head>
+<body>
+<h1>fbstatus on “{{ .Hostname }}”</h1>
+{{ if .ServiceURL }}
+<p><a href="{{ .ServiceURL }}">fbstatus in the gokrazy web interface</a></p>
+{{ end }}
+{{ if .Blanked }}
+<p>The display is currently blanked.</p>
+{{ end }}
+<p>Page {{ .Page }}</p>
+<img id="screen" src="screenshot.png" alt="screenshot of the display">
+<div class="lines">
+{{ range .Lines }}
+<div>{{ range . }}<span style="color: {{ .Color }}">{{ .Text }}</span>{{ else }}&nbsp;{{ end }}</div>
+{{ end }}
+</div>
+<script>
+// reload the screenshot periodically (like fbstatus redraws the display)
+window.setInterval(function() {
+  document.getElementById('screen').src = 'screenshot.png?' + Date.now();
+}, 2000);
+</script>
+</body>
+</html>
+`))
+
+// gokrazyServiceURL returns the URL of the fbstatus service page in the
+// gokrazy web interface on host (which also shows the logs of fbstatus), or
+// the empty string when not running on gokrazy.
+func (d *statusDrawer) gokrazyServiceURL(host string) string {
+	if httpPassword == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	u := url.URL{
+		Scheme:   "http",
+		Host:     host,
+		Path:     "/status",
+		RawQuery: url.Values{"path": {os.Args[0]}}.Encode(),
+	}
+	return u.String()
+}
+
+// serveStatus renders a status page with a live screenshot of the display and
+// the host information, for remote users to see what is on the display.
+func (d *statusDrawer) serveStatus(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	d.mu.Lock()
+	hostLines := d.hostLines()
+	pg := d.currentPage()
+	d.mu.Unlock()
+	lines := make([][]markupSpan, 0, len(hostLines))
+	for _, line := range hostLines {
+		lines = append(lines, markupSpans(line))
+	}
+	var buf bytes.Buffer
+	if err := statusTmpl.Execute(&buf, struct {
+		Hostname   string
+		ServiceURL string
+		Blanked    bool
+		Page       string
+		Lines      [][]markupSpan
+	}{
+		Hostname:   d.hostname,
+		ServiceURL: d.gokrazyServiceURL(r.Host),
+		Blanked:    d.control.isBlanked(),
+		Page:       pg.name,
+		Lines:      lines,
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := buf.WriteTo(w); err != nil {
+		slog.Debug("writing HTTP response failed", "err", err)
+	}
+}
+
+// serveScreenshot serves the most recently drawn frame as PNG image.
+func (d *statusDrawer) serveScreenshot(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	screenshot := image.NewRGBA(d.buffer.Rect)
+	copy(screenshot.Pix, d.buffer.Pix)
+	d.mu.Unlock()
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(&buf, screenshot); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := buf.WriteTo(w); err != nil {
+		slog.Debug("writing HTTP response failed", "err", err)
+	}
+}
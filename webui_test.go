@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarkupSpans(t *testing.T) {
+	for _, tt := range []struct {
+		markup string
+		want   []markupSpan
+	}{
+		{"Private IP addresses:", []markupSpan{{Color: "#eeeeec", Text: "Private IP addresses:"}}},
+		{"$$clock: $green$synchronized$$ (kernel)", []markupSpan{
+			{Color: "#eeeeec", Text: "clock: "},
+			{Color: "#8ae234", Text: "synchronized"},
+			{Color: "#eeeeec", Text: " (kernel)"},
+		}},
+		{"", nil},
+	} {
+		if got := markupSpans(tt.markup); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("markupSpans(%q) = %+v, want %+v", tt.markup, got, tt.want)
+		}
+	}
+}
+
+func TestWebInterface(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	d, err := newStatusDrawer(img, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.draw1(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	handler := d.httpHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"fbstatus on “" + d.hostname + "”",
+		`src="screenshot.png"`,
+		"Private IP addresses:",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("status page does not contain %q", want)
+		}
+	}
+	if strings.Contains(body, "gokrazy web interface") {
+		t.Errorf("status page links to the gokrazy web interface when not running on gokrazy")
+	}
+
+	defer func(password string) { httpPassword = password }(httpPassword)
+	httpPassword = "secret"
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Host = "scr0:8318"
+	handler.ServeHTTP(rec, req)
+	want := `<a href="http://scr0/status?path=` + url.QueryEscape(os.Args[0]) + `">`
+	if body := rec.Body.String(); !strings.Contains(body, want) {
+		t.Errorf("status page does not contain %q", want)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/screenshot.png", nil))
+	screenshot, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := screenshot.Bounds(), img.Bounds(); got != want {
+		t.Errorf("screenshot bounds = %v, want %v", got, want)
+	}
+}